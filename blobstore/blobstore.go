@@ -50,6 +50,7 @@ func (b *blobstore) Get(blobID, destinationPath string) error {
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Opening file for blob at %s", destinationPath)
 	}
+	defer targetFile.Close()
 
 	_, err = io.Copy(targetFile, readCloser)
 	if err != nil {
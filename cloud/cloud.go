@@ -22,6 +22,7 @@ type Cloud interface {
 	AttachDisk(vmCID, diskCID string) error
 	DetachDisk(vmCID, diskCID string) error
 	DeleteDisk(diskCID string) error
+	GetDisks(vmCID string) (diskCIDs []string, err error)
 	fmt.Stringer
 }
 
@@ -235,6 +236,33 @@ func (c cloud) DeleteDisk(diskCID string) error {
 	return nil
 }
 
+func (c cloud) GetDisks(vmCID string) ([]string, error) {
+	method := "get_disks"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, vmCID)
+	if err != nil {
+		return []string{}, bosherr.WrapError(err, "Calling CPI 'get_disks' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return []string{}, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	results, ok := cmdOutput.Result.([]interface{})
+	if !ok {
+		return []string{}, bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+	}
+
+	diskCIDs := make([]string, len(results))
+	for i, result := range results {
+		diskCID, ok := result.(string)
+		if !ok {
+			return []string{}, bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+		}
+		diskCIDs[i] = diskCID
+	}
+	return diskCIDs, nil
+}
+
 func (c cloud) String() string {
 	return fmt.Sprintf("Cloud{Context=%s}", c.context)
 }
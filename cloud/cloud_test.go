@@ -501,4 +501,51 @@ var _ = Describe("Cloud", func() {
 			return cloud.DeleteDisk("fake-disk-cid")
 		})
 	})
+
+	Describe("GetDisks", func() {
+		It("returns the cids of the disks attached to the VM", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: []interface{}{"fake-disk-cid-1", "fake-disk-cid-2"},
+			}
+
+			diskCIDs, err := cloud.GetDisks("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskCIDs).To(Equal([]string{"fake-disk-cid-1", "fake-disk-cid-2"}))
+
+			Expect(fakeCPICmdRunner.RunInputs).To(Equal([]fakebmcloud.RunInput{
+				{
+					Context:   context,
+					Method:    "get_disks",
+					Arguments: []interface{}{"fake-vm-cid"},
+				},
+			}))
+		})
+
+		It("returns an error when the result is not a list of cids", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: "fake-disk-cid",
+			}
+
+			_, err := cloud.GetDisks("fake-vm-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result"))
+		})
+
+		Context("when the cpi command execution fails", func() {
+			BeforeEach(func() {
+				fakeCPICmdRunner.RunErr = errors.New("fake-run-error")
+			})
+
+			It("returns an error", func() {
+				_, err := cloud.GetDisks("fake-vm-cid")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-run-error"))
+			})
+		})
+
+		itHandlesCPIErrors("get_disks", func() error {
+			_, err := cloud.GetDisks("fake-vm-cid")
+			return err
+		})
+	})
 })
@@ -8,6 +8,7 @@ const (
 	VMNotFoundError       = "Bosh::Cloud::VMNotFound"
 	DiskNotFoundError     = "Bosh::Cloud::DiskNotFound"
 	StemcellNotFoundError = "Bosh::Cloud::StemcellNotFound"
+	NotImplementedError   = "Bosh::Cloud::NotImplemented"
 )
 
 type Error interface {
@@ -31,6 +31,10 @@ type FakeCloud struct {
 
 	DeleteStemcellInputs []DeleteStemcellInput
 	DeleteStemcellErr    error
+
+	GetDisksInputs   []GetDisksInput
+	GetDisksDiskCIDs []string
+	GetDisksErr      error
 }
 
 type CreateStemcellInput struct {
@@ -78,6 +82,10 @@ type DeleteStemcellInput struct {
 	StemcellCID string
 }
 
+type GetDisksInput struct {
+	VMCID string
+}
+
 func NewFakeCloud() *FakeCloud {
 	return &FakeCloud{
 		CreateStemcellInputs: []CreateStemcellInput{},
@@ -173,3 +181,10 @@ func (c *FakeCloud) DeleteDisk(diskCID string) error {
 func (c *FakeCloud) String() string {
 	return "FakeCloud{}"
 }
+
+func (c *FakeCloud) GetDisks(vmCID string) ([]string, error) {
+	c.GetDisksInputs = append(c.GetDisksInputs, GetDisksInput{
+		VMCID: vmCID,
+	})
+	return c.GetDisksDiskCIDs, c.GetDisksErr
+}
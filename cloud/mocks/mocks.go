@@ -113,6 +113,17 @@ func (_mr *_MockCloudRecorder) DetachDisk(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DetachDisk", arg0, arg1)
 }
 
+func (_m *MockCloud) GetDisks(_param0 string) ([]string, error) {
+	ret := _m.ctrl.Call(_m, "GetDisks", _param0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockCloudRecorder) GetDisks(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetDisks", arg0)
+}
+
 func (_m *MockCloud) HasVM(_param0 string) (bool, error) {
 	ret := _m.ctrl.Call(_m, "HasVM", _param0)
 	ret0, _ := ret[0].(bool)
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
+	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type cleanupCmd struct {
+	ui                      bmui.UI
+	userConfig              bmconfig.UserConfig
+	fs                      boshsys.FileSystem
+	cpiInstaller            *cpiInstaller
+	cloudFactory            bmcloud.Factory
+	deploymentConfigService bmconfig.DeploymentConfigService
+	instanceRepo            bmconfig.InstanceRepo
+	stemcellRepo            bmconfig.StemcellRepo
+	diskManagerFactory      bmdisk.ManagerFactory
+	stemcellManagerFactory  bmstemcell.ManagerFactory
+	eventLogger             bmeventlog.EventLogger
+	logger                  boshlog.Logger
+	logTag                  string
+}
+
+type cleanupOptions struct {
+	dryRun bool
+	all    bool
+}
+
+func NewCleanupCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	releaseSetParser bmrelsetmanifest.Parser,
+	installationParser bminstallmanifest.Parser,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	releaseSetValidator bmrelsetmanifest.Validator,
+	installationValidator bminstallmanifest.Validator,
+	installerFactory bminstall.InstallerFactory,
+	releaseExtractor bmrel.Extractor,
+	releaseManager bmrel.Manager,
+	releaseResolver bmrelset.Resolver,
+	cloudFactory bmcloud.Factory,
+	instanceRepo bmconfig.InstanceRepo,
+	stemcellRepo bmconfig.StemcellRepo,
+	diskManagerFactory bmdisk.ManagerFactory,
+	stemcellManagerFactory bmstemcell.ManagerFactory,
+	eventLogger bmeventlog.EventLogger,
+	logger boshlog.Logger,
+) Cmd {
+	return &cleanupCmd{
+		ui:         ui,
+		userConfig: userConfig,
+		fs:         fs,
+		cpiInstaller: newCPIInstaller(
+			fs,
+			releaseSetParser,
+			installationParser,
+			releaseSetValidator,
+			installationValidator,
+			installerFactory,
+			releaseExtractor,
+			releaseManager,
+			releaseResolver,
+			logger,
+		),
+		cloudFactory:            cloudFactory,
+		deploymentConfigService: deploymentConfigService,
+		instanceRepo:            instanceRepo,
+		stemcellRepo:            stemcellRepo,
+		diskManagerFactory:      diskManagerFactory,
+		stemcellManagerFactory:  stemcellManagerFactory,
+		eventLogger:             eventLogger,
+		logger:                  logger,
+		logTag:                  "cleanupCmd",
+	}
+}
+
+func (c *cleanupCmd) Name() string {
+	return "cleanup"
+}
+
+func (c *cleanupCmd) Meta() Meta {
+	return Meta{
+		Summary: "Delete the disks & stemcells that the deployment no longer uses",
+		Args:    "<cpi-release-tarball>",
+		Flags: []Flag{
+			{Name: "dry-run", Usage: "List the unused disks & stemcells without deleting them"},
+			{Name: "all", Usage: "Also delete the current stemcell when no VM uses it"},
+		},
+	}
+}
+
+func (c *cleanupCmd) Run(args []string) error {
+	options, releaseTarballPath, err := c.parseCmdInputs(args)
+	if err != nil {
+		return err
+	}
+
+	deploymentManifestPath, err := getDeploymentManifest(c.userConfig, c.ui, c.fs)
+	if err != nil {
+		return bosherr.WrapError(err, "Running cleanup cmd")
+	}
+
+	validationStage := c.eventLogger.NewStage("validating")
+	validationStage.Start()
+
+	installationManifest, err := c.cpiInstaller.Validate(validationStage, deploymentManifestPath, releaseTarballPath)
+	defer c.cpiInstaller.Cleanup()
+	if err != nil {
+		return err
+	}
+
+	validationStage.Finish()
+
+	deploymentConfig, err := c.deploymentConfigService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment config")
+	}
+
+	installation, err := c.cpiInstaller.Install(installationManifest)
+	if err != nil {
+		return err
+	}
+
+	err = installation.StartRegistry()
+	if err != nil {
+		return bosherr.WrapError(err, "Starting Registry")
+	}
+	defer func() {
+		err := installation.StopRegistry()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Registry failed to stop: %s", err)
+		}
+	}()
+
+	cloud, err := c.cloudFactory.NewCloud(installation, deploymentConfig.DirectorID)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+	}
+
+	diskManager := c.diskManagerFactory.NewManager(cloud)
+	stemcellManager := c.stemcellManagerFactory.NewManager(cloud)
+
+	unusedDisks, err := diskManager.FindUnused()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding unused disks")
+	}
+
+	unusedStemcells, err := stemcellManager.FindUnused()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding unused stemcells")
+	}
+
+	currentStemcells := []bmstemcell.CloudStemcell{}
+	if options.all {
+		vmFound, err := c.anyVMFound()
+		if err != nil {
+			return err
+		}
+
+		if !vmFound {
+			currentStemcells, err = stemcellManager.FindCurrent()
+			if err != nil {
+				return bosherr.WrapError(err, "Finding current stemcell")
+			}
+		}
+	}
+
+	if len(unusedDisks)+len(unusedStemcells)+len(currentStemcells) == 0 {
+		c.ui.Sayln("Nothing to clean up")
+		return nil
+	}
+
+	c.ui.Sayln("Unused resources:")
+	for _, disk := range unusedDisks {
+		c.ui.Sayln(fmt.Sprintf("  disk '%s'", disk.CID()))
+	}
+	for _, stemcell := range append(unusedStemcells, currentStemcells...) {
+		c.ui.Sayln(fmt.Sprintf("  stemcell '%s' (%s/%s)", stemcell.CID(), stemcell.Name(), stemcell.Version()))
+	}
+
+	if options.dryRun {
+		c.ui.Sayln("Dry run, nothing was deleted")
+		return nil
+	}
+
+	cleanupStage := c.eventLogger.NewStage("cleaning up")
+	cleanupStage.Start()
+
+	err = diskManager.DeleteUnused(cleanupStage)
+	if err != nil {
+		return bosherr.WrapError(err, "Deleting unused disks")
+	}
+
+	err = stemcellManager.DeleteUnused(cleanupStage)
+	if err != nil {
+		return bosherr.WrapError(err, "Deleting unused stemcells")
+	}
+
+	for _, stemcell := range currentStemcells {
+		err = c.deleteCurrentStemcell(stemcell, cleanupStage)
+		if err != nil {
+			return bosherr.WrapError(err, "Deleting current stemcell")
+		}
+	}
+
+	cleanupStage.Finish()
+
+	return nil
+}
+
+// anyVMFound tells whether an instance of the deployment has a VM, which uses the current stemcell
+func (c *cleanupCmd) anyVMFound() (bool, error) {
+	instanceRecords, err := c.instanceRepo.All()
+	if err != nil {
+		return false, bosherr.WrapError(err, "Finding instance records")
+	}
+
+	for _, instanceRecord := range instanceRecords {
+		if instanceRecord.VMCID != "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deleteCurrentStemcell deletes a current stemcell that no VM uses, and clears it as current.
+func (c *cleanupCmd) deleteCurrentStemcell(stemcell bmstemcell.CloudStemcell, stage bmeventlog.Stage) error {
+	stepName := fmt.Sprintf("Deleting current stemcell '%s'", stemcell.CID())
+	return stage.PerformStep(stepName, func() error {
+		deleteErr := stemcell.Delete()
+		if deleteErr != nil {
+			cloudErr, ok := deleteErr.(bmcloud.Error)
+			if !ok || cloudErr.Type() != bmcloud.StemcellNotFoundError {
+				return deleteErr
+			}
+		}
+
+		err := c.stemcellRepo.ClearCurrent()
+		if err != nil {
+			return bosherr.WrapError(err, "Clearing current stemcell record")
+		}
+
+		if deleteErr != nil {
+			return bmeventlog.NewSkippedStepError(deleteErr.Error())
+		}
+		return nil
+	})
+}
+
+func (c *cleanupCmd) parseCmdInputs(args []string) (cleanupOptions, string, error) {
+	flags, positional, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return cleanupOptions{}, "", usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	options := cleanupOptions{
+		dryRun: flags.Bool("dry-run"),
+		all:    flags.Bool("all"),
+	}
+
+	if len(positional) != 1 {
+		return options, "", usageError(c, c.ui, c.logger, c.logTag, args, "cleanup command requires exactly 1 argument")
+	}
+
+	return options, positional[0], nil
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmcloudcheck "github.com/cloudfoundry/bosh-micro-cli/deployment/cloudcheck"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type cloudCheckCmd struct {
+	ui                      bmui.UI
+	userConfig              bmconfig.UserConfig
+	fs                      boshsys.FileSystem
+	cpiInstaller            *cpiInstaller
+	cloudFactory            bmcloud.Factory
+	agentClientFactory      bmhttpagent.AgentClientFactory
+	deploymentConfigService bmconfig.DeploymentConfigService
+	instanceRepo            bmconfig.InstanceRepo
+	vmRepo                  bmconfig.VMRepo
+	diskRepo                bmconfig.DiskRepo
+	stemcellRepo            bmconfig.StemcellRepo
+	uuidGenerator           boshuuid.Generator
+	deployCmd               Cmd
+	eventLogger             bmeventlog.EventLogger
+	logger                  boshlog.Logger
+	logTag                  string
+}
+
+type cloudCheckMode int
+
+const (
+	cloudCheckInteractive cloudCheckMode = iota
+	cloudCheckAuto
+	cloudCheckReport
+)
+
+func NewCloudCheckCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	releaseSetParser bmrelsetmanifest.Parser,
+	installationParser bminstallmanifest.Parser,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	releaseSetValidator bmrelsetmanifest.Validator,
+	installationValidator bminstallmanifest.Validator,
+	installerFactory bminstall.InstallerFactory,
+	releaseExtractor bmrel.Extractor,
+	releaseManager bmrel.Manager,
+	releaseResolver bmrelset.Resolver,
+	cloudFactory bmcloud.Factory,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	instanceRepo bmconfig.InstanceRepo,
+	vmRepo bmconfig.VMRepo,
+	diskRepo bmconfig.DiskRepo,
+	stemcellRepo bmconfig.StemcellRepo,
+	uuidGenerator boshuuid.Generator,
+	deployCmd Cmd,
+	eventLogger bmeventlog.EventLogger,
+	logger boshlog.Logger,
+) Cmd {
+	return &cloudCheckCmd{
+		ui:         ui,
+		userConfig: userConfig,
+		fs:         fs,
+		cpiInstaller: newCPIInstaller(
+			fs,
+			releaseSetParser,
+			installationParser,
+			releaseSetValidator,
+			installationValidator,
+			installerFactory,
+			releaseExtractor,
+			releaseManager,
+			releaseResolver,
+			logger,
+		),
+		cloudFactory:            cloudFactory,
+		agentClientFactory:      agentClientFactory,
+		deploymentConfigService: deploymentConfigService,
+		instanceRepo:            instanceRepo,
+		vmRepo:                  vmRepo,
+		diskRepo:                diskRepo,
+		stemcellRepo:            stemcellRepo,
+		uuidGenerator:           uuidGenerator,
+		deployCmd:               deployCmd,
+		eventLogger:             eventLogger,
+		logger:                  logger,
+		logTag:                  "cloudCheckCmd",
+	}
+}
+
+func (c *cloudCheckCmd) Name() string {
+	return "cloud-check"
+}
+
+func (c *cloudCheckCmd) Meta() Meta {
+	return Meta{
+		Summary: "Compare the deployment state with the cloud, and resolve the problems found",
+		Args:    "[<stemcell-tarball>] <cpi-release-tarball> [release-2-tarball [release-3-tarball...]]",
+		Flags: []Flag{
+			{Name: "auto", Usage: "Apply the recommended resolution to every problem, without asking"},
+			{Name: "report", Usage: "Only report the problems"},
+		},
+	}
+}
+
+func (c *cloudCheckCmd) Run(args []string) error {
+	mode, releaseTarballPath, recreateArgs, err := c.parseCmdInputs(args)
+	if err != nil {
+		return err
+	}
+
+	deploymentManifestPath, err := getDeploymentManifest(c.userConfig, c.ui, c.fs)
+	if err != nil {
+		return bosherr.WrapError(err, "Running cloud-check cmd")
+	}
+
+	recreateVM, err := c.check(mode, deploymentManifestPath, releaseTarballPath, len(recreateArgs) > 0)
+	if err != nil {
+		return err
+	}
+
+	if recreateVM {
+		return c.deployCmd.Run(append([]string{"--recreate"}, recreateArgs...))
+	}
+
+	return nil
+}
+
+// check compares the deployment state with the cloud and resolves the problems found.
+// It returns whether a VM was deleted to be recreated, which is done once the CPI
+// installation & registry of the check are cleaned up.
+func (c *cloudCheckCmd) check(mode cloudCheckMode, deploymentManifestPath, releaseTarballPath string, canRecreate bool) (bool, error) {
+	validationStage := c.eventLogger.NewStage("validating")
+	validationStage.Start()
+
+	installationManifest, err := c.cpiInstaller.Validate(validationStage, deploymentManifestPath, releaseTarballPath)
+	defer c.cpiInstaller.Cleanup()
+	if err != nil {
+		return false, err
+	}
+
+	validationStage.Finish()
+
+	deploymentConfig, err := c.deploymentConfigService.Load()
+	if err != nil {
+		return false, bosherr.WrapError(err, "Loading deployment config")
+	}
+
+	installation, err := c.cpiInstaller.Install(installationManifest)
+	if err != nil {
+		return false, err
+	}
+
+	err = installation.StartRegistry()
+	if err != nil {
+		return false, bosherr.WrapError(err, "Starting Registry")
+	}
+	defer func() {
+		err := installation.StopRegistry()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Registry failed to stop: %s", err)
+		}
+	}()
+
+	cloud, err := c.cloudFactory.NewCloud(installation, deploymentConfig.DirectorID)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Creating CPI client from CPI installation")
+	}
+
+	checker := bmcloudcheck.NewChecker(
+		cloud,
+		c.agentClientFactory,
+		installationManifest.Mbus,
+		c.deploymentConfigService,
+		c.instanceRepo,
+		c.vmRepo,
+		c.diskRepo,
+		c.stemcellRepo,
+		c.uuidGenerator,
+		c.logger,
+	)
+
+	checkStage := c.eventLogger.NewStage("checking deployment")
+	checkStage.Start()
+
+	var problems []bmcloudcheck.Problem
+	err = checkStage.PerformStep("Comparing VM, disk & stemcell records with the cloud", func() error {
+		var err error
+		problems, err = checker.Check()
+		return err
+	})
+	if err != nil {
+		return false, bosherr.WrapError(err, "Checking deployment")
+	}
+
+	checkStage.Finish()
+
+	if !canRecreate {
+		for i, problem := range problems {
+			problems[i] = problem.WithoutResolution(bmcloudcheck.RecreateVM)
+		}
+	}
+
+	if len(problems) == 0 {
+		c.ui.Sayln("No problems found")
+		return false, nil
+	}
+
+	c.ui.Sayln(fmt.Sprintf("Found %d problem(s)", len(problems)))
+
+	resolutions := make([]bmcloudcheck.Resolution, len(problems))
+	for i, problem := range problems {
+		c.ui.Sayln(fmt.Sprintf("Problem %d of %d: %s", i+1, len(problems), problem.Description))
+
+		switch mode {
+		case cloudCheckReport:
+			continue
+		case cloudCheckAuto:
+			resolutions[i] = problem.RecommendedResolution()
+		default:
+			resolutions[i], err = c.askResolution(problem)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if mode == cloudCheckReport {
+		return false, nil
+	}
+
+	resolveStage := c.eventLogger.NewStage("resolving problems")
+	resolveStage.Start()
+
+	vmDeleted := false
+	recreateVM := false
+	for i, problem := range problems {
+		resolution := resolutions[i]
+		if resolution == bmcloudcheck.Skip {
+			continue
+		}
+
+		stepName := fmt.Sprintf("%s: %s", resolution.Description(), problem.Description)
+		err = resolveStage.PerformStep(stepName, func() error {
+			return checker.Resolve(problem, resolution)
+		})
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Resolving problem '%s'", problem.Description)
+		}
+
+		switch resolution {
+		case bmcloudcheck.RecreateVM:
+			recreateVM = true
+		case bmcloudcheck.DeleteVM:
+			vmDeleted = true
+		}
+	}
+
+	resolveStage.Finish()
+
+	if vmDeleted && !recreateVM {
+		c.ui.Sayln("VM record cleared. Run 'bosh-micro start <stemcell-tarball> <cpi-release-tarball>' to recreate the VM.")
+	}
+
+	return recreateVM, nil
+}
+
+// askResolution lists the resolutions of the problem and reads the user's choice,
+// defaulting to the recommended resolution.
+func (c *cloudCheckCmd) askResolution(problem bmcloudcheck.Problem) (bmcloudcheck.Resolution, error) {
+	for i, resolution := range problem.Resolutions {
+		line := fmt.Sprintf("  %d. %s", i+1, resolution.Description())
+		if i == 0 {
+			line += " (recommended)"
+		}
+		c.ui.Sayln(line)
+	}
+
+	for {
+		answer, err := c.ui.Ask("Choose a resolution [1]: ")
+		if err != nil {
+			return "", bosherr.WrapError(err, "Asking for resolution")
+		}
+
+		if answer == "" {
+			return problem.RecommendedResolution(), nil
+		}
+
+		choice, err := strconv.Atoi(answer)
+		if err == nil && choice >= 1 && choice <= len(problem.Resolutions) {
+			return problem.Resolutions[choice-1], nil
+		}
+
+		c.ui.Error(fmt.Sprintf("Invalid resolution '%s', expected a number between 1 and %d", answer, len(problem.Resolutions)))
+	}
+}
+
+// parseCmdInputs returns the mode, the CPI release tarball path & the arguments of the deploy
+// that recreates deleted VMs, which are empty when only the CPI release tarball is given.
+func (c *cloudCheckCmd) parseCmdInputs(args []string) (cloudCheckMode, string, []string, error) {
+	flags, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return cloudCheckInteractive, "", nil, usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(positionalArgs) == 0 {
+		return cloudCheckInteractive, "", nil, usageError(c, c.ui, c.logger, c.logTag, args, "cloud-check command requires a CPI release tarball path")
+	}
+
+	releaseTarballPath := positionalArgs[0]
+	recreateArgs := []string{}
+	if len(positionalArgs) > 1 {
+		releaseTarballPath = positionalArgs[1]
+		recreateArgs = positionalArgs
+	}
+
+	switch {
+	case flags.Bool("auto") && flags.Bool("report"):
+		return cloudCheckInteractive, "", nil, usageError(c, c.ui, c.logger, c.logTag, args, "cloud-check command accepts only one of '--auto' & '--report'")
+	case flags.Bool("auto"):
+		return cloudCheckAuto, releaseTarballPath, recreateArgs, nil
+	case flags.Bool("report"):
+		return cloudCheckReport, releaseTarballPath, recreateArgs, nil
+	default:
+		return cloudCheckInteractive, releaseTarballPath, recreateArgs, nil
+	}
+}
@@ -1,6 +1,22 @@
 package cmd
 
+import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
 type Cmd interface {
 	Run([]string) error
 	Name() string
+	Meta() Meta
+}
+
+// usageError reports the invalid usage of the command, with its expected usage, and returns it as an error
+func usageError(cmd Cmd, ui bmui.UI, logger boshlog.Logger, logTag string, args []string, message string) error {
+	ui.Error("Invalid usage - " + message)
+	ui.Sayln("Expected usage: " + cmd.Meta().Usage(cmd.Name()))
+	logger.Error(logTag, "Invalid arguments: %#v", args)
+	return bosherr.Errorf("Invalid usage - %s", message)
 }
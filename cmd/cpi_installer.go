@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmcpirel "github.com/cloudfoundry/bosh-micro-cli/cpi/release"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+)
+
+// cpiInstaller validates a CPI release tarball against the deployment manifest and installs it.
+// It is used by the commands that need to talk to the cloud without deploying.
+type cpiInstaller struct {
+	fs                    boshsys.FileSystem
+	releaseSetParser      bmrelsetmanifest.Parser
+	installationParser    bminstallmanifest.Parser
+	releaseSetValidator   bmrelsetmanifest.Validator
+	installationValidator bminstallmanifest.Validator
+	installerFactory      bminstall.InstallerFactory
+	releaseExtractor      bmrel.Extractor
+	releaseManager        bmrel.Manager
+	releaseResolver       bmrelset.Resolver
+	logger                boshlog.Logger
+	logTag                string
+}
+
+func newCPIInstaller(
+	fs boshsys.FileSystem,
+	releaseSetParser bmrelsetmanifest.Parser,
+	installationParser bminstallmanifest.Parser,
+	releaseSetValidator bmrelsetmanifest.Validator,
+	installationValidator bminstallmanifest.Validator,
+	installerFactory bminstall.InstallerFactory,
+	releaseExtractor bmrel.Extractor,
+	releaseManager bmrel.Manager,
+	releaseResolver bmrelset.Resolver,
+	logger boshlog.Logger,
+) *cpiInstaller {
+	return &cpiInstaller{
+		fs:                    fs,
+		releaseSetParser:      releaseSetParser,
+		installationParser:    installationParser,
+		releaseSetValidator:   releaseSetValidator,
+		installationValidator: installationValidator,
+		installerFactory:      installerFactory,
+		releaseExtractor:      releaseExtractor,
+		releaseManager:        releaseManager,
+		releaseResolver:       releaseResolver,
+		logger:                logger,
+		logTag:                "cpiInstaller",
+	}
+}
+
+// Validate extracts the CPI release and validates the release set & installation sections of the manifest.
+// Callers must call Cleanup to delete the extracted release.
+func (i *cpiInstaller) Validate(
+	validationStage bmeventlog.Stage,
+	deploymentManifestPath string,
+	releaseTarballPath string,
+) (bminstallmanifest.Manifest, error) {
+	err := validationStage.PerformStep("Validating releases", func() error {
+		if !i.fs.FileExists(releaseTarballPath) {
+			return bosherr.Errorf("Verifying that the release '%s' exists", releaseTarballPath)
+		}
+
+		release, err := i.releaseExtractor.Extract(releaseTarballPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Extracting release '%s'", releaseTarballPath)
+		}
+		i.releaseManager.Add(release)
+
+		return nil
+	})
+	if err != nil {
+		return bminstallmanifest.Manifest{}, err
+	}
+
+	var installationManifest bminstallmanifest.Manifest
+	err = validationStage.PerformStep("Validating deployment manifest", func() error {
+		releaseSetManifest, err := i.releaseSetParser.Parse(deploymentManifestPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing release set manifest '%s'", deploymentManifestPath)
+		}
+
+		err = i.releaseSetValidator.Validate(releaseSetManifest)
+		if err != nil {
+			return bosherr.WrapError(err, "Validating release set manifest")
+		}
+
+		i.releaseResolver.Filter(releaseSetManifest.Releases)
+
+		installationManifest, err = i.installationParser.Parse(deploymentManifestPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing installation manifest '%s'", deploymentManifestPath)
+		}
+
+		err = i.installationValidator.Validate(installationManifest)
+		if err != nil {
+			return bosherr.WrapError(err, "Validating installation manifest")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return bminstallmanifest.Manifest{}, err
+	}
+
+	err = validationStage.PerformStep("Validating cpi release", func() error {
+		cpiRelease, err := i.releaseResolver.Find(installationManifest.Release)
+		if err != nil {
+			// should never happen, due to prior manifest validation
+			return bosherr.WrapErrorf(err, "installation release '%s' must refer to a provided release", installationManifest.Release)
+		}
+
+		err = bmcpirel.NewValidator().Validate(cpiRelease)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Invalid CPI release '%s'", cpiRelease.Name())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return bminstallmanifest.Manifest{}, err
+	}
+
+	return installationManifest, nil
+}
+
+// Install compiles & installs the CPI job (reusing the cached installation, if any).
+func (i *cpiInstaller) Install(installationManifest bminstallmanifest.Manifest) (bminstall.Installation, error) {
+	installer, err := i.installerFactory.NewInstaller()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating CPI Installer")
+	}
+
+	i.logger.Debug(i.logTag, "Installing CPI...")
+	installation, err := installer.Install(installationManifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Installing CPI")
+	}
+
+	return installation, nil
+}
+
+// Cleanup deletes the extracted releases.
+func (i *cpiInstaller) Cleanup() {
+	err := i.releaseManager.DeleteAll()
+	if err != nil {
+		i.logger.Warn(i.logTag, "Deleting all extracted releases: %s", err.Error())
+	}
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"errors"
-
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 	boshsys "github.com/cloudfoundry/bosh-agent/system"
@@ -12,7 +10,6 @@ import (
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
 	bmcpirel "github.com/cloudfoundry/bosh-micro-cli/cpi/release"
 	bmdepl "github.com/cloudfoundry/bosh-micro-cli/deployment"
-	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
 	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
 	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
 	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
@@ -36,7 +33,6 @@ type deleteCmd struct {
 	releaseManager           bmrel.Manager
 	releaseResolver          bmrelset.Resolver
 	cloudFactory             bmcloud.Factory
-	agentClientFactory       bmhttpagent.AgentClientFactory
 	blobstoreFactory         bmblobstore.Factory
 	deploymentManagerFactory bmdepl.ManagerFactory
 	eventLogger              bmeventlog.EventLogger
@@ -58,7 +54,6 @@ func NewDeleteCmd(
 	releaseManager bmrel.Manager,
 	releaseResolver bmrelset.Resolver,
 	cloudFactory bmcloud.Factory,
-	agentClientFactory bmhttpagent.AgentClientFactory,
 	blobstoreFactory bmblobstore.Factory,
 	deploymentManagerFactory bmdepl.ManagerFactory,
 	eventLogger bmeventlog.EventLogger,
@@ -78,7 +73,6 @@ func NewDeleteCmd(
 		releaseManager:           releaseManager,
 		releaseResolver:          releaseResolver,
 		cloudFactory:             cloudFactory,
-		agentClientFactory:       agentClientFactory,
 		blobstoreFactory:         blobstoreFactory,
 		deploymentManagerFactory: deploymentManagerFactory,
 		eventLogger:              eventLogger,
@@ -91,6 +85,13 @@ func (c *deleteCmd) Name() string {
 	return "delete"
 }
 
+func (c *deleteCmd) Meta() Meta {
+	return Meta{
+		Summary: "Delete the deployment: its VM, disks & stemcells",
+		Args:    "<cpi-release-tarball>",
+	}
+}
+
 func (c *deleteCmd) Run(args []string) error {
 	releaseTarballPath, err := c.parseCmdInputs(args)
 	if err != nil {
@@ -212,9 +213,6 @@ func (c *deleteCmd) Run(args []string) error {
 		return bosherr.WrapError(err, "Creating CPI client from CPI installation")
 	}
 
-	c.logger.Debug(c.logTag, "Creating agent client...")
-	agentClient := c.agentClientFactory.NewAgentClient(deploymentConfig.DirectorID, installationManifest.Mbus)
-
 	c.logger.Debug(c.logTag, "Creating blobstore client...")
 	blobstore, err := c.blobstoreFactory.Create(installationManifest.Mbus)
 	if err != nil {
@@ -222,7 +220,7 @@ func (c *deleteCmd) Run(args []string) error {
 	}
 
 	c.logger.Debug(c.logTag, "Creating deployment manager...")
-	deploymentManager := c.deploymentManagerFactory.NewManager(cloud, agentClient, blobstore)
+	deploymentManager := c.deploymentManagerFactory.NewManager(cloud, deploymentConfig.DirectorID, installationManifest.Mbus, blobstore)
 
 	c.logger.Debug(c.logTag, "Finding current deployment...")
 	deployment, found, err := deploymentManager.FindCurrent()
@@ -254,11 +252,13 @@ func (c *deleteCmd) Run(args []string) error {
 }
 
 func (c *deleteCmd) parseCmdInputs(args []string) (string, error) {
-	if len(args) != 1 {
-		c.ui.Error("Invalid usage - delete command requires exactly 1 argument")
-		c.ui.Sayln("Expected usage: bosh-micro delete <cpi-release-tarball>")
-		c.logger.Error(c.logTag, "Invalid arguments: %#v", args)
-		return "", errors.New("Invalid usage - delete command requires exactly 1 argument")
+	_, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return "", usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(positionalArgs) != 1 {
+		return "", usageError(c, c.ui, c.logger, c.logTag, args, "delete command requires exactly 1 argument")
 	}
-	return args[0], nil
+	return positionalArgs[0], nil
 }
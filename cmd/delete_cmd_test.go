@@ -11,8 +11,6 @@ import (
 	"code.google.com/p/gomock/gomock"
 	mock_blobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore/mocks"
 	mock_cloud "github.com/cloudfoundry/bosh-micro-cli/cloud/mocks"
-	mock_httpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http/mocks"
-	mock_agentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/mocks"
 	mock_deployment "github.com/cloudfoundry/bosh-micro-cli/deployment/mocks"
 	mock_install "github.com/cloudfoundry/bosh-micro-cli/installation/mocks"
 	mock_release "github.com/cloudfoundry/bosh-micro-cli/release/mocks"
@@ -30,6 +28,8 @@ import (
 	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
 
 	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 var _ = Describe("DeleteCmd", func() {
@@ -66,9 +66,7 @@ var _ = Describe("DeleteCmd", func() {
 			mockDeploymentManager        *mock_deployment.MockManager
 			mockDeployment               *mock_deployment.MockDeployment
 
-			mockAgentClient        *mock_agentclient.MockAgentClient
-			mockAgentClientFactory *mock_httpagent.MockAgentClientFactory
-			mockCloud              *mock_cloud.MockCloud
+			mockCloud *mock_cloud.MockCloud
 
 			directorID string
 
@@ -139,11 +137,11 @@ cloud_provider:
 		}
 
 		var newDeleteCmd = func() Cmd {
-			releaseSetParser := bmrelsetmanifest.NewParser(fs, logger)
+			releaseSetParser := bmrelsetmanifest.NewParser(fs, bmvars.NewInterpolator(bmvars.Variables{}), logger)
 			releaseSetResolver := bmrelset.NewResolver(releaseManager, logger)
 			releaseSetValidator := bmrelsetmanifest.NewValidator(logger, releaseSetResolver)
 			installationValidator := bminstallmanifest.NewValidator(logger, releaseSetResolver)
-			installationParser := bminstallmanifest.NewParser(fs, logger)
+			installationParser := bminstallmanifest.NewParser(fs, bmvars.NewInterpolator(bmvars.Variables{}), logger)
 
 			eventLogger := bmeventlog.NewEventLogger(ui)
 
@@ -161,7 +159,6 @@ cloud_provider:
 				releaseManager,
 				releaseSetResolver,
 				mockCloudFactory,
-				mockAgentClientFactory,
 				mockBlobstoreFactory,
 				mockDeploymentManagerFactory,
 				eventLogger,
@@ -170,7 +167,7 @@ cloud_provider:
 		}
 
 		var expectDeleteAndCleanup = func() {
-			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore).Return(mockDeploymentManager)
+			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, directorID, mbusURL, mockBlobstore).Return(mockDeploymentManager)
 			mockDeploymentManager.EXPECT().FindCurrent().Return(mockDeployment, true, nil)
 
 			//TODO: can we check that the stage is "deleting deployment"?
@@ -181,7 +178,7 @@ cloud_provider:
 		}
 
 		var expectCleanup = func() {
-			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore).Return(mockDeploymentManager).AnyTimes()
+			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, directorID, mbusURL, mockBlobstore).Return(mockDeploymentManager).AnyTimes()
 			mockDeploymentManager.EXPECT().FindCurrent().Return(nil, false, nil).AnyTimes()
 
 			//TODO: can we check that the stage is "deleting deployment"?
@@ -191,7 +188,7 @@ cloud_provider:
 		var expectValidationInstallationDeletionEvents = func() {
 			Expect(ui.Said).To(Equal([]string{
 				"Deployment manifest: '/deployment-dir/fake-deployment-manifest.yml'",
-				"Deployment state: '/deployment-dir/deployment.json'",
+				"Deployment state: '/deployment-dir/fake-deployment-manifest-state.json'",
 				"",
 				"Started validating",
 				"Started validating > Validating releases...", " done. (00:00:00)",
@@ -232,13 +229,8 @@ cloud_provider:
 
 			ui = &fakeui.FakeUI{}
 
-			mockAgentClientFactory = mock_httpagent.NewMockAgentClientFactory(mockCtrl)
-			mockAgentClient = mock_agentclient.NewMockAgentClient(mockCtrl)
-
 			userConfig = bmconfig.UserConfig{DeploymentManifestPath: deploymentManifestPath}
 
-			mockAgentClientFactory.EXPECT().NewAgentClient(gomock.Any(), gomock.Any()).Return(mockAgentClient).AnyTimes()
-
 			directorID = "fake-uuid-0"
 
 			writeDeploymentManifest()
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"errors"
+	"fmt"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
@@ -13,13 +13,13 @@ import (
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
 	bmcpirel "github.com/cloudfoundry/bosh-micro-cli/cpi/release"
 	bmdepl "github.com/cloudfoundry/bosh-micro-cli/deployment"
-	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmdryrun "github.com/cloudfoundry/bosh-micro-cli/deployment/dryrun"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
-	bmvm "github.com/cloudfoundry/bosh-micro-cli/deployment/vm"
 	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
 	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
 	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
 	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
 	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
 	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
@@ -33,6 +33,7 @@ type deployCmd struct {
 	releaseSetParser        bmrelsetmanifest.Parser
 	installationParser      bminstallmanifest.Parser
 	deploymentParser        bmdeplmanifest.Parser
+	patcher                 bmpatch.Patcher
 	deploymentConfigService bmconfig.DeploymentConfigService
 	releaseSetValidator     bmrelsetmanifest.Validator
 	installationValidator   bminstallmanifest.Validator
@@ -42,12 +43,11 @@ type deployCmd struct {
 	releaseManager          bmrel.Manager
 	releaseResolver         bmrelset.Resolver
 	cloudFactory            bmcloud.Factory
-	agentClientFactory      bmhttpagent.AgentClientFactory
-	vmManagerFactory        bmvm.ManagerFactory
 	stemcellExtractor       bmstemcell.Extractor
 	deploymentRecord        bmdepl.Record
 	blobstoreFactory        bmblobstore.Factory
 	deployer                bmdepl.Deployer
+	planner                 bmdryrun.Planner
 	eventLogger             bmeventlog.EventLogger
 	logger                  boshlog.Logger
 	logTag                  string
@@ -60,6 +60,7 @@ func NewDeployCmd(
 	releaseSetParser bmrelsetmanifest.Parser,
 	installationParser bminstallmanifest.Parser,
 	deploymentParser bmdeplmanifest.Parser,
+	patcher bmpatch.Patcher,
 	deploymentConfigService bmconfig.DeploymentConfigService,
 	releaseSetValidator bmrelsetmanifest.Validator,
 	installationValidator bminstallmanifest.Validator,
@@ -69,12 +70,11 @@ func NewDeployCmd(
 	releaseManager bmrel.Manager,
 	releaseResolver bmrelset.Resolver,
 	cloudFactory bmcloud.Factory,
-	agentClientFactory bmhttpagent.AgentClientFactory,
-	vmManagerFactory bmvm.ManagerFactory,
 	stemcellExtractor bmstemcell.Extractor,
 	deploymentRecord bmdepl.Record,
 	blobstoreFactory bmblobstore.Factory,
 	deployer bmdepl.Deployer,
+	planner bmdryrun.Planner,
 	eventLogger bmeventlog.EventLogger,
 	logger boshlog.Logger,
 ) Cmd {
@@ -85,6 +85,7 @@ func NewDeployCmd(
 		releaseSetParser:        releaseSetParser,
 		installationParser:      installationParser,
 		deploymentParser:        deploymentParser,
+		patcher:                 patcher,
 		deploymentConfigService: deploymentConfigService,
 		releaseSetValidator:     releaseSetValidator,
 		installationValidator:   installationValidator,
@@ -94,12 +95,11 @@ func NewDeployCmd(
 		releaseManager:          releaseManager,
 		releaseResolver:         releaseResolver,
 		cloudFactory:            cloudFactory,
-		agentClientFactory:      agentClientFactory,
-		vmManagerFactory:        vmManagerFactory,
 		stemcellExtractor:       stemcellExtractor,
 		deploymentRecord:        deploymentRecord,
 		blobstoreFactory:        blobstoreFactory,
 		deployer:                deployer,
+		planner:                 planner,
 		eventLogger:             eventLogger,
 		logger:                  logger,
 		logTag:                  "deployCmd",
@@ -110,8 +110,21 @@ func (c *deployCmd) Name() string {
 	return "deploy"
 }
 
+func (c *deployCmd) Meta() Meta {
+	return Meta{
+		Summary: "Create or update the deployment",
+		Args:    "<stemcell-tarball> <cpi-release-tarball> [release-2-tarball [release-3-tarball...]]",
+		Flags: []Flag{
+			{Name: "dry-run", Usage: "Show the deploy plan without changing anything"},
+			{Name: "recreate", Usage: "Recreate the VMs even if only jobs or nothing changed"},
+			{Name: "rollback-on-failure", Usage: "Restore the VM & jobs of the last successful deploy when the jobs fail to update"},
+			opsFileFlag,
+		},
+	}
+}
+
 func (c *deployCmd) Run(args []string) error {
-	stemcellTarballPath, releaseTarballPaths, err := c.parseCmdInputs(args)
+	stemcellTarballPath, releaseTarballPaths, options, err := c.parseCmdInputs(args)
 	if err != nil {
 		return err
 	}
@@ -120,6 +133,7 @@ func (c *deployCmd) Run(args []string) error {
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Running deploy cmd")
 	}
+	c.patcher.UseOpsFiles(options.opsFiles)
 
 	validationStage := c.eventLogger.NewStage("validating")
 	validationStage.Start()
@@ -224,7 +238,8 @@ func (c *deployCmd) Run(args []string) error {
 	}
 
 	err = validationStage.PerformStep("Validating cpi release", func() error {
-		cpiRelease, err := c.releaseResolver.Find(installationManifest.Release)
+		var err error
+		cpiRelease, err = c.releaseResolver.Find(installationManifest.Release)
 		if err != nil {
 			// should never happen, due to prior manifest validation
 			return bosherr.WrapErrorf(err, "installation release '%s' must refer to a provided release", installationManifest.Release)
@@ -243,14 +258,24 @@ func (c *deployCmd) Run(args []string) error {
 
 	validationStage.Finish()
 
-	isDeployed, err := c.deploymentRecord.IsDeployed(deploymentManifestPath, cpiRelease, extractedStemcell)
+	releases := c.releaseManager.List()
+	changes, err := c.deploymentRecord.Changes(deploymentManifest, installationManifest, releases, extractedStemcell)
 	if err != nil {
 		return bosherr.WrapError(err, "Checking if deployment has changed")
 	}
 
-	if isDeployed {
-		c.ui.Sayln("No deployment, stemcell or cpi release changes. Skipping deploy.")
-		return nil
+	if changes.IsEmpty() {
+		if !options.recreate {
+			c.ui.Sayln("No deployment, stemcell or release changes. Skipping deploy.")
+			return nil
+		}
+		c.ui.Sayln("No deployment, stemcell or release changes. Recreating the VM.")
+	} else {
+		c.logger.Info(c.logTag, "Deployment changes: %s", changes)
+	}
+
+	if options.dryRun {
+		return c.plan(deploymentConfig, deploymentManifest, extractedStemcell, options.recreate, changes.RenderedJobs)
 	}
 
 	installer, err := c.installerFactory.NewInstaller()
@@ -279,28 +304,31 @@ func (c *deployCmd) Run(args []string) error {
 		return bosherr.WrapError(err, "Creating CPI client from CPI installation")
 	}
 
-	agentClient := c.agentClientFactory.NewAgentClient(deploymentConfig.DirectorID, installationManifest.Mbus)
-	vmManager := c.vmManagerFactory.NewManager(cloud, agentClient)
-
 	blobstore, err := c.blobstoreFactory.Create(installationManifest.Mbus)
 	if err != nil {
 		return bosherr.WrapError(err, "Creating blobstore client")
 	}
 
-	_, err = c.deployer.Deploy(
+	deployment, err := c.deployer.Deploy(
 		cloud,
 		deploymentManifest,
 		extractedStemcell,
-		installationManifest.Registry,
-		installationManifest.SSHTunnel,
-		vmManager,
-		blobstore,
+		bmdepl.DeployOptions{
+			RegistryConfig:    installationManifest.Registry,
+			SSHTunnelConfig:   installationManifest.SSHTunnel,
+			DirectorID:        deploymentConfig.DirectorID,
+			MbusURL:           installationManifest.Mbus,
+			Blobstore:         blobstore,
+			Recreate:          options.recreate,
+			RenderedJobs:      changes.RenderedJobs,
+			RollbackOnFailure: options.rollbackOnFailure,
+		},
 	)
 	if err != nil {
 		return bosherr.WrapError(err, "Deploying Microbosh")
 	}
 
-	err = c.deploymentRecord.Update(deploymentManifestPath, cpiRelease)
+	err = c.deploymentRecord.Update(deploymentManifest, installationManifest, releases, cpiRelease, extractedStemcell, deployment.RenderedJobFingerprints())
 	if err != nil {
 		return bosherr.WrapError(err, "Updating deployment record")
 	}
@@ -310,14 +338,61 @@ func (c *deployCmd) Run(args []string) error {
 
 type Deployment struct{}
 
-func (c *deployCmd) parseCmdInputs(args []string) (string, []string, error) {
-	if len(args) < 2 {
-		c.ui.Error("Invalid usage - deploy command requires at least 2 arguments")
-		c.ui.Sayln("Expected usage: bosh-micro deploy <stemcell-tarball> <cpi-release-tarball> [release-2-tarball [release-3-tarball...]]")
-		c.logger.Error(c.logTag, "Invalid arguments: %#v", args)
-		return "", []string{}, errors.New("Invalid usage - deploy command requires at least 2 arguments")
+func (c *deployCmd) plan(
+	deploymentConfig bmconfig.DeploymentFile,
+	deploymentManifest bmdeplmanifest.Manifest,
+	extractedStemcell bmstemcell.ExtractedStemcell,
+	recreate bool,
+	renderedJobs map[string]string,
+) error {
+	plan, err := c.planner.Plan(deploymentConfig, deploymentManifest, extractedStemcell, recreate, renderedJobs)
+
+	c.ui.Sayln("")
+	c.ui.Sayln("Deploy plan (dry run, no changes will be made):")
+	for i, step := range plan.Steps {
+		stepDescription := fmt.Sprintf("%d. %s > %s", i+1, step.Stage, step.Name)
+		if step.Skipped() {
+			stepDescription = fmt.Sprintf("%s (skipped: %s)", stepDescription, step.SkipReason)
+		}
+		c.ui.Sayln(stepDescription)
+
+		for _, action := range step.Actions {
+			c.ui.Sayln(fmt.Sprintf("     - %s", action))
+		}
+	}
+
+	if err != nil {
+		return bosherr.WrapError(err, "Planning deploy")
+	}
+
+	return nil
+}
+
+// deployOptions are the flags accepted by the deploy cmd
+type deployOptions struct {
+	dryRun            bool
+	recreate          bool
+	rollbackOnFailure bool
+	opsFiles          []string
+}
+
+func (c *deployCmd) parseCmdInputs(args []string) (string, []string, deployOptions, error) {
+	flags, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return "", []string{}, deployOptions{}, usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	options := deployOptions{
+		dryRun:            flags.Bool("dry-run"),
+		recreate:          flags.Bool("recreate"),
+		rollbackOnFailure: flags.Bool("rollback-on-failure"),
+		opsFiles:          flags.Strings(opsFileFlag.Name),
+	}
+
+	if len(positionalArgs) < 2 {
+		return "", []string{}, options, usageError(c, c.ui, c.logger, c.logTag, args, "deploy command requires at least 2 arguments")
 	}
-	return args[0], args[1:], nil
+	return positionalArgs[0], positionalArgs[1:], options, nil
 }
 
 func (c *deployCmd) isBlank(str string) bool {
@@ -46,8 +46,20 @@ func (c *deploymentCmd) Name() string {
 	return "deployment"
 }
 
+func (c *deploymentCmd) Meta() Meta {
+	return Meta{
+		Summary: "Show the current deployment, or switch to a deployment by manifest path or name",
+		Args:    "[<deployment-manifest> | <deployment-name>]",
+	}
+}
+
 func (c *deploymentCmd) Run(args []string) error {
-	if args == nil || len(args) < 1 {
+	_, args, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(args) < 1 {
 		_, err := getDeploymentManifest(c.userConfig, c.ui, c.fs)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Running deployment cmd")
@@ -55,30 +67,67 @@ func (c *deploymentCmd) Run(args []string) error {
 		return nil
 	}
 
-	manifestFilePath := args[0]
-	return c.setDeployment(manifestFilePath)
+	manifestAbsFilePath, err := c.findManifest(args[0])
+	if err != nil {
+		return err
+	}
+
+	return c.setDeployment(manifestAbsFilePath)
 }
 
-func (c *deploymentCmd) setDeployment(manifestFilePath string) error {
-	manifestAbsFilePath, err := filepath.Abs(manifestFilePath)
+// findManifest returns the absolute path of the manifest at the path, or else of the deployment with that name
+func (c *deploymentCmd) findManifest(pathOrName string) (string, error) {
+	manifestAbsFilePath, err := filepath.Abs(pathOrName)
 	if err != nil {
-		c.ui.Error(fmt.Sprintf("Failed getting absolute path to deployment file '%s'", manifestFilePath))
-		return bosherr.WrapErrorf(err, "Getting absolute path to deployment file '%s'", manifestFilePath)
+		c.ui.Error(fmt.Sprintf("Failed getting absolute path to deployment file '%s'", pathOrName))
+		return "", bosherr.WrapErrorf(err, "Getting absolute path to deployment file '%s'", pathOrName)
 	}
 
-	if !c.fs.FileExists(manifestAbsFilePath) {
+	if c.fs.FileExists(manifestAbsFilePath) {
+		return manifestAbsFilePath, nil
+	}
+
+	deployments := c.userConfig.FindDeployments(pathOrName)
+	switch len(deployments) {
+	case 0:
 		c.ui.Error(fmt.Sprintf("Deployment '%s' does not exist", manifestAbsFilePath))
-		return bosherr.Errorf("Verifying that the deployment '%s' exists", manifestAbsFilePath)
+		return "", bosherr.Errorf("Verifying that the deployment '%s' exists", manifestAbsFilePath)
+	case 1:
+		return deployments[0].ManifestPath, nil
+	default:
+		c.ui.Error(fmt.Sprintf("Deployment name '%s' is ambiguous, use one of the manifest paths:", pathOrName))
+		for _, deployment := range deployments {
+			c.ui.Error(fmt.Sprintf("  %s", deployment.ManifestPath))
+		}
+		return "", bosherr.Errorf("Finding deployment '%s': %d deployments have that name", pathOrName, len(deployments))
+	}
+}
+
+func (c *deploymentCmd) setDeployment(manifestAbsFilePath string) error {
+	if !c.fs.FileExists(manifestAbsFilePath) {
+		c.ui.Error(fmt.Sprintf("Deployment manifest '%s' does not exist", manifestAbsFilePath))
+		return bosherr.Errorf("Verifying that the deployment manifest '%s' exists", manifestAbsFilePath)
 	}
 
-	c.userConfig.DeploymentManifestPath = manifestAbsFilePath
-	err = c.userConfigService.Save(c.userConfig)
+	name := deploymentName(c.fs, manifestAbsFilePath, c.logger)
+
+	c.userConfig.SetDeployment(name, manifestAbsFilePath)
+	err := c.userConfigService.Save(c.userConfig)
 	if err != nil {
 		return bosherr.WrapError(err, "Saving user config")
 	}
 
+	c.ui.Sayln(fmt.Sprintf("Deployment '%s' set", name))
 	c.ui.Sayln(fmt.Sprintf("Deployment manifest set to '%s'", manifestAbsFilePath))
 
+	migrated, err := bmconfig.MigrateLegacyDeploymentConfig(c.userConfig, c.fs, c.logger)
+	if err != nil {
+		return err
+	}
+	if migrated {
+		c.ui.Sayln(fmt.Sprintf("Deployment state migrated from '%s' to '%s'", c.userConfig.LegacyDeploymentConfigPath(), c.userConfig.DeploymentConfigPath()))
+	}
+
 	deploymentConfigPath := c.userConfig.DeploymentConfigPath()
 	deploymentConfigService := bmconfig.NewFileSystemDeploymentConfigService(deploymentConfigPath, c.fs, c.uuidGenerator, c.logger)
 
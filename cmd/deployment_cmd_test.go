@@ -78,7 +78,12 @@ var _ = Describe("DeploymentCmd", func() {
 					err = json.Unmarshal(userConfigContents, &userConfig)
 					Expect(err).ToNot(HaveOccurred())
 
-					Expect(userConfig).To(Equal(bmconfig.UserConfig{DeploymentManifestPath: manifestPath}))
+					Expect(userConfig).To(Equal(bmconfig.UserConfig{
+						DeploymentManifestPath: manifestPath,
+						Deployments: []bmconfig.NamedDeployment{
+							{Name: "manifestFile", ManifestPath: manifestPath},
+						},
+					}))
 				})
 
 				It("saves absolute path to deployment manifest in user config", func() {
@@ -98,7 +103,7 @@ var _ = Describe("DeploymentCmd", func() {
 					err = json.Unmarshal(userConfigContents, &userConfig)
 					Expect(err).ToNot(HaveOccurred())
 
-					Expect(userConfig).To(Equal(bmconfig.UserConfig{DeploymentManifestPath: manifestAbsolutePath}))
+					Expect(userConfig.DeploymentManifestPath).To(Equal(manifestAbsolutePath))
 				})
 
 				It("creates a deployment config", func() {
@@ -141,6 +146,110 @@ var _ = Describe("DeploymentCmd", func() {
 				})
 			})
 
+			Context("when a legacy deployment state is next to the deployment manifest", func() {
+				BeforeEach(func() {
+					err := fakeFs.MkdirAll("/fake-dir", os.ModePerm)
+					Expect(err).ToNot(HaveOccurred())
+					manifestPath = "/fake-dir/other-manifest.yml"
+					err = fakeFs.WriteFileString(manifestPath, "")
+					Expect(err).ToNot(HaveOccurred())
+					err = fakeFs.WriteFileString("/fake-dir/deployment.json", `{"director_id":"fake-director-id","current_stemcell_id":"fake-stemcell-id"}`)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("migrates it to the state of the manifest it switches to", func() {
+					err := command.Run([]string{manifestPath})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(fakeUI.Said).To(ContainElement("Deployment state migrated from '/fake-dir/deployment.json' to '/fake-dir/other-manifest-state.json'"))
+
+					Expect(fakeFs.FileExists("/fake-dir/deployment.json")).To(BeFalse())
+					deploymentConfigService := bmconfig.NewFileSystemDeploymentConfigService("/fake-dir/other-manifest-state.json", fakeFs, fakeUUID, logger)
+					deploymentConfig, err := deploymentConfigService.Load()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(deploymentConfig.DirectorID).To(Equal("fake-director-id"))
+					Expect(deploymentConfig.CurrentStemcellID).To(Equal("fake-stemcell-id"))
+				})
+			})
+
+			Context("when the deployment manifest has a name", func() {
+				BeforeEach(func() {
+					manifestPath = "/fake-dir/manifest.yml"
+					err := fakeFs.WriteFileString(manifestPath, "---\nname: fake-deployment-name\n")
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("records the deployment under its name", func() {
+					err := command.Run([]string{manifestPath})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(fakeUI.Said).To(ContainElement("Deployment 'fake-deployment-name' set"))
+
+					userConfig, err := userConfigService.Load()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(userConfig.Deployments).To(Equal([]bmconfig.NamedDeployment{
+						{Name: "fake-deployment-name", ManifestPath: manifestPath},
+					}))
+				})
+			})
+
+			Context("when given the name of a known deployment", func() {
+				BeforeEach(func() {
+					err := fakeFs.WriteFileString("/fake-dir/other-manifest.yml", "")
+					Expect(err).ToNot(HaveOccurred())
+
+					userConfig := bmconfig.UserConfig{
+						DeploymentManifestPath: "/fake-dir/manifest.yml",
+						Deployments: []bmconfig.NamedDeployment{
+							{Name: "fake-name", ManifestPath: "/fake-dir/manifest.yml"},
+							{Name: "fake-other-name", ManifestPath: "/fake-dir/other-manifest.yml"},
+						},
+					}
+					command = NewDeploymentCmd(fakeUI,
+						userConfig,
+						userConfigService,
+						fakeFs,
+						fakeUUID,
+						logger,
+					)
+				})
+
+				It("switches to the manifest of that deployment", func() {
+					err := command.Run([]string{"fake-other-name"})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(fakeUI.Said).To(ContainElement("Deployment manifest set to '/fake-dir/other-manifest.yml'"))
+					Expect(fakeUI.Said).To(ContainElement("Deployment state set to '/fake-dir/other-manifest-state.json'"))
+
+					userConfig, err := userConfigService.Load()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(userConfig.DeploymentManifestPath).To(Equal("/fake-dir/other-manifest.yml"))
+				})
+			})
+
+			Context("when the deployment name is ambiguous", func() {
+				BeforeEach(func() {
+					userConfig := bmconfig.UserConfig{
+						Deployments: []bmconfig.NamedDeployment{
+							{Name: "fake-name", ManifestPath: "/fake-dir-1/manifest.yml"},
+							{Name: "fake-name", ManifestPath: "/fake-dir-2/manifest.yml"},
+						},
+					}
+					command = NewDeploymentCmd(fakeUI,
+						userConfig,
+						userConfigService,
+						fakeFs,
+						fakeUUID,
+						logger,
+					)
+				})
+
+				It("returns an error listing the manifests", func() {
+					err := command.Run([]string{"fake-name"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("2 deployments have that name"))
+					Expect(fakeUI.Errors).To(ContainElement("  /fake-dir-1/manifest.yml"))
+					Expect(fakeUI.Errors).To(ContainElement("  /fake-dir-2/manifest.yml"))
+				})
+			})
+
 			Context("when the deployment manifest does not exist", func() {
 				It("returns err", func() {
 					err := command.Run([]string{"/fake/manifest/path"})
@@ -174,7 +283,7 @@ var _ = Describe("DeploymentCmd", func() {
 						err := command.Run([]string{})
 						Expect(err).ToNot(HaveOccurred())
 						Expect(fakeUI.Said).To(ContainElement("Deployment manifest: '/path/to/manifest.yml'"))
-						Expect(fakeUI.Said).To(ContainElement("Deployment state: '/path/to/deployment.json'"))
+						Expect(fakeUI.Said).To(ContainElement("Deployment state: '/path/to/manifest-state.json'"))
 					})
 				})
 
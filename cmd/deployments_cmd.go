@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type deploymentsCmd struct {
+	ui                bmui.UI
+	userConfig        bmconfig.UserConfig
+	userConfigService bmconfig.UserConfigService
+	fs                boshsys.FileSystem
+	logger            boshlog.Logger
+	logTag            string
+}
+
+func NewDeploymentsCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	userConfigService bmconfig.UserConfigService,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) Cmd {
+	return &deploymentsCmd{
+		ui:                ui,
+		userConfig:        userConfig,
+		userConfigService: userConfigService,
+		fs:                fs,
+		logger:            logger,
+		logTag:            "deploymentsCmd",
+	}
+}
+
+func (c *deploymentsCmd) Name() string {
+	return "deployments"
+}
+
+func (c *deploymentsCmd) Meta() Meta {
+	return Meta{
+		Summary: "List the known deployments with their last known status",
+	}
+}
+
+func (c *deploymentsCmd) Run(args []string) error {
+	_, args, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(args) > 0 {
+		return usageError(c, c.ui, c.logger, c.logTag, args, "deployments command accepts no arguments")
+	}
+
+	err = c.registerCurrentDeployment()
+	if err != nil {
+		return err
+	}
+
+	if len(c.userConfig.Deployments) == 0 {
+		c.ui.Sayln("No deployments")
+		return nil
+	}
+
+	nameWidth, statusWidth := 0, 0
+	statuses := make([]string, len(c.userConfig.Deployments))
+	for i, deployment := range c.userConfig.Deployments {
+		statuses[i] = deploymentStatus(deployment.ManifestPath, c.fs)
+		if len(deployment.Name) > nameWidth {
+			nameWidth = len(deployment.Name)
+		}
+		if len(statuses[i]) > statusWidth {
+			statusWidth = len(statuses[i])
+		}
+	}
+
+	c.ui.Sayln("Deployments:")
+	for i, deployment := range c.userConfig.Deployments {
+		marker := " "
+		if deployment.ManifestPath == c.userConfig.DeploymentManifestPath {
+			marker = "*"
+		}
+		c.ui.Sayln(fmt.Sprintf("%s %-*s  %-*s  %s", marker, nameWidth, deployment.Name, statusWidth, statuses[i], deployment.ManifestPath))
+	}
+
+	return nil
+}
+
+// registerCurrentDeployment names the current deployment when it was set before deployments had names
+func (c *deploymentsCmd) registerCurrentDeployment() error {
+	manifestPath := c.userConfig.DeploymentManifestPath
+	if manifestPath == "" {
+		return nil
+	}
+
+	for _, deployment := range c.userConfig.Deployments {
+		if deployment.ManifestPath == manifestPath {
+			return nil
+		}
+	}
+
+	c.userConfig.SetDeployment(deploymentName(c.fs, manifestPath, c.logger), manifestPath)
+	err := c.userConfigService.Save(c.userConfig)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving user config")
+	}
+	return nil
+}
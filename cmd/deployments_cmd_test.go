@@ -0,0 +1,90 @@
+package cmd_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+)
+
+var _ = Describe("DeploymentsCmd", func() {
+	var (
+		ui                *fakeui.FakeUI
+		fs                *fakesys.FakeFileSystem
+		logger            boshlog.Logger
+		userConfigService bmconfig.UserConfigService
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		userConfigService = bmconfig.NewFileSystemUserConfigService("/fake-user-config", fs, logger)
+	})
+
+	newDeploymentsCmd := func(userConfig bmconfig.UserConfig) Cmd {
+		return NewDeploymentsCmd(ui, userConfig, userConfigService, fs, logger)
+	}
+
+	Describe("Run", func() {
+		It("lists the deployments with their last known status, marking the current one", func() {
+			fs.WriteFileString("/fake-dir/deployed.yml", "")
+			fs.WriteFileString("/fake-dir/deployed-state.json", `{"current_vm_cid":"fake-vm-cid"}`)
+			fs.WriteFileString("/fake-dir/stopped.yml", "")
+			fs.WriteFileString("/fake-dir/stopped-state.json", `{"current_disk_id":"fake-disk-id"}`)
+			fs.WriteFileString("/fake-dir/new.yml", "")
+
+			userConfig := bmconfig.UserConfig{
+				DeploymentManifestPath: "/fake-dir/stopped.yml",
+				Deployments: []bmconfig.NamedDeployment{
+					{Name: "deployed", ManifestPath: "/fake-dir/deployed.yml"},
+					{Name: "stopped", ManifestPath: "/fake-dir/stopped.yml"},
+					{Name: "new", ManifestPath: "/fake-dir/new.yml"},
+					{Name: "removed", ManifestPath: "/fake-dir/removed.yml"},
+				},
+			}
+
+			err := newDeploymentsCmd(userConfig).Run([]string{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Said).To(Equal([]string{
+				"Deployments:",
+				"  deployed  deployed (VM 'fake-vm-cid')  /fake-dir/deployed.yml",
+				"* stopped   stopped (no VM)              /fake-dir/stopped.yml",
+				"  new       not deployed                 /fake-dir/new.yml",
+				"  removed   manifest missing             /fake-dir/removed.yml",
+			}))
+		})
+
+		It("names & saves the current deployment when it was set before deployments had names", func() {
+			fs.WriteFileString("/fake-dir/manifest.yml", "---\nname: fake-name\n")
+
+			err := newDeploymentsCmd(bmconfig.UserConfig{DeploymentManifestPath: "/fake-dir/manifest.yml"}).Run([]string{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Said).To(ContainElement("* fake-name  not deployed  /fake-dir/manifest.yml"))
+
+			userConfig, err := userConfigService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(userConfig.Deployments).To(Equal([]bmconfig.NamedDeployment{
+				{Name: "fake-name", ManifestPath: "/fake-dir/manifest.yml"},
+			}))
+		})
+
+		It("says when there are no deployments", func() {
+			err := newDeploymentsCmd(bmconfig.UserConfig{}).Run([]string{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Said).To(Equal([]string{"No deployments"}))
+		})
+
+		It("returns an error when given arguments", func() {
+			err := newDeploymentsCmd(bmconfig.UserConfig{}).Run([]string{"fake-arg"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid usage"))
+		})
+	})
+})
@@ -1,9 +1,11 @@
 package cmd
 
 import (
-	"errors"
+	"fmt"
+	"sort"
 	"time"
 
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 	boshcmd "github.com/cloudfoundry/bosh-agent/platform/commands"
 	boshsys "github.com/cloudfoundry/bosh-agent/system"
@@ -17,26 +19,32 @@ import (
 	bmdepl "github.com/cloudfoundry/bosh-micro-cli/deployment"
 	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
+	bmdryrun "github.com/cloudfoundry/bosh-micro-cli/deployment/dryrun"
 	bminstance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmdeplrel "github.com/cloudfoundry/bosh-micro-cli/deployment/release"
+	bmsshclient "github.com/cloudfoundry/bosh-micro-cli/deployment/sshclient"
 	bmsshtunnel "github.com/cloudfoundry/bosh-micro-cli/deployment/sshtunnel"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
 	bmvm "github.com/cloudfoundry/bosh-micro-cli/deployment/vm"
 	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
 	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
 	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
 	bmregistry "github.com/cloudfoundry/bosh-micro-cli/registry"
 	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
 	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
 	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+	bmstate "github.com/cloudfoundry/bosh-micro-cli/state"
 	bmtemplate "github.com/cloudfoundry/bosh-micro-cli/templatescompiler"
 	bmtemplateerb "github.com/cloudfoundry/bosh-micro-cli/templatescompiler/erbrenderer"
 	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 type Factory interface {
 	CreateCommand(name string) (Cmd, error)
+	CommandNames() []string
 }
 
 type factory struct {
@@ -49,6 +57,9 @@ type factory struct {
 	logger                   boshlog.Logger
 	uuidGenerator            boshuuid.Generator
 	workspaceRootPath        string
+	variables                bmvars.Variables
+	interpolator             bmvars.Interpolator
+	patcher                  bmpatch.Patcher
 	runner                   boshsys.CmdRunner
 	compressor               boshcmd.Compressor
 	agentClientFactory       bmhttpagent.AgentClientFactory
@@ -56,8 +67,11 @@ type factory struct {
 	vmRepo                   bmconfig.VMRepo
 	stemcellRepo             bmconfig.StemcellRepo
 	diskRepo                 bmconfig.DiskRepo
+	instanceRepo             bmconfig.InstanceRepo
+	releaseRepo              bmconfig.ReleaseRepo
 	registryServerManager    bmregistry.ServerManager
 	sshTunnelFactory         bmsshtunnel.Factory
+	sshClientFactory         bmsshclient.Factory
 	diskDeployer             bmvm.DiskDeployer
 	diskManagerFactory       bmdisk.ManagerFactory
 	instanceFactory          bminstance.Factory
@@ -66,6 +80,7 @@ type factory struct {
 	deploymentManagerFactory bmdepl.ManagerFactory
 	deploymentFactory        bmdepl.Factory
 	deployer                 bmdepl.Deployer
+	planner                  bmdryrun.Planner
 	blobstoreFactory         bmblobstore.Factory
 	eventLogger              bmeventlog.EventLogger
 	timeService              boshtime.Service
@@ -81,6 +96,7 @@ type factory struct {
 	deploymentValidator      bmdeplmanifest.Validator
 	cloudFactory             bmcloud.Factory
 	stateBuilderFactory      bminstance.StateBuilderFactory
+	jobListRenderer          bmtemplate.JobListRenderer
 }
 
 func NewFactory(
@@ -91,6 +107,7 @@ func NewFactory(
 	logger boshlog.Logger,
 	uuidGenerator boshuuid.Generator,
 	workspaceRootPath string,
+	variables bmvars.Variables,
 ) Factory {
 	f := &factory{
 		userConfig:        userConfig,
@@ -100,23 +117,66 @@ func NewFactory(
 		logger:            logger,
 		uuidGenerator:     uuidGenerator,
 		workspaceRootPath: workspaceRootPath,
+		variables:         variables,
 	}
 	f.commands = map[string](func() (Cmd, error)){
-		"deployment": f.createDeploymentCmd,
-		"deploy":     f.createDeployCmd,
-		"delete":     f.createDeleteCmd,
+		"deployment":       f.createDeploymentCmd,
+		"deployments":      f.createDeploymentsCmd,
+		"deploy":           f.createDeployCmd,
+		"delete":           f.createDeleteCmd,
+		"status":           f.createStatusCmd,
+		"validate":         f.createValidateCmd,
+		"ssh":              f.createSSHCmd,
+		"logs":             f.createLogsCmd,
+		"recreate":         f.createRecreateCmd,
+		"stop":             f.createStopCmd,
+		"start":            f.createStartCmd,
+		"restart":          f.createRestartCmd,
+		"cloud-check":      f.createCloudCheckCmd,
+		"state":            f.createStateCmd,
+		"cleanup":          f.createCleanupCmd,
+		"help":             f.createHelpCmd,
+		"interpolate":      f.createInterpolateCmd,
+		"render-templates": f.createRenderTemplatesCmd,
+		"run-errand":       f.createRunErrandCmd,
 	}
 	return f
 }
 
 func (f *factory) CreateCommand(name string) (Cmd, error) {
 	if f.commands[name] == nil {
-		return nil, errors.New("Invalid command name")
+		f.ui.Error(fmt.Sprintf("Unknown command '%s'. Run 'bosh-micro help' for the list of commands.", name))
+		return nil, bosherr.Errorf("Unknown command '%s'", name)
 	}
 
 	return f.commands[name]()
 }
 
+// CommandNames returns the names of all the commands, sorted
+func (f *factory) CommandNames() []string {
+	names := []string{}
+	for name := range f.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f *factory) createHelpCmd() (Cmd, error) {
+	return NewHelpCmd(f.ui, f, f.logger), nil
+}
+
+func (f *factory) createInterpolateCmd() (Cmd, error) {
+	return NewInterpolateCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadPatcher(),
+		f.loadInterpolator(),
+		f.logger,
+	), nil
+}
+
 func (f *factory) createDeploymentCmd() (Cmd, error) {
 	return NewDeploymentCmd(
 		f.ui,
@@ -128,14 +188,22 @@ func (f *factory) createDeploymentCmd() (Cmd, error) {
 	), nil
 }
 
+func (f *factory) createDeploymentsCmd() (Cmd, error) {
+	return NewDeploymentsCmd(
+		f.ui,
+		f.userConfig,
+		f.userConfigService,
+		f.fs,
+		f.logger,
+	), nil
+}
+
 func (f *factory) createDeployCmd() (Cmd, error) {
 	stemcellReader := bmstemcell.NewReader(f.loadCompressor(), f.fs)
 	stemcellExtractor := bmstemcell.NewExtractor(stemcellReader, f.fs)
 
 	deploymentRepo := bmconfig.NewDeploymentRepo(f.loadDeploymentConfigService())
-	releaseRepo := bmconfig.NewReleaseRepo(f.loadDeploymentConfigService(), f.uuidGenerator)
-	sha1Calculator := bmcrypto.NewSha1Calculator(f.fs)
-	deploymentRecord := bmdepl.NewRecord(deploymentRepo, releaseRepo, f.loadStemcellRepo(), sha1Calculator)
+	deploymentRecord := bmdepl.NewRecord(deploymentRepo, f.loadReleaseRepo())
 
 	return NewDeployCmd(
 		f.ui,
@@ -144,6 +212,7 @@ func (f *factory) createDeployCmd() (Cmd, error) {
 		f.loadReleaseSetParser(),
 		f.loadInstallationParser(),
 		f.loadDeploymentParser(),
+		f.loadPatcher(),
 		f.loadDeploymentConfigService(),
 		f.loadReleaseSetValidator(),
 		f.loadInstallationValidator(),
@@ -153,12 +222,160 @@ func (f *factory) createDeployCmd() (Cmd, error) {
 		f.loadReleaseManager(),
 		f.loadReleaseResolver(),
 		f.loadCloudFactory(),
-		f.loadAgentClientFactory(),
-		f.loadVMManagerFactory(),
 		stemcellExtractor,
 		deploymentRecord,
 		f.loadBlobstoreFactory(),
 		f.loadDeployer(),
+		f.loadPlanner(),
+		f.loadEventLogger(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createRecreateCmd() (Cmd, error) {
+	deployCmd, err := f.createDeployCmd()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRecreateCmd(f.ui, deployCmd, f.logger), nil
+}
+
+func (f *factory) createStopCmd() (Cmd, error) {
+	return NewStopCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadReleaseSetParser(),
+		f.loadInstallationParser(),
+		f.loadDeploymentParser(),
+		f.loadDeploymentConfigService(),
+		f.loadReleaseSetValidator(),
+		f.loadInstallationValidator(),
+		f.loadInstallerFactory(),
+		f.loadReleaseExtractor(),
+		f.loadReleaseManager(),
+		f.loadReleaseResolver(),
+		f.loadCloudFactory(),
+		f.loadAgentClientFactory(),
+		f.loadInstanceRepo(),
+		f.loadVMRepo(),
+		f.loadDiskRepo(),
+		f.uuidGenerator,
+		f.loadEventLogger(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createCloudCheckCmd() (Cmd, error) {
+	deployCmd, err := f.createDeployCmd()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCloudCheckCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadReleaseSetParser(),
+		f.loadInstallationParser(),
+		f.loadDeploymentConfigService(),
+		f.loadReleaseSetValidator(),
+		f.loadInstallationValidator(),
+		f.loadInstallerFactory(),
+		f.loadReleaseExtractor(),
+		f.loadReleaseManager(),
+		f.loadReleaseResolver(),
+		f.loadCloudFactory(),
+		f.loadAgentClientFactory(),
+		f.loadInstanceRepo(),
+		f.loadVMRepo(),
+		f.loadDiskRepo(),
+		f.loadStemcellRepo(),
+		f.uuidGenerator,
+		deployCmd,
+		f.loadEventLogger(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createStateCmd() (Cmd, error) {
+	return NewStateCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		bmstate.NewBundler(f.fs, f.loadCompressor(), f.workspaceRootPath, f.logger),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createCleanupCmd() (Cmd, error) {
+	return NewCleanupCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadReleaseSetParser(),
+		f.loadInstallationParser(),
+		f.loadDeploymentConfigService(),
+		f.loadReleaseSetValidator(),
+		f.loadInstallationValidator(),
+		f.loadInstallerFactory(),
+		f.loadReleaseExtractor(),
+		f.loadReleaseManager(),
+		f.loadReleaseResolver(),
+		f.loadCloudFactory(),
+		f.loadInstanceRepo(),
+		f.loadStemcellRepo(),
+		f.loadDiskManagerFactory(),
+		f.loadStemcellManagerFactory(),
+		f.loadEventLogger(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createStartCmd() (Cmd, error) {
+	deployCmd, err := f.createDeployCmd()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStartCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadInstallationParser(),
+		f.loadDeploymentParser(),
+		f.loadDeploymentConfigService(),
+		f.loadInstanceRepo(),
+		f.loadVMRepo(),
+		f.loadDiskRepo(),
+		f.uuidGenerator,
+		f.loadAgentClientFactory(),
+		deployCmd,
+		f.loadEventLogger(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createRestartCmd() (Cmd, error) {
+	deployCmd, err := f.createDeployCmd()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRestartCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadInstallationParser(),
+		f.loadDeploymentParser(),
+		f.loadDeploymentConfigService(),
+		f.loadInstanceRepo(),
+		f.loadVMRepo(),
+		f.loadDiskRepo(),
+		f.uuidGenerator,
+		f.loadAgentClientFactory(),
+		deployCmd,
 		f.loadEventLogger(),
 		f.logger,
 	), nil
@@ -179,7 +396,6 @@ func (f *factory) createDeleteCmd() (Cmd, error) {
 		f.loadReleaseManager(),
 		f.loadReleaseResolver(),
 		f.loadCloudFactory(),
-		f.loadAgentClientFactory(),
 		f.loadBlobstoreFactory(),
 		f.loadDeploymentManagerFactory(),
 		f.loadEventLogger(),
@@ -187,6 +403,121 @@ func (f *factory) createDeleteCmd() (Cmd, error) {
 	), nil
 }
 
+func (f *factory) createStatusCmd() (Cmd, error) {
+	return NewStatusCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadReleaseSetParser(),
+		f.loadInstallationParser(),
+		f.loadDeploymentConfigService(),
+		f.loadReleaseSetValidator(),
+		f.loadInstallationValidator(),
+		f.loadInstallerFactory(),
+		f.loadReleaseExtractor(),
+		f.loadReleaseManager(),
+		f.loadReleaseResolver(),
+		f.loadCloudFactory(),
+		f.loadAgentClientFactory(),
+		f.loadInstanceRepo(),
+		f.loadVMRepo(),
+		f.loadDiskRepo(),
+		f.loadStemcellRepo(),
+		f.loadReleaseRepo(),
+		f.uuidGenerator,
+		f.loadEventLogger(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createValidateCmd() (Cmd, error) {
+	return NewValidateCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadReleaseSetParser(),
+		f.loadInstallationParser(),
+		f.loadDeploymentParser(),
+		f.loadPatcher(),
+		f.loadReleaseExtractor(),
+		f.loadReleaseManager(),
+		f.loadReleaseResolver(),
+		f.loadEventLogger(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createRenderTemplatesCmd() (Cmd, error) {
+	return NewRenderTemplatesCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadReleaseSetParser(),
+		f.loadDeploymentParser(),
+		f.loadPatcher(),
+		f.loadReleaseExtractor(),
+		f.loadReleaseManager(),
+		f.loadReleaseResolver(),
+		bmdeplrel.NewJobResolver(f.loadReleaseResolver()),
+		f.loadJobListRenderer(),
+		f.loadCMDRunner(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createRunErrandCmd() (Cmd, error) {
+	return NewRunErrandCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadReleaseSetParser(),
+		f.loadInstallationParser(),
+		f.loadDeploymentParser(),
+		f.loadPatcher(),
+		f.loadDeploymentConfigService(),
+		f.loadInstanceRepo(),
+		f.loadVMRepo(),
+		f.loadDiskRepo(),
+		f.uuidGenerator,
+		f.loadAgentClientFactory(),
+		f.loadReleaseExtractor(),
+		f.loadReleaseManager(),
+		f.loadReleaseResolver(),
+		f.loadBlobstoreFactory(),
+		f.loadStateBuilderFactory(),
+		f.loadEventLogger(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createSSHCmd() (Cmd, error) {
+	return NewSSHCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadInstallationParser(),
+		f.loadInstanceRepo(),
+		f.loadSSHClientFactory(),
+		f.logger,
+	), nil
+}
+
+func (f *factory) createLogsCmd() (Cmd, error) {
+	return NewLogsCmd(
+		f.ui,
+		f.userConfig,
+		f.fs,
+		f.loadInstallationParser(),
+		f.loadDeploymentConfigService(),
+		f.loadInstanceRepo(),
+		f.loadAgentClientFactory(),
+		f.loadBlobstoreFactory(),
+		f.loadTimeService(),
+		f.loadEventLogger(),
+		f.logger,
+	), nil
+}
+
 func (f *factory) loadCMDRunner() boshsys.CmdRunner {
 	if f.runner != nil {
 		return f.runner
@@ -227,6 +558,22 @@ func (f *factory) loadDiskRepo() bmconfig.DiskRepo {
 	return f.diskRepo
 }
 
+func (f *factory) loadInstanceRepo() bmconfig.InstanceRepo {
+	if f.instanceRepo != nil {
+		return f.instanceRepo
+	}
+	f.instanceRepo = bmconfig.NewInstanceRepo(f.loadDeploymentConfigService())
+	return f.instanceRepo
+}
+
+func (f *factory) loadReleaseRepo() bmconfig.ReleaseRepo {
+	if f.releaseRepo != nil {
+		return f.releaseRepo
+	}
+	f.releaseRepo = bmconfig.NewReleaseRepo(f.loadDeploymentConfigService(), f.uuidGenerator)
+	return f.releaseRepo
+}
+
 func (f *factory) loadRegistryServerManager() bmregistry.ServerManager {
 	if f.registryServerManager != nil {
 		return f.registryServerManager
@@ -245,6 +592,15 @@ func (f *factory) loadSSHTunnelFactory() bmsshtunnel.Factory {
 	return f.sshTunnelFactory
 }
 
+func (f *factory) loadSSHClientFactory() bmsshclient.Factory {
+	if f.sshClientFactory != nil {
+		return f.sshClientFactory
+	}
+
+	f.sshClientFactory = bmsshclient.NewFactory(f.logger)
+	return f.sshClientFactory
+}
+
 func (f *factory) loadDiskDeployer() bmvm.DiskDeployer {
 	if f.diskDeployer != nil {
 		return f.diskDeployer
@@ -295,10 +651,6 @@ func (f *factory) loadStateBuilderFactory() bminstance.StateBuilderFactory {
 	releaseSetResolver := bmrelset.NewResolver(f.loadReleaseManager(), f.logger)
 	releaseJobResolver := bmdeplrel.NewJobResolver(releaseSetResolver)
 
-	erbRenderer := bmtemplateerb.NewERBRenderer(f.fs, f.loadCMDRunner(), f.logger)
-	jobRenderer := bmtemplate.NewJobRenderer(erbRenderer, f.fs, f.logger)
-	jobListRenderer := bmtemplate.NewJobListRenderer(jobRenderer, f.logger)
-
 	sha1Calculator := bmcrypto.NewSha1Calculator(f.fs)
 
 	renderedJobListCompressor := bmtemplate.NewRenderedJobListCompressor(
@@ -309,15 +661,29 @@ func (f *factory) loadStateBuilderFactory() bminstance.StateBuilderFactory {
 	)
 
 	f.stateBuilderFactory = bminstance.NewStateBuilderFactory(
+		releaseSetResolver,
 		releaseJobResolver,
-		jobListRenderer,
+		f.loadJobListRenderer(),
 		renderedJobListCompressor,
+		f.loadCompressor(),
+		sha1Calculator,
 		f.uuidGenerator,
 		f.logger,
 	)
 	return f.stateBuilderFactory
 }
 
+func (f *factory) loadJobListRenderer() bmtemplate.JobListRenderer {
+	if f.jobListRenderer != nil {
+		return f.jobListRenderer
+	}
+
+	erbRenderer := bmtemplateerb.NewERBRenderer(f.fs, f.loadCMDRunner(), f.logger)
+	jobRenderer := bmtemplate.NewJobRenderer(erbRenderer, f.fs, f.logger)
+	f.jobListRenderer = bmtemplate.NewJobListRenderer(jobRenderer, f.logger)
+	return f.jobListRenderer
+}
+
 func (f *factory) loadDeploymentManagerFactory() bmdepl.ManagerFactory {
 	if f.deploymentManagerFactory != nil {
 		return f.deploymentManagerFactory
@@ -329,6 +695,8 @@ func (f *factory) loadDeploymentManagerFactory() bmdepl.ManagerFactory {
 		f.loadDiskManagerFactory(),
 		f.loadStemcellManagerFactory(),
 		f.loadDeploymentFactory(),
+		f.loadAgentClientFactory(),
+		f.loadInstanceRepo(),
 	)
 	return f.deploymentManagerFactory
 }
@@ -366,6 +734,7 @@ func (f *factory) loadVMManagerFactory() bmvm.ManagerFactory {
 		f.loadVMRepo(),
 		f.loadStemcellRepo(),
 		f.loadDiskDeployer(),
+		f.loadDeploymentConfigService(),
 		f.uuidGenerator,
 		f.fs,
 		f.logger,
@@ -414,12 +783,29 @@ func (f *factory) loadDeployer() bmdepl.Deployer {
 		f.loadVMManagerFactory(),
 		f.loadInstanceManagerFactory(),
 		f.loadDeploymentFactory(),
+		f.loadAgentClientFactory(),
+		f.loadInstanceRepo(),
 		f.loadEventLogger(),
 		f.logger,
 	)
 	return f.deployer
 }
 
+func (f *factory) loadPlanner() bmdryrun.Planner {
+	if f.planner != nil {
+		return f.planner
+	}
+
+	f.planner = bmdryrun.NewPlanner(
+		f.loadInstanceFactory(),
+		f.loadSSHTunnelFactory(),
+		f.uuidGenerator,
+		f.fs,
+		f.logger,
+	)
+	return f.planner
+}
+
 func (f *factory) loadBlobstoreFactory() bmblobstore.Factory {
 	if f.blobstoreFactory != nil {
 		return f.blobstoreFactory
@@ -476,12 +862,43 @@ func (f *factory) loadReleaseResolver() bmrelset.Resolver {
 	return f.releaseResolver
 }
 
+func (f *factory) loadInterpolator() bmvars.Interpolator {
+	if f.interpolator != nil {
+		return f.interpolator
+	}
+
+	var interpolator bmvars.Interpolator
+	varsStorePath := f.userConfig.VarsStorePath()
+	if varsStorePath == "" {
+		interpolator = bmvars.NewInterpolator(f.variables)
+	} else {
+		interpolator = bmvars.NewGeneratingInterpolator(
+			f.variables,
+			bmvars.NewFileStore(varsStorePath, f.fs, f.logger),
+			bmvars.NewGenerator(f.loadTimeService()),
+			f.logger,
+		)
+	}
+
+	f.interpolator = bmpatch.NewPatchingInterpolator(f.loadPatcher(), interpolator)
+	return f.interpolator
+}
+
+func (f *factory) loadPatcher() bmpatch.Patcher {
+	if f.patcher != nil {
+		return f.patcher
+	}
+
+	f.patcher = bmpatch.NewPatcher(f.fs, f.logger)
+	return f.patcher
+}
+
 func (f *factory) loadReleaseSetParser() bmrelsetmanifest.Parser {
 	if f.releaseSetParser != nil {
 		return f.releaseSetParser
 	}
 
-	f.releaseSetParser = bmrelsetmanifest.NewParser(f.fs, f.logger)
+	f.releaseSetParser = bmrelsetmanifest.NewParser(f.fs, f.loadInterpolator(), f.logger)
 	return f.releaseSetParser
 }
 
@@ -490,7 +907,7 @@ func (f *factory) loadInstallationParser() bminstallmanifest.Parser {
 		return f.installationParser
 	}
 
-	f.installationParser = bminstallmanifest.NewParser(f.fs, f.logger)
+	f.installationParser = bminstallmanifest.NewParser(f.fs, f.loadInterpolator(), f.logger)
 	return f.installationParser
 }
 
@@ -499,7 +916,7 @@ func (f *factory) loadDeploymentParser() bmdeplmanifest.Parser {
 		return f.deploymentParser
 	}
 
-	f.deploymentParser = bmdeplmanifest.NewParser(f.fs, f.logger)
+	f.deploymentParser = bmdeplmanifest.NewParser(f.fs, f.loadInterpolator(), f.logger)
 	return f.deploymentParser
 }
 
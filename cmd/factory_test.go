@@ -1,11 +1,14 @@
 package cmd_test
 
 import (
+	"sort"
+
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 	boshsys "github.com/cloudfoundry/bosh-agent/system"
 
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
 	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 
 	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
 	. "github.com/onsi/ginkgo"
@@ -43,6 +46,7 @@ var _ = Describe("cmd.Factory", func() {
 			logger,
 			uuidGenerator,
 			"/fake-path",
+			bmvars.Variables{},
 		)
 	})
 
@@ -57,6 +61,44 @@ var _ = Describe("cmd.Factory", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(cmd.Name()).To(Equal("deployment"))
 			})
+
+			It("does not generate the variables of the new manifest into the vars store of the current deployment", func() {
+				err := fs.WriteFileString("/fake-path/manifest-vars-store.yml", "fake-variable: fake-value\n")
+				Expect(err).ToNot(HaveOccurred())
+				err = fs.WriteFileString("/fake-other-path/manifest.yml", `---
+name: fake-other-deployment
+properties:
+  password: ((fake-password))
+variables:
+- name: fake-password
+  type: password
+`)
+				Expect(err).ToNot(HaveOccurred())
+
+				cmd, err := factory.CreateCommand("deployment")
+				Expect(err).ToNot(HaveOccurred())
+				err = cmd.Run([]string{"/fake-other-path/manifest.yml"})
+				Expect(err).ToNot(HaveOccurred())
+
+				contents, err := fs.ReadFileString("/fake-path/manifest-vars-store.yml")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(contents).To(Equal("fake-variable: fake-value\n"))
+				Expect(fs.FileExists("/fake-other-path/manifest-vars-store.yml")).To(BeFalse())
+
+				userConfig, err := userConfigService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(userConfig.Deployments).To(Equal([]bmconfig.NamedDeployment{
+					{Name: "fake-other-deployment", ManifestPath: "/fake-other-path/manifest.yml"},
+				}))
+			})
+		})
+
+		Describe("deployments command", func() {
+			It("returns deployments command", func() {
+				cmd, err := factory.CreateCommand("deployments")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("deployments"))
+			})
 		})
 
 		Describe("deploy command", func() {
@@ -74,12 +116,141 @@ var _ = Describe("cmd.Factory", func() {
 				Expect(cmd.Name()).To(Equal("delete"))
 			})
 		})
+
+		Describe("status command", func() {
+			It("returns status command", func() {
+				cmd, err := factory.CreateCommand("status")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("status"))
+			})
+		})
+
+		Describe("validate command", func() {
+			It("returns validate command", func() {
+				cmd, err := factory.CreateCommand("validate")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("validate"))
+			})
+		})
+
+		Describe("ssh command", func() {
+			It("returns ssh command", func() {
+				cmd, err := factory.CreateCommand("ssh")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("ssh"))
+			})
+		})
+
+		Describe("logs command", func() {
+			It("returns logs command", func() {
+				cmd, err := factory.CreateCommand("logs")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("logs"))
+			})
+		})
+
+		Describe("recreate command", func() {
+			It("returns recreate command", func() {
+				cmd, err := factory.CreateCommand("recreate")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("recreate"))
+			})
+		})
+
+		Describe("stop command", func() {
+			It("returns stop command", func() {
+				cmd, err := factory.CreateCommand("stop")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("stop"))
+			})
+		})
+
+		Describe("start command", func() {
+			It("returns start command", func() {
+				cmd, err := factory.CreateCommand("start")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("start"))
+			})
+		})
+
+		Describe("restart command", func() {
+			It("returns restart command", func() {
+				cmd, err := factory.CreateCommand("restart")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("restart"))
+			})
+		})
+
+		Describe("cloud-check command", func() {
+			It("returns cloud-check command", func() {
+				cmd, err := factory.CreateCommand("cloud-check")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("cloud-check"))
+			})
+		})
+
+		Describe("state command", func() {
+			It("returns state command", func() {
+				cmd, err := factory.CreateCommand("state")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("state"))
+			})
+		})
+
+		Describe("cleanup command", func() {
+			It("returns cleanup command", func() {
+				cmd, err := factory.CreateCommand("cleanup")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("cleanup"))
+			})
+		})
+
+		Describe("render-templates command", func() {
+			It("returns render-templates command", func() {
+				cmd, err := factory.CreateCommand("render-templates")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("render-templates"))
+			})
+		})
+
+		Describe("run-errand command", func() {
+			It("returns run-errand command", func() {
+				cmd, err := factory.CreateCommand("run-errand")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("run-errand"))
+			})
+		})
+
+		Describe("help command", func() {
+			It("returns help command", func() {
+				cmd, err := factory.CreateCommand("help")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal("help"))
+			})
+		})
 	})
 
 	Context("unknown command name", func() {
 		It("returns error", func() {
 			_, err := factory.CreateCommand("bogus-cmd-name")
 			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Unknown command 'bogus-cmd-name'"))
+			Expect(ui.(*fakeui.FakeUI).Errors).To(ContainElement("Unknown command 'bogus-cmd-name'. Run 'bosh-micro help' for the list of commands."))
+		})
+	})
+
+	Describe("CommandNames", func() {
+		It("returns the sorted names of the commands, each of which can be created", func() {
+			names := factory.CommandNames()
+			Expect(names).To(ContainElement("deploy"))
+			Expect(names).To(ContainElement("help"))
+			Expect(sort.StringsAreSorted(names)).To(BeTrue())
+
+			for _, name := range names {
+				cmd, err := factory.CreateCommand(name)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmd.Name()).To(Equal(name))
+			}
 		})
 	})
 })
@@ -1,9 +1,14 @@
 package fakes
 
+import (
+	cmd "github.com/cloudfoundry/bosh-micro-cli/cmd"
+)
+
 type FakeCommand struct {
 	name        string
 	Args        []string
 	PresetError error
+	PresetMeta  cmd.Meta
 }
 
 func NewFakeCommand(name string) *FakeCommand {
@@ -17,6 +22,10 @@ func (f *FakeCommand) Name() string {
 	return f.name
 }
 
+func (f *FakeCommand) Meta() cmd.Meta {
+	return f.PresetMeta
+}
+
 func (f *FakeCommand) Run(args []string) error {
 	f.Args = args
 	return f.PresetError
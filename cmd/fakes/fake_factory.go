@@ -5,12 +5,17 @@ import (
 )
 
 type FakeFactory struct {
-	CommandName   string
-	PresetError   error
-	PresetCommand *FakeCommand
+	CommandName        string
+	PresetError        error
+	PresetCommand      *FakeCommand
+	PresetCommandNames []string
 }
 
 func (f *FakeFactory) CreateCommand(name string) (cmd.Cmd, error) {
 	f.CommandName = name
 	return f.PresetCommand, f.PresetError
 }
+
+func (f *FakeFactory) CommandNames() []string {
+	return f.PresetCommandNames
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+)
+
+// Meta describes a command for its usage & help text, and declares its flags.
+type Meta struct {
+	Summary string
+
+	// Args describes the positional arguments, e.g. "<cpi-release-tarball>"
+	Args string
+
+	Flags []Flag
+}
+
+// Flag is a command line flag of a command.
+// Flags with a ValueName take a string value, the others are booleans.
+// Repeatable flags collect the values of every occurrence.
+type Flag struct {
+	Name       string
+	Short      string
+	ValueName  string
+	Usage      string
+	Repeatable bool
+}
+
+// opsFileFlag is the flag of the commands patching the deployment manifest with ops files
+var opsFileFlag = Flag{
+	Name:       "ops-file",
+	Short:      "o",
+	ValueName:  "path",
+	Usage:      "Apply the replace & remove ops of the YAML file to the deployment manifest (can be repeated)",
+	Repeatable: true,
+}
+
+func (f Flag) String() string {
+	if f.ValueName == "" {
+		return "--" + f.Name
+	}
+	return fmt.Sprintf("--%s <%s>", f.Name, f.ValueName)
+}
+
+// helpName returns the flag with its short name, e.g. "-o, --ops-file <path>"
+func (f Flag) helpName() string {
+	if f.Short == "" {
+		return f.String()
+	}
+	return fmt.Sprintf("-%s, %s", f.Short, f)
+}
+
+// Usage returns the one line usage of the command, generated from its flags & arguments
+func (m Meta) Usage(name string) string {
+	parts := []string{"bosh-micro", name}
+	for _, f := range m.Flags {
+		if f.Repeatable {
+			parts = append(parts, fmt.Sprintf("[%s]...", f))
+		} else {
+			parts = append(parts, fmt.Sprintf("[%s]", f))
+		}
+	}
+	if m.Args != "" {
+		parts = append(parts, m.Args)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Help returns the usage of the command followed by its summary and the description of its flags
+func (m Meta) Help(name string) string {
+	lines := []string{"Usage: " + m.Usage(name)}
+
+	if m.Summary != "" {
+		lines = append(lines, "", m.Summary)
+	}
+
+	if len(m.Flags) > 0 {
+		lines = append(lines, "", "Flags:")
+		lines = append(lines, flagsHelp(m.Flags)...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// flagsHelp returns one aligned line per flag, with its usage
+func flagsHelp(flags []Flag) []string {
+	width := 0
+	for _, f := range flags {
+		if len(f.helpName()) > width {
+			width = len(f.helpName())
+		}
+	}
+
+	lines := []string{}
+	for _, f := range flags {
+		lines = append(lines, fmt.Sprintf("  %-*s  %s", width, f.helpName(), f.Usage))
+	}
+	return lines
+}
+
+// Flags are the flag values parsed for a command
+type Flags struct {
+	bools        map[string]*bool
+	strings      map[string]*string
+	stringSlices map[string]*stringSlice
+}
+
+func (f Flags) Bool(name string) bool {
+	value, found := f.bools[name]
+	return found && *value
+}
+
+func (f Flags) String(name string) string {
+	value, found := f.strings[name]
+	if !found {
+		return ""
+	}
+	return *value
+}
+
+// Strings returns the values of a repeatable flag, in order
+func (f Flags) Strings(name string) []string {
+	value, found := f.stringSlices[name]
+	if !found {
+		return nil
+	}
+	return *value
+}
+
+// parseFlags parses the flags declared by the meta out of args, returning the remaining positional arguments.
+// Flags may be given before, between or after the positional arguments; arguments after "--" are all positional.
+func parseFlags(meta Meta, args []string) (Flags, []string, error) {
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	flagSet.SetOutput(ioutil.Discard)
+
+	flags := Flags{
+		bools:        map[string]*bool{},
+		strings:      map[string]*string{},
+		stringSlices: map[string]*stringSlice{},
+	}
+	for _, f := range meta.Flags {
+		names := []string{f.Name}
+		if f.Short != "" {
+			names = append(names, f.Short)
+		}
+
+		switch {
+		case f.ValueName == "":
+			flags.bools[f.Name] = new(bool)
+			for _, name := range names {
+				flagSet.BoolVar(flags.bools[f.Name], name, false, f.Usage)
+			}
+		case f.Repeatable:
+			flags.stringSlices[f.Name] = &stringSlice{}
+			for _, name := range names {
+				flagSet.Var(flags.stringSlices[f.Name], name, f.Usage)
+			}
+		default:
+			flags.strings[f.Name] = new(string)
+			for _, name := range names {
+				flagSet.StringVar(flags.strings[f.Name], name, "", f.Usage)
+			}
+		}
+	}
+
+	positional := []string{}
+	remaining := args
+	for {
+		err := flagSet.Parse(remaining)
+		if err != nil {
+			return flags, positional, bosherr.WrapError(err, "Parsing flags")
+		}
+
+		remaining = flagSet.Args()
+		if len(remaining) == 0 {
+			break
+		}
+
+		consumed := len(args) - len(remaining)
+		if consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, remaining...)
+			break
+		}
+
+		positional = append(positional, remaining[0])
+		remaining = remaining[1:]
+		args = remaining
+	}
+
+	return flags, positional, nil
+}
+
+// stringSlice is a flag value collecting the values of a repeated flag
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,57 @@
+package cmd_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Meta", func() {
+	var meta Meta
+
+	BeforeEach(func() {
+		meta = Meta{
+			Summary: "fake-summary",
+			Args:    "<fake-arg>",
+			Flags: []Flag{
+				{Name: "fake-bool", Usage: "fake-bool-usage"},
+				{Name: "fake-string", ValueName: "fake-value", Usage: "fake-string-usage"},
+			},
+		}
+	})
+
+	Describe("Usage", func() {
+		It("lists the flags & the arguments", func() {
+			Expect(meta.Usage("fake-cmd")).To(Equal("bosh-micro fake-cmd [--fake-bool] [--fake-string <fake-value>] <fake-arg>"))
+		})
+
+		It("marks repeatable flags", func() {
+			meta.Flags = []Flag{{Name: "fake-list", Short: "l", ValueName: "fake-value", Repeatable: true}}
+			Expect(meta.Usage("fake-cmd")).To(Equal("bosh-micro fake-cmd [--fake-list <fake-value>]... <fake-arg>"))
+		})
+
+		It("is only the command name without flags & arguments", func() {
+			Expect(Meta{}.Usage("fake-cmd")).To(Equal("bosh-micro fake-cmd"))
+		})
+	})
+
+	Describe("Help", func() {
+		It("includes the usage, summary & aligned flag descriptions", func() {
+			Expect(meta.Help("fake-cmd")).To(Equal(`Usage: bosh-micro fake-cmd [--fake-bool] [--fake-string <fake-value>] <fake-arg>
+
+fake-summary
+
+Flags:
+  --fake-bool                 fake-bool-usage
+  --fake-string <fake-value>  fake-string-usage`))
+		})
+
+		It("includes the short names of the flags", func() {
+			meta.Flags = append(meta.Flags, Flag{Name: "fake-list", Short: "l", ValueName: "fake-value", Usage: "fake-list-usage", Repeatable: true})
+			Expect(meta.Help("fake-cmd")).To(ContainSubstring(`
+  --fake-bool                   fake-bool-usage
+  --fake-string <fake-value>    fake-string-usage
+  -l, --fake-list <fake-value>  fake-list-usage`))
+		})
+	})
+})
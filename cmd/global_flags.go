@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"flag"
+	"io/ioutil"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+)
+
+// GlobalFlags are the flags accepted before the command name.
+// The manifest variable flags are also accepted among the arguments of the command.
+var GlobalFlags = []Flag{
+	{Name: "state", ValueName: "path", Usage: "Path of the deployment state file, instead of <manifest>-state.json next to the deployment manifest"},
+	{Name: "json", Usage: "Write output & errors as JSON lines"},
+	{Name: "non-interactive", Usage: "Fail instead of asking for input"},
+	{Name: "log-level", ValueName: "level", Usage: "Log level (debug, info, warn, error or none), overrides BOSH_MICRO_LOG_LEVEL"},
+	{Name: "var", ValueName: "name=value", Usage: "Value of the ((name)) manifest variable (can be repeated)"},
+	{Name: "vars-file", ValueName: "path", Usage: "YAML file of manifest variable values (can be repeated)"},
+	{Name: "vars-env", ValueName: "prefix", Usage: "Take manifest variable values from the <prefix>_<name> environment variables (can be repeated)"},
+}
+
+// GlobalOptions are the values of the global flags
+type GlobalOptions struct {
+	StatePath      string
+	JSON           bool
+	NonInteractive bool
+	LogLevel       string
+
+	Vars            []string
+	VarsFiles       []string
+	VarsEnvPrefixes []string
+}
+
+// ParseGlobalFlags parses the global flags preceding the command name
+// and the manifest variable flags among the arguments of the command,
+// returning the command name & its remaining arguments.
+// "--help" or "-h" in place of the command name is returned as the "help" command.
+func ParseGlobalFlags(args []string) (GlobalOptions, []string, error) {
+	options := GlobalOptions{}
+
+	flagSet := flag.NewFlagSet("bosh-micro", flag.ContinueOnError)
+	flagSet.SetOutput(ioutil.Discard)
+	flagSet.StringVar(&options.StatePath, "state", "", "")
+	flagSet.BoolVar(&options.JSON, "json", false, "")
+	flagSet.BoolVar(&options.NonInteractive, "non-interactive", false, "")
+	flagSet.StringVar(&options.LogLevel, "log-level", "", "")
+	flagSet.Var((*stringSlice)(&options.Vars), "var", "")
+	flagSet.Var((*stringSlice)(&options.VarsFiles), "vars-file", "")
+	flagSet.Var((*stringSlice)(&options.VarsEnvPrefixes), "vars-env", "")
+
+	err := flagSet.Parse(args)
+	if err == flag.ErrHelp {
+		return options, []string{"help"}, nil
+	}
+	if err != nil {
+		return options, nil, bosherr.WrapError(err, "Parsing global flags")
+	}
+
+	args, err = extractVarFlags(flagSet.Args(), &options)
+	if err != nil {
+		return options, nil, bosherr.WrapError(err, "Parsing global flags")
+	}
+
+	return options, args, nil
+}
+
+// extractVarFlags removes the manifest variable flags from the command arguments, collecting their values
+// after the ones given before the command name. Arguments after "--" are left as they are.
+func extractVarFlags(args []string, options *GlobalOptions) ([]string, error) {
+	values := map[string]*[]string{
+		"var":       &options.Vars,
+		"vars-file": &options.VarsFiles,
+		"vars-env":  &options.VarsEnvPrefixes,
+	}
+
+	remaining := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			remaining = append(remaining, args[i:]...)
+			break
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		value := ""
+		hasValue := false
+		if parts := strings.SplitN(name, "=", 2); len(parts) == 2 {
+			name, value, hasValue = parts[0], parts[1], true
+		}
+
+		flagValues, found := values[name]
+		if !found || !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "---") {
+			remaining = append(remaining, arg)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 == len(args) {
+				return nil, bosherr.Errorf("flag needs an argument: -%s", name)
+			}
+			i++
+			value = args[i]
+		}
+		*flagValues = append(*flagValues, value)
+	}
+
+	return remaining, nil
+}
@@ -0,0 +1,91 @@
+package cmd_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseGlobalFlags", func() {
+	It("parses the flags preceding the command name", func() {
+		options, args, err := ParseGlobalFlags([]string{
+			"--state", "/fake-state.json",
+			"--json",
+			"--non-interactive",
+			"--log-level", "debug",
+			"deploy", "--dry-run", "/fake-stemcell.tgz",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options).To(Equal(GlobalOptions{
+			StatePath:      "/fake-state.json",
+			JSON:           true,
+			NonInteractive: true,
+			LogLevel:       "debug",
+		}))
+		Expect(args).To(Equal([]string{"deploy", "--dry-run", "/fake-stemcell.tgz"}))
+	})
+
+	It("collects the repeated vars flags", func() {
+		options, args, err := ParseGlobalFlags([]string{
+			"--var", "name=fake-name",
+			"--vars-file", "/fake-vars-1.yml",
+			"--var", "password=fake-password",
+			"--vars-env", "FAKE_PREFIX",
+			"--vars-file", "/fake-vars-2.yml",
+			"deploy",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Vars).To(Equal([]string{"name=fake-name", "password=fake-password"}))
+		Expect(options.VarsFiles).To(Equal([]string{"/fake-vars-1.yml", "/fake-vars-2.yml"}))
+		Expect(options.VarsEnvPrefixes).To(Equal([]string{"FAKE_PREFIX"}))
+		Expect(args).To(Equal([]string{"deploy"}))
+	})
+
+	It("collects the vars flags given after the command name, after the ones before it", func() {
+		options, args, err := ParseGlobalFlags([]string{
+			"--var", "name=fake-name",
+			"deploy", "/fake-manifest.yml",
+			"--var", "password=fake-password",
+			"--vars-file=/fake-vars.yml",
+			"-o", "/fake-ops.yml",
+			"--vars-env", "FAKE_PREFIX",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Vars).To(Equal([]string{"name=fake-name", "password=fake-password"}))
+		Expect(options.VarsFiles).To(Equal([]string{"/fake-vars.yml"}))
+		Expect(options.VarsEnvPrefixes).To(Equal([]string{"FAKE_PREFIX"}))
+		Expect(args).To(Equal([]string{"deploy", "/fake-manifest.yml", "-o", "/fake-ops.yml"}))
+	})
+
+	It("leaves the vars flags after -- as command arguments", func() {
+		options, args, err := ParseGlobalFlags([]string{"interpolate", "--", "--var", "name=fake-name"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.Vars).To(BeEmpty())
+		Expect(args).To(Equal([]string{"interpolate", "--", "--var", "name=fake-name"}))
+	})
+
+	It("returns an error for a vars flag without value after the command name", func() {
+		_, _, err := ParseGlobalFlags([]string{"deploy", "/fake-manifest.yml", "--var"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing global flags"))
+	})
+
+	It("leaves the arguments as they are without global flags", func() {
+		options, args, err := ParseGlobalFlags([]string{"deploy", "--json"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options).To(Equal(GlobalOptions{}))
+		Expect(args).To(Equal([]string{"deploy", "--json"}))
+	})
+
+	It("returns the help command for --help", func() {
+		_, args, err := ParseGlobalFlags([]string{"--help"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(args).To(Equal([]string{"help"}))
+	})
+
+	It("returns an error for unknown global flags", func() {
+		_, _, err := ParseGlobalFlags([]string{"--fake-flag", "deploy"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing global flags"))
+	})
+})
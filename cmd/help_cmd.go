@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type helpCmd struct {
+	ui      bmui.UI
+	factory Factory
+	logger  boshlog.Logger
+	logTag  string
+}
+
+func NewHelpCmd(ui bmui.UI, factory Factory, logger boshlog.Logger) Cmd {
+	return &helpCmd{
+		ui:      ui,
+		factory: factory,
+		logger:  logger,
+		logTag:  "helpCmd",
+	}
+}
+
+func (c *helpCmd) Name() string {
+	return "help"
+}
+
+func (c *helpCmd) Meta() Meta {
+	return Meta{
+		Summary: "Show the commands, or the flags and arguments of a command",
+		Args:    "[<command>]",
+	}
+}
+
+func (c *helpCmd) Run(args []string) error {
+	_, args, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	switch len(args) {
+	case 0:
+		return c.printCommands()
+	case 1:
+		return c.printCommand(args[0])
+	default:
+		return usageError(c, c.ui, c.logger, c.logTag, args, "help command accepts at most 1 argument")
+	}
+}
+
+func (c *helpCmd) printCommands() error {
+	c.ui.Sayln("Usage: bosh-micro [global flags] <command> [flags] [arguments]")
+	c.ui.Sayln("")
+	c.ui.Sayln("Commands:")
+
+	names := c.factory.CommandNames()
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	for _, name := range names {
+		cmd, err := c.factory.CreateCommand(name)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating command '%s'", name)
+		}
+		c.ui.Sayln(fmt.Sprintf("  %-*s  %s", width, name, cmd.Meta().Summary))
+	}
+
+	c.ui.Sayln("")
+	c.ui.Sayln("Global flags:")
+	for _, line := range flagsHelp(GlobalFlags) {
+		c.ui.Sayln(line)
+	}
+	c.ui.Sayln("")
+	c.ui.Sayln("Run 'bosh-micro help <command>' for the flags and arguments of a command.")
+
+	return nil
+}
+
+func (c *helpCmd) printCommand(name string) error {
+	cmd, err := c.factory.CreateCommand(name)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Showing help for command '%s'", name)
+	}
+
+	c.ui.Sayln(cmd.Meta().Help(name))
+	return nil
+}
@@ -0,0 +1,74 @@
+package cmd_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakecmd "github.com/cloudfoundry/bosh-micro-cli/cmd/fakes"
+	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+)
+
+var _ = Describe("HelpCmd", func() {
+	var (
+		command     Cmd
+		ui          *fakeui.FakeUI
+		factory     *fakecmd.FakeFactory
+		fakeCommand *fakecmd.FakeCommand
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fakeCommand = fakecmd.NewFakeCommand("fake-cmd")
+		fakeCommand.PresetMeta = Meta{
+			Summary: "fake-summary",
+			Args:    "<fake-arg>",
+			Flags:   []Flag{{Name: "fake-flag", Usage: "fake-flag-usage"}},
+		}
+		factory = &fakecmd.FakeFactory{
+			PresetCommand:      fakeCommand,
+			PresetCommandNames: []string{"fake-cmd", "fake-other-cmd"},
+		}
+		command = NewHelpCmd(ui, factory, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Run", func() {
+		It("lists the commands with their summaries & the global flags", func() {
+			err := command.Run([]string{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Said).To(ContainElement("  fake-cmd        fake-summary"))
+			Expect(ui.Said).To(ContainElement("  fake-other-cmd  fake-summary"))
+			Expect(ui.Said).To(ContainElement("Global flags:"))
+			Expect(ui.Said).To(ContainElement(ContainSubstring("--state <path>")))
+			Expect(ui.Said).To(ContainElement(ContainSubstring("--json")))
+			Expect(ui.Said).To(ContainElement(ContainSubstring("--non-interactive")))
+			Expect(ui.Said).To(ContainElement(ContainSubstring("--log-level <level>")))
+		})
+
+		It("shows the help of the given command", func() {
+			err := command.Run([]string{"fake-cmd"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(factory.CommandName).To(Equal("fake-cmd"))
+			Expect(ui.Said).To(Equal([]string{fakeCommand.PresetMeta.Help("fake-cmd")}))
+		})
+
+		It("returns an error for an unknown command", func() {
+			factory.PresetError = errors.New("fake-unknown-command-error")
+
+			err := command.Run([]string{"fake-unknown-cmd"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-unknown-command-error"))
+		})
+
+		It("returns an error for more than 1 argument", func() {
+			err := command.Run([]string{"fake-cmd", "fake-other-cmd"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid usage"))
+			Expect(ui.Said).To(ContainElement("Expected usage: bosh-micro help [<command>]"))
+		})
+	})
+})
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+)
+
+// allInstanceRecords returns the records of all the instances, the first instance first.
+// When nothing has been deployed, it returns the empty record of the first instance.
+func allInstanceRecords(instanceRepo bmconfig.InstanceRepo) ([]bmconfig.InstanceRecord, error) {
+	records, err := instanceRepo.All()
+	if err != nil {
+		return []bmconfig.InstanceRecord{}, bosherr.WrapError(err, "Finding instance records")
+	}
+
+	if len(records) == 0 {
+		return []bmconfig.InstanceRecord{{}}, nil
+	}
+	return records, nil
+}
+
+// instanceRecordName returns the '<job>/<index>' name of the instance, empty when the record of the first instance is not named yet
+func instanceRecordName(record bmconfig.InstanceRecord) string {
+	if record.JobName == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%d", record.JobName, record.Index)
+}
+
+// findInstanceRecord returns the position of the record of the instance named '<job>/<index>',
+// or of the first instance when the name is empty
+func findInstanceRecord(records []bmconfig.InstanceRecord, name string) (int, bool) {
+	if name == "" {
+		return 0, len(records) > 0
+	}
+
+	for i, record := range records {
+		if instanceRecordName(record) == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// instanceAgentMbusURL returns the mbus URL of the agent of the instance at the position of its record:
+// the agent of the first instance is reached at the mbus URL, the agents of the other instances at their IP
+func instanceAgentMbusURL(mbusURL string, position int, record bmconfig.InstanceRecord) (string, error) {
+	if position == 0 {
+		return mbusURL, nil
+	}
+
+	instanceMbusURL, err := bmhttpagent.InstanceMbusURL(mbusURL, record.IP)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Building the mbus URL of instance '%s'", instanceRecordName(record))
+	}
+	return instanceMbusURL, nil
+}
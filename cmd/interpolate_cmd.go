@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
+)
+
+type interpolateCmd struct {
+	ui           bmui.UI
+	userConfig   bmconfig.UserConfig
+	fs           boshsys.FileSystem
+	patcher      bmpatch.Patcher
+	interpolator bmvars.Interpolator
+	logger       boshlog.Logger
+	logTag       string
+}
+
+// NewInterpolateCmd returns a cmd that prints the deployment manifest as the parsers see it:
+// patched with the ops files, then with the variables interpolated
+func NewInterpolateCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	patcher bmpatch.Patcher,
+	interpolator bmvars.Interpolator,
+	logger boshlog.Logger,
+) Cmd {
+	return &interpolateCmd{
+		ui:           ui,
+		userConfig:   userConfig,
+		fs:           fs,
+		patcher:      patcher,
+		interpolator: interpolator,
+		logger:       logger,
+		logTag:       "interpolateCmd",
+	}
+}
+
+func (c *interpolateCmd) Name() string {
+	return "interpolate"
+}
+
+func (c *interpolateCmd) Meta() Meta {
+	return Meta{
+		Summary: "Show the deployment manifest with the ops files applied & the variables interpolated",
+		Flags:   []Flag{opsFileFlag},
+	}
+}
+
+func (c *interpolateCmd) Run(args []string) error {
+	flags, args, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(args) > 0 {
+		return usageError(c, c.ui, c.logger, c.logTag, args, "interpolate command accepts no arguments")
+	}
+
+	// Only the manifest is printed, so that the output can be redirected to a file
+	deploymentManifestPath := c.userConfig.DeploymentManifestPath
+	if deploymentManifestPath == "" {
+		c.ui.Error("Deployment manifest not set")
+		return bosherr.Error("Running interpolate cmd: Deployment manifest not set")
+	}
+	c.patcher.UseOpsFiles(flags.Strings(opsFileFlag.Name))
+
+	contents, err := c.fs.ReadFile(deploymentManifestPath)
+	if err != nil {
+		c.ui.Error("Could not read the deployment manifest")
+		return bosherr.WrapErrorf(err, "Reading deployment manifest '%s'", deploymentManifestPath)
+	}
+
+	contents, err = c.interpolator.Interpolate(contents)
+	if err != nil {
+		c.ui.Error("Could not interpolate the deployment manifest:\n" + err.Error())
+		return bosherr.WrapErrorf(err, "Interpolating deployment manifest '%s'", deploymentManifestPath)
+	}
+
+	c.ui.Sayln(strings.TrimRight(string(contents), "\n"))
+	return nil
+}
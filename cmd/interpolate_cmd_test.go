@@ -0,0 +1,67 @@
+package cmd_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
+
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+)
+
+var _ = Describe("InterpolateCmd", func() {
+	var (
+		ui         *fakeui.FakeUI
+		fs         *fakesys.FakeFileSystem
+		userConfig bmconfig.UserConfig
+		command    Cmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		userConfig = bmconfig.UserConfig{DeploymentManifestPath: "/fake-manifest.yml"}
+
+		fs.WriteFileString("/fake-manifest.yml", "name: ((name))\n")
+		fs.WriteFileString("/fake-ops.yml", "- type: replace\n  path: /disk_size\n  value: 2048\n")
+
+		patcher := bmpatch.NewPatcher(fs, logger)
+		interpolator := bmpatch.NewPatchingInterpolator(patcher, bmvars.NewInterpolator(bmvars.Variables{"name": "fake-name"}))
+		command = NewInterpolateCmd(ui, userConfig, fs, patcher, interpolator, logger)
+	})
+
+	It("prints the deployment manifest with the variables interpolated", func() {
+		err := command.Run([]string{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ui.Said).To(Equal([]string{"name: fake-name"}))
+	})
+
+	It("applies the ops files", func() {
+		err := command.Run([]string{"-o", "/fake-ops.yml"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ui.Said).To(HaveLen(1))
+		Expect(ui.Said[0]).To(ContainSubstring("name: fake-name"))
+		Expect(ui.Said[0]).To(ContainSubstring("disk_size: 2048"))
+	})
+
+	It("returns an error when a variable is missing", func() {
+		fs.WriteFileString("/fake-manifest.yml", "name: ((missing))\n")
+
+		err := command.Run([]string{})
+		Expect(err).To(HaveOccurred())
+		Expect(ui.Errors).To(ContainElement(ContainSubstring("Variable 'missing' is not set, used at 'name'")))
+	})
+
+	It("returns an error when given arguments", func() {
+		err := command.Run([]string{"fake-arg"})
+		Expect(err).To(HaveOccurred())
+		Expect(ui.Errors).To(ContainElement("Invalid usage - interpolate command accepts no arguments"))
+	})
+})
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshretry "github.com/cloudfoundry/bosh-agent/retrystrategy"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	boshtime "github.com/cloudfoundry/bosh-agent/time"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmagentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmvm "github.com/cloudfoundry/bosh-micro-cli/deployment/vm"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bmindex "github.com/cloudfoundry/bosh-micro-cli/index"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+// jobLifecycle stops & starts the jobs of the deployed instances through their agents.
+// It is used by the stop, start & restart commands.
+type jobLifecycle struct {
+	ui                      bmui.UI
+	userConfig              bmconfig.UserConfig
+	fs                      boshsys.FileSystem
+	installationParser      bminstallmanifest.Parser
+	deploymentParser        bmdeplmanifest.Parser
+	deploymentConfigService bmconfig.DeploymentConfigService
+	instanceRepo            bmconfig.InstanceRepo
+	vmRepo                  bmconfig.VMRepo
+	diskRepo                bmconfig.DiskRepo
+	uuidGenerator           boshuuid.Generator
+	agentClientFactory      bmhttpagent.AgentClientFactory
+	pingTimeout             time.Duration
+	pingDelay               time.Duration
+	logger                  boshlog.Logger
+	logTag                  string
+}
+
+func newJobLifecycle(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	installationParser bminstallmanifest.Parser,
+	deploymentParser bmdeplmanifest.Parser,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	instanceRepo bmconfig.InstanceRepo,
+	vmRepo bmconfig.VMRepo,
+	diskRepo bmconfig.DiskRepo,
+	uuidGenerator boshuuid.Generator,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	logger boshlog.Logger,
+) *jobLifecycle {
+	return &jobLifecycle{
+		ui:                      ui,
+		userConfig:              userConfig,
+		fs:                      fs,
+		installationParser:      installationParser,
+		deploymentParser:        deploymentParser,
+		deploymentConfigService: deploymentConfigService,
+		instanceRepo:            instanceRepo,
+		vmRepo:                  vmRepo,
+		diskRepo:                diskRepo,
+		uuidGenerator:           uuidGenerator,
+		agentClientFactory:      agentClientFactory,
+		pingTimeout:             10 * time.Second,
+		pingDelay:               500 * time.Millisecond,
+		logger:                  logger,
+		logTag:                  "jobLifecycle",
+	}
+}
+
+// lifecycleTarget is a deployed instance that the lifecycle commands operate on
+type lifecycleTarget struct {
+	deploymentManifestPath string
+	installationManifest   bminstallmanifest.Manifest
+	deploymentManifest     bmdeplmanifest.Manifest
+	directorID             string
+	name                   string
+	vmRepo                 bmconfig.VMRepo
+	diskRepo               bmconfig.DiskRepo
+	vmCID                  string
+	vmFound                bool
+	agentClient            bmagentclient.AgentClient
+	compiledPackageIndex   bmindex.Index
+}
+
+func (t lifecycleTarget) instanceName() string {
+	return t.name
+}
+
+// Load parses the manifests and finds the current VM of every instance of the deployment, the first instance first.
+func (l *jobLifecycle) Load(cmdName string) ([]lifecycleTarget, error) {
+	deploymentManifestPath, err := getDeploymentManifest(l.userConfig, l.ui, l.fs)
+	if err != nil {
+		return []lifecycleTarget{}, bosherr.WrapErrorf(err, "Running %s cmd", cmdName)
+	}
+
+	installationManifest, err := l.installationParser.Parse(deploymentManifestPath)
+	if err != nil {
+		return []lifecycleTarget{}, bosherr.WrapErrorf(err, "Parsing installation manifest '%s'", deploymentManifestPath)
+	}
+
+	deploymentManifest, err := l.deploymentParser.Parse(deploymentManifestPath)
+	if err != nil {
+		return []lifecycleTarget{}, bosherr.WrapErrorf(err, "Parsing deployment manifest '%s'", deploymentManifestPath)
+	}
+
+	deploymentConfig, err := l.deploymentConfigService.Load()
+	if err != nil {
+		return []lifecycleTarget{}, bosherr.WrapError(err, "Loading deployment config")
+	}
+
+	instanceRecords, err := allInstanceRecords(l.instanceRepo)
+	if err != nil {
+		return []lifecycleTarget{}, err
+	}
+
+	targets := []lifecycleTarget{}
+	for i, instanceRecord := range instanceRecords {
+		target := lifecycleTarget{
+			deploymentManifestPath: deploymentManifestPath,
+			installationManifest:   installationManifest,
+			deploymentManifest:     deploymentManifest,
+			directorID:             deploymentConfig.DirectorID,
+			name:                   instanceRecordName(instanceRecord),
+			vmRepo:                 l.vmRepo,
+			diskRepo:               l.diskRepo,
+		}
+
+		if i == 0 {
+			if target.name == "" {
+				target.name = firstInstanceName(deploymentManifest)
+			}
+		} else {
+			target.vmRepo = bmconfig.NewInstanceVMRepo(l.deploymentConfigService, instanceRecord.JobName, instanceRecord.Index)
+			target.diskRepo = bmconfig.NewInstanceDiskRepo(l.deploymentConfigService, l.uuidGenerator, instanceRecord.JobName, instanceRecord.Index)
+		}
+
+		target.vmCID, target.vmFound, err = target.vmRepo.FindCurrent()
+		if err != nil {
+			return []lifecycleTarget{}, bosherr.WrapErrorf(err, "Finding current VM record of instance '%s'", target.name)
+		}
+
+		mbusURL, err := instanceAgentMbusURL(installationManifest.Mbus, i, instanceRecord)
+		if err != nil {
+			return []lifecycleTarget{}, err
+		}
+
+		target.agentClient = l.agentClientFactory.NewAgentClient(target.directorID, mbusURL)
+		target.compiledPackageIndex = target.vmRepo.CompiledPackageIndex(target.vmCID)
+
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// DeployedTargets returns the targets that have a VM, saying which instances are skipped because they have none.
+func (l *jobLifecycle) DeployedTargets(targets []lifecycleTarget) []lifecycleTarget {
+	deployed := []lifecycleTarget{}
+	for _, target := range targets {
+		if target.vmFound {
+			deployed = append(deployed, target)
+		}
+	}
+
+	if len(deployed) > 0 {
+		for _, target := range targets {
+			if !target.vmFound {
+				l.ui.Sayln(fmt.Sprintf("No VM found for instance '%s', skipping", target.instanceName()))
+			}
+		}
+	}
+
+	return deployed
+}
+
+func firstInstanceName(deploymentManifest bmdeplmanifest.Manifest) string {
+	if len(deploymentManifest.Jobs) == 0 {
+		return "unknown/0"
+	}
+	return fmt.Sprintf("%s/0", deploymentManifest.Jobs[0].Name)
+}
+
+// StopJobs stops the jobs on the instance, leaving the VM running.
+func (l *jobLifecycle) StopJobs(target lifecycleTarget, stage bmeventlog.Stage) error {
+	stepName := fmt.Sprintf("Stopping jobs on instance '%s'", target.instanceName())
+	return stage.PerformStep(stepName, func() error {
+		err := target.agentClient.Stop()
+		if err != nil {
+			return bosherr.WrapError(err, "Stopping agent")
+		}
+		return nil
+	})
+}
+
+// StartJobs starts the jobs on the instance and waits for the agent to report them running.
+func (l *jobLifecycle) StartJobs(target lifecycleTarget, stage bmeventlog.Stage) error {
+	stepName := fmt.Sprintf("Starting jobs on instance '%s'", target.instanceName())
+	err := stage.PerformStep(stepName, func() error {
+		err := target.agentClient.Start()
+		if err != nil {
+			return bosherr.WrapError(err, "Starting agent")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	updateWatchTime := target.deploymentManifest.Update.UpdateWatchTime
+	start := time.Duration(updateWatchTime.Start) * time.Millisecond
+	end := time.Duration(updateWatchTime.End) * time.Millisecond
+	delayBetweenAttempts := 1 * time.Second
+	maxAttempts := int((end - start) / delayBetweenAttempts)
+
+	stepName = fmt.Sprintf("Waiting for instance '%s' to be running", target.instanceName())
+	return stage.PerformStep(stepName, func() error {
+		time.Sleep(start)
+		return bmvm.WaitForAgentToBeRunning(target.agentClient, maxAttempts, delayBetweenAttempts, l.logger)
+	})
+}
+
+// DeleteVM stops the jobs, detaches the current disk & deletes the VM.
+// The disk & stemcell records are kept so that the VM can be recreated.
+func (l *jobLifecycle) DeleteVM(target lifecycleTarget, cloud bmcloud.Cloud, stage bmeventlog.Stage) error {
+	diskRecord, diskFound, err := target.diskRepo.FindCurrent()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding current disk record")
+	}
+
+	stepName := fmt.Sprintf("Waiting for the agent on VM '%s'", target.vmCID)
+	agentErr := stage.PerformStep(stepName, func() error {
+		agentPingRetryable := bmagentclient.NewPingRetryable(target.agentClient)
+		agentPingRetryStrategy := boshretry.NewTimeoutRetryStrategy(l.pingTimeout, l.pingDelay, agentPingRetryable, boshtime.NewConcreteService(), l.logger)
+		if err := agentPingRetryStrategy.Try(); err != nil {
+			return bosherr.WrapError(err, "Agent unreachable")
+		}
+		return nil
+	})
+	if agentErr != nil {
+		l.logger.Warn(l.logTag, "Gave up waiting for agent: %s", agentErr.Error())
+	} else {
+		if err = l.StopJobs(target, stage); err != nil {
+			return err
+		}
+
+		if diskFound {
+			stepName = fmt.Sprintf("Unmounting disk '%s'", diskRecord.CID)
+			err = stage.PerformStep(stepName, func() error {
+				if err := target.agentClient.UnmountDisk(diskRecord.CID); err != nil {
+					return bosherr.WrapErrorf(err, "Unmounting disk '%s' from VM '%s'", diskRecord.CID, target.vmCID)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if diskFound {
+		stepName = fmt.Sprintf("Detaching disk '%s'", diskRecord.CID)
+		err = stage.PerformStep(stepName, func() error {
+			if err := cloud.DetachDisk(target.vmCID, diskRecord.CID); err != nil {
+				return bosherr.WrapErrorf(err, "Detaching disk '%s' from VM '%s'", diskRecord.CID, target.vmCID)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	stepName = fmt.Sprintf("Deleting VM '%s'", target.vmCID)
+	return stage.PerformStep(stepName, func() error {
+		deleteErr := cloud.DeleteVM(target.vmCID)
+		if deleteErr != nil {
+			cloudErr, ok := deleteErr.(bmcloud.Error)
+			if !ok || cloudErr.Type() != bmcloud.VMNotFoundError {
+				return bosherr.WrapErrorf(deleteErr, "Deleting VM '%s'", target.vmCID)
+			}
+		}
+
+		if err := target.vmRepo.ClearCurrent(); err != nil {
+			return bosherr.WrapError(err, "Clearing current VM record")
+		}
+
+		if deleteErr != nil {
+			return bmeventlog.NewSkippedStepError(deleteErr.Error())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	boshtime "github.com/cloudfoundry/bosh-agent/time"
+
+	bmblobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+const logsTimestampFormat = "20060102-150405"
+
+type logsCmd struct {
+	ui                      bmui.UI
+	userConfig              bmconfig.UserConfig
+	fs                      boshsys.FileSystem
+	installationParser      bminstallmanifest.Parser
+	deploymentConfigService bmconfig.DeploymentConfigService
+	instanceRepo            bmconfig.InstanceRepo
+	agentClientFactory      bmhttpagent.AgentClientFactory
+	blobstoreFactory        bmblobstore.Factory
+	timeService             boshtime.Service
+	eventLogger             bmeventlog.EventLogger
+	logger                  boshlog.Logger
+	logTag                  string
+}
+
+func NewLogsCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	installationParser bminstallmanifest.Parser,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	instanceRepo bmconfig.InstanceRepo,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	blobstoreFactory bmblobstore.Factory,
+	timeService boshtime.Service,
+	eventLogger bmeventlog.EventLogger,
+	logger boshlog.Logger,
+) Cmd {
+	return &logsCmd{
+		ui:                      ui,
+		userConfig:              userConfig,
+		fs:                      fs,
+		installationParser:      installationParser,
+		deploymentConfigService: deploymentConfigService,
+		instanceRepo:            instanceRepo,
+		agentClientFactory:      agentClientFactory,
+		blobstoreFactory:        blobstoreFactory,
+		timeService:             timeService,
+		eventLogger:             eventLogger,
+		logger:                  logger,
+		logTag:                  "logsCmd",
+	}
+}
+
+func (c *logsCmd) Name() string {
+	return "logs"
+}
+
+func (c *logsCmd) Meta() Meta {
+	return Meta{
+		Summary: "Fetch the job or agent logs of a deployed VM (of the first instance by default)",
+		Args:    "[<job>/<index>]",
+		Flags: []Flag{
+			{Name: "job", Usage: "Fetch the job logs (default)"},
+			{Name: "agent", Usage: "Fetch the agent logs"},
+			{Name: "dir", ValueName: "destination-dir", Usage: "Directory to write the logs tarball to (default: current directory)"},
+		},
+	}
+}
+
+func (c *logsCmd) Run(args []string) error {
+	logType, destinationDir, instanceName, err := c.parseCmdInputs(args)
+	if err != nil {
+		return err
+	}
+
+	deploymentManifestPath, err := getDeploymentManifest(c.userConfig, c.ui, c.fs)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running logs cmd")
+	}
+
+	installationManifest, err := c.installationParser.Parse(deploymentManifestPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing installation manifest '%s'", deploymentManifestPath)
+	}
+
+	deploymentConfig, err := c.deploymentConfigService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment config")
+	}
+
+	instanceRecords, err := allInstanceRecords(c.instanceRepo)
+	if err != nil {
+		return err
+	}
+
+	position, found := findInstanceRecord(instanceRecords, instanceName)
+	if !found {
+		c.ui.Error(fmt.Sprintf("No instance '%s' found", instanceName))
+		return bosherr.Errorf("Running logs cmd: No instance '%s' found", instanceName)
+	}
+	instanceRecord := instanceRecords[position]
+
+	if instanceRecord.VMCID == "" {
+		c.ui.Error("No deployed VM found")
+		return bosherr.Error("Running logs cmd: No deployed VM found")
+	}
+
+	mbusURL, err := instanceAgentMbusURL(installationManifest.Mbus, position, instanceRecord)
+	if err != nil {
+		return err
+	}
+
+	agentClient := c.agentClientFactory.NewAgentClient(deploymentConfig.DirectorID, mbusURL)
+
+	blobstore, err := c.blobstoreFactory.Create(mbusURL)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating blobstore client")
+	}
+
+	logsName := installationManifest.Name
+	if position > 0 {
+		logsName = fmt.Sprintf("%s-%s-%d", logsName, instanceRecord.JobName, instanceRecord.Index)
+	}
+	logsFileName := fmt.Sprintf("%s-%s-logs-%s.tgz", logsName, logType, c.timeService.Now().Format(logsTimestampFormat))
+	logsPath := filepath.Join(destinationDir, logsFileName)
+
+	stage := c.eventLogger.NewStage("fetching logs")
+	stage.Start()
+
+	var blobID string
+	err = stage.PerformStep(fmt.Sprintf("Fetching %s logs from the agent", logType), func() error {
+		blobID, err = agentClient.FetchLogs(logType, []string{})
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Fetching %s logs", logType)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = stage.PerformStep(fmt.Sprintf("Downloading logs to '%s'", logsPath), func() error {
+		err = blobstore.Get(blobID, logsPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Downloading logs blob '%s'", blobID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	stage.Finish()
+
+	c.ui.Sayln(fmt.Sprintf("Logs saved in '%s'", logsPath))
+
+	return nil
+}
+
+func (c *logsCmd) parseCmdInputs(args []string) (string, string, string, error) {
+	flags, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return "", "", "", usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(positionalArgs) > 1 {
+		return "", "", "", usageError(c, c.ui, c.logger, c.logTag, args, "logs command accepts only an optional '<job>/<index>'")
+	}
+
+	if flags.Bool("job") && flags.Bool("agent") {
+		return "", "", "", usageError(c, c.ui, c.logger, c.logTag, args, "logs command accepts only one of '--job' & '--agent'")
+	}
+
+	logType := "job"
+	if flags.Bool("agent") {
+		logType = "agent"
+	}
+
+	destinationDir := flags.String("dir")
+	if destinationDir == "" {
+		destinationDir = "."
+	}
+
+	instanceName := ""
+	if len(positionalArgs) == 1 {
+		instanceName = positionalArgs[0]
+	}
+
+	return logType, destinationDir, instanceName, nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type recreateCmd struct {
+	ui        bmui.UI
+	deployCmd Cmd
+	logger    boshlog.Logger
+	logTag    string
+}
+
+// NewRecreateCmd returns a cmd that deploys with '--recreate',
+// replacing the VMs (and keeping the persistent disks) even when only jobs or nothing have changed.
+func NewRecreateCmd(ui bmui.UI, deployCmd Cmd, logger boshlog.Logger) Cmd {
+	return &recreateCmd{
+		ui:        ui,
+		deployCmd: deployCmd,
+		logger:    logger,
+		logTag:    "recreateCmd",
+	}
+}
+
+func (c *recreateCmd) Name() string {
+	return "recreate"
+}
+
+func (c *recreateCmd) Meta() Meta {
+	return Meta{
+		Summary: "Deploy, replacing the VMs even if only jobs or nothing changed",
+		Args:    "<stemcell-tarball> <cpi-release-tarball> [release-2-tarball [release-3-tarball...]]",
+		Flags: []Flag{
+			{Name: "dry-run", Usage: "Show the deploy plan without changing anything"},
+			opsFileFlag,
+		},
+	}
+}
+
+func (c *recreateCmd) Run(args []string) error {
+	_, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(positionalArgs) < 2 {
+		return usageError(c, c.ui, c.logger, c.logTag, args, "recreate command requires at least 2 arguments")
+	}
+
+	return c.deployCmd.Run(append([]string{"--recreate"}, args...))
+}
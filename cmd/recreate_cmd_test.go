@@ -0,0 +1,59 @@
+package cmd_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	fakecmd "github.com/cloudfoundry/bosh-micro-cli/cmd/fakes"
+	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+)
+
+var _ = Describe("RecreateCmd", func() {
+	var (
+		ui            *fakeui.FakeUI
+		fakeDeployCmd *fakecmd.FakeCommand
+		command       Cmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fakeDeployCmd = fakecmd.NewFakeCommand("deploy")
+		command = NewRecreateCmd(ui, fakeDeployCmd, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Run", func() {
+		It("deploys with --recreate", func() {
+			err := command.Run([]string{"/fake-stemcell.tgz", "/fake-cpi-release.tgz"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeDeployCmd.Args).To(Equal([]string{"--recreate", "/fake-stemcell.tgz", "/fake-cpi-release.tgz"}))
+		})
+
+		It("passes --dry-run along to deploy", func() {
+			err := command.Run([]string{"--dry-run", "/fake-stemcell.tgz", "/fake-cpi-release.tgz"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeDeployCmd.Args).To(Equal([]string{"--recreate", "--dry-run", "/fake-stemcell.tgz", "/fake-cpi-release.tgz"}))
+		})
+
+		It("returns the deploy error", func() {
+			fakeDeployCmd.PresetError = errors.New("fake-deploy-error")
+
+			err := command.Run([]string{"/fake-stemcell.tgz", "/fake-cpi-release.tgz"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-deploy-error"))
+		})
+
+		It("returns an error when the stemcell or cpi release is missing", func() {
+			err := command.Run([]string{"--dry-run", "/fake-stemcell.tgz"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid usage"))
+			Expect(ui.Said).To(ContainElement("Expected usage: bosh-micro recreate [--dry-run] [--ops-file <path>]... <stemcell-tarball> <cpi-release-tarball> [release-2-tarball [release-3-tarball...]]"))
+			Expect(fakeDeployCmd.Args).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmdeplrel "github.com/cloudfoundry/bosh-micro-cli/deployment/release"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+	bmtemplate "github.com/cloudfoundry/bosh-micro-cli/templatescompiler"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type renderTemplatesCmd struct {
+	ui               bmui.UI
+	userConfig       bmconfig.UserConfig
+	fs               boshsys.FileSystem
+	releaseSetParser bmrelsetmanifest.Parser
+	deploymentParser bmdeplmanifest.Parser
+	patcher          bmpatch.Patcher
+	releaseExtractor bmrel.Extractor
+	releaseManager   bmrel.Manager
+	releaseResolver  bmrelset.Resolver
+	jobResolver      bmdeplrel.JobResolver
+	jobListRenderer  bmtemplate.JobListRenderer
+	cmdRunner        boshsys.CmdRunner
+	logger           boshlog.Logger
+	logTag           string
+}
+
+// NewRenderTemplatesCmd returns a cmd that renders the job templates of the deployment manifest locally,
+// with the same properties & evaluation context as deploy, without creating a VM.
+func NewRenderTemplatesCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	releaseSetParser bmrelsetmanifest.Parser,
+	deploymentParser bmdeplmanifest.Parser,
+	patcher bmpatch.Patcher,
+	releaseExtractor bmrel.Extractor,
+	releaseManager bmrel.Manager,
+	releaseResolver bmrelset.Resolver,
+	jobResolver bmdeplrel.JobResolver,
+	jobListRenderer bmtemplate.JobListRenderer,
+	cmdRunner boshsys.CmdRunner,
+	logger boshlog.Logger,
+) Cmd {
+	return &renderTemplatesCmd{
+		ui:               ui,
+		userConfig:       userConfig,
+		fs:               fs,
+		releaseSetParser: releaseSetParser,
+		deploymentParser: deploymentParser,
+		patcher:          patcher,
+		releaseExtractor: releaseExtractor,
+		releaseManager:   releaseManager,
+		releaseResolver:  releaseResolver,
+		jobResolver:      jobResolver,
+		jobListRenderer:  jobListRenderer,
+		cmdRunner:        cmdRunner,
+		logger:           logger,
+		logTag:           "renderTemplatesCmd",
+	}
+}
+
+func (c *renderTemplatesCmd) Name() string {
+	return "render-templates"
+}
+
+func (c *renderTemplatesCmd) Meta() Meta {
+	return Meta{
+		Summary: "Render the job templates of the deployment manifest, to debug them without deploying",
+		Args:    "<release-tarball> [release-2-tarball [release-3-tarball...]]",
+		Flags: []Flag{
+			{Name: "dir", ValueName: "output-dir", Usage: "Directory to write the rendered files to, one sub-dir per job"},
+			{Name: "diff", ValueName: "previous-dir", Usage: "Show the differences with the files rendered to the directory before"},
+			opsFileFlag,
+		},
+	}
+}
+
+func (c *renderTemplatesCmd) Run(args []string) error {
+	flags, releaseTarballPaths, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(releaseTarballPaths) == 0 {
+		return usageError(c, c.ui, c.logger, c.logTag, args, "render-templates command requires at least 1 argument")
+	}
+
+	outputDir := flags.String("dir")
+	previousDir := flags.String("diff")
+	if outputDir == "" && previousDir == "" {
+		return usageError(c, c.ui, c.logger, c.logTag, args, "render-templates command requires --dir or --diff")
+	}
+
+	deploymentManifestPath, err := getDeploymentManifest(c.userConfig, c.ui, c.fs)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running render-templates cmd")
+	}
+	c.patcher.UseOpsFiles(flags.Strings(opsFileFlag.Name))
+
+	for _, releaseTarballPath := range releaseTarballPaths {
+		if !c.fs.FileExists(releaseTarballPath) {
+			c.ui.Error(fmt.Sprintf("Release '%s' does not exist", releaseTarballPath))
+			return bosherr.Errorf("Verifying that the release '%s' exists", releaseTarballPath)
+		}
+
+		release, err := c.releaseExtractor.Extract(releaseTarballPath)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Could not extract release '%s'", releaseTarballPath))
+			return bosherr.WrapErrorf(err, "Extracting release '%s'", releaseTarballPath)
+		}
+		c.releaseManager.Add(release)
+	}
+	defer func() {
+		err := c.releaseManager.DeleteAll()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	releaseSetManifest, err := c.releaseSetParser.Parse(deploymentManifestPath)
+	if err != nil {
+		c.ui.Error("Could not parse the release set manifest")
+		return bosherr.WrapErrorf(err, "Parsing release set manifest '%s'", deploymentManifestPath)
+	}
+	c.releaseResolver.Filter(releaseSetManifest.Releases)
+
+	deploymentManifest, err := c.deploymentParser.Parse(deploymentManifestPath)
+	if err != nil {
+		c.ui.Error("Could not parse the deployment manifest")
+		return bosherr.WrapErrorf(err, "Parsing deployment manifest '%s'", deploymentManifestPath)
+	}
+
+	if outputDir == "" {
+		outputDir, err = c.fs.TempDir("render-templates")
+		if err != nil {
+			return bosherr.WrapError(err, "Creating rendered templates directory")
+		}
+		defer func() {
+			err := c.fs.RemoveAll(outputDir)
+			if err != nil {
+				c.logger.Warn(c.logTag, "Deleting rendered templates directory '%s': %s", outputDir, err.Error())
+			}
+		}()
+	}
+
+	for _, deploymentJob := range deploymentManifest.Jobs {
+		err = c.renderJob(deploymentManifest, deploymentJob, filepath.Join(outputDir, deploymentJob.Name))
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Could not render the templates of job '%s':\n%s", deploymentJob.Name, err.Error()))
+			return bosherr.WrapErrorf(err, "Rendering templates of job '%s'", deploymentJob.Name)
+		}
+
+		if previousDir == "" {
+			c.ui.Sayln(fmt.Sprintf("Rendered the templates of job '%s' to '%s'", deploymentJob.Name, filepath.Join(outputDir, deploymentJob.Name)))
+		}
+	}
+
+	if previousDir != "" {
+		return c.showDiff(previousDir, outputDir)
+	}
+
+	return nil
+}
+
+// renderJob renders the release jobs of the deployment job, each to a sub-dir of the job dir named after the release job
+func (c *renderTemplatesCmd) renderJob(deploymentManifest bmdeplmanifest.Manifest, deploymentJob bmdeplmanifest.Job, jobDir string) error {
+	releaseJobs := make([]bmrel.Job, len(deploymentJob.Templates), len(deploymentJob.Templates))
+	for i, jobRef := range deploymentJob.Templates {
+		releaseJob, err := c.jobResolver.Resolve(jobRef.Name, jobRef.Release)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Resolving job '%s' in release '%s'", jobRef.Name, jobRef.Release)
+		}
+		releaseJobs[i] = releaseJob
+	}
+
+	jobProperties, err := deploymentManifest.JobProperties(deploymentJob.Name)
+	if err != nil {
+		return err
+	}
+
+	renderedJobList, err := c.jobListRenderer.Render(releaseJobs, jobProperties, deploymentManifest.Name)
+	if err != nil {
+		return err
+	}
+	defer renderedJobList.DeleteSilently()
+
+	// files of a previous render must not be left behind, they would hide removed templates
+	err = c.fs.RemoveAll(jobDir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting previously rendered templates '%s'", jobDir)
+	}
+
+	for _, renderedJob := range renderedJobList.All() {
+		renderedJobDir := filepath.Join(jobDir, renderedJob.Job().Name)
+		err = c.fs.CopyDir(renderedJob.Path(), renderedJobDir)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Copying rendered job '%s' to '%s'", renderedJob.Job().Name, renderedJobDir)
+		}
+	}
+
+	return nil
+}
+
+// showDiff prints the unified diff of the previously rendered files with the newly rendered ones
+func (c *renderTemplatesCmd) showDiff(previousDir, outputDir string) error {
+	stdout, stderr, exitStatus, err := c.cmdRunner.RunCommand("diff", "-ruN", previousDir, outputDir)
+
+	// diff exits with 1 when the files differ
+	switch {
+	case exitStatus == 0:
+		c.ui.Sayln(fmt.Sprintf("No changes from the templates rendered to '%s'", previousDir))
+		return nil
+	case exitStatus == 1:
+		c.ui.Sayln(strings.TrimRight(stdout, "\n"))
+		return nil
+	}
+
+	c.ui.Error(fmt.Sprintf("Could not diff with the templates rendered to '%s':\n%s", previousDir, stderr))
+	if err == nil {
+		err = bosherr.Errorf("diff exited with status %d", exitStatus)
+	}
+	return bosherr.WrapErrorf(err, "Diffing rendered templates with '%s'", previousDir)
+}
@@ -0,0 +1,232 @@
+package cmd_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+
+	"code.google.com/p/gomock/gomock"
+	mock_release "github.com/cloudfoundry/bosh-micro-cli/release/mocks"
+	mock_template "github.com/cloudfoundry/bosh-micro-cli/templatescompiler/mocks"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmdeplrel "github.com/cloudfoundry/bosh-micro-cli/deployment/release"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+	bmtemplate "github.com/cloudfoundry/bosh-micro-cli/templatescompiler"
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
+
+	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+)
+
+var _ = Describe("RenderTemplatesCmd", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		var (
+			fs                   *fakesys.FakeFileSystem
+			logger               boshlog.Logger
+			cmdRunner            *fakesys.FakeCmdRunner
+			mockReleaseExtractor *mock_release.MockExtractor
+			mockJobListRenderer  *mock_template.MockJobListRenderer
+			ui                   *fakeui.FakeUI
+			userConfig           bmconfig.UserConfig
+			releaseJob           bmrel.Job
+
+			deploymentManifestPath = "/deployment-dir/fake-deployment-manifest.yml"
+		)
+
+		var newRenderTemplatesCmd = func() Cmd {
+			patcher := bmpatch.NewPatcher(fs, logger)
+			interpolator := bmpatch.NewPatchingInterpolator(patcher, bmvars.NewInterpolator(bmvars.Variables{}))
+			releaseManager := bmrel.NewManager(logger)
+			releaseResolver := bmrelset.NewResolver(releaseManager, logger)
+			return NewRenderTemplatesCmd(
+				ui,
+				userConfig,
+				fs,
+				bmrelsetmanifest.NewParser(fs, interpolator, logger),
+				bmdeplmanifest.NewParser(fs, interpolator, logger),
+				patcher,
+				mockReleaseExtractor,
+				releaseManager,
+				releaseResolver,
+				bmdeplrel.NewJobResolver(releaseResolver),
+				mockJobListRenderer,
+				cmdRunner,
+				logger,
+			)
+		}
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+			logger = boshlog.NewLogger(boshlog.LevelNone)
+			cmdRunner = fakesys.NewFakeCmdRunner()
+			mockReleaseExtractor = mock_release.NewMockExtractor(mockCtrl)
+			mockJobListRenderer = mock_template.NewMockJobListRenderer(mockCtrl)
+			ui = &fakeui.FakeUI{}
+			userConfig = bmconfig.UserConfig{DeploymentManifestPath: deploymentManifestPath}
+
+			fs.WriteFileString(deploymentManifestPath, `---
+name: fake-deployment-name
+releases:
+- name: fake-release-name
+  version: 1.0
+jobs:
+- name: fake-job-name
+  templates:
+  - {name: fake-release-job-name, release: fake-release-name}
+  properties:
+    fake-property: fake-value
+properties:
+  fake-global-property: fake-global-value
+`)
+			fs.WriteFileString("/fake-release.tgz", "fake-tgz-content")
+
+			releaseJob = bmrel.Job{Name: "fake-release-job-name"}
+			release := bmrel.NewRelease(
+				"fake-release-name",
+				"1.0",
+				[]bmrel.Job{releaseJob},
+				[]*bmrel.Package{},
+				"/fake-extracted-release",
+				fs,
+			)
+			mockReleaseExtractor.EXPECT().Extract("/fake-release.tgz").Return(release, nil).AnyTimes()
+		})
+
+		var expectRender = func() *gomock.Call {
+			fs.WriteFileString("/fake-rendered-job/bin/ctl", "fake-ctl")
+			fs.WriteFileString("/fake-rendered-job/monit", "fake-monit")
+
+			renderedJobList := bmtemplate.NewRenderedJobList()
+			renderedJobList.Add(bmtemplate.NewRenderedJob(releaseJob, "/fake-rendered-job", fs, logger))
+
+			return mockJobListRenderer.EXPECT().Render(
+				[]bmrel.Job{releaseJob},
+				map[string]interface{}{"fake-property": "fake-value", "fake-global-property": "fake-global-value"},
+				"fake-deployment-name",
+			).Return(renderedJobList, nil)
+		}
+
+		It("writes the rendered files & monit of each job to the output dir", func() {
+			expectRender()
+
+			err := newRenderTemplatesCmd().Run([]string{"--dir", "/fake-output", "/fake-release.tgz"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/fake-output/fake-job-name/fake-release-job-name/bin/ctl")).To(Equal("fake-ctl"))
+			Expect(fs.ReadFileString("/fake-output/fake-job-name/fake-release-job-name/monit")).To(Equal("fake-monit"))
+			Expect(ui.Said).To(ContainElement("Rendered the templates of job 'fake-job-name' to '/fake-output/fake-job-name'"))
+		})
+
+		It("deletes the rendered job list & the files rendered before", func() {
+			fs.WriteFileString("/fake-output/fake-job-name/stale-job/monit", "stale")
+			expectRender()
+
+			err := newRenderTemplatesCmd().Run([]string{"--dir", "/fake-output", "/fake-release.tgz"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/fake-rendered-job")).To(BeFalse())
+			Expect(fs.FileExists("/fake-output/fake-job-name/stale-job/monit")).To(BeFalse())
+		})
+
+		Context("with --diff", func() {
+			BeforeEach(func() {
+				fs.TempDirDir = "/fake-tmp-render"
+			})
+
+			It("shows the differences with the previous render & deletes the new one", func() {
+				expectRender()
+				cmdRunner.AddCmdResult("diff -ruN /fake-previous /fake-tmp-render", fakesys.FakeCmdResult{
+					Stdout:     "fake-diff\n",
+					ExitStatus: 1,
+					Error:      errors.New("fake-exit-status-1"),
+				})
+
+				err := newRenderTemplatesCmd().Run([]string{"--diff", "/fake-previous", "/fake-release.tgz"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ui.Said).To(ContainElement("fake-diff"))
+				Expect(fs.FileExists("/fake-tmp-render")).To(BeFalse())
+			})
+
+			It("says when nothing changed", func() {
+				expectRender()
+				cmdRunner.AddCmdResult("diff -ruN /fake-previous /fake-tmp-render", fakesys.FakeCmdResult{})
+
+				err := newRenderTemplatesCmd().Run([]string{"--diff", "/fake-previous", "/fake-release.tgz"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ui.Said).To(ContainElement("No changes from the templates rendered to '/fake-previous'"))
+			})
+
+			It("returns an error when diff fails", func() {
+				expectRender()
+				cmdRunner.AddCmdResult("diff -ruN /fake-previous /fake-tmp-render", fakesys.FakeCmdResult{
+					Stderr:     "fake-stderr",
+					ExitStatus: 2,
+					Error:      errors.New("fake-diff-error"),
+				})
+
+				err := newRenderTemplatesCmd().Run([]string{"--diff", "/fake-previous", "/fake-release.tgz"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-diff-error"))
+				Expect(ui.Errors).To(ContainElement("Could not diff with the templates rendered to '/fake-previous':\nfake-stderr"))
+			})
+		})
+
+		It("returns an error when rendering fails", func() {
+			expectRender().Return(nil, errors.New("fake-render-error"))
+
+			err := newRenderTemplatesCmd().Run([]string{"--dir", "/fake-output", "/fake-release.tgz"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-render-error"))
+			Expect(ui.Errors).To(ContainElement("Could not render the templates of job 'fake-job-name':\nfake-render-error"))
+		})
+
+		It("returns an error when a job is not in the releases", func() {
+			fs.WriteFileString(deploymentManifestPath, `---
+name: fake-deployment-name
+releases:
+- name: fake-release-name
+  version: 1.0
+jobs:
+- name: fake-job-name
+  templates:
+  - {name: missing-job-name, release: fake-release-name}
+`)
+
+			err := newRenderTemplatesCmd().Run([]string{"--dir", "/fake-output", "/fake-release.tgz"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Finding job 'missing-job-name' in release 'fake-release-name'"))
+		})
+
+		It("returns an error without --dir or --diff", func() {
+			err := newRenderTemplatesCmd().Run([]string{"/fake-release.tgz"})
+			Expect(err).To(HaveOccurred())
+			Expect(ui.Errors).To(ContainElement("Invalid usage - render-templates command requires --dir or --diff"))
+		})
+
+		It("returns an error without release tarballs", func() {
+			err := newRenderTemplatesCmd().Run([]string{"--dir", "/fake-output"})
+			Expect(err).To(HaveOccurred())
+			Expect(ui.Errors).To(ContainElement("Invalid usage - render-templates command requires at least 1 argument"))
+		})
+	})
+})
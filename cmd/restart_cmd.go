@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type restartCmd struct {
+	ui           bmui.UI
+	jobLifecycle *jobLifecycle
+	deployCmd    Cmd
+	eventLogger  bmeventlog.EventLogger
+	logger       boshlog.Logger
+	logTag       string
+}
+
+// NewRestartCmd returns a cmd that restarts the jobs of the deployed instances, one instance at a time.
+// With '--hard', the VMs are replaced by deploying with '--recreate'.
+func NewRestartCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	installationParser bminstallmanifest.Parser,
+	deploymentParser bmdeplmanifest.Parser,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	instanceRepo bmconfig.InstanceRepo,
+	vmRepo bmconfig.VMRepo,
+	diskRepo bmconfig.DiskRepo,
+	uuidGenerator boshuuid.Generator,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	deployCmd Cmd,
+	eventLogger bmeventlog.EventLogger,
+	logger boshlog.Logger,
+) Cmd {
+	return &restartCmd{
+		ui: ui,
+		jobLifecycle: newJobLifecycle(
+			ui,
+			userConfig,
+			fs,
+			installationParser,
+			deploymentParser,
+			deploymentConfigService,
+			instanceRepo,
+			vmRepo,
+			diskRepo,
+			uuidGenerator,
+			agentClientFactory,
+			logger,
+		),
+		deployCmd:   deployCmd,
+		eventLogger: eventLogger,
+		logger:      logger,
+		logTag:      "restartCmd",
+	}
+}
+
+func (c *restartCmd) Name() string {
+	return "restart"
+}
+
+func (c *restartCmd) Meta() Meta {
+	return Meta{
+		Summary: "Restart the jobs of the deployed VMs, or recreate the VMs with --hard",
+		Args:    "[<stemcell-tarball> <cpi-release-tarball> [release-2-tarball [release-3-tarball...]]]",
+		Flags: []Flag{
+			{Name: "hard", Usage: "Recreate the VMs (requires the stemcell & CPI release tarballs)"},
+		},
+	}
+}
+
+func (c *restartCmd) Run(args []string) error {
+	flags, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	hard := flags.Bool("hard")
+	if (!hard && len(positionalArgs) > 0) || (hard && len(positionalArgs) < 2) {
+		return usageError(c, c.ui, c.logger, c.logTag, args, "restart command accepts no arguments, or '--hard <stemcell-tarball> <cpi-release-tarball>'")
+	}
+
+	if hard {
+		return c.deployCmd.Run(append([]string{"--recreate"}, positionalArgs...))
+	}
+
+	targets, err := c.jobLifecycle.Load(c.Name())
+	if err != nil {
+		return err
+	}
+
+	targets = c.jobLifecycle.DeployedTargets(targets)
+	if len(targets) == 0 {
+		c.ui.Error("No deployed VM found")
+		return bosherr.Error("Running restart cmd: No deployed VM found")
+	}
+
+	restartStage := c.eventLogger.NewStage("restarting")
+	restartStage.Start()
+
+	for _, target := range targets {
+		err = c.jobLifecycle.StopJobs(target, restartStage)
+		if err != nil {
+			return err
+		}
+
+		err = c.jobLifecycle.StartJobs(target, restartStage)
+		if err != nil {
+			return err
+		}
+	}
+
+	restartStage.Finish()
+
+	return nil
+}
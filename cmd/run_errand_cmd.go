@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmblobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmagentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bminstance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type runErrandCmd struct {
+	ui                  bmui.UI
+	fs                  boshsys.FileSystem
+	jobLifecycle        *jobLifecycle
+	releaseSetParser    bmrelsetmanifest.Parser
+	patcher             bmpatch.Patcher
+	releaseExtractor    bmrel.Extractor
+	releaseManager      bmrel.Manager
+	releaseResolver     bmrelset.Resolver
+	blobstoreFactory    bmblobstore.Factory
+	stateBuilderFactory bminstance.StateBuilderFactory
+	eventLogger         bmeventlog.EventLogger
+	logger              boshlog.Logger
+	logTag              string
+}
+
+// NewRunErrandCmd returns a cmd that runs an errand job on the VM of the first instance.
+// The jobs of the instance are stopped while the errand runs, then its state is applied & started again.
+func NewRunErrandCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	releaseSetParser bmrelsetmanifest.Parser,
+	installationParser bminstallmanifest.Parser,
+	deploymentParser bmdeplmanifest.Parser,
+	patcher bmpatch.Patcher,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	instanceRepo bmconfig.InstanceRepo,
+	vmRepo bmconfig.VMRepo,
+	diskRepo bmconfig.DiskRepo,
+	uuidGenerator boshuuid.Generator,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	releaseExtractor bmrel.Extractor,
+	releaseManager bmrel.Manager,
+	releaseResolver bmrelset.Resolver,
+	blobstoreFactory bmblobstore.Factory,
+	stateBuilderFactory bminstance.StateBuilderFactory,
+	eventLogger bmeventlog.EventLogger,
+	logger boshlog.Logger,
+) Cmd {
+	return &runErrandCmd{
+		ui: ui,
+		fs: fs,
+		jobLifecycle: newJobLifecycle(
+			ui,
+			userConfig,
+			fs,
+			installationParser,
+			deploymentParser,
+			deploymentConfigService,
+			instanceRepo,
+			vmRepo,
+			diskRepo,
+			uuidGenerator,
+			agentClientFactory,
+			logger,
+		),
+		releaseSetParser:    releaseSetParser,
+		patcher:             patcher,
+		releaseExtractor:    releaseExtractor,
+		releaseManager:      releaseManager,
+		releaseResolver:     releaseResolver,
+		blobstoreFactory:    blobstoreFactory,
+		stateBuilderFactory: stateBuilderFactory,
+		eventLogger:         eventLogger,
+		logger:              logger,
+		logTag:              "runErrandCmd",
+	}
+}
+
+func (c *runErrandCmd) Name() string {
+	return "run-errand"
+}
+
+func (c *runErrandCmd) Meta() Meta {
+	return Meta{
+		Summary: "Run an errand job on the deployed VM",
+		Args:    "<errand-name> <release-tarball> [release-2-tarball [release-3-tarball...]]",
+		Flags: []Flag{
+			{Name: "dir", ValueName: "output-dir", Usage: "Directory to save the stdout, stderr & exit code of the errand to, instead of printing them"},
+			opsFileFlag,
+		},
+	}
+}
+
+func (c *runErrandCmd) Run(args []string) error {
+	flags, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(positionalArgs) < 2 {
+		return usageError(c, c.ui, c.logger, c.logTag, args, "run-errand command requires at least 2 arguments")
+	}
+	errandName := positionalArgs[0]
+	releaseTarballPaths := positionalArgs[1:]
+	outputDir := flags.String("dir")
+
+	c.patcher.UseOpsFiles(flags.Strings(opsFileFlag.Name))
+
+	targets, err := c.jobLifecycle.Load(c.Name())
+	if err != nil {
+		return err
+	}
+
+	// errands run on the VM of the first instance
+	target := targets[0]
+
+	if !target.vmFound {
+		c.ui.Error("No deployed VM found")
+		return bosherr.Error("Running run-errand cmd: No deployed VM found")
+	}
+
+	errandManifest, err := c.errandManifest(target.deploymentManifest, errandName)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return bosherr.WrapError(err, "Running run-errand cmd")
+	}
+
+	for _, releaseTarballPath := range releaseTarballPaths {
+		release, err := c.releaseExtractor.Extract(releaseTarballPath)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Could not extract release '%s'", releaseTarballPath))
+			return bosherr.WrapErrorf(err, "Extracting release '%s'", releaseTarballPath)
+		}
+		c.releaseManager.Add(release)
+	}
+	defer func() {
+		err := c.releaseManager.DeleteAll()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	releaseSetManifest, err := c.releaseSetParser.Parse(target.deploymentManifestPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing release set manifest '%s'", target.deploymentManifestPath)
+	}
+	c.releaseResolver.Filter(releaseSetManifest.Releases)
+
+	blobstore, err := c.blobstoreFactory.Create(target.installationManifest.Mbus)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating blobstore client")
+	}
+	stateBuilder := c.stateBuilderFactory.NewStateBuilder(blobstore, target.agentClient, target.compiledPackageIndex)
+
+	errandStage := c.eventLogger.NewStage("running errand")
+	errandStage.Start()
+
+	// both states are built before stopping the jobs, so that a compilation or rendering error leaves the instance untouched
+	var errandState, instanceState bminstance.State
+	err = errandStage.PerformStep(fmt.Sprintf("Preparing errand '%s'", errandName), func() error {
+		errandState, err = stateBuilder.Build(errandName, 0, errandManifest)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Building state for errand '%s'", errandName)
+		}
+
+		instanceState, err = stateBuilder.Build(target.deploymentManifest.Jobs[0].Name, 0, target.deploymentManifest)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Building state for instance '%s'", target.instanceName())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.jobLifecycle.StopJobs(target, errandStage)
+	if err != nil {
+		return err
+	}
+
+	result, errandErr := c.runErrand(target, errandName, errandState, errandStage)
+
+	err = errandStage.PerformStep(fmt.Sprintf("Applying instance '%s'", target.instanceName()), func() error {
+		if err := target.agentClient.Apply(instanceState.ToApplySpec()); err != nil {
+			return bosherr.WrapError(err, "Applying the agent state")
+		}
+		return nil
+	})
+	if err == nil {
+		err = c.jobLifecycle.StartJobs(target, errandStage)
+	}
+	if errandErr != nil {
+		if err != nil {
+			c.logger.Warn(c.logTag, "Failed to restore instance '%s' after errand failure: %s", target.instanceName(), err.Error())
+		}
+		return errandErr
+	}
+	if err != nil {
+		return err
+	}
+
+	errandStage.Finish()
+
+	return c.reportResult(errandName, result, outputDir)
+}
+
+// errandManifest finds the errand job, and gives it the networks of the first job,
+// since the errand runs on the VM of the first instance
+func (c *runErrandCmd) errandManifest(deploymentManifest bmdeplmanifest.Manifest, errandName string) (bmdeplmanifest.Manifest, error) {
+	errandManifest := deploymentManifest
+	errandManifest.Jobs = make([]bmdeplmanifest.Job, len(deploymentManifest.Jobs))
+	copy(errandManifest.Jobs, deploymentManifest.Jobs)
+
+	for idx, job := range errandManifest.Jobs {
+		if job.Name != errandName {
+			continue
+		}
+		if !job.IsErrand() {
+			return errandManifest, bosherr.Errorf("Job '%s' is not an errand", errandName)
+		}
+		errandManifest.Jobs[idx].Networks = deploymentManifest.Jobs[0].Networks
+		return errandManifest, nil
+	}
+
+	return errandManifest, bosherr.Errorf("Errand '%s' not found in the deployment manifest", errandName)
+}
+
+func (c *runErrandCmd) runErrand(target lifecycleTarget, errandName string, errandState bminstance.State, stage bmeventlog.Stage) (bmagentclient.ErrandResult, error) {
+	err := stage.PerformStep(fmt.Sprintf("Applying errand '%s'", errandName), func() error {
+		if err := target.agentClient.Apply(errandState.ToApplySpec()); err != nil {
+			return bosherr.WrapError(err, "Applying the errand state")
+		}
+		return nil
+	})
+	if err != nil {
+		return bmagentclient.ErrandResult{}, err
+	}
+
+	var result bmagentclient.ErrandResult
+	err = stage.PerformStep(fmt.Sprintf("Running errand '%s'", errandName), func() error {
+		result, err = target.agentClient.RunErrand()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Running errand '%s'", errandName)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// reportResult prints the output of the errand, or saves it to the output dir, and fails on a non-zero exit code
+func (c *runErrandCmd) reportResult(errandName string, result bmagentclient.ErrandResult, outputDir string) error {
+	if outputDir != "" {
+		err := c.saveResult(result, outputDir)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Could not save the output of errand '%s' to '%s'", errandName, outputDir))
+			return err
+		}
+		c.ui.Sayln(fmt.Sprintf("Saved the output of errand '%s' to '%s'", errandName, outputDir))
+	} else {
+		c.ui.Sayln(fmt.Sprintf("[stdout]\n%s", result.Stdout))
+		c.ui.Sayln(fmt.Sprintf("[stderr]\n%s", result.Stderr))
+	}
+
+	if result.ExitStatus != 0 {
+		c.ui.Error(fmt.Sprintf("Errand '%s' completed with error (exit code %d)", errandName, result.ExitStatus))
+		return bosherr.Errorf("Errand '%s' exited with code %d", errandName, result.ExitStatus)
+	}
+
+	c.ui.Sayln(fmt.Sprintf("Errand '%s' completed successfully (exit code 0)", errandName))
+	return nil
+}
+
+func (c *runErrandCmd) saveResult(result bmagentclient.ErrandResult, outputDir string) error {
+	err := c.fs.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating errand output directory '%s'", outputDir)
+	}
+
+	files := map[string]string{
+		"stdout":    result.Stdout,
+		"stderr":    result.Stderr,
+		"exit_code": strconv.Itoa(result.ExitStatus) + "\n",
+	}
+	for fileName, contents := range files {
+		path := filepath.Join(outputDir, fileName)
+		err = c.fs.WriteFileString(path, contents)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing errand output '%s'", path)
+		}
+	}
+
+	return nil
+}
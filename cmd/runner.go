@@ -27,10 +27,36 @@ func (runner *Runner) Run(args []string) error {
 	}
 
 	commandName := args[0]
+	commandArgs := args[1:]
+
+	if isHelpFlag(commandName) {
+		commandName = "help"
+	} else if commandName != "help" && hasHelpFlag(commandArgs) {
+		commandArgs = []string{commandName}
+		commandName = "help"
+	}
+
 	cmd, err := runner.factory.CreateCommand(commandName)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Failed creating command with name: %s", commandName)
 	}
 
-	return cmd.Run(args[1:])
+	return cmd.Run(commandArgs)
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "--help" || arg == "-h"
+}
+
+// hasHelpFlag returns true if the args ask for help before any "--" argument
+func hasHelpFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if isHelpFlag(arg) {
+			return true
+		}
+	}
+	return false
 }
@@ -41,6 +41,33 @@ var _ = Describe("Runner", func() {
 			})
 		})
 
+		Context("help", func() {
+			BeforeEach(func() {
+				runner = cmd.NewRunner(factory)
+			})
+
+			It("runs the help command for --help", func() {
+				err := runner.Run([]string{"--help"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(factory.CommandName).To(Equal("help"))
+				Expect(fakeCommand.GetArgs()).To(BeEmpty())
+			})
+
+			It("runs the help command for a command given --help or -h", func() {
+				err := runner.Run([]string{"deployment", "/fake/manifest_path", "-h"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(factory.CommandName).To(Equal("help"))
+				Expect(fakeCommand.GetArgs()).To(Equal([]string{"deployment"}))
+			})
+
+			It("passes --help after -- to the command", func() {
+				err := runner.Run([]string{"deployment", "--", "--help"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(factory.CommandName).To(Equal("deployment"))
+				Expect(fakeCommand.GetArgs()).To(Equal([]string{"--", "--help"}))
+			})
+		})
+
 		Context("invalid args", func() {
 			BeforeEach(func() {
 				runner = cmd.NewRunner(factory)
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmsshclient "github.com/cloudfoundry/bosh-micro-cli/deployment/sshclient"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+const defaultSSHPort = 22
+
+type sshCmd struct {
+	ui                 bmui.UI
+	userConfig         bmconfig.UserConfig
+	fs                 boshsys.FileSystem
+	installationParser bminstallmanifest.Parser
+	instanceRepo       bmconfig.InstanceRepo
+	sshClientFactory   bmsshclient.Factory
+	logger             boshlog.Logger
+	logTag             string
+}
+
+func NewSSHCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	installationParser bminstallmanifest.Parser,
+	instanceRepo bmconfig.InstanceRepo,
+	sshClientFactory bmsshclient.Factory,
+	logger boshlog.Logger,
+) Cmd {
+	return &sshCmd{
+		ui:                 ui,
+		userConfig:         userConfig,
+		fs:                 fs,
+		installationParser: installationParser,
+		instanceRepo:       instanceRepo,
+		sshClientFactory:   sshClientFactory,
+		logger:             logger,
+		logTag:             "sshCmd",
+	}
+}
+
+func (c *sshCmd) Name() string {
+	return "ssh"
+}
+
+func (c *sshCmd) Meta() Meta {
+	return Meta{
+		Summary: "Open an ssh session to a deployed VM (of the first instance by default), or run a command on it",
+		Args:    "[<job>/<index>]",
+		Flags: []Flag{
+			{Name: "command", ValueName: "command", Usage: "Run the command instead of opening an interactive session"},
+		},
+	}
+}
+
+func (c *sshCmd) Run(args []string) error {
+	command, instanceName, err := c.parseCmdInputs(args)
+	if err != nil {
+		return err
+	}
+
+	deploymentManifestPath, err := getDeploymentManifest(c.userConfig, c.ui, c.fs)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running ssh cmd")
+	}
+
+	installationManifest, err := c.installationParser.Parse(deploymentManifestPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing installation manifest '%s'", deploymentManifestPath)
+	}
+
+	sshTunnel := installationManifest.SSHTunnel
+	if sshTunnel.IsEmpty() {
+		c.ui.Error("No ssh credentials found in the deployment manifest")
+		return bosherr.Errorf("Running ssh cmd: cloud_provider.ssh_tunnel must be configured in '%s'", deploymentManifestPath)
+	}
+
+	instanceRecords, err := allInstanceRecords(c.instanceRepo)
+	if err != nil {
+		return err
+	}
+
+	position, found := findInstanceRecord(instanceRecords, instanceName)
+	if !found {
+		c.ui.Error(fmt.Sprintf("No instance '%s' found", instanceName))
+		return bosherr.Errorf("Running ssh cmd: No instance '%s' found", instanceName)
+	}
+	instanceRecord := instanceRecords[position]
+
+	vmCID := instanceRecord.VMCID
+	if vmCID == "" {
+		c.ui.Error("No deployed VM found")
+		return bosherr.Error("Running ssh cmd: No deployed VM found")
+	}
+
+	host := sshTunnel.Host
+	if position > 0 {
+		host = instanceRecord.IP
+	}
+
+	port := sshTunnel.Port
+	if port == 0 {
+		port = defaultSSHPort
+	}
+
+	sshClient := c.sshClientFactory.NewClient(bmsshclient.Options{
+		Host:       host,
+		Port:       port,
+		User:       sshTunnel.User,
+		PrivateKey: sshTunnel.PrivateKey,
+		Password:   sshTunnel.Password,
+	})
+
+	c.logger.Debug(c.logTag, "Connecting to VM '%s' at %s:%d as '%s'", vmCID, host, port, sshTunnel.User)
+
+	if command != "" {
+		return sshClient.Run(command)
+	}
+	return sshClient.Shell()
+}
+
+func (c *sshCmd) parseCmdInputs(args []string) (string, string, error) {
+	flags, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return "", "", usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	switch len(positionalArgs) {
+	case 0:
+		return flags.String("command"), "", nil
+	case 1:
+		return flags.String("command"), positionalArgs[0], nil
+	default:
+		return "", "", usageError(c, c.ui, c.logger, c.logTag, args, "ssh command accepts only an optional '<job>/<index>' & '--command <command>'")
+	}
+}
@@ -0,0 +1,238 @@
+package cmd_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+
+	"code.google.com/p/gomock/gomock"
+	mock_sshclient "github.com/cloudfoundry/bosh-micro-cli/deployment/sshclient/mocks"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-agent/uuid/fakes"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmsshclient "github.com/cloudfoundry/bosh-micro-cli/deployment/sshclient"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+
+	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
+)
+
+var _ = Describe("SSHCmd", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		var (
+			fs                      *fakesys.FakeFileSystem
+			logger                  boshlog.Logger
+			userConfig              bmconfig.UserConfig
+			deploymentConfigService bmconfig.DeploymentConfigService
+			vmRepo                  bmconfig.VMRepo
+			mockSSHClientFactory    *mock_sshclient.MockFactory
+			mockSSHClient           *mock_sshclient.MockClient
+
+			ui *fakeui.FakeUI
+
+			deploymentManifestPath = "/deployment-dir/fake-deployment-manifest.yml"
+			deploymentConfigPath   = "/fake-bosh-deployments.json"
+		)
+
+		var newSSHCmd = func() Cmd {
+			return NewSSHCmd(
+				ui,
+				userConfig,
+				fs,
+				bminstallmanifest.NewParser(fs, bmvars.NewInterpolator(bmvars.Variables{}), logger),
+				bmconfig.NewInstanceRepo(deploymentConfigService),
+				mockSSHClientFactory,
+				logger,
+			)
+		}
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+			logger = boshlog.NewLogger(boshlog.LevelNone)
+			deploymentConfigService = bmconfig.NewFileSystemDeploymentConfigService(deploymentConfigPath, fs, fakeuuid.NewFakeGenerator(), logger)
+			vmRepo = bmconfig.NewVMRepo(deploymentConfigService)
+			mockSSHClientFactory = mock_sshclient.NewMockFactory(mockCtrl)
+			mockSSHClient = mock_sshclient.NewMockClient(mockCtrl)
+
+			ui = &fakeui.FakeUI{}
+			userConfig = bmconfig.UserConfig{DeploymentManifestPath: deploymentManifestPath}
+
+			fs.WriteFileString(deploymentManifestPath, `---
+name: fake-deployment-name
+cloud_provider:
+  release: fake-cpi-release-name
+  ssh_tunnel:
+    host: 54.34.56.8
+    user: fake-ssh-user
+    private_key: /tmp/fake-ssh-key.pem
+    password: fake-password
+`)
+		})
+
+		Context("when the VM is deployed", func() {
+			var expectedOptions bmsshclient.Options
+
+			BeforeEach(func() {
+				err := vmRepo.UpdateCurrent("fake-vm-cid")
+				Expect(err).ToNot(HaveOccurred())
+
+				expectedOptions = bmsshclient.Options{
+					Host:       "54.34.56.8",
+					Port:       22,
+					User:       "fake-ssh-user",
+					PrivateKey: "/tmp/fake-ssh-key.pem",
+					Password:   "fake-password",
+				}
+			})
+
+			It("opens a shell with the ssh_tunnel credentials, defaulting to port 22", func() {
+				mockSSHClientFactory.EXPECT().NewClient(expectedOptions).Return(mockSSHClient)
+				mockSSHClient.EXPECT().Shell()
+
+				err := newSSHCmd().Run([]string{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("runs the given command when --command is specified", func() {
+				mockSSHClientFactory.EXPECT().NewClient(expectedOptions).Return(mockSSHClient)
+				mockSSHClient.EXPECT().Run("sudo monit summary")
+
+				err := newSSHCmd().Run([]string{"--command", "sudo monit summary"})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("uses the configured port", func() {
+				fs.WriteFileString(deploymentManifestPath, `---
+cloud_provider:
+  ssh_tunnel:
+    host: 54.34.56.8
+    port: 2222
+    user: fake-ssh-user
+    password: fake-password
+`)
+				mockSSHClientFactory.EXPECT().NewClient(bmsshclient.Options{
+					Host:     "54.34.56.8",
+					Port:     2222,
+					User:     "fake-ssh-user",
+					Password: "fake-password",
+				}).Return(mockSSHClient)
+				mockSSHClient.EXPECT().Shell()
+
+				err := newSSHCmd().Run([]string{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("when the deployment has another instance", func() {
+				BeforeEach(func() {
+					_, err := bmconfig.NewInstanceRepo(deploymentConfigService).SaveFirst("fake-job-name", 0)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = bmconfig.NewInstanceRepo(deploymentConfigService).Save("fake-job-name", 1, "10.0.0.8")
+					Expect(err).ToNot(HaveOccurred())
+					err = bmconfig.NewInstanceVMRepo(deploymentConfigService, "fake-job-name", 1).UpdateCurrent("fake-other-vm-cid")
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("connects to the first instance by default", func() {
+					mockSSHClientFactory.EXPECT().NewClient(expectedOptions).Return(mockSSHClient)
+					mockSSHClient.EXPECT().Shell()
+
+					err := newSSHCmd().Run([]string{})
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("connects to the given instance at its IP", func() {
+					expectedOptions.Host = "10.0.0.8"
+					mockSSHClientFactory.EXPECT().NewClient(expectedOptions).Return(mockSSHClient)
+					mockSSHClient.EXPECT().Run("sudo monit summary")
+
+					err := newSSHCmd().Run([]string{"--command", "sudo monit summary", "fake-job-name/1"})
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("connects to the first instance when it is given by name", func() {
+					mockSSHClientFactory.EXPECT().NewClient(expectedOptions).Return(mockSSHClient)
+					mockSSHClient.EXPECT().Shell()
+
+					err := newSSHCmd().Run([]string{"fake-job-name/0"})
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("returns an error when the instance does not exist", func() {
+					err := newSSHCmd().Run([]string{"fake-job-name/2"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Running ssh cmd: No instance 'fake-job-name/2' found"))
+					Expect(ui.Errors).To(ContainElement("No instance 'fake-job-name/2' found"))
+				})
+			})
+
+			It("returns an error when the ssh session fails", func() {
+				mockSSHClientFactory.EXPECT().NewClient(expectedOptions).Return(mockSSHClient)
+				mockSSHClient.EXPECT().Run("false").Return(errors.New("fake-run-error"))
+
+				err := newSSHCmd().Run([]string{"--command", "false"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-run-error"))
+			})
+		})
+
+		Context("when the VM is not deployed", func() {
+			It("returns an error", func() {
+				err := newSSHCmd().Run([]string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Running ssh cmd: No deployed VM found"))
+				Expect(ui.Errors).To(ContainElement("No deployed VM found"))
+			})
+		})
+
+		Context("when the manifest has no ssh_tunnel", func() {
+			BeforeEach(func() {
+				fs.WriteFileString(deploymentManifestPath, `---
+cloud_provider:
+  release: fake-cpi-release-name
+`)
+			})
+
+			It("returns an error", func() {
+				err := newSSHCmd().Run([]string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cloud_provider.ssh_tunnel must be configured"))
+			})
+		})
+
+		Context("when the deployment has not been set", func() {
+			BeforeEach(func() {
+				userConfig.DeploymentManifestPath = ""
+			})
+
+			It("returns an error", func() {
+				err := newSSHCmd().Run([]string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Running ssh cmd: Deployment manifest not set"))
+			})
+		})
+
+		It("returns an error for unexpected arguments", func() {
+			err := newSSHCmd().Run([]string{"fake-job-name/0", "fake-arg"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid usage"))
+			Expect(ui.Said).To(ContainElement("Expected usage: bosh-micro ssh [--command <command>] [<job>/<index>]"))
+		})
+	})
+})
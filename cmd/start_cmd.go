@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type startCmd struct {
+	ui           bmui.UI
+	jobLifecycle *jobLifecycle
+	deployCmd    Cmd
+	eventLogger  bmeventlog.EventLogger
+	logger       boshlog.Logger
+	logTag       string
+}
+
+// NewStartCmd returns a cmd that starts the jobs of the deployed instances.
+// When a VM was deleted by 'stop --hard', the VMs are recreated by deploying with '--recreate'.
+func NewStartCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	installationParser bminstallmanifest.Parser,
+	deploymentParser bmdeplmanifest.Parser,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	instanceRepo bmconfig.InstanceRepo,
+	vmRepo bmconfig.VMRepo,
+	diskRepo bmconfig.DiskRepo,
+	uuidGenerator boshuuid.Generator,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	deployCmd Cmd,
+	eventLogger bmeventlog.EventLogger,
+	logger boshlog.Logger,
+) Cmd {
+	return &startCmd{
+		ui: ui,
+		jobLifecycle: newJobLifecycle(
+			ui,
+			userConfig,
+			fs,
+			installationParser,
+			deploymentParser,
+			deploymentConfigService,
+			instanceRepo,
+			vmRepo,
+			diskRepo,
+			uuidGenerator,
+			agentClientFactory,
+			logger,
+		),
+		deployCmd:   deployCmd,
+		eventLogger: eventLogger,
+		logger:      logger,
+		logTag:      "startCmd",
+	}
+}
+
+func (c *startCmd) Name() string {
+	return "start"
+}
+
+func (c *startCmd) Meta() Meta {
+	return Meta{
+		Summary: "Start the jobs of the deployed VMs, or recreate the VMs when one was deleted",
+		Args:    "[<stemcell-tarball> <cpi-release-tarball> [release-2-tarball [release-3-tarball...]]]",
+	}
+}
+
+func (c *startCmd) Run(args []string) error {
+	_, args, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	targets, err := c.jobLifecycle.Load(c.Name())
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		if target.vmFound {
+			continue
+		}
+
+		if len(args) < 2 {
+			c.ui.Error(fmt.Sprintf("No deployed VM found for instance '%s' - the stemcell & cpi release tarballs are required to recreate it", target.instanceName()))
+			c.ui.Sayln("Expected usage: " + c.Meta().Usage(c.Name()))
+			return bosherr.Error("Running start cmd: No deployed VM found")
+		}
+
+		return c.deployCmd.Run(append([]string{"--recreate"}, args...))
+	}
+
+	startStage := c.eventLogger.NewStage("starting")
+	startStage.Start()
+
+	for _, target := range targets {
+		err = c.jobLifecycle.StartJobs(target, startStage)
+		if err != nil {
+			return err
+		}
+	}
+
+	startStage.Finish()
+
+	return nil
+}
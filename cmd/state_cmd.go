@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmstate "github.com/cloudfoundry/bosh-micro-cli/state"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type stateCmd struct {
+	ui         bmui.UI
+	userConfig bmconfig.UserConfig
+	fs         boshsys.FileSystem
+	bundler    bmstate.Bundler
+	logger     boshlog.Logger
+	logTag     string
+}
+
+func NewStateCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	bundler bmstate.Bundler,
+	logger boshlog.Logger,
+) Cmd {
+	return &stateCmd{
+		ui:         ui,
+		userConfig: userConfig,
+		fs:         fs,
+		bundler:    bundler,
+		logger:     logger,
+		logTag:     "stateCmd",
+	}
+}
+
+func (c *stateCmd) Name() string {
+	return "state"
+}
+
+func (c *stateCmd) Meta() Meta {
+	return Meta{
+		Summary: "Export the deployment state to a bundle, or import it from one",
+		Args:    "export|import <bundle-path>",
+		Flags: []Flag{
+			{Name: "force", Usage: "Overwrite the existing state on import"},
+		},
+	}
+}
+
+func (c *stateCmd) Run(args []string) error {
+	subcommand, bundlePath, force, err := c.parseCmdInputs(args)
+	if err != nil {
+		return err
+	}
+
+	_, err = getDeploymentManifest(c.userConfig, c.ui, c.fs)
+	if err != nil {
+		return bosherr.WrapError(err, "Running state cmd")
+	}
+
+	deploymentConfigPath := c.userConfig.DeploymentConfigPath()
+
+	if subcommand == "export" {
+		err = c.bundler.Export(deploymentConfigPath, bundlePath)
+		if err != nil {
+			c.ui.Error("Failed to export deployment state")
+			return bosherr.WrapError(err, "Exporting deployment state")
+		}
+
+		c.ui.Sayln(fmt.Sprintf("Deployment state exported to '%s'", bundlePath))
+		return nil
+	}
+
+	err = c.bundler.Import(bundlePath, deploymentConfigPath, force)
+	if err != nil {
+		c.ui.Error("Failed to import deployment state")
+		return bosherr.WrapError(err, "Importing deployment state")
+	}
+
+	c.ui.Sayln(fmt.Sprintf("Deployment state imported from '%s'", bundlePath))
+	return nil
+}
+
+func (c *stateCmd) parseCmdInputs(args []string) (string, string, bool, error) {
+	flags, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return "", "", false, usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	force := flags.Bool("force")
+	switch {
+	case len(positionalArgs) == 2 && positionalArgs[0] == "export" && !force:
+		return "export", positionalArgs[1], false, nil
+	case len(positionalArgs) == 2 && positionalArgs[0] == "import":
+		return "import", positionalArgs[1], force, nil
+	default:
+		return "", "", false, usageError(c, c.ui, c.logger, c.logTag, args, "state command requires 'export <bundle-path>' or 'import [--force] <bundle-path>'")
+	}
+}
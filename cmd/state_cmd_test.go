@@ -0,0 +1,95 @@
+package cmd_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+
+	"code.google.com/p/gomock/gomock"
+	mock_state "github.com/cloudfoundry/bosh-micro-cli/state/mocks"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+
+	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+)
+
+var _ = Describe("StateCmd", func() {
+	var (
+		mockCtrl    *gomock.Controller
+		mockBundler *mock_state.MockBundler
+		fs          *fakesys.FakeFileSystem
+		ui          *fakeui.FakeUI
+		userConfig  bmconfig.UserConfig
+		command     Cmd
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockBundler = mock_state.NewMockBundler(mockCtrl)
+		fs = fakesys.NewFakeFileSystem()
+		ui = &fakeui.FakeUI{}
+		userConfig = bmconfig.UserConfig{DeploymentManifestPath: "/deployment-dir/fake-deployment-manifest.yml"}
+		fs.WriteFileString("/deployment-dir/fake-deployment-manifest.yml", "")
+
+		command = NewStateCmd(ui, userConfig, fs, mockBundler, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		It("exports the deployment state", func() {
+			mockBundler.EXPECT().Export("/deployment-dir/fake-deployment-manifest-state.json", "/fake-state.tgz")
+
+			err := command.Run([]string{"export", "/fake-state.tgz"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Said).To(ContainElement("Deployment state exported to '/fake-state.tgz'"))
+		})
+
+		It("imports the deployment state", func() {
+			mockBundler.EXPECT().Import("/fake-state.tgz", "/deployment-dir/fake-deployment-manifest-state.json", false)
+
+			err := command.Run([]string{"import", "/fake-state.tgz"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Said).To(ContainElement("Deployment state imported from '/fake-state.tgz'"))
+		})
+
+		It("imports the deployment state over existing state when --force is given", func() {
+			mockBundler.EXPECT().Import("/fake-state.tgz", "/deployment-dir/fake-deployment-manifest-state.json", true)
+
+			err := command.Run([]string{"import", "--force", "/fake-state.tgz"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error when importing fails", func() {
+			mockBundler.EXPECT().Import("/fake-state.tgz", "/deployment-dir/fake-deployment-manifest-state.json", false).Return(errors.New("fake-import-error"))
+
+			err := command.Run([]string{"import", "/fake-state.tgz"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-import-error"))
+			Expect(ui.Errors).To(ContainElement("Failed to import deployment state"))
+		})
+
+		It("returns an error when the deployment manifest is not set", func() {
+			command = NewStateCmd(ui, bmconfig.UserConfig{}, fs, mockBundler, boshlog.NewLogger(boshlog.LevelNone))
+
+			err := command.Run([]string{"export", "/fake-state.tgz"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deployment manifest not set"))
+		})
+
+		It("returns an error when the arguments are invalid", func() {
+			err := command.Run([]string{"export"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid usage"))
+			Expect(ui.Said).To(ContainElement("Expected usage: bosh-micro state [--force] export|import <bundle-path>"))
+		})
+	})
+})
@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+// DeploymentStatus summarizes how the recorded deployment state compares to the cloud & the agent.
+type DeploymentStatus string
+
+const (
+	DeploymentStatusNotDeployed DeploymentStatus = "not deployed"
+	DeploymentStatusHealthy     DeploymentStatus = "healthy"
+	DeploymentStatusDrifted     DeploymentStatus = "drifted"
+	DeploymentStatusUnreachable DeploymentStatus = "unreachable"
+)
+
+type statusCmd struct {
+	ui                      bmui.UI
+	userConfig              bmconfig.UserConfig
+	fs                      boshsys.FileSystem
+	deploymentConfigService bmconfig.DeploymentConfigService
+	cpiInstaller            *cpiInstaller
+	cloudFactory            bmcloud.Factory
+	agentClientFactory      bmhttpagent.AgentClientFactory
+	instanceRepo            bmconfig.InstanceRepo
+	vmRepo                  bmconfig.VMRepo
+	diskRepo                bmconfig.DiskRepo
+	stemcellRepo            bmconfig.StemcellRepo
+	releaseRepo             bmconfig.ReleaseRepo
+	uuidGenerator           boshuuid.Generator
+	eventLogger             bmeventlog.EventLogger
+	logger                  boshlog.Logger
+	logTag                  string
+}
+
+func NewStatusCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	releaseSetParser bmrelsetmanifest.Parser,
+	installationParser bminstallmanifest.Parser,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	releaseSetValidator bmrelsetmanifest.Validator,
+	installationValidator bminstallmanifest.Validator,
+	installerFactory bminstall.InstallerFactory,
+	releaseExtractor bmrel.Extractor,
+	releaseManager bmrel.Manager,
+	releaseResolver bmrelset.Resolver,
+	cloudFactory bmcloud.Factory,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	instanceRepo bmconfig.InstanceRepo,
+	vmRepo bmconfig.VMRepo,
+	diskRepo bmconfig.DiskRepo,
+	stemcellRepo bmconfig.StemcellRepo,
+	releaseRepo bmconfig.ReleaseRepo,
+	uuidGenerator boshuuid.Generator,
+	eventLogger bmeventlog.EventLogger,
+	logger boshlog.Logger,
+) Cmd {
+	return &statusCmd{
+		ui:                      ui,
+		userConfig:              userConfig,
+		fs:                      fs,
+		deploymentConfigService: deploymentConfigService,
+		cpiInstaller: newCPIInstaller(
+			fs,
+			releaseSetParser,
+			installationParser,
+			releaseSetValidator,
+			installationValidator,
+			installerFactory,
+			releaseExtractor,
+			releaseManager,
+			releaseResolver,
+			logger,
+		),
+		cloudFactory:       cloudFactory,
+		agentClientFactory: agentClientFactory,
+		instanceRepo:       instanceRepo,
+		vmRepo:             vmRepo,
+		diskRepo:           diskRepo,
+		stemcellRepo:       stemcellRepo,
+		releaseRepo:        releaseRepo,
+		uuidGenerator:      uuidGenerator,
+		eventLogger:        eventLogger,
+		logger:             logger,
+		logTag:             "statusCmd",
+	}
+}
+
+func (c *statusCmd) Name() string {
+	return "status"
+}
+
+func (c *statusCmd) Meta() Meta {
+	return Meta{
+		Summary: "Show the state of the deployment & the jobs of its instances",
+		Args:    "<cpi-release-tarball>",
+	}
+}
+
+func (c *statusCmd) Run(args []string) error {
+	releaseTarballPath, err := c.parseCmdInputs(args)
+	if err != nil {
+		return err
+	}
+
+	deploymentManifestPath, err := getDeploymentManifest(c.userConfig, c.ui, c.fs)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running status cmd")
+	}
+
+	validationStage := c.eventLogger.NewStage("validating")
+	validationStage.Start()
+
+	deploymentConfig, err := c.deploymentConfigService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment config")
+	}
+
+	installationManifest, err := c.cpiInstaller.Validate(validationStage, deploymentManifestPath, releaseTarballPath)
+	defer c.cpiInstaller.Cleanup()
+	if err != nil {
+		return err
+	}
+
+	validationStage.Finish()
+
+	installation, err := c.cpiInstaller.Install(installationManifest)
+	if err != nil {
+		return err
+	}
+
+	cloud, err := c.cloudFactory.NewCloud(installation, deploymentConfig.DirectorID)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+	}
+
+	report, err := c.buildReport(deploymentConfig, cloud, installationManifest)
+	if err != nil {
+		return err
+	}
+
+	c.printReport(report)
+
+	return nil
+}
+
+type statusReport struct {
+	instances      []instanceStatusReport
+	stemcellRecord bmconfig.StemcellRecord
+	releaseRecord  bmconfig.ReleaseRecord
+	problems       []string
+	status         DeploymentStatus
+}
+
+type instanceStatusReport struct {
+	name       string
+	vmCID      string
+	vmExists   bool
+	diskRecord bmconfig.DiskRecord
+	jobState   string
+	status     DeploymentStatus
+}
+
+func (c *statusCmd) buildReport(
+	deploymentConfig bmconfig.DeploymentFile,
+	cloud bmcloud.Cloud,
+	installationManifest bminstallmanifest.Manifest,
+) (statusReport, error) {
+	report := statusReport{problems: []string{}}
+
+	stemcellRecord, stemcellFound, err := c.stemcellRepo.FindCurrent()
+	if err != nil {
+		return report, bosherr.WrapError(err, "Finding current stemcell record")
+	}
+	report.stemcellRecord = stemcellRecord
+	if !stemcellFound && deploymentConfig.CurrentStemcellID != "" {
+		report.problems = append(report.problems, fmt.Sprintf("Current stemcell record '%s' does not exist", deploymentConfig.CurrentStemcellID))
+	}
+
+	releaseRecord, _, err := c.releaseRepo.FindCurrent()
+	if err != nil {
+		return report, bosherr.WrapError(err, "Finding current release record")
+	}
+	report.releaseRecord = releaseRecord
+
+	instanceRecords, err := allInstanceRecords(c.instanceRepo)
+	if err != nil {
+		return report, err
+	}
+
+	notDeployed := 0
+	unreachable := false
+	for i, instanceRecord := range instanceRecords {
+		instanceReport, err := c.buildInstanceReport(deploymentConfig, cloud, installationManifest, i, instanceRecord, stemcellFound, &report)
+		if err != nil {
+			return report, err
+		}
+		report.instances = append(report.instances, instanceReport)
+
+		switch instanceReport.status {
+		case DeploymentStatusNotDeployed:
+			notDeployed++
+		case DeploymentStatusUnreachable:
+			unreachable = true
+		}
+	}
+
+	switch {
+	case unreachable:
+		report.status = DeploymentStatusUnreachable
+	case len(report.problems) > 0:
+		report.status = DeploymentStatusDrifted
+	case notDeployed == len(report.instances):
+		report.status = DeploymentStatusNotDeployed
+	case notDeployed > 0:
+		report.status = DeploymentStatusDrifted
+	default:
+		report.status = DeploymentStatusHealthy
+	}
+
+	return report, nil
+}
+
+// buildInstanceReport compares the VM & disk records of the instance at the position of its record with the cloud & its agent,
+// adding the problems found to the report of the deployment
+func (c *statusCmd) buildInstanceReport(
+	deploymentConfig bmconfig.DeploymentFile,
+	cloud bmcloud.Cloud,
+	installationManifest bminstallmanifest.Manifest,
+	position int,
+	instanceRecord bmconfig.InstanceRecord,
+	stemcellFound bool,
+	report *statusReport,
+) (instanceStatusReport, error) {
+	instanceReport := instanceStatusReport{name: instanceRecordName(instanceRecord)}
+
+	vmRepo, diskRepo := c.vmRepo, c.diskRepo
+	if position > 0 {
+		vmRepo = bmconfig.NewInstanceVMRepo(c.deploymentConfigService, instanceRecord.JobName, instanceRecord.Index)
+		diskRepo = bmconfig.NewInstanceDiskRepo(c.deploymentConfigService, c.uuidGenerator, instanceRecord.JobName, instanceRecord.Index)
+	}
+
+	vmCID, vmFound, err := vmRepo.FindCurrent()
+	if err != nil {
+		return instanceReport, bosherr.WrapError(err, "Finding current VM record")
+	}
+	instanceReport.vmCID = vmCID
+
+	diskRecord, diskFound, err := diskRepo.FindCurrent()
+	if err != nil {
+		return instanceReport, bosherr.WrapError(err, "Finding current disk record")
+	}
+	instanceReport.diskRecord = diskRecord
+	if !diskFound && instanceRecord.DiskID != "" {
+		report.problems = append(report.problems, fmt.Sprintf("Current disk record '%s' does not exist", instanceRecord.DiskID))
+	}
+
+	if !vmFound {
+		switch {
+		case position > 0:
+			report.problems = append(report.problems, fmt.Sprintf("No current VM for instance '%s'", instanceReport.name))
+			instanceReport.status = DeploymentStatusDrifted
+		case diskFound || stemcellFound || len(report.problems) > 0:
+			report.problems = append(report.problems, "No current VM, but other resources are still recorded")
+			instanceReport.status = DeploymentStatusDrifted
+		default:
+			instanceReport.status = DeploymentStatusNotDeployed
+		}
+		return instanceReport, nil
+	}
+
+	instanceReport.vmExists, err = cloud.HasVM(vmCID)
+	if err != nil {
+		return instanceReport, bosherr.WrapErrorf(err, "Checking existence of VM '%s'", vmCID)
+	}
+	if !instanceReport.vmExists {
+		report.problems = append(report.problems, fmt.Sprintf("VM '%s' does not exist in the cloud", vmCID))
+		instanceReport.status = DeploymentStatusDrifted
+		return instanceReport, nil
+	}
+
+	mbusURL, err := instanceAgentMbusURL(installationManifest.Mbus, position, instanceRecord)
+	if err != nil {
+		return instanceReport, err
+	}
+
+	agentClient := c.agentClientFactory.NewAgentClient(deploymentConfig.DirectorID, mbusURL)
+	agentState, err := agentClient.GetState()
+	if err != nil {
+		c.logger.Debug(c.logTag, "Getting agent state failed: %s", err.Error())
+		report.problems = append(report.problems, fmt.Sprintf("Agent on VM '%s' is unreachable: %s", vmCID, err.Error()))
+		instanceReport.status = DeploymentStatusUnreachable
+		return instanceReport, nil
+	}
+	instanceReport.jobState = agentState.JobState
+	if agentState.JobState != "running" {
+		report.problems = append(report.problems, fmt.Sprintf("Jobs on VM '%s' are not running (job state '%s')", vmCID, agentState.JobState))
+		instanceReport.status = DeploymentStatusDrifted
+		return instanceReport, nil
+	}
+
+	instanceReport.status = DeploymentStatusHealthy
+	return instanceReport, nil
+}
+
+func (c *statusCmd) printReport(report statusReport) {
+	for _, instanceReport := range report.instances {
+		c.ui.Sayln("")
+		if len(report.instances) > 1 {
+			c.ui.Sayln(fmt.Sprintf("Instance '%s':", instanceReport.name))
+		}
+		c.ui.Sayln(fmt.Sprintf("Current VM:       %s", c.describe(instanceReport.vmCID)))
+		c.ui.Sayln(fmt.Sprintf("Current disk:     %s", c.describe(instanceReport.diskRecord.CID)))
+		if instanceReport.jobState != "" {
+			c.ui.Sayln(fmt.Sprintf("Job state:        '%s'", instanceReport.jobState))
+		}
+	}
+
+	c.ui.Sayln("")
+	if report.stemcellRecord.ID != "" {
+		c.ui.Sayln(fmt.Sprintf("Current stemcell: '%s/%s' (cid '%s')", report.stemcellRecord.Name, report.stemcellRecord.Version, report.stemcellRecord.CID))
+	} else {
+		c.ui.Sayln("Current stemcell: none")
+	}
+	if report.releaseRecord.ID != "" {
+		c.ui.Sayln(fmt.Sprintf("Current release:  '%s/%s'", report.releaseRecord.Name, report.releaseRecord.Version))
+	} else {
+		c.ui.Sayln("Current release:  none")
+	}
+
+	c.ui.Sayln("")
+	c.ui.Sayln(fmt.Sprintf("Status: %s", report.status))
+	for _, problem := range report.problems {
+		c.ui.Sayln(fmt.Sprintf("  - %s", problem))
+	}
+}
+
+func (c *statusCmd) describe(cid string) string {
+	if cid == "" {
+		return "none"
+	}
+	return fmt.Sprintf("'%s'", cid)
+}
+
+func (c *statusCmd) parseCmdInputs(args []string) (string, error) {
+	_, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return "", usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	if len(positionalArgs) != 1 {
+		return "", usageError(c, c.ui, c.logger, c.logTag, args, "status command requires exactly 1 argument")
+	}
+	return positionalArgs[0], nil
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type stopCmd struct {
+	ui           bmui.UI
+	jobLifecycle *jobLifecycle
+	cpiInstaller *cpiInstaller
+	cloudFactory bmcloud.Factory
+	eventLogger  bmeventlog.EventLogger
+	logger       boshlog.Logger
+	logTag       string
+}
+
+func NewStopCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	releaseSetParser bmrelsetmanifest.Parser,
+	installationParser bminstallmanifest.Parser,
+	deploymentParser bmdeplmanifest.Parser,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	releaseSetValidator bmrelsetmanifest.Validator,
+	installationValidator bminstallmanifest.Validator,
+	installerFactory bminstall.InstallerFactory,
+	releaseExtractor bmrel.Extractor,
+	releaseManager bmrel.Manager,
+	releaseResolver bmrelset.Resolver,
+	cloudFactory bmcloud.Factory,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	instanceRepo bmconfig.InstanceRepo,
+	vmRepo bmconfig.VMRepo,
+	diskRepo bmconfig.DiskRepo,
+	uuidGenerator boshuuid.Generator,
+	eventLogger bmeventlog.EventLogger,
+	logger boshlog.Logger,
+) Cmd {
+	return &stopCmd{
+		ui: ui,
+		jobLifecycle: newJobLifecycle(
+			ui,
+			userConfig,
+			fs,
+			installationParser,
+			deploymentParser,
+			deploymentConfigService,
+			instanceRepo,
+			vmRepo,
+			diskRepo,
+			uuidGenerator,
+			agentClientFactory,
+			logger,
+		),
+		cpiInstaller: newCPIInstaller(
+			fs,
+			releaseSetParser,
+			installationParser,
+			releaseSetValidator,
+			installationValidator,
+			installerFactory,
+			releaseExtractor,
+			releaseManager,
+			releaseResolver,
+			logger,
+		),
+		cloudFactory: cloudFactory,
+		eventLogger:  eventLogger,
+		logger:       logger,
+		logTag:       "stopCmd",
+	}
+}
+
+func (c *stopCmd) Name() string {
+	return "stop"
+}
+
+func (c *stopCmd) Meta() Meta {
+	return Meta{
+		Summary: "Stop the jobs of the deployed VMs, or delete the VMs with --hard",
+		Args:    "[<cpi-release-tarball>]",
+		Flags: []Flag{
+			{Name: "hard", Usage: "Delete the VMs, keeping their disks (requires the CPI release tarball)"},
+		},
+	}
+}
+
+func (c *stopCmd) Run(args []string) error {
+	hard, releaseTarballPath, err := c.parseCmdInputs(args)
+	if err != nil {
+		return err
+	}
+
+	targets, err := c.jobLifecycle.Load(c.Name())
+	if err != nil {
+		return err
+	}
+
+	targets = c.jobLifecycle.DeployedTargets(targets)
+	if len(targets) == 0 {
+		c.ui.Error("No deployed VM found")
+		return bosherr.Error("Running stop cmd: No deployed VM found")
+	}
+	firstTarget := targets[0]
+
+	if !hard {
+		stopStage := c.eventLogger.NewStage("stopping")
+		stopStage.Start()
+
+		for _, target := range targets {
+			err = c.jobLifecycle.StopJobs(target, stopStage)
+			if err != nil {
+				return err
+			}
+		}
+
+		stopStage.Finish()
+		return nil
+	}
+
+	validationStage := c.eventLogger.NewStage("validating")
+	validationStage.Start()
+
+	installationManifest, err := c.cpiInstaller.Validate(validationStage, firstTarget.deploymentManifestPath, releaseTarballPath)
+	defer c.cpiInstaller.Cleanup()
+	if err != nil {
+		return err
+	}
+
+	validationStage.Finish()
+
+	installation, err := c.cpiInstaller.Install(installationManifest)
+	if err != nil {
+		return err
+	}
+
+	err = installation.StartRegistry()
+	if err != nil {
+		return bosherr.WrapError(err, "Starting Registry")
+	}
+	defer func() {
+		err := installation.StopRegistry()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Registry failed to stop: %s", err)
+		}
+	}()
+
+	cloud, err := c.cloudFactory.NewCloud(installation, firstTarget.directorID)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+	}
+
+	stopStage := c.eventLogger.NewStage("stopping")
+	stopStage.Start()
+
+	for _, target := range targets {
+		err = c.jobLifecycle.DeleteVM(target, cloud, stopStage)
+		if err != nil {
+			return err
+		}
+	}
+
+	stopStage.Finish()
+
+	if len(targets) == 1 {
+		c.ui.Sayln("VM deleted. Run 'bosh-micro start <stemcell-tarball> <cpi-release-tarball>' to recreate it.")
+	} else {
+		c.ui.Sayln("VMs deleted. Run 'bosh-micro start <stemcell-tarball> <cpi-release-tarball>' to recreate them.")
+	}
+
+	return nil
+}
+
+func (c *stopCmd) parseCmdInputs(args []string) (bool, string, error) {
+	flags, positionalArgs, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return false, "", usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	hard := flags.Bool("hard")
+	switch {
+	case !hard && len(positionalArgs) == 0:
+		return false, "", nil
+	case hard && len(positionalArgs) == 1:
+		return true, positionalArgs[0], nil
+	default:
+		return false, "", usageError(c, c.ui, c.logger, c.logTag, args, "stop command accepts no arguments, or '--hard <cpi-release-tarball>'")
+	}
+}
@@ -2,8 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 	boshsys "github.com/cloudfoundry/bosh-agent/system"
 
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
@@ -29,3 +34,57 @@ func getDeploymentManifest(userConfig bmconfig.UserConfig, ui bmui.UI, fs boshsy
 
 	return deploymentManifestPath, nil
 }
+
+// deploymentName returns the name of the deployment in the manifest,
+// or the manifest file name without extension when the manifest has no name.
+// The manifest is read as plain YAML: its variables are neither interpolated nor generated,
+// as the vars store of the current deployment is not the one of the manifest.
+func deploymentName(fs boshsys.FileSystem, manifestPath string, logger boshlog.Logger) string {
+	var manifest struct {
+		Name string `yaml:"name"`
+	}
+	contents, err := fs.ReadFile(manifestPath)
+	if err == nil {
+		err = candiedyaml.Unmarshal(contents, &manifest)
+	}
+	if err != nil {
+		logger.Warn("userConfig", "Reading the name of deployment manifest '%s': %s", manifestPath, err.Error())
+	} else if manifest.Name != "" {
+		return manifest.Name
+	}
+
+	fileName := filepath.Base(manifestPath)
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+}
+
+// deploymentStatus returns the last known status of the deployment of a manifest, read from its state file
+func deploymentStatus(manifestPath string, fs boshsys.FileSystem) string {
+	if !fs.FileExists(manifestPath) {
+		return "manifest missing"
+	}
+
+	statePath := bmconfig.DeploymentStatePath(manifestPath)
+	if !fs.FileExists(statePath) {
+		return "not deployed"
+	}
+
+	contents, err := fs.ReadFile(statePath)
+	if err != nil {
+		return "unreadable state"
+	}
+
+	deploymentFile, err := bmconfig.ParseDeploymentFile(contents)
+	if err != nil {
+		return "invalid state"
+	}
+
+	firstInstance := deploymentFile.FirstInstance()
+	switch {
+	case firstInstance.VMCID != "":
+		return fmt.Sprintf("deployed (VM '%s')", firstInstance.VMCID)
+	case firstInstance.DiskID != "" || deploymentFile.CurrentStemcellID != "":
+		return "stopped (no VM)"
+	default:
+		return "not deployed"
+	}
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmcpirel "github.com/cloudfoundry/bosh-micro-cli/cpi/release"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmerr "github.com/cloudfoundry/bosh-micro-cli/release/errors"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+type validateCmd struct {
+	ui                 bmui.UI
+	userConfig         bmconfig.UserConfig
+	fs                 boshsys.FileSystem
+	releaseSetParser   bmrelsetmanifest.Parser
+	installationParser bminstallmanifest.Parser
+	deploymentParser   bmdeplmanifest.Parser
+	patcher            bmpatch.Patcher
+	releaseExtractor   bmrel.Extractor
+	releaseManager     bmrel.Manager
+	releaseResolver    bmrelset.Resolver
+	eventLogger        bmeventlog.EventLogger
+	logger             boshlog.Logger
+	logTag             string
+}
+
+func NewValidateCmd(
+	ui bmui.UI,
+	userConfig bmconfig.UserConfig,
+	fs boshsys.FileSystem,
+	releaseSetParser bmrelsetmanifest.Parser,
+	installationParser bminstallmanifest.Parser,
+	deploymentParser bmdeplmanifest.Parser,
+	patcher bmpatch.Patcher,
+	releaseExtractor bmrel.Extractor,
+	releaseManager bmrel.Manager,
+	releaseResolver bmrelset.Resolver,
+	eventLogger bmeventlog.EventLogger,
+	logger boshlog.Logger,
+) Cmd {
+	return &validateCmd{
+		ui:                 ui,
+		userConfig:         userConfig,
+		fs:                 fs,
+		releaseSetParser:   releaseSetParser,
+		installationParser: installationParser,
+		deploymentParser:   deploymentParser,
+		patcher:            patcher,
+		releaseExtractor:   releaseExtractor,
+		releaseManager:     releaseManager,
+		releaseResolver:    releaseResolver,
+		eventLogger:        eventLogger,
+		logger:             logger,
+		logTag:             "validateCmd",
+	}
+}
+
+func (c *validateCmd) Name() string {
+	return "validate"
+}
+
+// validationSection is the list of errors found while validating one part of the deployment
+type validationSection struct {
+	name   string
+	errors []error
+}
+
+func (c *validateCmd) Meta() Meta {
+	return Meta{
+		Summary: "Validate the deployment manifest, and the releases when given",
+		Args:    "[<cpi-release-tarball> [release-2-tarball [release-3-tarball...]]]",
+		Flags:   []Flag{opsFileFlag},
+	}
+}
+
+func (c *validateCmd) Run(args []string) error {
+	flags, releaseTarballPaths, err := parseFlags(c.Meta(), args)
+	if err != nil {
+		return usageError(c, c.ui, c.logger, c.logTag, args, err.Error())
+	}
+
+	deploymentManifestPath, err := getDeploymentManifest(c.userConfig, c.ui, c.fs)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running validate cmd")
+	}
+	c.patcher.UseOpsFiles(flags.Strings(opsFileFlag.Name))
+
+	var (
+		releaseSetValidator   bmrelsetmanifest.Validator
+		installationValidator bminstallmanifest.Validator
+		deploymentValidator   bmdeplmanifest.Validator
+	)
+	validateReleases := len(releaseTarballPaths) > 0
+	if validateReleases {
+		releaseSetValidator = bmrelsetmanifest.NewValidator(c.logger, c.releaseResolver)
+		installationValidator = bminstallmanifest.NewValidator(c.logger, c.releaseResolver)
+		deploymentValidator = bmdeplmanifest.NewValidator(c.logger, c.releaseResolver)
+	} else {
+		releaseSetValidator = bmrelsetmanifest.NewValidatorWithoutReleases(c.logger)
+		installationValidator = bminstallmanifest.NewValidatorWithoutReleases(c.logger)
+		deploymentValidator = bmdeplmanifest.NewValidatorWithoutReleases(c.logger)
+	}
+
+	sections := []*validationSection{}
+	performValidation := func(stage bmeventlog.Stage, name string, validate func() error) bool {
+		section := &validationSection{name: name, errors: c.validationErrors(validate())}
+		sections = append(sections, section)
+
+		stage.PerformStep(fmt.Sprintf("Validating %s", name), func() error {
+			if len(section.errors) > 0 {
+				return bosherr.Errorf("%d error(s)", len(section.errors))
+			}
+			return nil
+		})
+
+		return len(section.errors) == 0
+	}
+
+	validationStage := c.eventLogger.NewStage("validating")
+	validationStage.Start()
+
+	if validateReleases {
+		defer func() {
+			err := c.releaseManager.DeleteAll()
+			if err != nil {
+				c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+			}
+		}()
+
+		performValidation(validationStage, "releases", func() error {
+			errs := []error{}
+			for _, releaseTarballPath := range releaseTarballPaths {
+				if !c.fs.FileExists(releaseTarballPath) {
+					errs = append(errs, bosherr.Errorf("Verifying that the release '%s' exists", releaseTarballPath))
+					continue
+				}
+
+				release, err := c.releaseExtractor.Extract(releaseTarballPath)
+				if err != nil {
+					errs = append(errs, bosherr.WrapErrorf(err, "Extracting release '%s'", releaseTarballPath))
+					continue
+				}
+				c.releaseManager.Add(release)
+			}
+			if len(errs) > 0 {
+				return bmerr.NewExplainableError(errs)
+			}
+			return nil
+		})
+	}
+
+	performValidation(validationStage, "release set manifest", func() error {
+		releaseSetManifest, err := c.releaseSetParser.Parse(deploymentManifestPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing release set manifest '%s'", deploymentManifestPath)
+		}
+
+		err = releaseSetValidator.Validate(releaseSetManifest)
+		if err != nil {
+			return err
+		}
+
+		if validateReleases {
+			c.releaseResolver.Filter(releaseSetManifest.Releases)
+		}
+		return nil
+	})
+
+	var installationManifest bminstallmanifest.Manifest
+	installationValid := performValidation(validationStage, "installation manifest", func() error {
+		installationManifest, err = c.installationParser.Parse(deploymentManifestPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing installation manifest '%s'", deploymentManifestPath)
+		}
+
+		return installationValidator.Validate(installationManifest)
+	})
+
+	performValidation(validationStage, "deployment manifest", func() error {
+		deploymentManifest, err := c.deploymentParser.Parse(deploymentManifestPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing deployment manifest '%s'", deploymentManifestPath)
+		}
+
+		return deploymentValidator.Validate(deploymentManifest)
+	})
+
+	if validateReleases && installationValid {
+		performValidation(validationStage, "cpi release", func() error {
+			cpiRelease, err := c.releaseResolver.Find(installationManifest.Release)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "installation release '%s' must refer to a provided release", installationManifest.Release)
+			}
+
+			err = bmcpirel.NewValidator().Validate(cpiRelease)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Invalid CPI release '%s'", cpiRelease.Name())
+			}
+			return nil
+		})
+	}
+
+	validationStage.Finish()
+
+	errorCount := 0
+	for _, section := range sections {
+		errorCount += len(section.errors)
+	}
+
+	if errorCount == 0 {
+		c.ui.Sayln(fmt.Sprintf("Deployment manifest '%s' is valid", deploymentManifestPath))
+		return nil
+	}
+
+	c.ui.Error(fmt.Sprintf("Deployment manifest '%s' is invalid:", deploymentManifestPath))
+	for _, section := range sections {
+		if len(section.errors) == 0 {
+			continue
+		}
+		c.ui.Error(fmt.Sprintf("  %s:", section.name))
+		for _, err := range section.errors {
+			c.ui.Error(fmt.Sprintf("    - %s", err.Error()))
+		}
+	}
+
+	return bosherr.Errorf("Validating deployment manifest '%s': found %d error(s)", deploymentManifestPath, errorCount)
+}
+
+func (c *validateCmd) validationErrors(err error) []error {
+	if err == nil {
+		return []error{}
+	}
+
+	if explainableErr, ok := err.(bmerr.ExplainableError); ok {
+		return explainableErr.Errors()
+	}
+
+	return []error{err}
+}
@@ -0,0 +1,309 @@
+package cmd_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/cmd"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"os"
+
+	"code.google.com/p/gomock/gomock"
+	mock_release "github.com/cloudfoundry/bosh-micro-cli/release/mocks"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+
+	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
+)
+
+var _ = Describe("ValidateCmd", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		var (
+			fs                   boshsys.FileSystem
+			logger               boshlog.Logger
+			releaseManager       bmrel.Manager
+			mockReleaseExtractor *mock_release.MockExtractor
+			userConfig           bmconfig.UserConfig
+
+			ui *fakeui.FakeUI
+
+			deploymentManifestPath = "/deployment-dir/fake-deployment-manifest.yml"
+
+			validManifest = `---
+name: fake-deployment-name
+
+releases:
+- name: fake-cpi-release-name
+  version: 1.0
+
+networks:
+- name: fake-network-name
+  type: dynamic
+
+resource_pools:
+- name: fake-resource-pool-name
+  network: fake-network-name
+
+jobs:
+- name: fake-job-name
+  instances: 1
+  templates:
+  - name: cpi
+    release: fake-cpi-release-name
+  networks:
+  - name: fake-network-name
+
+cloud_provider:
+  release: fake-cpi-release-name
+`
+		)
+
+		var newValidateCmd = func() Cmd {
+			patcher := bmpatch.NewPatcher(fs, logger)
+			interpolator := bmpatch.NewPatchingInterpolator(patcher, bmvars.NewInterpolator(bmvars.Variables{}))
+			return NewValidateCmd(
+				ui,
+				userConfig,
+				fs,
+				bmrelsetmanifest.NewParser(fs, interpolator, logger),
+				bminstallmanifest.NewParser(fs, interpolator, logger),
+				bmdeplmanifest.NewParser(fs, interpolator, logger),
+				patcher,
+				mockReleaseExtractor,
+				releaseManager,
+				bmrelset.NewResolver(releaseManager, logger),
+				bmeventlog.NewEventLogger(ui),
+				logger,
+			)
+		}
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+			logger = boshlog.NewLogger(boshlog.LevelNone)
+			mockReleaseExtractor = mock_release.NewMockExtractor(mockCtrl)
+			releaseManager = bmrel.NewManager(logger)
+
+			ui = &fakeui.FakeUI{}
+			userConfig = bmconfig.UserConfig{DeploymentManifestPath: deploymentManifestPath}
+
+			fs.WriteFileString(deploymentManifestPath, validManifest)
+		})
+
+		Context("when the deployment has not been set", func() {
+			BeforeEach(func() {
+				userConfig.DeploymentManifestPath = ""
+			})
+
+			It("returns an error", func() {
+				err := newValidateCmd().Run([]string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Running validate cmd: Deployment manifest not set"))
+			})
+		})
+
+		Context("when no release tarballs are given", func() {
+			It("validates the manifests without checking release references", func() {
+				err := newValidateCmd().Run([]string{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ui.Said).To(ContainElement("Deployment manifest '/deployment-dir/fake-deployment-manifest.yml' is valid"))
+			})
+
+			Context("when the manifests are invalid", func() {
+				BeforeEach(func() {
+					fs.WriteFileString(deploymentManifestPath, `---
+releases:
+- name: fake-cpi-release-name
+- name: fake-cpi-release-name
+
+jobs:
+- name: fake-job-name
+
+cloud_provider:
+  release: ""
+`)
+				})
+
+				It("reports every error of every manifest", func() {
+					err := newValidateCmd().Run([]string{})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("found 5 error(s)"))
+
+					Expect(ui.Errors).To(Equal([]string{
+						"Deployment manifest '/deployment-dir/fake-deployment-manifest.yml' is invalid:",
+						"  release set manifest:",
+						"    - releases[1].name 'fake-cpi-release-name' must be unique",
+						"  installation manifest:",
+						"    - cloud_provider.release must be provided",
+						"  deployment manifest:",
+						"    - name must be provided",
+						"    - resource_pools must be a non-empty array",
+						"    - jobs[0].networks must be a non-empty array",
+					}))
+				})
+			})
+
+			Context("when ops files are given", func() {
+				BeforeEach(func() {
+					fs.WriteFileString("/ops.yml", `---
+- type: remove
+  path: /name
+- type: replace
+  path: /releases/name=fake-cpi-release-name/version
+  value: 2.0
+`)
+				})
+
+				It("validates the patched manifests", func() {
+					err := newValidateCmd().Run([]string{"-o", "/ops.yml"})
+					Expect(err).To(HaveOccurred())
+					Expect(ui.Errors).To(ContainElement("    - name must be provided"))
+				})
+
+				It("reports ops errors for each manifest", func() {
+					err := newValidateCmd().Run([]string{"--ops-file", "/missing-ops.yml"})
+					Expect(err).To(HaveOccurred())
+					Expect(ui.Errors).To(ContainElement(ContainSubstring("Reading ops file '/missing-ops.yml'")))
+				})
+			})
+
+			Context("when the manifest cannot be parsed", func() {
+				BeforeEach(func() {
+					fs.WriteFileString(deploymentManifestPath, "{")
+				})
+
+				It("reports the parse error for each manifest", func() {
+					err := newValidateCmd().Run([]string{})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("found 3 error(s)"))
+					Expect(ui.Errors).To(ContainElement(ContainSubstring("Parsing release set manifest")))
+					Expect(ui.Errors).To(ContainElement(ContainSubstring("Parsing installation manifest")))
+					Expect(ui.Errors).To(ContainElement(ContainSubstring("Parsing deployment manifest")))
+				})
+			})
+		})
+
+		Context("when release tarballs are given", func() {
+			BeforeEach(func() {
+				fs.WriteFileString("/fake-cpi-release.tgz", "fake-tgz-content")
+
+				cpiRelease := bmrel.NewRelease(
+					"fake-cpi-release-name",
+					"1.0",
+					[]bmrel.Job{
+						{
+							Name: "cpi",
+							Templates: map[string]string{
+								"templates/cpi.erb": "bin/cpi",
+							},
+						},
+					},
+					[]*bmrel.Package{},
+					"fake-cpi-extracted-dir",
+					fs,
+				)
+				mockReleaseExtractor.EXPECT().Extract("/fake-cpi-release.tgz").Do(func(_ string) {
+					err := fs.MkdirAll("fake-cpi-extracted-dir", os.ModePerm)
+					Expect(err).ToNot(HaveOccurred())
+				}).Return(cpiRelease, nil).AnyTimes()
+			})
+
+			It("validates the manifests against the releases", func() {
+				err := newValidateCmd().Run([]string{"/fake-cpi-release.tgz"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ui.Said).To(ContainElement("Deployment manifest '/deployment-dir/fake-deployment-manifest.yml' is valid"))
+			})
+
+			It("deletes the extracted releases", func() {
+				err := newValidateCmd().Run([]string{"/fake-cpi-release.tgz"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fs.FileExists("fake-cpi-extracted-dir")).To(BeFalse())
+			})
+
+			Context("when the manifests refer to releases that were not given", func() {
+				BeforeEach(func() {
+					fs.WriteFileString(deploymentManifestPath, `---
+name: fake-deployment-name
+
+releases:
+- name: fake-cpi-release-name
+  version: 1.0
+- name: fake-other-release-name
+  version: 1.0
+
+networks:
+- name: fake-network-name
+  type: dynamic
+
+resource_pools:
+- name: fake-resource-pool-name
+  network: fake-network-name
+
+jobs:
+- name: fake-job-name
+  instances: 1
+  templates:
+  - name: fake-other-job-name
+    release: fake-other-release-name
+  networks:
+  - name: fake-network-name
+
+cloud_provider:
+  release: fake-cpi-release-name
+`)
+				})
+
+				It("reports the missing releases", func() {
+					err := newValidateCmd().Run([]string{"/fake-cpi-release.tgz"})
+					Expect(err).To(HaveOccurred())
+					Expect(ui.Errors).To(ContainElement(ContainSubstring("releases[1] must refer to an available release")))
+					Expect(ui.Errors).To(ContainElement(ContainSubstring("jobs[0].templates[0].release must refer to an available release")))
+				})
+			})
+
+			Context("when a release tarball does not exist", func() {
+				It("reports the missing release tarball", func() {
+					err := newValidateCmd().Run([]string{"/fake-cpi-release.tgz", "/fake-missing-release.tgz"})
+					Expect(err).To(HaveOccurred())
+					Expect(ui.Errors).To(ContainElement("    - Verifying that the release '/fake-missing-release.tgz' exists"))
+				})
+			})
+
+			Context("when a release cannot be extracted", func() {
+				BeforeEach(func() {
+					fs.WriteFileString("/fake-broken-release.tgz", "fake-tgz-content")
+					mockReleaseExtractor.EXPECT().Extract("/fake-broken-release.tgz").Return(nil, errors.New("fake-extract-error"))
+				})
+
+				It("reports the extraction error", func() {
+					err := newValidateCmd().Run([]string{"/fake-cpi-release.tgz", "/fake-broken-release.tgz"})
+					Expect(err).To(HaveOccurred())
+					Expect(ui.Errors).To(ContainElement("    - Extracting release '/fake-broken-release.tgz': fake-extract-error"))
+				})
+			})
+		})
+	})
+})
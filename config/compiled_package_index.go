@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+
+	bmindex "github.com/cloudfoundry/bosh-micro-cli/index"
+)
+
+// compiledPackageIndex is the index of the packages compiled on a VM.
+// Its records are kept in the deployment config until the VM is replaced or deleted.
+type compiledPackageIndex struct {
+	configService DeploymentConfigService
+	vmCID         string
+}
+
+func (i compiledPackageIndex) Find(key interface{}, entry interface{}) error {
+	rawKey, err := i.rawKey(key)
+	if err != nil {
+		return err
+	}
+
+	config, err := i.configService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	for _, record := range config.CompiledPackages {
+		if record.VMCID == i.vmCID && reflect.DeepEqual(record.Key, rawKey) {
+			return json.Unmarshal(record.Value, entry)
+		}
+	}
+
+	return bmindex.ErrNotFound
+}
+
+func (i compiledPackageIndex) Save(key interface{}, entry interface{}) error {
+	rawKey, err := i.rawKey(key)
+	if err != nil {
+		return err
+	}
+
+	rawValue, err := json.Marshal(entry)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling index entry")
+	}
+
+	err = i.configService.Update(func(config *DeploymentFile) error {
+		for j, record := range config.CompiledPackages {
+			if record.VMCID == i.vmCID && reflect.DeepEqual(record.Key, rawKey) {
+				config.CompiledPackages[j].Value = rawValue
+				return nil
+			}
+		}
+
+		config.CompiledPackages = append(config.CompiledPackages, CompiledPackageRecord{
+			VMCID: i.vmCID,
+			Key:   rawKey,
+			Value: rawValue,
+		})
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Updating config")
+	}
+	return nil
+}
+
+// rawKey returns the key the way it is loaded from the config, to compare it with the keys of the records
+func (i compiledPackageIndex) rawKey(key interface{}) (interface{}, error) {
+	bytes, err := json.Marshal(key)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling index key")
+	}
+
+	var rawKey interface{}
+	err = json.Unmarshal(bytes, &rawKey)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling index key")
+	}
+
+	return rawKey, nil
+}
+
+// forgetCompiledPackages forgets the packages compiled on the VM
+func forgetCompiledPackages(config *DeploymentFile, vmCID string) {
+	records := []CompiledPackageRecord{}
+	for _, record := range config.CompiledPackages {
+		if record.VMCID != vmCID {
+			records = append(records, record)
+		}
+	}
+	config.CompiledPackages = records
+}
@@ -1,16 +1,47 @@
 package config
 
+import (
+	"encoding/json"
+)
+
 type DeploymentFile struct {
-	DirectorID          string           `json:"director_id"`
-	InstallationID      string           `json:"installation_id"`
-	CurrentVMCID        string           `json:"current_vm_cid"`
-	CurrentStemcellID   string           `json:"current_stemcell_id"`
-	CurrentDiskID       string           `json:"current_disk_id"`
-	CurrentReleaseID    string           `json:"current_release_id"`
-	CurrentManifestSHA1 string           `json:"current_manifest_sha1"`
-	Disks               []DiskRecord     `json:"disks"`
-	Stemcells           []StemcellRecord `json:"stemcells"`
-	Releases            []ReleaseRecord  `json:"releases"`
+	DirectorID          string                  `json:"director_id"`
+	InstallationID      string                  `json:"installation_id"`
+	CurrentStemcellID   string                  `json:"current_stemcell_id"`
+	CurrentReleaseID    string                  `json:"current_release_id"`
+	CurrentFingerprints Fingerprints            `json:"current_fingerprints"`
+	Disks               []DiskRecord            `json:"disks"`
+	Stemcells           []StemcellRecord        `json:"stemcells"`
+	Releases            []ReleaseRecord         `json:"releases"`
+	Instances           []InstanceRecord        `json:"instances"`
+	CompiledPackages    []CompiledPackageRecord `json:"compiled_packages"`
+}
+
+// FirstInstance returns the record of the first instance, empty before the first deploy
+func (f DeploymentFile) FirstInstance() InstanceRecord {
+	if len(f.Instances) == 0 {
+		return InstanceRecord{}
+	}
+	return f.Instances[0]
+}
+
+// Fingerprints identify each component of the last deploy, so that a change can be traced to what it affects.
+// Manifest maps the sections of the parsed deployment manifest to their fingerprint,
+// RenderedJobs maps the instances ("job/index") to the fingerprint of their rendered job templates.
+type Fingerprints struct {
+	Manifest     map[string]string     `json:"manifest"`
+	Releases     []ReleaseFingerprints `json:"releases"`
+	StemcellSHA1 string                `json:"stemcell_sha1"`
+	RenderedJobs map[string]string     `json:"rendered_jobs"`
+}
+
+// ReleaseFingerprints identify a release by its version and the fingerprints of its jobs & packages,
+// which change when a release is rebuilt without bumping its version.
+type ReleaseFingerprints struct {
+	Name     string            `json:"name"`
+	Version  string            `json:"version"`
+	Jobs     map[string]string `json:"jobs"`
+	Packages map[string]string `json:"packages"`
 }
 
 type StemcellRecord struct {
@@ -27,6 +58,40 @@ type DiskRecord struct {
 	CloudProperties map[string]interface{} `json:"cloud_properties"`
 }
 
+// InstanceRecord is an instance of the deployment, with its VM & disk.
+// The first record is the first instance, whose agent is reached at the mbus URL;
+// the agents of the other instances are reached at their IP.
+type InstanceRecord struct {
+	JobName       string     `json:"job_name"`
+	Index         int        `json:"index"`
+	IP            string     `json:"ip"`
+	VMCID         string     `json:"vm_cid"`
+	VMFingerprint string     `json:"vm_fingerprint"`
+	VMSnapshot    VMSnapshot `json:"vm_snapshot"`
+	DiskID        string     `json:"disk_id"`
+}
+
+// VMSnapshot is what the VM of an instance last ran successfully with, which a failed deploy can roll back to:
+// the stemcell, cloud properties, networks & env the VM was created with, the apply spec of its jobs,
+// and the deployment manifest (as YAML) the jobs were built from, so they can be rebuilt on a new VM.
+// It is kept when the VM is deleted, as the deploy replacing the VM may fail.
+type VMSnapshot struct {
+	StemcellCID     string                            `json:"stemcell_cid"`
+	CloudProperties map[string]interface{}            `json:"cloud_properties"`
+	Networks        map[string]map[string]interface{} `json:"networks"`
+	Env             map[string]interface{}            `json:"env"`
+	ApplySpec       json.RawMessage                   `json:"apply_spec,omitempty"`
+	Manifest        string                            `json:"manifest,omitempty"`
+}
+
+// CompiledPackageRecord is a package compiled on a VM, whose blob is in the blobstore of the agent of the VM.
+// Key & Value are the key & entry of the compiled package index.
+type CompiledPackageRecord struct {
+	VMCID string          `json:"vm_cid"`
+	Key   interface{}     `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
 type ReleaseRecord struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
@@ -36,4 +101,8 @@ type ReleaseRecord struct {
 type DeploymentConfigService interface {
 	Load() (DeploymentFile, error)
 	Save(DeploymentFile) error
+
+	// Update loads the config, changes it with the update func & saves it, without other updates in between.
+	// Nothing is saved when the update func fails.
+	Update(updateFunc func(*DeploymentFile) error) error
 }
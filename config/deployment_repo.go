@@ -5,8 +5,8 @@ import (
 )
 
 type DeploymentRepo interface {
-	UpdateCurrent(manifestSHA1 string) error
-	FindCurrent() (manifestSHA1 string, found bool, err error)
+	UpdateCurrent(fingerprints Fingerprints) error
+	FindCurrent() (fingerprints Fingerprints, found bool, err error)
 }
 
 type deploymentRepo struct {
@@ -19,27 +19,27 @@ func NewDeploymentRepo(configService DeploymentConfigService) deploymentRepo {
 	}
 }
 
-func (r deploymentRepo) FindCurrent() (string, bool, error) {
+func (r deploymentRepo) FindCurrent() (Fingerprints, bool, error) {
 	config, err := r.configService.Load()
 	if err != nil {
-		return "", false, bosherr.WrapError(err, "Loading existing config")
+		return Fingerprints{}, false, bosherr.WrapError(err, "Loading existing config")
 	}
 
-	currentManifestSHA1 := config.CurrentManifestSHA1
-	if currentManifestSHA1 != "" {
-		return currentManifestSHA1, true, nil
+	currentFingerprints := config.CurrentFingerprints
+	if len(currentFingerprints.Manifest) > 0 {
+		return currentFingerprints, true, nil
 	}
 
-	return "", false, nil
+	return Fingerprints{}, false, nil
 }
 
-func (r deploymentRepo) UpdateCurrent(manifestSHA1 string) error {
+func (r deploymentRepo) UpdateCurrent(fingerprints Fingerprints) error {
 	config, err := r.configService.Load()
 	if err != nil {
 		return bosherr.WrapError(err, "Loading existing config")
 	}
 
-	config.CurrentManifestSHA1 = manifestSHA1
+	config.CurrentFingerprints = fingerprints
 
 	err = r.configService.Save(config)
 	if err != nil {
@@ -17,6 +17,7 @@ var _ = Describe("DeploymentRepo", func() {
 		configService     DeploymentConfigService
 		fs                *fakesys.FakeFileSystem
 		fakeUUIDGenerator *fakeuuid.FakeGenerator
+		fingerprints      Fingerprints
 	)
 
 	BeforeEach(func() {
@@ -25,11 +26,25 @@ var _ = Describe("DeploymentRepo", func() {
 		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
 		configService = NewFileSystemDeploymentConfigService("/fake/path", fs, fakeUUIDGenerator, logger)
 		repo = NewDeploymentRepo(configService)
+
+		fingerprints = Fingerprints{
+			Manifest: map[string]string{"jobs": "fake-jobs-fingerprint"},
+			Releases: []ReleaseFingerprints{
+				{
+					Name:     "fake-release-name",
+					Version:  "fake-release-version",
+					Jobs:     map[string]string{"fake-job-name": "fake-job-fingerprint"},
+					Packages: map[string]string{"fake-package-name": "fake-package-fingerprint"},
+				},
+			},
+			StemcellSHA1: "fake-stemcell-sha1",
+			RenderedJobs: map[string]string{"fake-job-name/0": "fake-rendered-job-fingerprint"},
+		}
 	})
 
 	Describe("UpdateCurrent", func() {
-		It("updates deployment manifest sha1", func() {
-			err := repo.UpdateCurrent("fake-manifest-sha1")
+		It("updates the fingerprints of the deployment", func() {
+			err := repo.UpdateCurrent(fingerprints)
 			Expect(err).ToNot(HaveOccurred())
 
 			deploymentConfig, err := configService.Load()
@@ -37,28 +52,28 @@ var _ = Describe("DeploymentRepo", func() {
 
 			expectedConfig := DeploymentFile{
 				DirectorID:          "fake-uuid-0",
-				CurrentManifestSHA1: "fake-manifest-sha1",
+				CurrentFingerprints: fingerprints,
 			}
 			Expect(deploymentConfig).To(Equal(expectedConfig))
 		})
 	})
 
 	Describe("FindCurrent", func() {
-		Context("when a current manifest sha1 is set", func() {
+		Context("when current fingerprints are set", func() {
 			BeforeEach(func() {
-				err := repo.UpdateCurrent("fake-manifest-sha1")
+				err := repo.UpdateCurrent(fingerprints)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			It("returns current manifest sha1", func() {
+			It("returns the current fingerprints", func() {
 				record, found, err := repo.FindCurrent()
 				Expect(err).ToNot(HaveOccurred())
 				Expect(found).To(BeTrue())
-				Expect(record).To(Equal("fake-manifest-sha1"))
+				Expect(record).To(Equal(fingerprints))
 			})
 		})
 
-		Context("when a current manifest sha1 is not set", func() {
+		Context("when current fingerprints are not set", func() {
 			It("returns false", func() {
 				_, found, err := repo.FindCurrent()
 				Expect(err).ToNot(HaveOccurred())
@@ -8,6 +8,7 @@ import (
 type DiskRepo interface {
 	UpdateCurrent(diskID string) error
 	FindCurrent() (DiskRecord, bool, error)
+	FindAllCurrent() ([]DiskRecord, error)
 	ClearCurrent() error
 	Save(cid string, size int, cloudProperties map[string]interface{}) (DiskRecord, error)
 	Find(cid string) (DiskRecord, bool, error)
@@ -18,42 +19,51 @@ type DiskRepo interface {
 type diskRepo struct {
 	configService DeploymentConfigService
 	uuidGenerator boshuuid.Generator
+	instance      instanceScope
 }
 
+// NewDiskRepo returns the repo of the disks, whose current disk is the disk of the first instance
 func NewDiskRepo(configService DeploymentConfigService, uuidGenerator boshuuid.Generator) diskRepo {
 	return diskRepo{
 		configService: configService,
 		uuidGenerator: uuidGenerator,
+		instance:      firstInstanceScope(),
 	}
 }
 
-func (r diskRepo) Save(cid string, size int, cloudProperties map[string]interface{}) (DiskRecord, error) {
-	config, records, err := r.load()
-	if err != nil {
-		return DiskRecord{}, err
-	}
-
-	oldRecord, found := r.find(records, cid)
-	if found {
-		return DiskRecord{}, bosherr.Errorf("Failed to save disk cid '%s', existing record found '%s'", cid, oldRecord)
+// NewInstanceDiskRepo returns the repo of the disks, whose current disk is the disk of an instance after the first one
+func NewInstanceDiskRepo(configService DeploymentConfigService, uuidGenerator boshuuid.Generator, jobName string, index int) diskRepo {
+	return diskRepo{
+		configService: configService,
+		uuidGenerator: uuidGenerator,
+		instance:      otherInstanceScope(jobName, index),
 	}
+}
 
+func (r diskRepo) Save(cid string, size int, cloudProperties map[string]interface{}) (DiskRecord, error) {
 	newRecord := DiskRecord{
 		CID:             cid,
 		Size:            size,
 		CloudProperties: cloudProperties,
 	}
-	newRecord.ID, err = r.uuidGenerator.Generate()
-	if err != nil {
-		return newRecord, bosherr.WrapError(err, "Generating disk id")
-	}
 
-	records = append(records, newRecord)
-	config.Disks = records
+	err := r.configService.Update(func(config *DeploymentFile) error {
+		oldRecord, found := r.find(config.Disks, cid)
+		if found {
+			return bosherr.Errorf("Failed to save disk cid '%s', existing record found '%#v'", cid, oldRecord)
+		}
+
+		var err error
+		newRecord.ID, err = r.uuidGenerator.Generate()
+		if err != nil {
+			return bosherr.WrapError(err, "Generating disk id")
+		}
 
-	err = r.configService.Save(config)
+		config.Disks = append(config.Disks, newRecord)
+		return nil
+	})
 	if err != nil {
-		return newRecord, bosherr.WrapError(err, "Saving new config")
+		return DiskRecord{}, err
 	}
 	return newRecord, nil
 }
@@ -64,13 +74,13 @@ func (r diskRepo) FindCurrent() (DiskRecord, bool, error) {
 		return DiskRecord{}, false, bosherr.WrapError(err, "Loading existing config")
 	}
 
-	currentDiskID := config.CurrentDiskID
-	if currentDiskID == "" {
+	instanceRecord, found := r.instance.find(&config)
+	if !found || instanceRecord.DiskID == "" {
 		return DiskRecord{}, false, nil
 	}
 
 	for _, oldRecord := range config.Disks {
-		if oldRecord.ID == currentDiskID {
+		if oldRecord.ID == instanceRecord.DiskID {
 			return oldRecord, true, nil
 		}
 	}
@@ -78,29 +88,43 @@ func (r diskRepo) FindCurrent() (DiskRecord, bool, error) {
 	return DiskRecord{}, false, nil
 }
 
-func (r diskRepo) UpdateCurrent(diskID string) error {
+// FindAllCurrent returns the current disks of all the instances
+func (r diskRepo) FindAllCurrent() ([]DiskRecord, error) {
 	config, err := r.configService.Load()
 	if err != nil {
-		return bosherr.WrapError(err, "Loading existing config")
+		return []DiskRecord{}, bosherr.WrapError(err, "Loading existing config")
 	}
 
-	found := false
-	for _, oldRecord := range config.Disks {
-		if oldRecord.ID == diskID {
-			found = true
-		}
+	currentDiskIDs := map[string]struct{}{}
+	for _, instanceRecord := range config.Instances {
+		currentDiskIDs[instanceRecord.DiskID] = struct{}{}
 	}
-	if !found {
-		return bosherr.Errorf("Verifying disk record exists with id '%s'", diskID)
+
+	records := []DiskRecord{}
+	for _, record := range config.Disks {
+		if _, found := currentDiskIDs[record.ID]; found {
+			records = append(records, record)
+		}
 	}
 
-	config.CurrentDiskID = diskID
+	return records, nil
+}
 
-	err = r.configService.Save(config)
-	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
-	}
-	return nil
+func (r diskRepo) UpdateCurrent(diskID string) error {
+	return r.configService.Update(func(config *DeploymentFile) error {
+		found := false
+		for _, oldRecord := range config.Disks {
+			if oldRecord.ID == diskID {
+				found = true
+			}
+		}
+		if !found {
+			return bosherr.Errorf("Verifying disk record exists with id '%s'", diskID)
+		}
+
+		r.instance.findOrAdd(config).DiskID = diskID
+		return nil
+	})
 }
 
 func (r diskRepo) Find(cid string) (DiskRecord, bool, error) {
@@ -122,44 +146,40 @@ func (r diskRepo) All() ([]DiskRecord, error) {
 	return config.Disks, nil
 }
 
+// Delete removes the disk record, which stops being the current disk of any instance
 func (r diskRepo) Delete(diskRecord DiskRecord) error {
-	config, records, err := r.load()
-	if err != nil {
-		return err
-	}
-
-	newRecords := []DiskRecord{}
-	for _, record := range records {
-		if record.ID != diskRecord.ID {
-			newRecords = append(newRecords, record)
+	err := r.configService.Update(func(config *DeploymentFile) error {
+		newRecords := []DiskRecord{}
+		for _, record := range config.Disks {
+			if record.ID != diskRecord.ID {
+				newRecords = append(newRecords, record)
+			}
 		}
-	}
-
-	config.Disks = newRecords
-
-	if config.CurrentDiskID == diskRecord.ID {
-		config.CurrentDiskID = ""
-	}
+		config.Disks = newRecords
 
-	err = r.configService.Save(config)
+		for i := range config.Instances {
+			if config.Instances[i].DiskID == diskRecord.ID {
+				config.Instances[i].DiskID = ""
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
+		return bosherr.WrapError(err, "Updating config")
 	}
 
 	return nil
 }
 
 func (r diskRepo) ClearCurrent() error {
-	config, err := r.configService.Load()
-	if err != nil {
-		return bosherr.WrapError(err, "Loading existing config")
-	}
-
-	config.CurrentDiskID = ""
-
-	err = r.configService.Save(config)
+	err := r.configService.Update(func(config *DeploymentFile) error {
+		if instanceRecord, found := r.instance.find(config); found {
+			instanceRecord.DiskID = ""
+		}
+		return nil
+	})
 	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
+		return bosherr.WrapError(err, "Updating config")
 	}
 	return nil
 }
@@ -100,7 +100,7 @@ var _ = Describe("DiskRepo", func() {
 				deploymentConfig, err := configService.Load()
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(deploymentConfig.CurrentDiskID).To(Equal(recordID))
+				Expect(deploymentConfig.FirstInstance().DiskID).To(Equal(recordID))
 			})
 		})
 
@@ -194,6 +194,26 @@ var _ = Describe("DiskRepo", func() {
 		})
 	})
 
+	Describe("FindAllCurrent", func() {
+		It("returns the current disks of the first & of the other instances", func() {
+			firstDisk, err := repo.Save("fake-cid-1", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+			otherDisk, err := repo.Save("fake-cid-2", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = repo.Save("fake-cid-3", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateCurrent(firstDisk.ID)
+			Expect(err).ToNot(HaveOccurred())
+			err = NewInstanceDiskRepo(configService, fakeUUIDGenerator, "fake-job-name", 1).UpdateCurrent(otherDisk.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.FindAllCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]DiskRecord{firstDisk, otherDisk}))
+		})
+	})
+
 	Describe("Delete", func() {
 		var (
 			firstDisk  DiskRecord
@@ -242,6 +262,53 @@ var _ = Describe("DiskRepo", func() {
 				Expect(found).To(BeFalse())
 			})
 		})
+
+		Context("when the disk to be deleted is the current disk of another instance", func() {
+			It("clears the current disk of the instance", func() {
+				instanceRepo := NewInstanceDiskRepo(configService, fakeUUIDGenerator, "fake-job-name", 1)
+				err := instanceRepo.UpdateCurrent(secondDisk.ID)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = repo.Delete(secondDisk)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, found, err := instanceRepo.FindCurrent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("NewInstanceDiskRepo", func() {
+		It("keeps the current disk of the instance apart from the current disk of the first instance", func() {
+			firstDisk, err := repo.Save("fake-cid-1", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+			otherDisk, err := repo.Save("fake-cid-2", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			instanceRepo := NewInstanceDiskRepo(configService, fakeUUIDGenerator, "fake-job-name", 1)
+			err = instanceRepo.UpdateCurrent(otherDisk.ID)
+			Expect(err).ToNot(HaveOccurred())
+			err = repo.UpdateCurrent(firstDisk.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			record, found, err := instanceRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(record).To(Equal(otherDisk))
+
+			record, found, err = repo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(record).To(Equal(firstDisk))
+
+			deploymentConfig, err := configService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentConfig.Instances).To(Equal([]InstanceRecord{
+				{DiskID: firstDisk.ID},
+				{JobName: "fake-job-name", Index: 1, DiskID: otherDisk.ID},
+			}))
+		})
 	})
 
 	Describe("ClearCurrent", func() {
@@ -253,8 +320,7 @@ var _ = Describe("DiskRepo", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			expectedConfig := DeploymentFile{
-				DirectorID:    "fake-uuid-0",
-				CurrentDiskID: "",
+				DirectorID: "fake-uuid-0",
 			}
 			Expect(deploymentConfig).To(Equal(expectedConfig))
 
@@ -1,14 +1,18 @@
 package fakes
 
+import (
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+)
+
 type FakeDeploymentRepo struct {
-	UpdateCurrentManifestSHA1 string
+	UpdateCurrentFingerprints bmconfig.Fingerprints
 	UpdateCurrentErr          error
 
 	findCurrentOutput deploymentRepoFindCurrentOutput
 }
 
 type deploymentRepoFindCurrentOutput struct {
-	manifestSHA1 string
+	fingerprints bmconfig.Fingerprints
 	found        bool
 	err          error
 }
@@ -17,18 +21,18 @@ func NewFakeDeploymentRepo() *FakeDeploymentRepo {
 	return &FakeDeploymentRepo{}
 }
 
-func (r *FakeDeploymentRepo) UpdateCurrent(manifestSHA1 string) error {
-	r.UpdateCurrentManifestSHA1 = manifestSHA1
+func (r *FakeDeploymentRepo) UpdateCurrent(fingerprints bmconfig.Fingerprints) error {
+	r.UpdateCurrentFingerprints = fingerprints
 	return r.UpdateCurrentErr
 }
 
-func (r *FakeDeploymentRepo) FindCurrent() (manifestSHA1 string, found bool, err error) {
-	return r.findCurrentOutput.manifestSHA1, r.findCurrentOutput.found, r.findCurrentOutput.err
+func (r *FakeDeploymentRepo) FindCurrent() (fingerprints bmconfig.Fingerprints, found bool, err error) {
+	return r.findCurrentOutput.fingerprints, r.findCurrentOutput.found, r.findCurrentOutput.err
 }
 
-func (r *FakeDeploymentRepo) SetFindCurrentBehavior(manifestSHA1 string, found bool, err error) {
+func (r *FakeDeploymentRepo) SetFindCurrentBehavior(fingerprints bmconfig.Fingerprints, found bool, err error) {
 	r.findCurrentOutput = deploymentRepoFindCurrentOutput{
-		manifestSHA1: manifestSHA1,
+		fingerprints: fingerprints,
 		found:        found,
 		err:          err,
 	}
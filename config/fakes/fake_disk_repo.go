@@ -77,6 +77,14 @@ func (r *FakeDiskRepo) FindCurrent() (bmconfig.DiskRecord, bool, error) {
 	return r.findCurrentOutput.diskRecord, r.findCurrentOutput.found, r.findCurrentOutput.err
 }
 
+// FindAllCurrent returns the current disk, as when there is only one instance
+func (r *FakeDiskRepo) FindAllCurrent() ([]bmconfig.DiskRecord, error) {
+	if !r.findCurrentOutput.found {
+		return []bmconfig.DiskRecord{}, r.findCurrentOutput.err
+	}
+	return []bmconfig.DiskRecord{r.findCurrentOutput.diskRecord}, r.findCurrentOutput.err
+}
+
 func (r *FakeDiskRepo) ClearCurrent() error {
 	return nil
 }
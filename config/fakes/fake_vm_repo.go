@@ -1,12 +1,33 @@
 package fakes
 
+import (
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmindex "github.com/cloudfoundry/bosh-micro-cli/index"
+)
+
 type FakeVMRepo struct {
 	UpdateCurrentCID string
 	UpdateCurrentErr error
 
+	UpdateCurrentFingerprintFingerprint string
+	UpdateCurrentFingerprintErr         error
+
+	FindCurrentFingerprintFingerprint string
+	FindCurrentFingerprintErr         error
+
+	FindCurrentSnapshotSnapshot bmconfig.VMSnapshot
+	FindCurrentSnapshotFound    bool
+	FindCurrentSnapshotErr      error
+
+	UpdateCurrentSnapshotSnapshot bmconfig.VMSnapshot
+	UpdateCurrentSnapshotErr      error
+
 	ClearCurrentCalled bool
 	ClearCurrentErr    error
 
+	CompiledPackageIndexCID   string
+	CompiledPackageIndexIndex bmindex.Index
+
 	findCurrentOutput vmRepoFindCurrentOutput
 }
 
@@ -37,7 +58,30 @@ func (r *FakeVMRepo) UpdateCurrent(cid string) error {
 	return r.UpdateCurrentErr
 }
 
+func (r *FakeVMRepo) FindCurrentFingerprint() (string, error) {
+	return r.FindCurrentFingerprintFingerprint, r.FindCurrentFingerprintErr
+}
+
+func (r *FakeVMRepo) UpdateCurrentFingerprint(fingerprint string) error {
+	r.UpdateCurrentFingerprintFingerprint = fingerprint
+	return r.UpdateCurrentFingerprintErr
+}
+
+func (r *FakeVMRepo) FindCurrentSnapshot() (bmconfig.VMSnapshot, bool, error) {
+	return r.FindCurrentSnapshotSnapshot, r.FindCurrentSnapshotFound, r.FindCurrentSnapshotErr
+}
+
+func (r *FakeVMRepo) UpdateCurrentSnapshot(snapshot bmconfig.VMSnapshot) error {
+	r.UpdateCurrentSnapshotSnapshot = snapshot
+	return r.UpdateCurrentSnapshotErr
+}
+
 func (r *FakeVMRepo) ClearCurrent() error {
 	r.ClearCurrentCalled = true
 	return r.ClearCurrentErr
 }
+
+func (r *FakeVMRepo) CompiledPackageIndex(cid string) bmindex.Index {
+	r.CompiledPackageIndexCID = cid
+	return r.CompiledPackageIndexIndex
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"sync"
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
@@ -15,10 +16,53 @@ type fileSystemDeploymentConfigService struct {
 	uuidGenerator boshuuid.Generator
 	logger        boshlog.Logger
 	logTag        string
+
+	// lock serializes the loads & saves of the instances deployed at the same time
+	lock sync.Mutex
+}
+
+// legacyFirstInstance is the VM & disk of the first instance, which earlier versions saved outside of the instance records
+type legacyFirstInstance struct {
+	CurrentVMCID         *string    `json:"current_vm_cid"`
+	CurrentVMFingerprint string     `json:"current_vm_fingerprint"`
+	CurrentVMSnapshot    VMSnapshot `json:"current_vm_snapshot"`
+	CurrentDiskID        *string    `json:"current_disk_id"`
+}
+
+// ParseDeploymentFile unmarshals the JSON contents of a deployment config file.
+// The VM & disk of the first instance saved outside of the instance records by earlier versions become the first record.
+func ParseDeploymentFile(contents []byte) (DeploymentFile, error) {
+	deploymentFile := DeploymentFile{}
+	err := json.Unmarshal(contents, &deploymentFile)
+	if err != nil {
+		return DeploymentFile{}, err
+	}
+
+	legacy := legacyFirstInstance{}
+	err = json.Unmarshal(contents, &legacy)
+	if err != nil {
+		return DeploymentFile{}, err
+	}
+
+	if legacy.CurrentVMCID != nil || legacy.CurrentDiskID != nil {
+		firstInstance := InstanceRecord{
+			VMFingerprint: legacy.CurrentVMFingerprint,
+			VMSnapshot:    legacy.CurrentVMSnapshot,
+		}
+		if legacy.CurrentVMCID != nil {
+			firstInstance.VMCID = *legacy.CurrentVMCID
+		}
+		if legacy.CurrentDiskID != nil {
+			firstInstance.DiskID = *legacy.CurrentDiskID
+		}
+		deploymentFile.Instances = append([]InstanceRecord{firstInstance}, deploymentFile.Instances...)
+	}
+
+	return deploymentFile, nil
 }
 
 func NewFileSystemDeploymentConfigService(configPath string, fs boshsys.FileSystem, uuidGenerator boshuuid.Generator, logger boshlog.Logger) DeploymentConfigService {
-	return fileSystemDeploymentConfigService{
+	return &fileSystemDeploymentConfigService{
 		configPath:    configPath,
 		fs:            fs,
 		uuidGenerator: uuidGenerator,
@@ -27,7 +71,38 @@ func NewFileSystemDeploymentConfigService(configPath string, fs boshsys.FileSyst
 	}
 }
 
-func (s fileSystemDeploymentConfigService) Load() (DeploymentFile, error) {
+func (s *fileSystemDeploymentConfigService) Load() (DeploymentFile, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.load()
+}
+
+func (s *fileSystemDeploymentConfigService) Save(deploymentFile DeploymentFile) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.save(deploymentFile)
+}
+
+func (s *fileSystemDeploymentConfigService) Update(updateFunc func(*DeploymentFile) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	deploymentFile, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	err = updateFunc(&deploymentFile)
+	if err != nil {
+		return err
+	}
+
+	return s.save(deploymentFile)
+}
+
+func (s *fileSystemDeploymentConfigService) load() (DeploymentFile, error) {
 	s.logger.Debug(s.logTag, "Loading deployment config: %s", s.configPath)
 
 	deploymentFile := &DeploymentFile{}
@@ -39,7 +114,7 @@ func (s fileSystemDeploymentConfigService) Load() (DeploymentFile, error) {
 		}
 		s.logger.Debug(s.logTag, "Deployment File Contents %#s", deploymentFileContents)
 
-		err = json.Unmarshal(deploymentFileContents, deploymentFile)
+		*deploymentFile, err = ParseDeploymentFile(deploymentFileContents)
 		if err != nil {
 			return DeploymentFile{}, bosherr.WrapErrorf(err, "Unmarshalling deployment config file '%s'", s.configPath)
 		}
@@ -47,13 +122,13 @@ func (s fileSystemDeploymentConfigService) Load() (DeploymentFile, error) {
 
 	err := s.initDefaults(deploymentFile)
 	if err != nil {
-		return DeploymentFile{}, bosherr.WrapErrorf(err, "Initializing deployment config defaults", s.configPath)
+		return DeploymentFile{}, bosherr.WrapErrorf(err, "Initializing deployment config defaults '%s'", s.configPath)
 	}
 
 	return *deploymentFile, nil
 }
 
-func (s fileSystemDeploymentConfigService) Save(deploymentFile DeploymentFile) error {
+func (s *fileSystemDeploymentConfigService) save(deploymentFile DeploymentFile) error {
 	s.logger.Debug(s.logTag, "Saving Deployment Config %#v", deploymentFile)
 
 	jsonContent, err := json.MarshalIndent(deploymentFile, "", "    ")
@@ -69,7 +144,7 @@ func (s fileSystemDeploymentConfigService) Save(deploymentFile DeploymentFile) e
 	return nil
 }
 
-func (s fileSystemDeploymentConfigService) initDefaults(deploymentFile *DeploymentFile) error {
+func (s *fileSystemDeploymentConfigService) initDefaults(deploymentFile *DeploymentFile) error {
 	if deploymentFile.DirectorID == "" {
 		uuid, err := s.uuidGenerator.Generate()
 		if err != nil {
@@ -77,7 +152,7 @@ func (s fileSystemDeploymentConfigService) initDefaults(deploymentFile *Deployme
 		}
 		deploymentFile.DirectorID = uuid
 
-		err = s.Save(*deploymentFile)
+		err = s.save(*deploymentFile)
 		if err != nil {
 			return bosherr.WrapError(err, "Saving deployment config")
 		}
@@ -53,13 +53,15 @@ var _ = Describe("fileSystemConfigService", func() {
 					},
 				},
 			}
+			instances := []InstanceRecord{
+				{JobName: "fake-job-name", Index: 0, VMCID: "fake-vm-cid", DiskID: "fake-disk-id"},
+			}
 			deploymentFileContents, err := json.Marshal(map[string]interface{}{
-				"director_id":     "fake-director-id",
-				"deployment_id":   "fake-deployment-id",
-				"stemcells":       stemcells,
-				"current_vm_cid":  "fake-vm-cid",
-				"current_disk_id": "fake-disk-id",
-				"disks":           disks,
+				"director_id":   "fake-director-id",
+				"deployment_id": "fake-deployment-id",
+				"stemcells":     stemcells,
+				"instances":     instances,
+				"disks":         disks,
 			})
 			fakeFs.WriteFile(deploymentFilePath, deploymentFileContents)
 
@@ -67,11 +69,52 @@ var _ = Describe("fileSystemConfigService", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(deploymentFile.DirectorID).To(Equal("fake-director-id"))
 			Expect(deploymentFile.Stemcells).To(Equal(stemcells))
-			Expect(deploymentFile.CurrentVMCID).To(Equal("fake-vm-cid"))
-			Expect(deploymentFile.CurrentDiskID).To(Equal("fake-disk-id"))
+			Expect(deploymentFile.Instances).To(Equal(instances))
 			Expect(deploymentFile.Disks).To(Equal(disks))
 		})
 
+		Context("when the config has the current VM & disk of earlier versions", func() {
+			BeforeEach(func() {
+				fakeFs.WriteFileString(deploymentFilePath, `{
+					"director_id": "fake-director-id",
+					"current_vm_cid": "fake-vm-cid",
+					"current_vm_fingerprint": "fake-vm-fingerprint",
+					"current_vm_snapshot": {"stemcell_cid": "fake-stemcell-cid"},
+					"current_disk_id": "fake-disk-id",
+					"instances": [{"job_name": "fake-job-name", "index": 1, "ip": "10.0.0.11", "vm_cid": "fake-other-vm-cid"}]
+				}`)
+			})
+
+			It("makes them the record of the first instance, before the records of the other instances", func() {
+				deploymentFile, err := service.Load()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deploymentFile.Instances).To(Equal([]InstanceRecord{
+					{
+						VMCID:         "fake-vm-cid",
+						VMFingerprint: "fake-vm-fingerprint",
+						VMSnapshot:    VMSnapshot{StemcellCID: "fake-stemcell-cid"},
+						DiskID:        "fake-disk-id",
+					},
+					{JobName: "fake-job-name", Index: 1, IP: "10.0.0.11", VMCID: "fake-other-vm-cid"},
+				}))
+			})
+
+			It("saves them as the record of the first instance", func() {
+				deploymentFile, err := service.Load()
+				Expect(err).NotTo(HaveOccurred())
+				err = service.Save(deploymentFile)
+				Expect(err).NotTo(HaveOccurred())
+
+				deploymentFileContents, err := fakeFs.ReadFileString(deploymentFilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deploymentFileContents).ToNot(ContainSubstring("current_vm_cid"))
+
+				reloadedDeploymentFile, err := service.Load()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reloadedDeploymentFile).To(Equal(deploymentFile))
+			})
+		})
+
 		Context("when the config does not exist", func() {
 			It("returns a new DeploymentConfig with generated defaults", func() {
 				config, err := service.Load()
@@ -119,7 +162,9 @@ var _ = Describe("fileSystemConfigService", func() {
 						CID:     "fake-stemcell-cid",
 					},
 				},
-				CurrentVMCID: "fake-vm-cid",
+				Instances: []InstanceRecord{
+					{VMCID: "fake-vm-cid"},
+				},
 				Disks: []DiskRecord{
 					{
 						CID:  "fake-disk-cid",
@@ -144,7 +189,9 @@ var _ = Describe("fileSystemConfigService", func() {
 						CID:     "fake-stemcell-cid",
 					},
 				},
-				CurrentVMCID: "fake-vm-cid",
+				Instances: []InstanceRecord{
+					{VMCID: "fake-vm-cid"},
+				},
 				Disks: []DiskRecord{
 					{
 						CID:  "fake-disk-cid",
@@ -174,4 +221,31 @@ var _ = Describe("fileSystemConfigService", func() {
 			})
 		})
 	})
+
+	Describe("Update", func() {
+		It("saves the config changed by the update func", func() {
+			err := service.Update(func(deploymentFile *DeploymentFile) error {
+				deploymentFile.CurrentStemcellID = "fake-stemcell-id"
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentFile, err := service.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deploymentFile.CurrentStemcellID).To(Equal("fake-stemcell-id"))
+		})
+
+		It("saves nothing when the update func fails", func() {
+			err := service.Update(func(deploymentFile *DeploymentFile) error {
+				deploymentFile.CurrentStemcellID = "fake-stemcell-id"
+				return errors.New("fake-update-error")
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-update-error"))
+
+			deploymentFile, err := service.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deploymentFile.CurrentStemcellID).To(BeEmpty())
+		})
+	})
 })
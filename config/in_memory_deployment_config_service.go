@@ -0,0 +1,67 @@
+package config
+
+import (
+	"sync"
+)
+
+type inMemoryDeploymentConfigService struct {
+	deploymentFile DeploymentFile
+	lock           sync.Mutex
+}
+
+// NewInMemoryDeploymentConfigService returns a DeploymentConfigService that never touches the file system.
+// It starts from a copy of the provided config and keeps every change in memory.
+func NewInMemoryDeploymentConfigService(deploymentFile DeploymentFile) DeploymentConfigService {
+	return &inMemoryDeploymentConfigService{
+		deploymentFile: copyDeploymentFile(deploymentFile),
+	}
+}
+
+func (s *inMemoryDeploymentConfigService) Load() (DeploymentFile, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return copyDeploymentFile(s.deploymentFile), nil
+}
+
+func (s *inMemoryDeploymentConfigService) Save(deploymentFile DeploymentFile) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.deploymentFile = copyDeploymentFile(deploymentFile)
+	return nil
+}
+
+func (s *inMemoryDeploymentConfigService) Update(updateFunc func(*DeploymentFile) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	deploymentFile := copyDeploymentFile(s.deploymentFile)
+	err := updateFunc(&deploymentFile)
+	if err != nil {
+		return err
+	}
+
+	s.deploymentFile = copyDeploymentFile(deploymentFile)
+	return nil
+}
+
+func copyDeploymentFile(deploymentFile DeploymentFile) DeploymentFile {
+	result := deploymentFile
+	if deploymentFile.Disks != nil {
+		result.Disks = append([]DiskRecord{}, deploymentFile.Disks...)
+	}
+	if deploymentFile.Stemcells != nil {
+		result.Stemcells = append([]StemcellRecord{}, deploymentFile.Stemcells...)
+	}
+	if deploymentFile.Releases != nil {
+		result.Releases = append([]ReleaseRecord{}, deploymentFile.Releases...)
+	}
+	if deploymentFile.Instances != nil {
+		result.Instances = append([]InstanceRecord{}, deploymentFile.Instances...)
+	}
+	if deploymentFile.CompiledPackages != nil {
+		result.CompiledPackages = append([]CompiledPackageRecord{}, deploymentFile.CompiledPackages...)
+	}
+	return result
+}
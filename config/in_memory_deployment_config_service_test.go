@@ -0,0 +1,70 @@
+package config_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("inMemoryDeploymentConfigService", func() {
+	var (
+		service       DeploymentConfigService
+		initialConfig DeploymentFile
+	)
+
+	BeforeEach(func() {
+		initialConfig = DeploymentFile{
+			DirectorID: "fake-director-id",
+			Instances:  []InstanceRecord{{VMCID: "fake-vm-cid"}},
+			Disks: []DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid"},
+			},
+		}
+		service = NewInMemoryDeploymentConfigService(initialConfig)
+	})
+
+	Describe("Load", func() {
+		It("returns the initial config", func() {
+			config, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(initialConfig))
+		})
+	})
+
+	Describe("Save", func() {
+		It("keeps the saved config in memory", func() {
+			err := service.Save(DeploymentFile{DirectorID: "fake-other-director-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			config, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(DeploymentFile{DirectorID: "fake-other-director-id"}))
+		})
+
+		It("does not modify the initial config", func() {
+			config, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			config.Disks[0].CID = "fake-new-disk-cid"
+
+			err = service.Save(config)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(initialConfig.Disks[0].CID).To(Equal("fake-disk-cid"))
+		})
+	})
+
+	Describe("Update", func() {
+		It("keeps the config changed by the update func in memory", func() {
+			err := service.Update(func(config *DeploymentFile) error {
+				config.Instances[0].VMCID = "fake-new-vm-cid"
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			config, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Instances).To(Equal([]InstanceRecord{{VMCID: "fake-new-vm-cid"}}))
+			Expect(initialConfig.Instances).To(Equal([]InstanceRecord{{VMCID: "fake-vm-cid"}}))
+		})
+	})
+})
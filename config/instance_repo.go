@@ -0,0 +1,151 @@
+package config
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+)
+
+// InstanceRepo persists the records of the instances.
+// The first instance always has the first record, the other instances are saved & deleted by job name & index.
+type InstanceRepo interface {
+	SaveFirst(jobName string, index int) (InstanceRecord, error)
+	Save(jobName string, index int, ip string) (InstanceRecord, error)
+	All() ([]InstanceRecord, error)
+	Others() ([]InstanceRecord, error)
+	Delete(jobName string, index int) error
+}
+
+type instanceRepo struct {
+	configService DeploymentConfigService
+}
+
+func NewInstanceRepo(configService DeploymentConfigService) instanceRepo {
+	return instanceRepo{
+		configService: configService,
+	}
+}
+
+// SaveFirst names the record of the first instance, keeping its VM & disk
+func (r instanceRepo) SaveFirst(jobName string, index int) (InstanceRecord, error) {
+	return r.save(firstInstanceScope(), func(record *InstanceRecord) {
+		record.JobName = jobName
+		record.Index = index
+	})
+}
+
+// Save creates the record of an instance after the first one, or updates its IP, keeping its VM & disk
+func (r instanceRepo) Save(jobName string, index int, ip string) (InstanceRecord, error) {
+	return r.save(otherInstanceScope(jobName, index), func(record *InstanceRecord) {
+		record.IP = ip
+	})
+}
+
+// All returns the records of all the instances, the first instance first
+func (r instanceRepo) All() ([]InstanceRecord, error) {
+	config, err := r.configService.Load()
+	if err != nil {
+		return []InstanceRecord{}, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if config.Instances == nil {
+		return []InstanceRecord{}, nil
+	}
+	return config.Instances, nil
+}
+
+// Others returns the records of the instances after the first one
+func (r instanceRepo) Others() ([]InstanceRecord, error) {
+	records, err := r.All()
+	if err != nil {
+		return []InstanceRecord{}, err
+	}
+
+	if len(records) < 2 {
+		return []InstanceRecord{}, nil
+	}
+	return records[1:], nil
+}
+
+// Delete removes the record of an instance after the first one, which leaves its disk unused
+func (r instanceRepo) Delete(jobName string, index int) error {
+	err := r.configService.Update(func(config *DeploymentFile) error {
+		instance := otherInstanceScope(jobName, index)
+
+		newRecords := []InstanceRecord{}
+		for i, record := range config.Instances {
+			if !instance.matches(i, record) {
+				newRecords = append(newRecords, record)
+			}
+		}
+		config.Instances = newRecords
+
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Updating config")
+	}
+	return nil
+}
+
+func (r instanceRepo) save(instance instanceScope, updateFunc func(*InstanceRecord)) (InstanceRecord, error) {
+	var savedRecord InstanceRecord
+
+	err := r.configService.Update(func(config *DeploymentFile) error {
+		record := instance.findOrAdd(config)
+		updateFunc(record)
+		savedRecord = *record
+		return nil
+	})
+	if err != nil {
+		return InstanceRecord{}, bosherr.WrapError(err, "Updating config")
+	}
+	return savedRecord, nil
+}
+
+// instanceScope selects the record of an instance in the config:
+// the first record for the first instance, the record with the job name & index after it for the other instances
+type instanceScope struct {
+	first   bool
+	jobName string
+	index   int
+}
+
+func firstInstanceScope() instanceScope {
+	return instanceScope{first: true}
+}
+
+func otherInstanceScope(jobName string, index int) instanceScope {
+	return instanceScope{jobName: jobName, index: index}
+}
+
+func (s instanceScope) matches(position int, record InstanceRecord) bool {
+	if s.first {
+		return position == 0
+	}
+	return position > 0 && record.JobName == s.jobName && record.Index == s.index
+}
+
+func (s instanceScope) find(config *DeploymentFile) (*InstanceRecord, bool) {
+	for i, record := range config.Instances {
+		if s.matches(i, record) {
+			return &config.Instances[i], true
+		}
+	}
+	return nil, false
+}
+
+// findOrAdd returns the record of the instance, adding it (after an empty record of the first instance if needed) when missing
+func (s instanceScope) findOrAdd(config *DeploymentFile) *InstanceRecord {
+	if record, found := s.find(config); found {
+		return record
+	}
+
+	if len(config.Instances) == 0 {
+		config.Instances = append(config.Instances, InstanceRecord{})
+		if s.first {
+			return &config.Instances[0]
+		}
+	}
+
+	config.Instances = append(config.Instances, InstanceRecord{JobName: s.jobName, Index: s.index})
+	return &config.Instances[len(config.Instances)-1]
+}
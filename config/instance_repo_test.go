@@ -0,0 +1,149 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-agent/uuid/fakes"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/config"
+)
+
+var _ = Describe("InstanceRepo", func() {
+	var (
+		configService DeploymentConfigService
+		repo          InstanceRepo
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := fakesys.NewFakeFileSystem()
+		fakeUUIDGenerator := &fakeuuid.FakeGenerator{}
+		configService = NewFileSystemDeploymentConfigService("/fake/path", fs, fakeUUIDGenerator, logger)
+		repo = NewInstanceRepo(configService)
+	})
+
+	Describe("Save", func() {
+		It("saves the instance record after an empty record of the first instance", func() {
+			record, err := repo.Save("fake-job-name", 1, "10.0.0.11")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record).To(Equal(InstanceRecord{JobName: "fake-job-name", Index: 1, IP: "10.0.0.11"}))
+
+			deploymentConfig, err := configService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentConfig.Instances).To(Equal([]InstanceRecord{
+				{},
+				{JobName: "fake-job-name", Index: 1, IP: "10.0.0.11"},
+			}))
+		})
+
+		Context("when the instance is already recorded", func() {
+			BeforeEach(func() {
+				deploymentConfig, err := configService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				deploymentConfig.Instances = []InstanceRecord{
+					{VMCID: "fake-first-vm-cid"},
+					{JobName: "fake-job-name", Index: 1, IP: "10.0.0.11", VMCID: "fake-vm-cid", DiskID: "fake-disk-id"},
+				}
+				err = configService.Save(deploymentConfig)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("updates the IP, keeping the VM & disk", func() {
+				record, err := repo.Save("fake-job-name", 1, "10.0.0.21")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(record).To(Equal(InstanceRecord{JobName: "fake-job-name", Index: 1, IP: "10.0.0.21", VMCID: "fake-vm-cid", DiskID: "fake-disk-id"}))
+
+				records, err := repo.Others()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]InstanceRecord{record}))
+			})
+		})
+
+		Context("when the first instance has the same job name & index", func() {
+			BeforeEach(func() {
+				_, err := repo.SaveFirst("fake-job-name", 1)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("saves a separate record", func() {
+				_, err := repo.Save("fake-job-name", 1, "10.0.0.11")
+				Expect(err).ToNot(HaveOccurred())
+
+				records, err := repo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]InstanceRecord{
+					{JobName: "fake-job-name", Index: 1},
+					{JobName: "fake-job-name", Index: 1, IP: "10.0.0.11"},
+				}))
+			})
+		})
+	})
+
+	Describe("SaveFirst", func() {
+		It("names the record of the first instance, keeping its VM & disk", func() {
+			err := NewVMRepo(configService).UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			record, err := repo.SaveFirst("fake-job-name", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record).To(Equal(InstanceRecord{JobName: "fake-job-name", Index: 0, VMCID: "fake-vm-cid"}))
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]InstanceRecord{record}))
+		})
+	})
+
+	Describe("All", func() {
+		It("returns no records when none were saved", func() {
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]InstanceRecord{}))
+		})
+	})
+
+	Describe("Others", func() {
+		It("returns no records when only the first instance was saved", func() {
+			_, err := repo.SaveFirst("fake-job-name", 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.Others()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]InstanceRecord{}))
+		})
+	})
+
+	Describe("Delete", func() {
+		BeforeEach(func() {
+			_, err := repo.SaveFirst("fake-job-name", 0)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = repo.Save("fake-job-name", 1, "10.0.0.11")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = repo.Save("fake-job-name", 2, "10.0.0.12")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("removes the instance record", func() {
+			err := repo.Delete("fake-job-name", 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.Others()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]InstanceRecord{
+				{JobName: "fake-job-name", Index: 2, IP: "10.0.0.12"},
+			}))
+		})
+
+		It("keeps the record of the first instance", func() {
+			err := repo.Delete("fake-job-name", 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(HaveLen(3))
+		})
+	})
+})
@@ -0,0 +1,34 @@
+package config
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+)
+
+// MigrateLegacyDeploymentConfig moves the deployment.json state file next to the current deployment manifest
+// to the state path of the manifest, unless a state file already exists there.
+// It returns whether the state was migrated.
+func MigrateLegacyDeploymentConfig(userConfig UserConfig, fs boshsys.FileSystem, logger boshlog.Logger) (bool, error) {
+	if userConfig.StatePath != "" {
+		return false, nil
+	}
+
+	legacyPath := userConfig.LegacyDeploymentConfigPath()
+	if legacyPath == "" || !fs.FileExists(legacyPath) {
+		return false, nil
+	}
+
+	statePath := userConfig.DeploymentConfigPath()
+	if fs.FileExists(statePath) {
+		logger.Warn("legacyDeploymentConfig", "Not migrating '%s': deployment state '%s' already exists", legacyPath, statePath)
+		return false, nil
+	}
+
+	err := fs.Rename(legacyPath, statePath)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Migrating deployment state '%s' to '%s'", legacyPath, statePath)
+	}
+
+	return true, nil
+}
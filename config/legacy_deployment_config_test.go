@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/config"
+
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+)
+
+var _ = Describe("MigrateLegacyDeploymentConfig", func() {
+	var (
+		fs         *fakesys.FakeFileSystem
+		logger     boshlog.Logger
+		userConfig UserConfig
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		userConfig = UserConfig{DeploymentManifestPath: "/fake-dir/manifest.yml"}
+	})
+
+	It("moves deployment.json to the state path of the manifest", func() {
+		fs.MkdirAll("/fake-dir", os.ModePerm)
+		fs.WriteFileString("/fake-dir/deployment.json", "fake-legacy-state")
+
+		migrated, err := MigrateLegacyDeploymentConfig(userConfig, fs, logger)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrated).To(BeTrue())
+		Expect(fs.FileExists("/fake-dir/deployment.json")).To(BeFalse())
+		Expect(fs.ReadFileString("/fake-dir/manifest-state.json")).To(Equal("fake-legacy-state"))
+	})
+
+	It("does nothing without deployment.json", func() {
+		migrated, err := MigrateLegacyDeploymentConfig(userConfig, fs, logger)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrated).To(BeFalse())
+	})
+
+	It("keeps both files when the state path already exists", func() {
+		fs.WriteFileString("/fake-dir/deployment.json", "fake-legacy-state")
+		fs.WriteFileString("/fake-dir/manifest-state.json", "fake-state")
+
+		migrated, err := MigrateLegacyDeploymentConfig(userConfig, fs, logger)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrated).To(BeFalse())
+		Expect(fs.ReadFileString("/fake-dir/deployment.json")).To(Equal("fake-legacy-state"))
+		Expect(fs.ReadFileString("/fake-dir/manifest-state.json")).To(Equal("fake-state"))
+	})
+
+	It("does nothing when the state path is overridden", func() {
+		fs.WriteFileString("/fake-dir/deployment.json", "fake-legacy-state")
+		userConfig.StatePath = "/fake-state.json"
+
+		migrated, err := MigrateLegacyDeploymentConfig(userConfig, fs, logger)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrated).To(BeFalse())
+		Expect(fs.FileExists("/fake-dir/deployment.json")).To(BeTrue())
+	})
+
+	It("returns an error when moving fails", func() {
+		fs.WriteFileString("/fake-dir/deployment.json", "fake-legacy-state")
+		fs.RenameError = errors.New("fake-rename-error")
+
+		_, err := MigrateLegacyDeploymentConfig(userConfig, fs, logger)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-rename-error"))
+	})
+})
@@ -112,30 +112,22 @@ func (r stemcellRepo) All() ([]StemcellRecord, error) {
 }
 
 func (r stemcellRepo) Delete(stemcellRecord StemcellRecord) error {
-	config, records, err := r.load()
-	if err != nil {
-		return err
-	}
-
-	newRecords := []StemcellRecord{}
-	for _, record := range records {
-		if stemcellRecord.ID != record.ID {
-			newRecords = append(newRecords, record)
+	return r.updateConfig(func(config *DeploymentFile) error {
+		newRecords := []StemcellRecord{}
+		for _, record := range config.Stemcells {
+			if stemcellRecord.ID != record.ID {
+				newRecords = append(newRecords, record)
+			}
 		}
-	}
 
-	config.Stemcells = newRecords
+		config.Stemcells = newRecords
 
-	if config.CurrentStemcellID == stemcellRecord.ID {
-		config.CurrentStemcellID = ""
-	}
-
-	err = r.configService.Save(config)
-	if err != nil {
-		return bosherr.WrapError(err, "Saving config")
-	}
+		if config.CurrentStemcellID == stemcellRecord.ID {
+			config.CurrentStemcellID = ""
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (r stemcellRepo) UpdateCurrent(recordID string) error {
@@ -165,22 +157,7 @@ func (r stemcellRepo) ClearCurrent() error {
 }
 
 func (r stemcellRepo) updateConfig(updateFunc func(*DeploymentFile) error) error {
-	config, err := r.configService.Load()
-	if err != nil {
-		return bosherr.WrapError(err, "Loading existing config")
-	}
-
-	err = updateFunc(&config)
-	if err != nil {
-		return err
-	}
-
-	err = r.configService.Save(config)
-	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
-	}
-
-	return nil
+	return r.configService.Update(updateFunc)
 }
 
 func (r stemcellRepo) load() (DeploymentFile, []StemcellRecord, error) {
@@ -1,11 +1,92 @@
 package config
 
-import "path"
+import (
+	"path"
+	"strings"
+)
 
 type UserConfig struct {
-	DeploymentManifestPath string `json:"deployment"`
+	DeploymentManifestPath string            `json:"deployment"`
+	Deployments            []NamedDeployment `json:"deployments,omitempty"`
+
+	// StatePath overrides the deployment state path for one invocation (--state), it is not saved
+	StatePath string `json:"-"`
+}
+
+// NamedDeployment is a deployment manifest known by the name of its deployment
+type NamedDeployment struct {
+	Name         string `json:"name"`
+	ManifestPath string `json:"manifest"`
+}
+
+// DeploymentStatePath returns the path of the state file of a deployment manifest:
+// the manifest path with its extension replaced by "-state.json"
+func DeploymentStatePath(manifestPath string) string {
+	if manifestPath == "" {
+		return ""
+	}
+	return strings.TrimSuffix(manifestPath, path.Ext(manifestPath)) + "-state.json"
 }
 
 func (c UserConfig) DeploymentConfigPath() string {
+	if c.StatePath != "" {
+		return c.StatePath
+	}
+	return DeploymentStatePath(c.DeploymentManifestPath)
+}
+
+// VarsStorePath returns the path of the file of generated manifest variables, next to the deployment state file:
+// the state path with "-state.json" (or its extension) replaced by "-vars-store.yml"
+func (c UserConfig) VarsStorePath() string {
+	statePath := c.DeploymentConfigPath()
+	if statePath == "" {
+		return ""
+	}
+	if strings.HasSuffix(statePath, "-state.json") {
+		return strings.TrimSuffix(statePath, "-state.json") + "-vars-store.yml"
+	}
+	return strings.TrimSuffix(statePath, path.Ext(statePath)) + "-vars-store.yml"
+}
+
+// LegacyDeploymentConfigPath returns the path of the state file shared by all the manifests of a directory,
+// used before state files were derived per manifest
+func (c UserConfig) LegacyDeploymentConfigPath() string {
+	if c.DeploymentManifestPath == "" {
+		return ""
+	}
 	return path.Join(path.Dir(c.DeploymentManifestPath), "deployment.json")
 }
+
+// FindDeployments returns the deployments with the given name
+func (c UserConfig) FindDeployments(name string) []NamedDeployment {
+	found := []NamedDeployment{}
+	for _, deployment := range c.Deployments {
+		if deployment.Name == name {
+			found = append(found, deployment)
+		}
+	}
+	return found
+}
+
+// SetDeployment records the deployment of the manifest under the name, replacing any previous name of the manifest,
+// and makes it the current deployment
+func (c *UserConfig) SetDeployment(name, manifestPath string) {
+	deployments := []NamedDeployment{}
+	replaced := false
+	for _, deployment := range c.Deployments {
+		if deployment.ManifestPath == manifestPath {
+			if !replaced {
+				deployments = append(deployments, NamedDeployment{Name: name, ManifestPath: manifestPath})
+				replaced = true
+			}
+			continue
+		}
+		deployments = append(deployments, deployment)
+	}
+	if !replaced {
+		deployments = append(deployments, NamedDeployment{Name: name, ManifestPath: manifestPath})
+	}
+
+	c.Deployments = deployments
+	c.DeploymentManifestPath = manifestPath
+}
@@ -0,0 +1,106 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/config"
+)
+
+var _ = Describe("UserConfig", func() {
+	Describe("DeploymentConfigPath", func() {
+		It("is the manifest path with its extension replaced by -state.json", func() {
+			userConfig := UserConfig{DeploymentManifestPath: "/fake-dir/manifest.yml"}
+			Expect(userConfig.DeploymentConfigPath()).To(Equal("/fake-dir/manifest-state.json"))
+		})
+
+		It("is different for manifests in the same directory", func() {
+			userConfig1 := UserConfig{DeploymentManifestPath: "/fake-dir/manifest-1.yml"}
+			userConfig2 := UserConfig{DeploymentManifestPath: "/fake-dir/manifest-2.yml"}
+			Expect(userConfig1.DeploymentConfigPath()).ToNot(Equal(userConfig2.DeploymentConfigPath()))
+		})
+
+		It("is the state path when set", func() {
+			userConfig := UserConfig{
+				DeploymentManifestPath: "/fake-dir/manifest.yml",
+				StatePath:              "/fake-state-dir/state.json",
+			}
+			Expect(userConfig.DeploymentConfigPath()).To(Equal("/fake-state-dir/state.json"))
+		})
+
+		It("is empty without deployment manifest", func() {
+			Expect(UserConfig{}.DeploymentConfigPath()).To(Equal(""))
+		})
+	})
+
+	Describe("VarsStorePath", func() {
+		It("is next to the deployment state file", func() {
+			userConfig := UserConfig{DeploymentManifestPath: "/fake-dir/manifest.yml"}
+			Expect(userConfig.VarsStorePath()).To(Equal("/fake-dir/manifest-vars-store.yml"))
+		})
+
+		It("is next to the state path when set", func() {
+			userConfig := UserConfig{
+				DeploymentManifestPath: "/fake-dir/manifest.yml",
+				StatePath:              "/fake-state-dir/state.json",
+			}
+			Expect(userConfig.VarsStorePath()).To(Equal("/fake-state-dir/state-vars-store.yml"))
+		})
+
+		It("is empty without deployment manifest", func() {
+			Expect(UserConfig{}.VarsStorePath()).To(Equal(""))
+		})
+	})
+
+	Describe("LegacyDeploymentConfigPath", func() {
+		It("is deployment.json next to the deployment manifest", func() {
+			userConfig := UserConfig{DeploymentManifestPath: "/fake-dir/manifest.yml"}
+			Expect(userConfig.LegacyDeploymentConfigPath()).To(Equal("/fake-dir/deployment.json"))
+		})
+	})
+
+	Describe("SetDeployment", func() {
+		It("adds the named deployment and makes it current", func() {
+			userConfig := UserConfig{}
+			userConfig.SetDeployment("fake-name", "/fake-dir/manifest.yml")
+
+			Expect(userConfig.DeploymentManifestPath).To(Equal("/fake-dir/manifest.yml"))
+			Expect(userConfig.Deployments).To(Equal([]NamedDeployment{
+				{Name: "fake-name", ManifestPath: "/fake-dir/manifest.yml"},
+			}))
+		})
+
+		It("renames the deployment of a known manifest", func() {
+			userConfig := UserConfig{
+				Deployments: []NamedDeployment{
+					{Name: "fake-old-name", ManifestPath: "/fake-dir/manifest.yml"},
+					{Name: "fake-other-name", ManifestPath: "/fake-dir/other-manifest.yml"},
+				},
+			}
+			userConfig.SetDeployment("fake-new-name", "/fake-dir/manifest.yml")
+
+			Expect(userConfig.Deployments).To(Equal([]NamedDeployment{
+				{Name: "fake-new-name", ManifestPath: "/fake-dir/manifest.yml"},
+				{Name: "fake-other-name", ManifestPath: "/fake-dir/other-manifest.yml"},
+			}))
+		})
+	})
+
+	Describe("FindDeployments", func() {
+		It("returns the deployments with the name", func() {
+			userConfig := UserConfig{
+				Deployments: []NamedDeployment{
+					{Name: "fake-name", ManifestPath: "/fake-dir-1/manifest.yml"},
+					{Name: "fake-other-name", ManifestPath: "/fake-dir/other-manifest.yml"},
+					{Name: "fake-name", ManifestPath: "/fake-dir-2/manifest.yml"},
+				},
+			}
+
+			Expect(userConfig.FindDeployments("fake-name")).To(Equal([]NamedDeployment{
+				{Name: "fake-name", ManifestPath: "/fake-dir-1/manifest.yml"},
+				{Name: "fake-name", ManifestPath: "/fake-dir-2/manifest.yml"},
+			}))
+			Expect(userConfig.FindDeployments("fake-unknown-name")).To(BeEmpty())
+		})
+	})
+})
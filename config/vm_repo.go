@@ -2,64 +2,157 @@ package config
 
 import (
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+
+	bmindex "github.com/cloudfoundry/bosh-micro-cli/index"
 )
 
+// VMRepo persists the VM of an instance
 type VMRepo interface {
 	FindCurrent() (cid string, found bool, err error)
 	UpdateCurrent(cid string) error
+	FindCurrentFingerprint() (fingerprint string, err error)
+	UpdateCurrentFingerprint(fingerprint string) error
+	FindCurrentSnapshot() (snapshot VMSnapshot, found bool, err error)
+	UpdateCurrentSnapshot(snapshot VMSnapshot) error
 	ClearCurrent() error
+	CompiledPackageIndex(cid string) bmindex.Index
 }
 
 type vMRepo struct {
 	configService DeploymentConfigService
+	instance      instanceScope
 }
 
+// NewVMRepo returns the repo of the VM of the first instance
 func NewVMRepo(configService DeploymentConfigService) vMRepo {
 	return vMRepo{
 		configService: configService,
+		instance:      firstInstanceScope(),
+	}
+}
+
+// NewInstanceVMRepo returns the repo of the VM of an instance after the first one
+func NewInstanceVMRepo(configService DeploymentConfigService, jobName string, index int) vMRepo {
+	return vMRepo{
+		configService: configService,
+		instance:      otherInstanceScope(jobName, index),
 	}
 }
 
 func (r vMRepo) FindCurrent() (string, bool, error) {
-	config, err := r.configService.Load()
+	record, err := r.findInstance()
 	if err != nil {
-		return "", false, bosherr.WrapError(err, "Loading existing config")
+		return "", false, err
 	}
 
-	currentVMCID := config.CurrentVMCID
-	if currentVMCID != "" {
-		return currentVMCID, true, nil
+	if record.VMCID != "" {
+		return record.VMCID, true, nil
 	}
 
 	return "", false, nil
 }
 
+// UpdateCurrent records the VM, forgetting the packages compiled on the VM it replaces
 func (r vMRepo) UpdateCurrent(cid string) error {
-	config, err := r.configService.Load()
+	err := r.configService.Update(func(config *DeploymentFile) error {
+		record := r.instance.findOrAdd(config)
+		if record.VMCID != "" && record.VMCID != cid {
+			forgetCompiledPackages(config, record.VMCID)
+		}
+		record.VMCID = cid
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Updating config")
+	}
+	return nil
+}
+
+// FindCurrentFingerprint returns the fingerprint of what the current vm was created with,
+// empty when the vm was created before fingerprints were recorded
+func (r vMRepo) FindCurrentFingerprint() (string, error) {
+	record, err := r.findInstance()
+	if err != nil {
+		return "", err
+	}
+
+	return record.VMFingerprint, nil
+}
+
+func (r vMRepo) UpdateCurrentFingerprint(fingerprint string) error {
+	return r.updateInstance(func(record *InstanceRecord) {
+		record.VMFingerprint = fingerprint
+	})
+}
+
+// FindCurrentSnapshot returns what the current vm last ran successfully with, not found before the first successful deploy
+func (r vMRepo) FindCurrentSnapshot() (VMSnapshot, bool, error) {
+	record, err := r.findInstance()
 	if err != nil {
-		return bosherr.WrapError(err, "Loading existing config")
+		return VMSnapshot{}, false, err
 	}
 
-	config.CurrentVMCID = cid
+	if record.VMSnapshot.StemcellCID == "" {
+		return VMSnapshot{}, false, nil
+	}
+
+	return record.VMSnapshot, true, nil
+}
+
+func (r vMRepo) UpdateCurrentSnapshot(snapshot VMSnapshot) error {
+	return r.updateInstance(func(record *InstanceRecord) {
+		record.VMSnapshot = snapshot
+	})
+}
 
-	err = r.configService.Save(config)
+// ClearCurrent forgets the VM, and the packages compiled on it
+func (r vMRepo) ClearCurrent() error {
+	err := r.configService.Update(func(config *DeploymentFile) error {
+		if record, found := r.instance.find(config); found {
+			if record.VMCID != "" {
+				forgetCompiledPackages(config, record.VMCID)
+			}
+			record.VMCID = ""
+			record.VMFingerprint = ""
+		}
+		return nil
+	})
 	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
+		return bosherr.WrapError(err, "Updating config")
 	}
 	return nil
 }
 
-func (r vMRepo) ClearCurrent() error {
+// findInstance returns the record of the instance, empty when the instance has no record yet
+// CompiledPackageIndex returns the index of the packages compiled on the VM, kept until the VM is replaced or cleared
+func (r vMRepo) CompiledPackageIndex(cid string) bmindex.Index {
+	return compiledPackageIndex{
+		configService: r.configService,
+		vmCID:         cid,
+	}
+}
+
+func (r vMRepo) findInstance() (InstanceRecord, error) {
 	config, err := r.configService.Load()
 	if err != nil {
-		return bosherr.WrapError(err, "Loading existing config")
+		return InstanceRecord{}, bosherr.WrapError(err, "Loading existing config")
 	}
 
-	config.CurrentVMCID = ""
+	record, found := r.instance.find(&config)
+	if !found {
+		return InstanceRecord{}, nil
+	}
+	return *record, nil
+}
 
-	err = r.configService.Save(config)
+// updateInstance changes the record of the instance, adding it when missing
+func (r vMRepo) updateInstance(updateFunc func(*InstanceRecord)) error {
+	err := r.configService.Update(func(config *DeploymentFile) error {
+		updateFunc(r.instance.findOrAdd(config))
+		return nil
+	})
 	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
+		return bosherr.WrapError(err, "Updating config")
 	}
 	return nil
 }
@@ -9,6 +9,8 @@ import (
 	fakeuuid "github.com/cloudfoundry/bosh-agent/uuid/fakes"
 
 	. "github.com/cloudfoundry/bosh-micro-cli/config"
+
+	bmindex "github.com/cloudfoundry/bosh-micro-cli/index"
 )
 
 var _ = Describe("VMRepo", func() {
@@ -60,24 +62,67 @@ var _ = Describe("VMRepo", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			expectedConfig := DeploymentFile{
-				DirectorID:   "fake-uuid-0",
-				CurrentVMCID: "fake-vm-cid",
+				DirectorID: "fake-uuid-0",
+				Instances:  []InstanceRecord{{VMCID: "fake-vm-cid"}},
 			}
 			Expect(deploymentConfig).To(Equal(expectedConfig))
 		})
 	})
 
+	Describe("UpdateCurrentFingerprint", func() {
+		It("updates vm fingerprint", func() {
+			err := repo.UpdateCurrentFingerprint("fake-vm-fingerprint")
+			Expect(err).ToNot(HaveOccurred())
+
+			fingerprint, err := repo.FindCurrentFingerprint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fingerprint).To(Equal("fake-vm-fingerprint"))
+		})
+	})
+
+	Describe("UpdateCurrentSnapshot", func() {
+		It("updates vm snapshot", func() {
+			snapshot := VMSnapshot{
+				StemcellCID:     "fake-stemcell-cid",
+				CloudProperties: map[string]interface{}{"fake-cloud-property-key": "fake-cloud-property-value"},
+				ApplySpec:       []byte(`{"deployment":"fake-deployment-name"}`),
+			}
+			err := repo.UpdateCurrentSnapshot(snapshot)
+			Expect(err).ToNot(HaveOccurred())
+
+			foundSnapshot, found, err := repo.FindCurrentSnapshot()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(foundSnapshot.StemcellCID).To(Equal("fake-stemcell-cid"))
+			Expect(foundSnapshot.CloudProperties).To(Equal(snapshot.CloudProperties))
+			Expect(foundSnapshot.ApplySpec).To(MatchJSON(`{"deployment":"fake-deployment-name"}`))
+		})
+	})
+
+	Describe("FindCurrentSnapshot", func() {
+		Context("when no snapshot is set", func() {
+			It("returns false", func() {
+				_, found, err := repo.FindCurrentSnapshot()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+
 	Describe("ClearCurrent", func() {
 		It("updates vm cid", func() {
-			err := repo.ClearCurrent()
+			err := repo.UpdateCurrentFingerprint("fake-vm-fingerprint")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.ClearCurrent()
 			Expect(err).ToNot(HaveOccurred())
 
 			deploymentConfig, err := configService.Load()
 			Expect(err).ToNot(HaveOccurred())
 
 			expectedConfig := DeploymentFile{
-				DirectorID:   "fake-uuid-0",
-				CurrentVMCID: "",
+				DirectorID: "fake-uuid-0",
+				Instances:  []InstanceRecord{{}},
 			}
 			Expect(deploymentConfig).To(Equal(expectedConfig))
 
@@ -85,5 +130,142 @@ var _ = Describe("VMRepo", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(found).To(BeFalse())
 		})
+
+		It("keeps the vm snapshot", func() {
+			err := repo.UpdateCurrentSnapshot(VMSnapshot{StemcellCID: "fake-stemcell-cid"})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.ClearCurrent()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := repo.FindCurrentSnapshot()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+		})
+	})
+
+	Describe("CompiledPackageIndex", func() {
+		type fakeKey struct {
+			Name        string
+			Fingerprint string
+		}
+
+		type fakeEntry struct {
+			BlobID string
+		}
+
+		var key fakeKey
+
+		BeforeEach(func() {
+			err := repo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			key = fakeKey{Name: "fake-package-name", Fingerprint: "fake-package-fingerprint"}
+			err = repo.CompiledPackageIndex("fake-vm-cid").Save(key, fakeEntry{BlobID: "fake-blob-id"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("keeps the compiled packages of the VM in the deployment config", func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			reloadedConfigService := NewFileSystemDeploymentConfigService("/fake/path", fs, fakeUUIDGenerator, logger)
+
+			var entry fakeEntry
+			err := NewVMRepo(reloadedConfigService).CompiledPackageIndex("fake-vm-cid").Find(key, &entry)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entry).To(Equal(fakeEntry{BlobID: "fake-blob-id"}))
+		})
+
+		It("replaces the entry of a key that is saved again", func() {
+			err := repo.CompiledPackageIndex("fake-vm-cid").Save(key, fakeEntry{BlobID: "fake-other-blob-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			var entry fakeEntry
+			err = repo.CompiledPackageIndex("fake-vm-cid").Find(key, &entry)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entry).To(Equal(fakeEntry{BlobID: "fake-other-blob-id"}))
+
+			deploymentConfig, err := configService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentConfig.CompiledPackages).To(HaveLen(1))
+		})
+
+		It("does not find the packages compiled on an other VM", func() {
+			var entry fakeEntry
+			err := repo.CompiledPackageIndex("fake-other-vm-cid").Find(key, &entry)
+			Expect(err).To(Equal(bmindex.ErrNotFound))
+		})
+
+		It("forgets the compiled packages when the VM is replaced", func() {
+			err := repo.UpdateCurrent("fake-new-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			var entry fakeEntry
+			err = repo.CompiledPackageIndex("fake-vm-cid").Find(key, &entry)
+			Expect(err).To(Equal(bmindex.ErrNotFound))
+		})
+
+		It("forgets the compiled packages when the VM is cleared", func() {
+			err := repo.ClearCurrent()
+			Expect(err).ToNot(HaveOccurred())
+
+			var entry fakeEntry
+			err = repo.CompiledPackageIndex("fake-vm-cid").Find(key, &entry)
+			Expect(err).To(Equal(bmindex.ErrNotFound))
+		})
+	})
+
+	Describe("NewInstanceVMRepo", func() {
+		var instanceRepo VMRepo
+
+		BeforeEach(func() {
+			instanceRepo = NewInstanceVMRepo(configService, "fake-job-name", 1)
+		})
+
+		It("keeps the vm of the instance in its record, after the record of the first instance", func() {
+			err := instanceRepo.UpdateCurrent("fake-other-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+			err = repo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			cid, found, err := instanceRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(cid).To(Equal("fake-other-vm-cid"))
+
+			deploymentConfig, err := configService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentConfig.Instances).To(Equal([]InstanceRecord{
+				{VMCID: "fake-vm-cid"},
+				{JobName: "fake-job-name", Index: 1, VMCID: "fake-other-vm-cid"},
+			}))
+		})
+
+		It("does not find the vm of the first instance", func() {
+			err := repo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := instanceRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("clears only the vm of the instance", func() {
+			err := repo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+			err = instanceRepo.UpdateCurrent("fake-other-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = instanceRepo.ClearCurrent()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := instanceRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			cid, found, err := repo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(cid).To(Equal("fake-vm-cid"))
+		})
 	})
 })
@@ -14,8 +14,25 @@ type AgentClient interface {
 	UnmountDisk(string) error
 	ListDisk() ([]string, error)
 	MigrateDisk() error
+	FetchLogs(logType string, filters []string) (blobID string, err error)
+	RunErrand() (ErrandResult, error)
+	CompilePackage(packageSource BlobRef, compiledPackageDependencies []BlobRef) (compiledPackageRef BlobRef, err error)
 }
 
 type AgentState struct {
 	JobState string
 }
+
+type ErrandResult struct {
+	ExitStatus int
+	Stdout     string
+	Stderr     string
+}
+
+// BlobRef is a reference to a package blob in the blobstore of the agent
+type BlobRef struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	SHA1        string `json:"sha1"`
+	BlobstoreID string `json:"blobstore_id"`
+}
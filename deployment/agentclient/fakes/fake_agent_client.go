@@ -33,6 +33,32 @@ type FakeAgentClient struct {
 
 	MigrateDiskCalledTimes int
 	migrateDiskErr         error
+
+	FetchLogsInputs []FetchLogsInput
+	fetchLogsBlobID string
+	fetchLogsErr    error
+
+	RunErrandCalledTimes int
+	runErrandResult      bmagentclient.ErrandResult
+	runErrandErr         error
+
+	CompilePackageInputs  []CompilePackageInput
+	compilePackageOutputs map[string]compilePackageOutput
+}
+
+type CompilePackageInput struct {
+	PackageSource               bmagentclient.BlobRef
+	CompiledPackageDependencies []bmagentclient.BlobRef
+}
+
+type compilePackageOutput struct {
+	compiledPackageRef bmagentclient.BlobRef
+	err                error
+}
+
+type FetchLogsInput struct {
+	LogType string
+	Filters []string
 }
 
 type pingResponse struct {
@@ -47,7 +73,8 @@ type getStateOutput struct {
 
 func NewFakeAgentClient() *FakeAgentClient {
 	return &FakeAgentClient{
-		getStateOutputs: []getStateOutput{},
+		getStateOutputs:       []getStateOutput{},
+		compilePackageOutputs: map[string]compilePackageOutput{},
 	}
 }
 
@@ -109,6 +136,28 @@ func (c *FakeAgentClient) MigrateDisk() error {
 	return c.migrateDiskErr
 }
 
+func (c *FakeAgentClient) FetchLogs(logType string, filters []string) (string, error) {
+	c.FetchLogsInputs = append(c.FetchLogsInputs, FetchLogsInput{
+		LogType: logType,
+		Filters: filters,
+	})
+	return c.fetchLogsBlobID, c.fetchLogsErr
+}
+
+func (c *FakeAgentClient) RunErrand() (bmagentclient.ErrandResult, error) {
+	c.RunErrandCalledTimes++
+	return c.runErrandResult, c.runErrandErr
+}
+
+func (c *FakeAgentClient) CompilePackage(packageSource bmagentclient.BlobRef, compiledPackageDependencies []bmagentclient.BlobRef) (bmagentclient.BlobRef, error) {
+	c.CompilePackageInputs = append(c.CompilePackageInputs, CompilePackageInput{
+		PackageSource:               packageSource,
+		CompiledPackageDependencies: compiledPackageDependencies,
+	})
+	output := c.compilePackageOutputs[packageSource.Name]
+	return output.compiledPackageRef, output.err
+}
+
 func (c *FakeAgentClient) SetPingBehavior(response string, err error) {
 	c.PingResponses = append(c.PingResponses, pingResponse{
 		response: response,
@@ -143,6 +192,23 @@ func (c *FakeAgentClient) SetMigrateDiskBehavior(err error) {
 	c.migrateDiskErr = err
 }
 
+func (c *FakeAgentClient) SetFetchLogsBehavior(blobID string, err error) {
+	c.fetchLogsBlobID = blobID
+	c.fetchLogsErr = err
+}
+
+func (c *FakeAgentClient) SetRunErrandBehavior(result bmagentclient.ErrandResult, err error) {
+	c.runErrandResult = result
+	c.runErrandErr = err
+}
+
+func (c *FakeAgentClient) SetCompilePackageBehavior(packageName string, compiledPackageRef bmagentclient.BlobRef, err error) {
+	c.compilePackageOutputs[packageName] = compilePackageOutput{
+		compiledPackageRef: compiledPackageRef,
+		err:                err,
+	}
+}
+
 func (c *FakeAgentClient) SetListDiskBehavior(disks []string, err error) {
 	c.listDiskDisks = disks
 	c.listDiskErr = err
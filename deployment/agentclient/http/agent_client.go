@@ -105,18 +105,83 @@ func (c *agentClient) MigrateDisk() error {
 	return c.sendAsyncTaskMessage("migrate_disk", []interface{}{})
 }
 
+func (c *agentClient) FetchLogs(logType string, filters []string) (string, error) {
+	response, err := c.sendAsyncTaskMessageForResult("fetch_logs", []interface{}{logType, filters})
+	if err != nil {
+		return "", err
+	}
+
+	blobID, err := response.BlobstoreID()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting logs blobstore id")
+	}
+
+	return blobID, nil
+}
+
+func (c *agentClient) RunErrand() (bmac.ErrandResult, error) {
+	response, err := c.sendAsyncTaskMessageForResult("run_errand", []interface{}{})
+	if err != nil {
+		return bmac.ErrandResult{}, err
+	}
+
+	errandResult, err := response.ErrandResult()
+	if err != nil {
+		return bmac.ErrandResult{}, bosherr.WrapError(err, "Getting errand result")
+	}
+
+	return errandResult, nil
+}
+
+// CompilePackage compiles the package source blob on the VM, with its compiled dependencies installed,
+// and returns the compiled package blob uploaded by the agent
+func (c *agentClient) CompilePackage(packageSource bmac.BlobRef, compiledPackageDependencies []bmac.BlobRef) (bmac.BlobRef, error) {
+	dependencies := make(map[string]bmac.BlobRef, len(compiledPackageDependencies))
+	for _, dependency := range compiledPackageDependencies {
+		dependencies[dependency.Name] = dependency
+	}
+
+	arguments := []interface{}{
+		packageSource.BlobstoreID,
+		packageSource.SHA1,
+		packageSource.Name,
+		packageSource.Version,
+		dependencies,
+	}
+	response, err := c.sendAsyncTaskMessageForResult("compile_package", arguments)
+	if err != nil {
+		return bmac.BlobRef{}, err
+	}
+
+	compiledPackageRef, err := response.CompiledPackageRef()
+	if err != nil {
+		return bmac.BlobRef{}, bosherr.WrapErrorf(err, "Getting compiled package '%s'", packageSource.Name)
+	}
+	compiledPackageRef.Name = packageSource.Name
+	compiledPackageRef.Version = packageSource.Version
+
+	return compiledPackageRef, nil
+}
+
 func (c *agentClient) sendAsyncTaskMessage(method string, arguments []interface{}) error {
+	_, err := c.sendAsyncTaskMessageForResult(method, arguments)
+	return err
+}
+
+// sendAsyncTaskMessageForResult waits for the agent task to finish and returns its final get_task response
+func (c *agentClient) sendAsyncTaskMessageForResult(method string, arguments []interface{}) (TaskResponse, error) {
 	var response TaskResponse
 	err := c.agentRequest.Send(method, arguments, &response)
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Sending '%s' to the agent", method)
+		return TaskResponse{}, bosherr.WrapErrorf(err, "Sending '%s' to the agent", method)
 	}
 
 	agentTaskID, err := response.TaskID()
 	if err != nil {
-		return bosherr.WrapError(err, "Getting agent task id")
+		return TaskResponse{}, bosherr.WrapError(err, "Getting agent task id")
 	}
 
+	var finishedResponse TaskResponse
 	getTaskRetryable := boshretry.NewRetryable(func() (bool, error) {
 		var response TaskResponse
 		err = c.agentRequest.Send("get_task", []interface{}{agentTaskID}, &response)
@@ -130,6 +195,7 @@ func (c *agentClient) sendAsyncTaskMessage(method string, arguments []interface{
 		}
 
 		if taskState != "running" {
+			finishedResponse = response
 			return true, nil
 		}
 
@@ -137,5 +203,10 @@ func (c *agentClient) sendAsyncTaskMessage(method string, arguments []interface{
 	})
 
 	getTaskRetryStrategy := boshretry.NewUnlimitedRetryStrategy(c.getTaskDelay, getTaskRetryable, c.logger)
-	return getTaskRetryStrategy.Try()
+	err = getTaskRetryStrategy.Try()
+	if err != nil {
+		return TaskResponse{}, err
+	}
+
+	return finishedResponse, nil
 }
@@ -1,8 +1,11 @@
 package http
 
 import (
+	"net"
+	"net/url"
 	"time"
 
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 	bmac "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
 	bmhttpclient "github.com/cloudfoundry/bosh-micro-cli/deployment/httpclient"
@@ -31,3 +34,20 @@ func (f *agentClientFactory) NewAgentClient(directorID, mbusURL string) bmac.Age
 	httpClient := bmhttpclient.NewHTTPClient(f.logger)
 	return NewAgentClient(mbusURL, directorID, f.getTaskDelay, httpClient, f.logger)
 }
+
+// InstanceMbusURL returns the mbus URL of the agent of an instance after the first one:
+// the mbus URL with the IP of the instance as host, keeping the port
+func InstanceMbusURL(mbusURL string, ip string) (string, error) {
+	parsedURL, err := url.Parse(mbusURL)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing URL '%s'", mbusURL)
+	}
+
+	if _, port, err := net.SplitHostPort(parsedURL.Host); err == nil {
+		parsedURL.Host = net.JoinHostPort(ip, port)
+	} else {
+		parsedURL.Host = ip
+	}
+
+	return parsedURL.String(), nil
+}
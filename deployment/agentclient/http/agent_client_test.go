@@ -566,4 +566,181 @@ var _ = Describe("AgentClient", func() {
 			})
 		})
 	})
+
+	Describe("FetchLogs", func() {
+		Context("when agent responds with a value", func() {
+			BeforeEach(func() {
+				fakeHTTPClient.SetPostBehavior(`{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`, 200, nil)
+				fakeHTTPClient.SetPostBehavior(`{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`, 200, nil)
+				fakeHTTPClient.SetPostBehavior(`{"value":{"blobstore_id":"fake-blobstore-id"}}`, 200, nil)
+			})
+
+			It("makes a POST request to the endpoint", func() {
+				_, err := agentClient.FetchLogs("job", []string{"fake-filter"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeHTTPClient.PostInputs).To(HaveLen(3))
+				Expect(fakeHTTPClient.PostInputs[0].Endpoint).To(Equal("http://localhost:6305/agent"))
+
+				var request AgentRequestMessage
+				err = json.Unmarshal(fakeHTTPClient.PostInputs[0].Payload, &request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(request).To(Equal(AgentRequestMessage{
+					Method:    "fetch_logs",
+					Arguments: []interface{}{"job", []interface{}{"fake-filter"}},
+					ReplyTo:   "fake-uuid",
+				}))
+			})
+
+			It("waits for the task to be finished & returns the blobstore id of the logs", func() {
+				blobID, err := agentClient.FetchLogs("job", []string{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(blobID).To(Equal("fake-blobstore-id"))
+
+				var request AgentRequestMessage
+				err = json.Unmarshal(fakeHTTPClient.PostInputs[1].Payload, &request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(request).To(Equal(AgentRequestMessage{
+					Method:    "get_task",
+					Arguments: []interface{}{"fake-agent-task-id"},
+					ReplyTo:   "fake-uuid",
+				}))
+			})
+		})
+
+		Context("when the finished task does not return a blobstore id", func() {
+			BeforeEach(func() {
+				fakeHTTPClient.SetPostBehavior(`{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`, 200, nil)
+				fakeHTTPClient.SetPostBehavior(`{"value":{}}`, 200, nil)
+			})
+
+			It("returns an error", func() {
+				_, err := agentClient.FetchLogs("job", []string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Getting logs blobstore id"))
+			})
+		})
+	})
+
+	Describe("RunErrand", func() {
+		Context("when agent responds with a value", func() {
+			BeforeEach(func() {
+				fakeHTTPClient.SetPostBehavior(`{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`, 200, nil)
+				fakeHTTPClient.SetPostBehavior(`{"value":{"exit_code":1,"stdout":"fake-stdout","stderr":"fake-stderr"}}`, 200, nil)
+			})
+
+			It("makes a POST request to the endpoint", func() {
+				_, err := agentClient.RunErrand()
+				Expect(err).ToNot(HaveOccurred())
+
+				var request AgentRequestMessage
+				err = json.Unmarshal(fakeHTTPClient.PostInputs[0].Payload, &request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(request).To(Equal(AgentRequestMessage{
+					Method:    "run_errand",
+					Arguments: []interface{}{},
+					ReplyTo:   "fake-uuid",
+				}))
+			})
+
+			It("waits for the task to be finished & returns the errand result", func() {
+				result, err := agentClient.RunErrand()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(bmac.ErrandResult{
+					ExitStatus: 1,
+					Stdout:     "fake-stdout",
+					Stderr:     "fake-stderr",
+				}))
+			})
+		})
+
+		Context("when the finished task does not return an exit code", func() {
+			BeforeEach(func() {
+				fakeHTTPClient.SetPostBehavior(`{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`, 200, nil)
+				fakeHTTPClient.SetPostBehavior(`{"value":{}}`, 200, nil)
+			})
+
+			It("returns an error", func() {
+				_, err := agentClient.RunErrand()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Getting errand result"))
+			})
+		})
+	})
+
+	Describe("CompilePackage", func() {
+		var (
+			packageSource bmac.BlobRef
+			dependencies  []bmac.BlobRef
+		)
+
+		BeforeEach(func() {
+			packageSource = bmac.BlobRef{Name: "fake-package-name", Version: "fake-package-version", SHA1: "fake-source-sha1", BlobstoreID: "fake-source-blob-id"}
+			dependencies = []bmac.BlobRef{
+				{Name: "fake-dependency-name", Version: "fake-dependency-version", SHA1: "fake-dependency-sha1", BlobstoreID: "fake-dependency-blob-id"},
+			}
+		})
+
+		Context("when agent responds with a value", func() {
+			BeforeEach(func() {
+				fakeHTTPClient.SetPostBehavior(`{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`, 200, nil)
+				fakeHTTPClient.SetPostBehavior(`{"value":{"result":{"sha1":"fake-compiled-sha1","blobstore_id":"fake-compiled-blob-id"}}}`, 200, nil)
+			})
+
+			It("makes a POST request to the endpoint with the source & the compiled dependencies by name", func() {
+				_, err := agentClient.CompilePackage(packageSource, dependencies)
+				Expect(err).ToNot(HaveOccurred())
+
+				var request AgentRequestMessage
+				err = json.Unmarshal(fakeHTTPClient.PostInputs[0].Payload, &request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(request).To(Equal(AgentRequestMessage{
+					Method: "compile_package",
+					Arguments: []interface{}{
+						"fake-source-blob-id",
+						"fake-source-sha1",
+						"fake-package-name",
+						"fake-package-version",
+						map[string]interface{}{
+							"fake-dependency-name": map[string]interface{}{
+								"name":         "fake-dependency-name",
+								"version":      "fake-dependency-version",
+								"sha1":         "fake-dependency-sha1",
+								"blobstore_id": "fake-dependency-blob-id",
+							},
+						},
+					},
+					ReplyTo: "fake-uuid",
+				}))
+			})
+
+			It("waits for the task to be finished & returns the compiled package", func() {
+				compiledPackageRef, err := agentClient.CompilePackage(packageSource, dependencies)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(compiledPackageRef).To(Equal(bmac.BlobRef{
+					Name:        "fake-package-name",
+					Version:     "fake-package-version",
+					SHA1:        "fake-compiled-sha1",
+					BlobstoreID: "fake-compiled-blob-id",
+				}))
+			})
+		})
+
+		Context("when the finished task does not return a compile result", func() {
+			BeforeEach(func() {
+				fakeHTTPClient.SetPostBehavior(`{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`, 200, nil)
+				fakeHTTPClient.SetPostBehavior(`{"value":{}}`, 200, nil)
+			})
+
+			It("returns an error", func() {
+				_, err := agentClient.CompilePackage(packageSource, dependencies)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Getting compiled package 'fake-package-name'"))
+			})
+		})
+	})
 })
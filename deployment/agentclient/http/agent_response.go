@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	bmac "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
 )
 
 type Response interface {
@@ -111,3 +112,74 @@ func (r *TaskResponse) TaskState() (string, error) {
 
 	return "finished", nil
 }
+
+// BlobstoreID returns the id of the blob uploaded by a finished task, e.g. fetch_logs.
+//
+// Agent responds with value as { blobstore_id: "blob-id" }
+func (r *TaskResponse) BlobstoreID() (string, error) {
+	complexResponse, ok := r.Value.(map[string]interface{})
+	if !ok {
+		return "", bosherr.Errorf("Failed to convert agent response to map %#v", r.Value)
+	}
+
+	blobstoreID, ok := complexResponse["blobstore_id"].(string)
+	if !ok {
+		return "", bosherr.Errorf("Failed to parse blobstore id from agent response %#v", r.Value)
+	}
+
+	return blobstoreID, nil
+}
+
+// ErrandResult returns the output of a finished run_errand task.
+//
+// Agent responds with value as { exit_code: 0, stdout: "...", stderr: "..." }
+func (r *TaskResponse) ErrandResult() (bmac.ErrandResult, error) {
+	complexResponse, ok := r.Value.(map[string]interface{})
+	if !ok {
+		return bmac.ErrandResult{}, bosherr.Errorf("Failed to convert agent response to map %#v", r.Value)
+	}
+
+	exitCode, ok := complexResponse["exit_code"].(float64)
+	if !ok {
+		return bmac.ErrandResult{}, bosherr.Errorf("Failed to parse exit code from agent response %#v", r.Value)
+	}
+
+	stdout, _ := complexResponse["stdout"].(string)
+	stderr, _ := complexResponse["stderr"].(string)
+
+	return bmac.ErrandResult{
+		ExitStatus: int(exitCode),
+		Stdout:     stdout,
+		Stderr:     stderr,
+	}, nil
+}
+
+// CompiledPackageRef returns the blob of the package compiled by a finished compile_package task.
+//
+// Agent responds with value as { result: { sha1: "sha1", blobstore_id: "blob-id" } }
+func (r *TaskResponse) CompiledPackageRef() (bmac.BlobRef, error) {
+	complexResponse, ok := r.Value.(map[string]interface{})
+	if !ok {
+		return bmac.BlobRef{}, bosherr.Errorf("Failed to convert agent response to map %#v", r.Value)
+	}
+
+	result, ok := complexResponse["result"].(map[string]interface{})
+	if !ok {
+		return bmac.BlobRef{}, bosherr.Errorf("Failed to parse compile result from agent response %#v", r.Value)
+	}
+
+	sha1, ok := result["sha1"].(string)
+	if !ok {
+		return bmac.BlobRef{}, bosherr.Errorf("Failed to parse compiled package sha1 from agent response %#v", r.Value)
+	}
+
+	blobstoreID, ok := result["blobstore_id"].(string)
+	if !ok {
+		return bmac.BlobRef{}, bosherr.Errorf("Failed to parse compiled package blobstore id from agent response %#v", r.Value)
+	}
+
+	return bmac.BlobRef{
+		SHA1:        sha1,
+		BlobstoreID: blobstoreID,
+	}, nil
+}
@@ -83,5 +83,26 @@ var _ = Describe("AgentResponse", func() {
 				})
 			})
 		})
+
+		Describe("BlobstoreID", func() {
+			It("returns the blobstore id of the finished task", func() {
+				agentResponseJSON := `{"value":{"blobstore_id":"fake-blobstore-id"}}`
+				err := json.Unmarshal([]byte(agentResponseJSON), &agentTaskResponse)
+				Expect(err).ToNot(HaveOccurred())
+
+				blobstoreID, err := agentTaskResponse.BlobstoreID()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(blobstoreID).To(Equal("fake-blobstore-id"))
+			})
+
+			It("returns an error when the value does not have a blobstore id", func() {
+				agentResponseJSON := `{"value":"stopped"}`
+				err := json.Unmarshal([]byte(agentResponseJSON), &agentTaskResponse)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = agentTaskResponse.BlobstoreID()
+				Expect(err).To(HaveOccurred())
+			})
+		})
 	})
 })
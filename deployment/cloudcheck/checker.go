@@ -0,0 +1,443 @@
+package cloudcheck
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmagentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+)
+
+// Checker compares the VM, disk & stemcell records of the deployment with the cloud & the agent,
+// and applies the chosen resolutions to the problems it finds.
+type Checker interface {
+	Check() ([]Problem, error)
+	Resolve(Problem, Resolution) error
+}
+
+type checker struct {
+	cloud                   bmcloud.Cloud
+	agentClientFactory      bmhttpagent.AgentClientFactory
+	mbusURL                 string
+	deploymentConfigService bmconfig.DeploymentConfigService
+	instanceRepo            bmconfig.InstanceRepo
+	vmRepo                  bmconfig.VMRepo
+	diskRepo                bmconfig.DiskRepo
+	stemcellRepo            bmconfig.StemcellRepo
+	uuidGenerator           boshuuid.Generator
+	logger                  boshlog.Logger
+	logTag                  string
+}
+
+// NewChecker returns a checker of every instance of the deployment.
+// The agent of the first instance is reached at the mbus URL, the agents of the other instances at their IP.
+func NewChecker(
+	cloud bmcloud.Cloud,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	mbusURL string,
+	deploymentConfigService bmconfig.DeploymentConfigService,
+	instanceRepo bmconfig.InstanceRepo,
+	vmRepo bmconfig.VMRepo,
+	diskRepo bmconfig.DiskRepo,
+	stemcellRepo bmconfig.StemcellRepo,
+	uuidGenerator boshuuid.Generator,
+	logger boshlog.Logger,
+) Checker {
+	return &checker{
+		cloud:                   cloud,
+		agentClientFactory:      agentClientFactory,
+		mbusURL:                 mbusURL,
+		deploymentConfigService: deploymentConfigService,
+		instanceRepo:            instanceRepo,
+		vmRepo:                  vmRepo,
+		diskRepo:                diskRepo,
+		stemcellRepo:            stemcellRepo,
+		uuidGenerator:           uuidGenerator,
+		logger:                  logger,
+		logTag:                  "cloudChecker",
+	}
+}
+
+// checkedInstance is the VM, disk & agent of an instance of the deployment
+type checkedInstance struct {
+	name        string
+	record      bmconfig.InstanceRecord
+	vmRepo      bmconfig.VMRepo
+	diskRepo    bmconfig.DiskRepo
+	agentClient bmagentclient.AgentClient
+}
+
+func (c *checker) Check() ([]Problem, error) {
+	problems := []Problem{}
+
+	deploymentConfig, err := c.deploymentConfigService.Load()
+	if err != nil {
+		return problems, bosherr.WrapError(err, "Loading deployment config")
+	}
+
+	instances, err := c.instances(deploymentConfig.DirectorID)
+	if err != nil {
+		return problems, err
+	}
+
+	for _, instance := range instances {
+		currentDisk, currentDiskFound, err := instance.diskRepo.FindCurrent()
+		if err != nil {
+			return problems, bosherr.WrapError(err, "Finding current disk record")
+		}
+		if instance.record.DiskID != "" && !currentDiskFound {
+			problems = append(problems, Problem{
+				Type:         MissingCurrentDisk,
+				Description:  fmt.Sprintf("Current disk '%s' has no disk record", instance.record.DiskID),
+				Resolutions:  []Resolution{ForgetRecord, Skip},
+				InstanceName: instance.name,
+			})
+		}
+
+		vmProblems, err := c.checkVM(instance, currentDisk, currentDiskFound)
+		if err != nil {
+			return problems, err
+		}
+		problems = append(problems, vmProblems...)
+	}
+
+	currentStemcell, currentStemcellFound, err := c.stemcellRepo.FindCurrent()
+	if err != nil {
+		return problems, bosherr.WrapError(err, "Finding current stemcell record")
+	}
+	if deploymentConfig.CurrentStemcellID != "" && !currentStemcellFound {
+		problems = append(problems, Problem{
+			Type:        MissingCurrentStemcell,
+			Description: fmt.Sprintf("Current stemcell '%s' has no stemcell record", deploymentConfig.CurrentStemcellID),
+			Resolutions: []Resolution{ForgetRecord, Skip},
+		})
+	}
+
+	currentDisks, err := c.diskRepo.FindAllCurrent()
+	if err != nil {
+		return problems, bosherr.WrapError(err, "Finding current disk records")
+	}
+	diskRecords, err := c.diskRepo.All()
+	if err != nil {
+		return problems, bosherr.WrapError(err, "Finding disk records")
+	}
+	for _, diskRecord := range diskRecords {
+		if containsDisk(currentDisks, diskRecord) {
+			continue
+		}
+		problems = append(problems, Problem{
+			Type:        OrphanDisk,
+			Description: fmt.Sprintf("Disk '%s' is not used by the deployment", diskRecord.CID),
+			Resolutions: []Resolution{Skip, DeleteOrphan, ForgetRecord},
+			DiskRecord:  diskRecord,
+		})
+	}
+
+	stemcellRecords, err := c.stemcellRepo.All()
+	if err != nil {
+		return problems, bosherr.WrapError(err, "Finding stemcell records")
+	}
+	for _, stemcellRecord := range stemcellRecords {
+		if currentStemcellFound && stemcellRecord.ID == currentStemcell.ID {
+			continue
+		}
+		problems = append(problems, Problem{
+			Type:           OrphanStemcell,
+			Description:    fmt.Sprintf("Stemcell '%s' is not used by the deployment", stemcellRecord.CID),
+			Resolutions:    []Resolution{DeleteOrphan, ForgetRecord, Skip},
+			StemcellRecord: stemcellRecord,
+		})
+	}
+
+	return problems, nil
+}
+
+// instances returns the instances of the deployment, the first instance first
+func (c *checker) instances(directorID string) ([]checkedInstance, error) {
+	instanceRecords, err := c.instanceRepo.All()
+	if err != nil {
+		return []checkedInstance{}, bosherr.WrapError(err, "Finding instance records")
+	}
+
+	instances := []checkedInstance{}
+	for i, instanceRecord := range instanceRecords {
+		if i == 0 {
+			instances = append(instances, checkedInstance{
+				record:      instanceRecord,
+				vmRepo:      c.vmRepo,
+				diskRepo:    c.diskRepo,
+				agentClient: c.agentClientFactory.NewAgentClient(directorID, c.mbusURL),
+			})
+			continue
+		}
+
+		instanceMbusURL, err := bmhttpagent.InstanceMbusURL(c.mbusURL, instanceRecord.IP)
+		if err != nil {
+			return []checkedInstance{}, bosherr.WrapErrorf(err, "Building the mbus URL of instance '%s/%d'", instanceRecord.JobName, instanceRecord.Index)
+		}
+
+		instances = append(instances, checkedInstance{
+			name:        fmt.Sprintf("%s/%d", instanceRecord.JobName, instanceRecord.Index),
+			record:      instanceRecord,
+			vmRepo:      bmconfig.NewInstanceVMRepo(c.deploymentConfigService, instanceRecord.JobName, instanceRecord.Index),
+			diskRepo:    bmconfig.NewInstanceDiskRepo(c.deploymentConfigService, c.uuidGenerator, instanceRecord.JobName, instanceRecord.Index),
+			agentClient: c.agentClientFactory.NewAgentClient(directorID, instanceMbusURL),
+		})
+	}
+
+	return instances, nil
+}
+
+// findInstance returns the instance a problem is about
+func (c *checker) findInstance(problem Problem) (checkedInstance, error) {
+	deploymentConfig, err := c.deploymentConfigService.Load()
+	if err != nil {
+		return checkedInstance{}, bosherr.WrapError(err, "Loading deployment config")
+	}
+
+	instances, err := c.instances(deploymentConfig.DirectorID)
+	if err != nil {
+		return checkedInstance{}, err
+	}
+
+	for _, instance := range instances {
+		if instance.name == problem.InstanceName {
+			return instance, nil
+		}
+	}
+
+	return checkedInstance{}, bosherr.Errorf("Instance '%s' has no record", problem.InstanceName)
+}
+
+func (c *checker) checkVM(instance checkedInstance, currentDisk bmconfig.DiskRecord, currentDiskFound bool) ([]Problem, error) {
+	vmCID, vmFound, err := instance.vmRepo.FindCurrent()
+	if err != nil {
+		return []Problem{}, bosherr.WrapError(err, "Finding current VM record")
+	}
+	if !vmFound {
+		return []Problem{}, nil
+	}
+
+	exists, err := c.cloud.HasVM(vmCID)
+	if err != nil {
+		return []Problem{}, bosherr.WrapErrorf(err, "Checking existence of VM '%s'", vmCID)
+	}
+	if !exists {
+		return []Problem{{
+			Type:         MissingVM,
+			Description:  fmt.Sprintf("VM '%s' is missing from the cloud", vmCID),
+			Resolutions:  []Resolution{RecreateVM, DeleteVM, Skip},
+			VMCID:        vmCID,
+			InstanceName: instance.name,
+		}}, nil
+	}
+
+	_, err = instance.agentClient.Ping()
+	if err != nil {
+		c.logger.Debug(c.logTag, "Pinging agent on VM '%s' failed: %s", vmCID, err.Error())
+		return []Problem{{
+			Type:         UnresponsiveAgent,
+			Description:  fmt.Sprintf("Agent on VM '%s' is not responding", vmCID),
+			Resolutions:  []Resolution{RecreateVM, DeleteVM, Skip},
+			VMCID:        vmCID,
+			InstanceName: instance.name,
+		}}, nil
+	}
+
+	if !currentDiskFound {
+		return []Problem{}, nil
+	}
+
+	attached, err := c.isDiskAttached(vmCID, currentDisk.CID)
+	if err != nil {
+		return []Problem{}, err
+	}
+
+	mountedDiskCIDs, err := instance.agentClient.ListDisk()
+	if err != nil {
+		return []Problem{}, bosherr.WrapErrorf(err, "Listing disks mounted on VM '%s'", vmCID)
+	}
+	mounted := contains(mountedDiskCIDs, currentDisk.CID)
+
+	if attached && mounted {
+		return []Problem{}, nil
+	}
+
+	description := fmt.Sprintf("Disk '%s' is not mounted on VM '%s'", currentDisk.CID, vmCID)
+	if !attached {
+		description = fmt.Sprintf("Disk '%s' is not attached to VM '%s'", currentDisk.CID, vmCID)
+	}
+
+	return []Problem{{
+		Type:         UnattachedDisk,
+		Description:  description,
+		Resolutions:  []Resolution{ReattachDisk, Skip},
+		VMCID:        vmCID,
+		DiskRecord:   currentDisk,
+		DiskAttached: attached,
+		InstanceName: instance.name,
+	}}, nil
+}
+
+// isDiskAttached asks the CPI for the disks attached to the VM.
+// CPIs that do not implement get_disks are trusted to have the disk attached.
+func (c *checker) isDiskAttached(vmCID, diskCID string) (bool, error) {
+	attachedDiskCIDs, err := c.cloud.GetDisks(vmCID)
+	if err != nil {
+		cloudErr, ok := err.(bmcloud.Error)
+		if ok && cloudErr.Type() == bmcloud.NotImplementedError {
+			c.logger.Info(c.logTag, "CPI does not implement get_disks, skipping disk attachment check")
+			return true, nil
+		}
+		return false, bosherr.WrapErrorf(err, "Listing disks attached to VM '%s'", vmCID)
+	}
+	return contains(attachedDiskCIDs, diskCID), nil
+}
+
+func (c *checker) Resolve(problem Problem, resolution Resolution) error {
+	if !problem.HasResolution(resolution) {
+		return bosherr.Errorf("Resolution '%s' does not apply to problem '%s'", resolution, problem.Type)
+	}
+
+	switch resolution {
+	case Skip:
+		return nil
+	case RecreateVM, DeleteVM:
+		return c.deleteVM(problem)
+	case ReattachDisk:
+		return c.reattachDisk(problem)
+	case ForgetRecord:
+		return c.forgetRecord(problem)
+	case DeleteOrphan:
+		return c.deleteOrphan(problem)
+	}
+
+	return bosherr.Errorf("Unsupported resolution '%s'", resolution)
+}
+
+// deleteVM deletes the VM & clears the VM record, keeping the disk & stemcell records.
+// It does not create a VM: RecreateVM is completed by a deploy with --recreate,
+// which creates a new VM from the current stemcell and attaches the current disk to it.
+func (c *checker) deleteVM(problem Problem) error {
+	instance, err := c.findInstance(problem)
+	if err != nil {
+		return err
+	}
+
+	if problem.Type == UnresponsiveAgent {
+		currentDisk, found, err := instance.diskRepo.FindCurrent()
+		if err != nil {
+			return bosherr.WrapError(err, "Finding current disk record")
+		}
+		if found {
+			err = c.cloud.DetachDisk(problem.VMCID, currentDisk.CID)
+			if err != nil {
+				c.logger.Warn(c.logTag, "Failed to detach disk '%s' from VM '%s': %s", currentDisk.CID, problem.VMCID, err.Error())
+			}
+		}
+
+		err = c.cloud.DeleteVM(problem.VMCID)
+		if err != nil {
+			cloudErr, ok := err.(bmcloud.Error)
+			if !ok || cloudErr.Type() != bmcloud.VMNotFoundError {
+				return bosherr.WrapErrorf(err, "Deleting VM '%s'", problem.VMCID)
+			}
+		}
+	}
+
+	err = instance.vmRepo.ClearCurrent()
+	if err != nil {
+		return bosherr.WrapError(err, "Clearing current VM record")
+	}
+	return nil
+}
+
+func (c *checker) reattachDisk(problem Problem) error {
+	instance, err := c.findInstance(problem)
+	if err != nil {
+		return err
+	}
+
+	diskCID := problem.DiskRecord.CID
+
+	if !problem.DiskAttached {
+		err := c.cloud.AttachDisk(problem.VMCID, diskCID)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Attaching disk '%s' to VM '%s'", diskCID, problem.VMCID)
+		}
+	}
+
+	err = instance.agentClient.MountDisk(diskCID)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Mounting disk '%s' on VM '%s'", diskCID, problem.VMCID)
+	}
+	return nil
+}
+
+func (c *checker) forgetRecord(problem Problem) error {
+	var err error
+	switch problem.Type {
+	case MissingCurrentDisk:
+		var instance checkedInstance
+		instance, err = c.findInstance(problem)
+		if err == nil {
+			err = instance.diskRepo.ClearCurrent()
+		}
+	case MissingCurrentStemcell:
+		err = c.stemcellRepo.ClearCurrent()
+	case OrphanDisk:
+		err = c.diskRepo.Delete(problem.DiskRecord)
+	case OrphanStemcell:
+		err = c.stemcellRepo.Delete(problem.StemcellRecord)
+	}
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Forgetting record for problem '%s'", problem.Type)
+	}
+	return nil
+}
+
+func (c *checker) deleteOrphan(problem Problem) error {
+	switch problem.Type {
+	case OrphanDisk:
+		err := c.cloud.DeleteDisk(problem.DiskRecord.CID)
+		if err != nil {
+			cloudErr, ok := err.(bmcloud.Error)
+			if !ok || cloudErr.Type() != bmcloud.DiskNotFoundError {
+				return bosherr.WrapErrorf(err, "Deleting disk '%s'", problem.DiskRecord.CID)
+			}
+		}
+	case OrphanStemcell:
+		err := c.cloud.DeleteStemcell(problem.StemcellRecord.CID)
+		if err != nil {
+			cloudErr, ok := err.(bmcloud.Error)
+			if !ok || cloudErr.Type() != bmcloud.StemcellNotFoundError {
+				return bosherr.WrapErrorf(err, "Deleting stemcell '%s'", problem.StemcellRecord.CID)
+			}
+		}
+	}
+
+	return c.forgetRecord(problem)
+}
+
+func containsDisk(diskRecords []bmconfig.DiskRecord, diskRecord bmconfig.DiskRecord) bool {
+	for _, r := range diskRecords {
+		if r.ID == diskRecord.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(cids []string, cid string) bool {
+	for _, c := range cids {
+		if c == cid {
+			return true
+		}
+	}
+	return false
+}
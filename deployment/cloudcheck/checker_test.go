@@ -0,0 +1,481 @@
+package cloudcheck_test
+
+import (
+	"errors"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/deployment/cloudcheck"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.google.com/p/gomock/gomock"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-agent/uuid/fakes"
+
+	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+
+	fakebmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud/fakes"
+	fakebmagentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/fakes"
+	mock_httpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http/mocks"
+)
+
+var _ = Describe("Checker", func() {
+	var (
+		mockCtrl *gomock.Controller
+
+		fakeCloud              *fakebmcloud.FakeCloud
+		fakeAgentClient        *fakebmagentclient.FakeAgentClient
+		fakeOtherAgentClient   *fakebmagentclient.FakeAgentClient
+		mockAgentClientFactory *mock_httpagent.MockAgentClientFactory
+		fakeUUIDGenerator      *fakeuuid.FakeGenerator
+		configService          bmconfig.DeploymentConfigService
+		instanceRepo           bmconfig.InstanceRepo
+		vmRepo                 bmconfig.VMRepo
+		diskRepo               bmconfig.DiskRepo
+		stemcellRepo           bmconfig.StemcellRepo
+
+		checker Checker
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fakeCloud = fakebmcloud.NewFakeCloud()
+		fakeAgentClient = fakebmagentclient.NewFakeAgentClient()
+		fakeOtherAgentClient = fakebmagentclient.NewFakeAgentClient()
+		mockAgentClientFactory = mock_httpagent.NewMockAgentClientFactory(mockCtrl)
+		mockAgentClientFactory.EXPECT().NewAgentClient("fake-director-id", "https://fake-mbus-host:6868").Return(fakeAgentClient).AnyTimes()
+		mockAgentClientFactory.EXPECT().NewAgentClient("fake-director-id", "https://10.0.0.2:6868").Return(fakeOtherAgentClient).AnyTimes()
+
+		configService = bmconfig.NewInMemoryDeploymentConfigService(bmconfig.DeploymentFile{DirectorID: "fake-director-id"})
+		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
+		instanceRepo = bmconfig.NewInstanceRepo(configService)
+		vmRepo = bmconfig.NewVMRepo(configService)
+		diskRepo = bmconfig.NewDiskRepo(configService, fakeUUIDGenerator)
+		stemcellRepo = bmconfig.NewStemcellRepo(configService, fakeUUIDGenerator)
+
+		checker = NewChecker(
+			fakeCloud,
+			mockAgentClientFactory,
+			"https://fake-mbus-host:6868",
+			configService,
+			instanceRepo,
+			vmRepo,
+			diskRepo,
+			stemcellRepo,
+			fakeUUIDGenerator,
+			logger,
+		)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Check", func() {
+		var diskRecord bmconfig.DiskRecord
+
+		BeforeEach(func() {
+			err := vmRepo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			diskRecord, err = diskRepo.Save("fake-disk-cid", 1024, map[string]interface{}{})
+			Expect(err).ToNot(HaveOccurred())
+			err = diskRepo.UpdateCurrent(diskRecord.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			err = stemcellRepo.UpdateCurrent(stemcellRecord.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeCloud.HasVMFound = true
+			fakeCloud.GetDisksDiskCIDs = []string{"fake-disk-cid"}
+			fakeAgentClient.SetListDiskBehavior([]string{"fake-disk-cid"}, nil)
+		})
+
+		It("finds no problems when the records match the cloud and the agent", func() {
+			problems, err := checker.Check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+
+			Expect(fakeCloud.HasVMInput).To(Equal(fakebmcloud.HasVMInput{VMCID: "fake-vm-cid"}))
+			Expect(fakeCloud.GetDisksInputs).To(Equal([]fakebmcloud.GetDisksInput{{VMCID: "fake-vm-cid"}}))
+			Expect(fakeAgentClient.ListDiskCalled).To(BeTrue())
+		})
+
+		It("reports a missing VM", func() {
+			fakeCloud.HasVMFound = false
+
+			problems, err := checker.Check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]Problem{
+				{
+					Type:        MissingVM,
+					Description: "VM 'fake-vm-cid' is missing from the cloud",
+					Resolutions: []Resolution{RecreateVM, DeleteVM, Skip},
+					VMCID:       "fake-vm-cid",
+				},
+			}))
+		})
+
+		It("reports an unresponsive agent", func() {
+			fakeAgentClient.SetPingBehavior("", errors.New("fake-ping-error"))
+
+			problems, err := checker.Check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(HaveLen(1))
+			Expect(problems[0].Type).To(Equal(UnresponsiveAgent))
+			Expect(problems[0].RecommendedResolution()).To(Equal(RecreateVM))
+		})
+
+		It("offers deleting the VM when recreating it is not possible", func() {
+			fakeCloud.HasVMFound = false
+
+			problems, err := checker.Check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(HaveLen(1))
+
+			problem := problems[0].WithoutResolution(RecreateVM)
+			Expect(problem.Resolutions).To(Equal([]Resolution{DeleteVM, Skip}))
+			Expect(problem.RecommendedResolution()).To(Equal(DeleteVM))
+			Expect(problems[0].Resolutions).To(Equal([]Resolution{RecreateVM, DeleteVM, Skip}))
+		})
+
+		It("reports a disk that is not attached to the VM", func() {
+			fakeCloud.GetDisksDiskCIDs = []string{}
+
+			problems, err := checker.Check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]Problem{
+				{
+					Type:         UnattachedDisk,
+					Description:  "Disk 'fake-disk-cid' is not attached to VM 'fake-vm-cid'",
+					Resolutions:  []Resolution{ReattachDisk, Skip},
+					VMCID:        "fake-vm-cid",
+					DiskRecord:   diskRecord,
+					DiskAttached: false,
+				},
+			}))
+		})
+
+		It("reports a disk that is attached but not mounted", func() {
+			fakeAgentClient.SetListDiskBehavior([]string{}, nil)
+
+			problems, err := checker.Check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(HaveLen(1))
+			Expect(problems[0].Description).To(Equal("Disk 'fake-disk-cid' is not mounted on VM 'fake-vm-cid'"))
+			Expect(problems[0].DiskAttached).To(BeTrue())
+		})
+
+		It("skips the attachment check when the CPI does not implement get_disks", func() {
+			fakeCloud.GetDisksErr = bmcloud.NewCPIError("get_disks", bmcloud.CmdError{
+				Type: bmcloud.NotImplementedError,
+			})
+
+			problems, err := checker.Check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+		})
+
+		It("returns an error when get_disks fails", func() {
+			fakeCloud.GetDisksErr = errors.New("fake-get-disks-error")
+
+			_, err := checker.Check()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-disks-error"))
+		})
+
+		It("reports orphan disk and stemcell records", func() {
+			orphanDisk, err := diskRepo.Save("fake-orphan-disk-cid", 1024, map[string]interface{}{})
+			Expect(err).ToNot(HaveOccurred())
+			orphanStemcell, err := stemcellRepo.Save("fake-stemcell-name", "fake-old-version", "fake-orphan-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			problems, err := checker.Check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]Problem{
+				{
+					Type:        OrphanDisk,
+					Description: "Disk 'fake-orphan-disk-cid' is not used by the deployment",
+					Resolutions: []Resolution{Skip, DeleteOrphan, ForgetRecord},
+					DiskRecord:  orphanDisk,
+				},
+				{
+					Type:           OrphanStemcell,
+					Description:    "Stemcell 'fake-orphan-stemcell-cid' is not used by the deployment",
+					Resolutions:    []Resolution{DeleteOrphan, ForgetRecord, Skip},
+					StemcellRecord: orphanStemcell,
+				},
+			}))
+		})
+
+		Context("when there is an other instance", func() {
+			var (
+				otherDiskRecord bmconfig.DiskRecord
+				otherVMRepo     bmconfig.VMRepo
+				otherDiskRepo   bmconfig.DiskRepo
+			)
+
+			BeforeEach(func() {
+				_, err := instanceRepo.Save("fake-job-name", 1, "10.0.0.2")
+				Expect(err).ToNot(HaveOccurred())
+
+				otherVMRepo = bmconfig.NewInstanceVMRepo(configService, "fake-job-name", 1)
+				err = otherVMRepo.UpdateCurrent("fake-other-vm-cid")
+				Expect(err).ToNot(HaveOccurred())
+
+				otherDiskRepo = bmconfig.NewInstanceDiskRepo(configService, fakeUUIDGenerator, "fake-job-name", 1)
+				otherDiskRecord, err = otherDiskRepo.Save("fake-other-disk-cid", 1024, map[string]interface{}{})
+				Expect(err).ToNot(HaveOccurred())
+				err = otherDiskRepo.UpdateCurrent(otherDiskRecord.ID)
+				Expect(err).ToNot(HaveOccurred())
+
+				fakeCloud.GetDisksDiskCIDs = []string{"fake-disk-cid", "fake-other-disk-cid"}
+				fakeOtherAgentClient.SetListDiskBehavior([]string{"fake-other-disk-cid"}, nil)
+			})
+
+			It("checks the VM & disk of every instance, without reporting their disks as orphans", func() {
+				problems, err := checker.Check()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(problems).To(BeEmpty())
+
+				Expect(fakeCloud.GetDisksInputs).To(Equal([]fakebmcloud.GetDisksInput{
+					{VMCID: "fake-vm-cid"},
+					{VMCID: "fake-other-vm-cid"},
+				}))
+				Expect(fakeAgentClient.ListDiskCalled).To(BeTrue())
+				Expect(fakeOtherAgentClient.ListDiskCalled).To(BeTrue())
+			})
+
+			It("reports the problems of the other instance with its name", func() {
+				fakeOtherAgentClient.SetListDiskBehavior([]string{}, nil)
+
+				problems, err := checker.Check()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(problems).To(Equal([]Problem{
+					{
+						Type:         UnattachedDisk,
+						Description:  "Disk 'fake-other-disk-cid' is not mounted on VM 'fake-other-vm-cid'",
+						Resolutions:  []Resolution{ReattachDisk, Skip},
+						VMCID:        "fake-other-vm-cid",
+						DiskRecord:   otherDiskRecord,
+						DiskAttached: true,
+						InstanceName: "fake-job-name/1",
+					},
+				}))
+			})
+
+			It("resolves the problems of the other instance with its records & agent", func() {
+				err := checker.Resolve(Problem{
+					Type:         UnresponsiveAgent,
+					Resolutions:  []Resolution{RecreateVM, DeleteVM, Skip},
+					VMCID:        "fake-other-vm-cid",
+					InstanceName: "fake-job-name/1",
+				}, DeleteVM)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCloud.DetachDiskInput).To(Equal(fakebmcloud.DetachDiskInput{VMCID: "fake-other-vm-cid", DiskCID: "fake-other-disk-cid"}))
+
+				_, found, err := otherVMRepo.FindCurrent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+
+				vmCID, found, err := vmRepo.FindCurrent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(vmCID).To(Equal("fake-vm-cid"))
+
+				err = checker.Resolve(Problem{
+					Type:         UnattachedDisk,
+					Resolutions:  []Resolution{ReattachDisk, Skip},
+					VMCID:        "fake-other-vm-cid",
+					DiskRecord:   otherDiskRecord,
+					DiskAttached: true,
+					InstanceName: "fake-job-name/1",
+				}, ReattachDisk)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeOtherAgentClient.MountDiskCID).To(Equal("fake-other-disk-cid"))
+				Expect(fakeAgentClient.MountDiskCID).To(BeEmpty())
+			})
+		})
+
+		It("reports current ids without records", func() {
+			deploymentFile, err := configService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			deploymentFile.Instances[0].DiskID = "fake-missing-disk-id"
+			err = configService.Save(deploymentFile)
+			Expect(err).ToNot(HaveOccurred())
+
+			problems, err := checker.Check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(ContainElement(Problem{
+				Type:        MissingCurrentDisk,
+				Description: "Current disk 'fake-missing-disk-id' has no disk record",
+				Resolutions: []Resolution{ForgetRecord, Skip},
+			}))
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("clears the VM record of a missing VM", func() {
+			err := vmRepo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = checker.Resolve(Problem{
+				Type:        MissingVM,
+				Resolutions: []Resolution{RecreateVM, DeleteVM, Skip},
+				VMCID:       "fake-vm-cid",
+			}, DeleteVM)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := vmRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(fakeCloud.DeleteVMInput).To(Equal(fakebmcloud.DeleteVMInput{}))
+		})
+
+		It("detaches the disk and deletes the VM of an unresponsive agent", func() {
+			err := vmRepo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+			diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, map[string]interface{}{})
+			Expect(err).ToNot(HaveOccurred())
+			err = diskRepo.UpdateCurrent(diskRecord.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = checker.Resolve(Problem{
+				Type:        UnresponsiveAgent,
+				Resolutions: []Resolution{RecreateVM, DeleteVM, Skip},
+				VMCID:       "fake-vm-cid",
+			}, DeleteVM)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.DetachDiskInput).To(Equal(fakebmcloud.DetachDiskInput{VMCID: "fake-vm-cid", DiskCID: "fake-disk-cid"}))
+			Expect(fakeCloud.DeleteVMInput).To(Equal(fakebmcloud.DeleteVMInput{VMCID: "fake-vm-cid"}))
+
+			_, found, err := vmRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("deletes the VM of an unresponsive agent for recreation, keeping the disk & stemcell records", func() {
+			err := vmRepo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+			diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, map[string]interface{}{})
+			Expect(err).ToNot(HaveOccurred())
+			err = diskRepo.UpdateCurrent(diskRecord.ID)
+			Expect(err).ToNot(HaveOccurred())
+			stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			err = stemcellRepo.UpdateCurrent(stemcellRecord.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = checker.Resolve(Problem{
+				Type:        UnresponsiveAgent,
+				Resolutions: []Resolution{RecreateVM, DeleteVM, Skip},
+				VMCID:       "fake-vm-cid",
+			}, RecreateVM)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.DetachDiskInput).To(Equal(fakebmcloud.DetachDiskInput{VMCID: "fake-vm-cid", DiskCID: "fake-disk-cid"}))
+			Expect(fakeCloud.DeleteVMInput).To(Equal(fakebmcloud.DeleteVMInput{VMCID: "fake-vm-cid"}))
+
+			_, found, err := vmRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			currentDisk, found, err := diskRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(currentDisk).To(Equal(diskRecord))
+
+			currentStemcell, found, err := stemcellRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(currentStemcell).To(Equal(stemcellRecord))
+		})
+
+		It("clears the VM record of a missing VM for recreation", func() {
+			err := vmRepo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = checker.Resolve(Problem{
+				Type:        MissingVM,
+				Resolutions: []Resolution{RecreateVM, DeleteVM, Skip},
+				VMCID:       "fake-vm-cid",
+			}, RecreateVM)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := vmRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(fakeCloud.DeleteVMInput).To(Equal(fakebmcloud.DeleteVMInput{}))
+		})
+
+		It("attaches and mounts an unattached disk", func() {
+			err := vmRepo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = checker.Resolve(Problem{
+				Type:        UnattachedDisk,
+				Resolutions: []Resolution{ReattachDisk, Skip},
+				VMCID:       "fake-vm-cid",
+				DiskRecord:  bmconfig.DiskRecord{CID: "fake-disk-cid"},
+			}, ReattachDisk)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.AttachDiskInput).To(Equal(fakebmcloud.AttachDiskInput{VMCID: "fake-vm-cid", DiskCID: "fake-disk-cid"}))
+			Expect(fakeAgentClient.MountDiskCID).To(Equal("fake-disk-cid"))
+		})
+
+		It("deletes an orphan disk and forgets its record, tolerating a disk missing from the cloud", func() {
+			diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, map[string]interface{}{})
+			Expect(err).ToNot(HaveOccurred())
+			fakeCloud.DeleteDiskErr = bmcloud.NewCPIError("delete_disk", bmcloud.CmdError{
+				Type: bmcloud.DiskNotFoundError,
+			})
+
+			err = checker.Resolve(Problem{
+				Type:        OrphanDisk,
+				Resolutions: []Resolution{Skip, DeleteOrphan, ForgetRecord},
+				DiskRecord:  diskRecord,
+			}, DeleteOrphan)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.DeleteDiskInputs).To(Equal([]fakebmcloud.DeleteDiskInput{{DiskCID: "fake-disk-cid"}}))
+			records, err := diskRepo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(BeEmpty())
+		})
+
+		It("forgets an orphan stemcell record without deleting the stemcell", func() {
+			stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = checker.Resolve(Problem{
+				Type:           OrphanStemcell,
+				Resolutions:    []Resolution{DeleteOrphan, ForgetRecord, Skip},
+				StemcellRecord: stemcellRecord,
+			}, ForgetRecord)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.DeleteStemcellInputs).To(BeEmpty())
+			records, err := stemcellRepo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(BeEmpty())
+		})
+
+		It("returns an error when the resolution does not apply to the problem", func() {
+			err := checker.Resolve(Problem{
+				Type:        MissingVM,
+				Resolutions: []Resolution{RecreateVM, DeleteVM, Skip},
+			}, DeleteOrphan)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Resolution 'delete_orphan' does not apply to problem 'missing_vm'"))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package cloudcheck_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCloudCheck(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CloudCheck Suite")
+}
@@ -0,0 +1,86 @@
+package cloudcheck
+
+import (
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+)
+
+type ProblemType string
+
+const (
+	MissingVM              ProblemType = "missing_vm"
+	UnresponsiveAgent      ProblemType = "unresponsive_agent"
+	UnattachedDisk         ProblemType = "unattached_disk"
+	MissingCurrentDisk     ProblemType = "missing_current_disk"
+	MissingCurrentStemcell ProblemType = "missing_current_stemcell"
+	OrphanDisk             ProblemType = "orphan_disk"
+	OrphanStemcell         ProblemType = "orphan_stemcell"
+)
+
+type Resolution string
+
+const (
+	RecreateVM   Resolution = "recreate_vm"
+	DeleteVM     Resolution = "delete_vm"
+	ReattachDisk Resolution = "reattach_disk"
+	ForgetRecord Resolution = "forget_record"
+	DeleteOrphan Resolution = "delete_orphan"
+	Skip         Resolution = "skip"
+)
+
+func (r Resolution) Description() string {
+	switch r {
+	case RecreateVM:
+		return "Recreate VM from the current stemcell & disk"
+	case DeleteVM:
+		return "Delete VM, the next start or deploy recreates it"
+	case ReattachDisk:
+		return "Reattach disk"
+	case ForgetRecord:
+		return "Forget record"
+	case DeleteOrphan:
+		return "Delete from the cloud and forget record"
+	default:
+		return "Skip for now"
+	}
+}
+
+// Problem is a mismatch between the deployment state file and the cloud or the agent.
+// The first of its Resolutions is the recommended one.
+type Problem struct {
+	Type        ProblemType
+	Description string
+	Resolutions []Resolution
+
+	VMCID          string
+	DiskRecord     bmconfig.DiskRecord
+	DiskAttached   bool
+	StemcellRecord bmconfig.StemcellRecord
+
+	// InstanceName is the job name & index of the instance a VM or disk problem is about, empty for the first instance
+	InstanceName string
+}
+
+func (p Problem) RecommendedResolution() Resolution {
+	return p.Resolutions[0]
+}
+
+func (p Problem) HasResolution(resolution Resolution) bool {
+	for _, r := range p.Resolutions {
+		if r == resolution {
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutResolution returns a copy of the problem that does not offer the resolution.
+func (p Problem) WithoutResolution(resolution Resolution) Problem {
+	resolutions := []Resolution{}
+	for _, r := range p.Resolutions {
+		if r != resolution {
+			resolutions = append(resolutions, r)
+		}
+	}
+	p.Resolutions = resolutions
+	return p
+}
@@ -1,6 +1,7 @@
 package deployment
 
 import (
+	"sync"
 	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
@@ -8,6 +9,8 @@ import (
 
 	bmblobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
 	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	bminstance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
@@ -19,21 +22,38 @@ import (
 
 type Deployer interface {
 	Deploy(
-		bmcloud.Cloud,
-		bmdeplmanifest.Manifest,
-		bmstemcell.ExtractedStemcell,
-		bminstallmanifest.Registry,
-		bminstallmanifest.SSHTunnel,
-		bmvm.Manager,
-		bmblobstore.Blobstore,
+		cloud bmcloud.Cloud,
+		deploymentManifest bmdeplmanifest.Manifest,
+		extractedStemcell bmstemcell.ExtractedStemcell,
+		options DeployOptions,
 	) (Deployment, error)
 }
 
+// DeployOptions are how the instances of a deploy are reached & updated
+type DeployOptions struct {
+	RegistryConfig  bminstallmanifest.Registry
+	SSHTunnelConfig bminstallmanifest.SSHTunnel
+	DirectorID      string
+	MbusURL         string
+	Blobstore       bmblobstore.Blobstore
+
+	// Recreate replaces the VMs even when they are up to date
+	Recreate bool
+
+	// RenderedJobs are the fingerprints of the rendered job templates of the last deploy, by instance name
+	RenderedJobs map[string]string
+
+	// RollbackOnFailure rolls back the instances whose jobs fail to update
+	RollbackOnFailure bool
+}
+
 type deployer struct {
 	stemcellManagerFactory bmstemcell.ManagerFactory
 	vmManagerFactory       bmvm.ManagerFactory
 	instanceManagerFactory bminstance.ManagerFactory
 	deploymentFactory      Factory
+	agentClientFactory     bmhttpagent.AgentClientFactory
+	instanceRepo           bmconfig.InstanceRepo
 	eventLogger            bmeventlog.EventLogger
 	logger                 boshlog.Logger
 	logTag                 string
@@ -44,6 +64,8 @@ func NewDeployer(
 	vmManagerFactory bmvm.ManagerFactory,
 	instanceManagerFactory bminstance.ManagerFactory,
 	deploymentFactory Factory,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	instanceRepo bmconfig.InstanceRepo,
 	eventLogger bmeventlog.EventLogger,
 	logger boshlog.Logger,
 ) *deployer {
@@ -52,20 +74,27 @@ func NewDeployer(
 		vmManagerFactory:       vmManagerFactory,
 		instanceManagerFactory: instanceManagerFactory,
 		deploymentFactory:      deploymentFactory,
+		agentClientFactory:     agentClientFactory,
+		instanceRepo:           instanceRepo,
 		eventLogger:            eventLogger,
 		logger:                 logger,
 		logTag:                 "deployer",
 	}
 }
 
+// Deploy replaces the instances of the deployment, or only updates their jobs when their VMs are up to date
+// (same stemcell, resource pool cloud properties & env, and networks), unless Recreate is set.
+// The jobs of an up-to-date instance are left running when their rendered templates still have
+// the fingerprint given for the instance in RenderedJobs.
+// When the jobs of an instance fail to update and RollbackOnFailure is set, the instance is rolled back
+// to the snapshot of its last successful deploy in a separate stage, and the deploy still fails.
+// The agent of the first instance of the first job is reached at the mbus URL,
+// the agents of the other instances at the mbus URL with their IP as host.
 func (d *deployer) Deploy(
 	cloud bmcloud.Cloud,
 	deploymentManifest bmdeplmanifest.Manifest,
 	extractedStemcell bmstemcell.ExtractedStemcell,
-	registryConfig bminstallmanifest.Registry,
-	sshTunnelConfig bminstallmanifest.SSHTunnel,
-	vmManager bmvm.Manager,
-	blobstore bmblobstore.Blobstore,
+	options DeployOptions,
 ) (Deployment, error) {
 
 	//TODO: handle stage construction outside of this class
@@ -84,15 +113,36 @@ func (d *deployer) Deploy(
 	deployStage := d.eventLogger.NewStage("deploying")
 	deployStage.Start()
 
-	instanceManager := d.instanceManagerFactory.NewManager(cloud, vmManager, blobstore)
+	agentClient := d.agentClientFactory.NewAgentClient(options.DirectorID, options.MbusURL)
+	vmManager := d.vmManagerFactory.NewManager(cloud, agentClient)
+	instanceManager := d.instanceManagerFactory.NewManager(cloud, vmManager, options.Blobstore)
+
+	deployment := instanceDeployment{
+		cloud:             cloud,
+		manifest:          deploymentManifest,
+		cloudStemcell:     cloudStemcell,
+		registryConfig:    options.RegistryConfig,
+		sshTunnelConfig:   options.SSHTunnelConfig,
+		directorID:        options.DirectorID,
+		mbusURL:           options.MbusURL,
+		blobstore:         options.Blobstore,
+		stage:             deployStage,
+		pingTimeout:       10 * time.Second,
+		pingDelay:         500 * time.Millisecond,
+		upToDateInstances: map[instanceKey]bminstance.Instance{},
+		renderedJobs:      options.RenderedJobs,
+		rollbackOnFailure: options.RollbackOnFailure,
+	}
+
+	if err = d.deleteFirstInstance(deployment, instanceManager, options.Recreate); err != nil {
+		return nil, err
+	}
 
-	pingTimeout := 10 * time.Second
-	pingDelay := 500 * time.Millisecond
-	if err = instanceManager.DeleteAll(pingTimeout, pingDelay, deployStage); err != nil {
+	if err = d.deleteOtherInstances(deployment, options.Recreate); err != nil {
 		return nil, err
 	}
 
-	instances, disks, err := d.createAllInstances(deploymentManifest, instanceManager, extractedStemcell, cloudStemcell, registryConfig, sshTunnelConfig, deployStage)
+	instances, disks, err := d.createAllInstances(deployment, instanceManager)
 	if err != nil {
 		return nil, err
 	}
@@ -108,43 +158,361 @@ func (d *deployer) Deploy(
 	return d.deploymentFactory.NewDeployment(instances, disks, stemcells), nil
 }
 
+// instanceDeployment is what creating each instance of a deployment needs
+type instanceDeployment struct {
+	cloud           bmcloud.Cloud
+	manifest        bmdeplmanifest.Manifest
+	cloudStemcell   bmstemcell.CloudStemcell
+	registryConfig  bminstallmanifest.Registry
+	sshTunnelConfig bminstallmanifest.SSHTunnel
+	directorID      string
+	mbusURL         string
+	blobstore       bmblobstore.Blobstore
+	stage           bmeventlog.Stage
+	pingTimeout     time.Duration
+	pingDelay       time.Duration
+
+	// upToDateInstances are the instances kept with their VM, whose jobs are only updated
+	upToDateInstances map[instanceKey]bminstance.Instance
+
+	// renderedJobs are the fingerprints of the rendered job templates of the last deploy, by instance name
+	renderedJobs map[string]string
+
+	rollbackOnFailure bool
+}
+
+type instanceKey struct {
+	jobName string
+	index   int
+}
+
+// deleteFirstInstance deletes the VM of the first instance of the first job, unless it is up to date
+func (d *deployer) deleteFirstInstance(
+	deployment instanceDeployment,
+	instanceManager bminstance.Manager,
+	recreate bool,
+) error {
+	if !recreate && len(deployment.manifest.Jobs) > 0 && deployment.manifest.Jobs[0].Instances > 0 {
+		jobName := deployment.manifest.Jobs[0].Name
+		instance, found, err := instanceManager.FindUpToDate(jobName, 0, deployment.manifest, deployment.cloudStemcell)
+		if err != nil {
+			return err
+		}
+		if found {
+			d.logger.Info(d.logTag, "Updating the jobs of instance '%s/0' on its VM", jobName)
+			deployment.upToDateInstances[instanceKey{jobName, 0}] = instance
+			return nil
+		}
+	}
+
+	return instanceManager.DeleteAll(deployment.pingTimeout, deployment.pingDelay, deployment.stage)
+}
+
+// deleteOtherInstances deletes the VMs of the instances after the first one, unless they are up to date,
+// and forgets the instances that are not in the manifest anymore, belong to errands or became the first instance,
+// which leaves their disks unused
+func (d *deployer) deleteOtherInstances(
+	deployment instanceDeployment,
+	recreate bool,
+) error {
+	instanceRecords, err := d.instanceRepo.Others()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding the other instances")
+	}
+
+	for _, instanceRecord := range instanceRecords {
+		instanceManager, err := newOtherInstanceManager(d.vmManagerFactory, d.instanceManagerFactory, d.agentClientFactory, deployment.cloud, instanceRecord, deployment.directorID, deployment.mbusURL, deployment.blobstore)
+		if err != nil {
+			return err
+		}
+
+		job, found := deployment.manifest.FindJobByName(instanceRecord.JobName)
+		inManifest := found && !job.IsErrand() && instanceRecord.Index < job.Instances && !isFirstInstance(deployment.manifest, instanceRecord)
+
+		if inManifest && !recreate {
+			instance, upToDate, err := instanceManager.FindUpToDate(instanceRecord.JobName, instanceRecord.Index, deployment.manifest, deployment.cloudStemcell)
+			if err != nil {
+				return err
+			}
+			if upToDate {
+				d.logger.Info(d.logTag, "Updating the jobs of instance '%s/%d' on its VM", instanceRecord.JobName, instanceRecord.Index)
+				deployment.upToDateInstances[instanceKey{instanceRecord.JobName, instanceRecord.Index}] = instance
+				continue
+			}
+		}
+
+		if err = instanceManager.DeleteAll(deployment.pingTimeout, deployment.pingDelay, deployment.stage); err != nil {
+			return bosherr.WrapErrorf(err, "Deleting instance '%s/%d'", instanceRecord.JobName, instanceRecord.Index)
+		}
+
+		if !inManifest {
+			if err = d.instanceRepo.Delete(instanceRecord.JobName, instanceRecord.Index); err != nil {
+				return bosherr.WrapErrorf(err, "Deleting the record of instance '%s/%d'", instanceRecord.JobName, instanceRecord.Index)
+			}
+		}
+	}
+
+	return nil
+}
+
+// createAllInstances creates (or updates, when up to date) the first instance of the first job, then the other instances job by job,
+// at most update.max_in_flight instances of a job at the same time. Errand jobs are skipped, they only run with run-errand.
 func (d *deployer) createAllInstances(
-	deploymentManifest bmdeplmanifest.Manifest,
+	deployment instanceDeployment,
 	instanceManager bminstance.Manager,
-	extractedStemcell bmstemcell.ExtractedStemcell,
-	cloudStemcell bmstemcell.CloudStemcell,
-	registryConfig bminstallmanifest.Registry,
-	sshTunnelConfig bminstallmanifest.SSHTunnel,
-	deployStage bmeventlog.Stage,
 ) ([]bminstance.Instance, []bmdisk.Disk, error) {
 	instances := []bminstance.Instance{}
 	disks := []bmdisk.Disk{}
 
-	if len(deploymentManifest.Jobs) != 1 {
-		return instances, disks, bosherr.Errorf("There must only be one job, found %d", len(deploymentManifest.Jobs))
-	}
-
-	for _, jobSpec := range deploymentManifest.Jobs {
-		if jobSpec.Instances != 1 {
-			return instances, disks, bosherr.Errorf("Job '%s' must have only one instance, found %d", jobSpec.Name, jobSpec.Instances)
+	for jobIdx, jobSpec := range deployment.manifest.Jobs {
+		if jobSpec.IsErrand() {
+			continue
 		}
-		for instanceID := 0; instanceID < jobSpec.Instances; instanceID++ {
-			instance, instanceDisks, err := instanceManager.Create(jobSpec.Name, instanceID, deploymentManifest, cloudStemcell, registryConfig, sshTunnelConfig, deployStage)
-			if err != nil {
-				return instances, disks, bosherr.WrapErrorf(err, "Creating instance '%s/%d'", jobSpec.Name, instanceID)
+
+		firstIndex := 0
+		if jobIdx == 0 && jobSpec.Instances > 0 {
+			if _, err := d.instanceRepo.SaveFirst(jobSpec.Name, 0); err != nil {
+				return instances, disks, bosherr.WrapErrorf(err, "Saving the record of instance '%s/0'", jobSpec.Name)
 			}
-			instances = append(instances, instance)
-			disks = append(disks, instanceDisks...)
 
-			//TODO: compile packages (on the vm), upload compiled packages (to the blobstore)
-			// instance.CompilePackages
+			instance, instanceDisks, err := d.createOrUpdateInstance(deployment, instanceManager, deployment.sshTunnelConfig, jobSpec.Name, 0)
+			instances, disks = d.appendInstance(instances, disks, instance, instanceDisks)
+			if err != nil {
+				return instances, disks, err
+			}
+			firstIndex = 1
+		}
 
-			err = instance.UpdateJobs(deploymentManifest, extractedStemcell.ApplySpec(), deployStage)
+		otherInstances := []otherInstance{}
+		for index := firstIndex; index < jobSpec.Instances; index++ {
+			other, err := d.prepareOtherInstance(deployment, jobSpec.Name, index)
 			if err != nil {
 				return instances, disks, err
 			}
+			otherInstances = append(otherInstances, other)
+		}
+
+		jobInstances, jobDisks, err := d.createInstancesInParallel(deployment, jobSpec.Name, otherInstances)
+		instances = append(instances, jobInstances...)
+		disks = append(disks, jobDisks...)
+		if err != nil {
+			return instances, disks, err
 		}
 	}
 
 	return instances, disks, nil
 }
+
+// isFirstInstance returns whether the instance is the first instance of the first job of the manifest
+func isFirstInstance(deploymentManifest bmdeplmanifest.Manifest, instanceRecord bmconfig.InstanceRecord) bool {
+	return len(deploymentManifest.Jobs) > 0 && deploymentManifest.Jobs[0].Name == instanceRecord.JobName && instanceRecord.Index == 0
+}
+
+// otherInstance is an instance after the first one, whose agent is reached at its IP
+type otherInstance struct {
+	index           int
+	instanceManager bminstance.Manager
+	sshTunnelConfig bminstallmanifest.SSHTunnel
+}
+
+// prepareOtherInstance records the IP of an instance after the first one,
+// which replaces the host of the mbus URL & of the SSH tunnel
+func (d *deployer) prepareOtherInstance(deployment instanceDeployment, jobName string, index int) (otherInstance, error) {
+	ip, err := deployment.manifest.InstanceIP(jobName, index)
+	if err != nil {
+		return otherInstance{}, bosherr.WrapErrorf(err, "Finding the IP of instance '%s/%d'", jobName, index)
+	}
+
+	instanceRecord, err := d.instanceRepo.Save(jobName, index, ip)
+	if err != nil {
+		return otherInstance{}, bosherr.WrapErrorf(err, "Saving the record of instance '%s/%d'", jobName, index)
+	}
+
+	instanceManager, err := newOtherInstanceManager(d.vmManagerFactory, d.instanceManagerFactory, d.agentClientFactory, deployment.cloud, instanceRecord, deployment.directorID, deployment.mbusURL, deployment.blobstore)
+	if err != nil {
+		return otherInstance{}, err
+	}
+
+	sshTunnelConfig := deployment.sshTunnelConfig
+	if !sshTunnelConfig.IsEmpty() {
+		sshTunnelConfig.Host = ip
+	}
+
+	return otherInstance{index: index, instanceManager: instanceManager, sshTunnelConfig: sshTunnelConfig}, nil
+}
+
+// createInstancesInParallel creates the other instances of a job, at most update.max_in_flight at the same time.
+// It returns the created instances in index order & the first error.
+func (d *deployer) createInstancesInParallel(
+	deployment instanceDeployment,
+	jobName string,
+	otherInstances []otherInstance,
+) ([]bminstance.Instance, []bmdisk.Disk, error) {
+	maxInFlight := deployment.manifest.Update.MaxInFlight
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+
+	results := make([]createInstanceResult, len(otherInstances))
+	inFlight := make(chan struct{}, maxInFlight)
+	var wg sync.WaitGroup
+
+	for i, other := range otherInstances {
+		wg.Add(1)
+		inFlight <- struct{}{}
+		go func(i int, other otherInstance) {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+
+			result := createInstanceResult{}
+			result.instance, result.disks, result.err = d.createOrUpdateInstance(deployment, other.instanceManager, other.sshTunnelConfig, jobName, other.index)
+			results[i] = result
+		}(i, other)
+	}
+	wg.Wait()
+
+	instances := []bminstance.Instance{}
+	disks := []bmdisk.Disk{}
+	var firstErr error
+	for _, result := range results {
+		instances, disks = d.appendInstance(instances, disks, result.instance, result.disks)
+		if result.err != nil && firstErr == nil {
+			firstErr = result.err
+		}
+	}
+
+	return instances, disks, firstErr
+}
+
+type createInstanceResult struct {
+	instance bminstance.Instance
+	disks    []bmdisk.Disk
+	err      error
+}
+
+// createOrUpdateInstance updates the disks & jobs of an up-to-date instance on its VM, or creates the instance
+func (d *deployer) createOrUpdateInstance(
+	deployment instanceDeployment,
+	instanceManager bminstance.Manager,
+	sshTunnelConfig bminstallmanifest.SSHTunnel,
+	jobName string,
+	index int,
+) (bminstance.Instance, []bmdisk.Disk, error) {
+	instance, found := deployment.upToDateInstances[instanceKey{jobName, index}]
+	if !found {
+		return d.createInstance(deployment, instanceManager, sshTunnelConfig, jobName, index)
+	}
+
+	instanceDisks, err := instance.UpdateDisks(deployment.manifest, deployment.stage)
+	if err != nil {
+		return instance, instanceDisks, bosherr.WrapErrorf(err, "Updating disks of instance '%s/%d'", jobName, index)
+	}
+
+	renderedJobsFingerprint := deployment.renderedJobs[InstanceName(jobName, index)]
+	err = instance.UpdateChangedJobs(deployment.manifest, renderedJobsFingerprint, deployment.stage)
+	if err != nil {
+		return d.rollbackInstance(deployment, instanceManager, sshTunnelConfig, jobName, index, instance, instanceDisks, err)
+	}
+
+	return instance, instanceDisks, d.updateSnapshot(deployment, instance)
+}
+
+func (d *deployer) createInstance(
+	deployment instanceDeployment,
+	instanceManager bminstance.Manager,
+	sshTunnelConfig bminstallmanifest.SSHTunnel,
+	jobName string,
+	index int,
+) (bminstance.Instance, []bmdisk.Disk, error) {
+	instance, instanceDisks, err := instanceManager.Create(jobName, index, deployment.manifest, deployment.cloudStemcell, deployment.registryConfig, sshTunnelConfig, deployment.stage)
+	if err != nil {
+		return instance, instanceDisks, bosherr.WrapErrorf(err, "Creating instance '%s/%d'", jobName, index)
+	}
+
+	err = instance.UpdateJobs(deployment.manifest, deployment.stage)
+	if err != nil {
+		return d.rollbackInstance(deployment, instanceManager, sshTunnelConfig, jobName, index, instance, instanceDisks, err)
+	}
+
+	return instance, instanceDisks, d.updateSnapshot(deployment, instance)
+}
+
+// updateSnapshot records the VM & jobs of the instance as the ones to roll back to when a later deploy fails
+func (d *deployer) updateSnapshot(deployment instanceDeployment, instance bminstance.Instance) error {
+	return instance.UpdateSnapshot(deployment.cloudStemcell, deployment.manifest)
+}
+
+// rollbackInstance returns the instance, whose jobs failed to update, to the snapshot of its last successful deploy
+// in a "rolling back" stage, when rollback on failure is set. It returns the update error either way.
+func (d *deployer) rollbackInstance(
+	deployment instanceDeployment,
+	instanceManager bminstance.Manager,
+	sshTunnelConfig bminstallmanifest.SSHTunnel,
+	jobName string,
+	index int,
+	instance bminstance.Instance,
+	instanceDisks []bmdisk.Disk,
+	updateErr error,
+) (bminstance.Instance, []bmdisk.Disk, error) {
+	if !deployment.rollbackOnFailure {
+		return instance, instanceDisks, updateErr
+	}
+
+	d.logger.Warn(d.logTag, "Rolling back instance '%s/%d' after failing to update its jobs: %s", jobName, index, updateErr.Error())
+
+	rollbackStage := d.eventLogger.NewStage("rolling back")
+	rollbackStage.Start()
+
+	rolledBackInstance, rolledBackDisks, err := instanceManager.Rollback(
+		jobName,
+		index,
+		deployment.manifest,
+		deployment.registryConfig,
+		sshTunnelConfig,
+		deployment.pingTimeout,
+		deployment.pingDelay,
+		rollbackStage,
+	)
+	if err != nil {
+		rollbackStage.Fail()
+		return rolledBackInstance, rolledBackDisks, bosherr.WrapErrorf(updateErr, "Rolling back instance '%s/%d' failed: %s", jobName, index, err.Error())
+	}
+
+	rollbackStage.Finish()
+
+	return rolledBackInstance, rolledBackDisks, bosherr.WrapErrorf(updateErr, "Rolled back instance '%s/%d'", jobName, index)
+}
+
+func (d *deployer) appendInstance(
+	instances []bminstance.Instance,
+	disks []bmdisk.Disk,
+	instance bminstance.Instance,
+	instanceDisks []bmdisk.Disk,
+) ([]bminstance.Instance, []bmdisk.Disk) {
+	if instance != nil {
+		instances = append(instances, instance)
+	}
+	return instances, append(disks, instanceDisks...)
+}
+
+// newOtherInstanceManager returns the instance manager of an instance after the first one,
+// whose agent is reached at the mbus URL with the IP of the instance as host
+func newOtherInstanceManager(
+	vmManagerFactory bmvm.ManagerFactory,
+	instanceManagerFactory bminstance.ManagerFactory,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	cloud bmcloud.Cloud,
+	instanceRecord bmconfig.InstanceRecord,
+	directorID string,
+	mbusURL string,
+	blobstore bmblobstore.Blobstore,
+) (bminstance.Manager, error) {
+	instanceMbusURL, err := bmhttpagent.InstanceMbusURL(mbusURL, instanceRecord.IP)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Building the mbus URL of instance '%s/%d'", instanceRecord.JobName, instanceRecord.Index)
+	}
+
+	agentClient := agentClientFactory.NewAgentClient(directorID, instanceMbusURL)
+	vmManager := vmManagerFactory.NewInstanceManager(cloud, agentClient, instanceRecord.JobName, instanceRecord.Index)
+	return instanceManagerFactory.NewManager(cloud, vmManager, blobstore), nil
+}
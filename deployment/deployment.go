@@ -13,6 +13,12 @@ import (
 
 type Deployment interface {
 	Delete(bmeventlog.Stage) error
+	RenderedJobFingerprints() map[string]string
+}
+
+// InstanceName identifies an instance of a job in the deployment state, e.g. "job/0"
+func InstanceName(jobName string, index int) string {
+	return fmt.Sprintf("%s/%d", jobName, index)
 }
 
 type deployment struct {
@@ -39,6 +45,16 @@ func NewDeployment(
 	}
 }
 
+// RenderedJobFingerprints returns the fingerprints of the rendered job templates running on the instances,
+// by instance name
+func (d *deployment) RenderedJobFingerprints() map[string]string {
+	fingerprints := map[string]string{}
+	for _, instance := range d.instances {
+		fingerprints[InstanceName(instance.JobName(), instance.ID())] = instance.RenderedJobsFingerprint()
+	}
+	return fingerprints
+}
+
 func (d *deployment) Delete(deleteStage bmeventlog.Stage) error {
 	// le sigh... consuming from an array sucks without generics
 	for len(d.instances) > 0 {
@@ -11,6 +11,7 @@ import (
 	"code.google.com/p/gomock/gomock"
 	mock_blobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore/mocks"
 	mock_cloud "github.com/cloudfoundry/bosh-micro-cli/cloud/mocks"
+	mock_httpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http/mocks"
 	mock_agentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/mocks"
 	mock_instance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance/mocks"
 
@@ -57,8 +58,9 @@ var _ = Describe("Deployment", func() {
 			diskRepo                bmconfig.DiskRepo
 			stemcellRepo            bmconfig.StemcellRepo
 
-			mockCloud       *mock_cloud.MockCloud
-			mockAgentClient *mock_agentclient.MockAgentClient
+			mockCloud              *mock_cloud.MockCloud
+			mockAgentClient        *mock_agentclient.MockAgentClient
+			mockAgentClientFactory *mock_httpagent.MockAgentClientFactory
 
 			mockStateBuilderFactory *mock_instance.MockStateBuilderFactory
 			mockStateBuilder        *mock_instance.MockStateBuilder
@@ -121,8 +123,8 @@ var _ = Describe("Deployment", func() {
 				ConfigurationHash:        "",
 			}
 
-			mockStateBuilderFactory.EXPECT().NewStateBuilder(mockBlobstore).Return(mockStateBuilder).AnyTimes()
-			mockStateBuilder.EXPECT().Build(jobName, jobIndex, gomock.Any(), gomock.Any()).Return(mockState, nil).AnyTimes()
+			mockStateBuilderFactory.EXPECT().NewStateBuilder(mockBlobstore, gomock.Any(), gomock.Any()).Return(mockStateBuilder).AnyTimes()
+			mockStateBuilder.EXPECT().Build(jobName, jobIndex, gomock.Any()).Return(mockState, nil).AnyTimes()
 			mockState.EXPECT().ToApplySpec().Return(applySpec).AnyTimes()
 		}
 
@@ -140,6 +142,8 @@ var _ = Describe("Deployment", func() {
 
 			mockCloud = mock_cloud.NewMockCloud(mockCtrl)
 			mockAgentClient = mock_agentclient.NewMockAgentClient(mockCtrl)
+			mockAgentClientFactory = mock_httpagent.NewMockAgentClientFactory(mockCtrl)
+			mockAgentClientFactory.EXPECT().NewAgentClient("fake-director-id", "https://fake-mbus-host:6868").Return(mockAgentClient).AnyTimes()
 
 			fakeStage = fakebmeventlog.NewFakeStage()
 
@@ -153,7 +157,7 @@ var _ = Describe("Deployment", func() {
 			diskManagerFactory := bmdisk.NewManagerFactory(diskRepo, logger)
 			diskDeployer := bmvm.NewDiskDeployer(diskManagerFactory, diskRepo, logger)
 
-			vmManagerFactory := bmvm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+			vmManagerFactory := bmvm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, deploymentConfigService, fakeUUIDGenerator, fs, logger)
 			sshTunnelFactory := bmsshtunnel.NewFactory(logger)
 
 			mockStateBuilderFactory = mock_instance.NewMockStateBuilderFactory(mockCtrl)
@@ -166,8 +170,8 @@ var _ = Describe("Deployment", func() {
 
 			mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
 
-			deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceManagerFactory, diskManagerFactory, stemcellManagerFactory, deploymentFactory)
-			deploymentManager := deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore)
+			deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceManagerFactory, diskManagerFactory, stemcellManagerFactory, deploymentFactory, mockAgentClientFactory, bmconfig.NewInstanceRepo(deploymentConfigService))
+			deploymentManager := deploymentManagerFactory.NewManager(mockCloud, "fake-director-id", "https://fake-mbus-host:6868", mockBlobstore)
 
 			allowApplySpecToBeCreated()
 
@@ -183,9 +187,8 @@ var _ = Describe("Deployment", func() {
 				deploymentConfigService.Save(bmconfig.DeploymentFile{
 					DirectorID:        "fake-director-id",
 					InstallationID:    "fake-installation-id",
-					CurrentVMCID:      "fake-vm-cid",
 					CurrentStemcellID: "fake-stemcell-guid",
-					CurrentDiskID:     "fake-disk-guid",
+					Instances:         []bmconfig.InstanceRecord{{VMCID: "fake-vm-cid", DiskID: "fake-disk-guid"}},
 					Disks: []bmconfig.DiskRecord{
 						{
 							ID:   "fake-disk-guid",
@@ -0,0 +1,127 @@
+package dryrun
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	bmac "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmas "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
+)
+
+// agentClientFactory returns recording agent clients, each listing the disks of the instance reached at its mbus URL
+type agentClientFactory struct {
+	recorder     *recorder
+	diskCIDs     []string
+	diskCIDsByIP map[string][]string
+}
+
+func newAgentClientFactory(recorder *recorder, diskCIDs []string, diskCIDsByIP map[string][]string) bmhttpagent.AgentClientFactory {
+	return &agentClientFactory{
+		recorder:     recorder,
+		diskCIDs:     diskCIDs,
+		diskCIDsByIP: diskCIDsByIP,
+	}
+}
+
+func (f *agentClientFactory) NewAgentClient(directorID, mbusURL string) bmac.AgentClient {
+	if diskCIDs, found := f.diskCIDsByIP[mbusHost(mbusURL)]; found {
+		return newAgentClient(f.recorder, diskCIDs)
+	}
+	return newAgentClient(f.recorder, f.diskCIDs)
+}
+
+// mbusHost returns the host of the mbus URL, without its port
+func mbusHost(mbusURL string) string {
+	parsedURL, err := url.Parse(mbusURL)
+	if err != nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(parsedURL.Host)
+	if err != nil {
+		return parsedURL.Host
+	}
+	return host
+}
+
+// agentClient records the agent requests that would change the VM, without sending them.
+// The agent is assumed to be responsive, with its jobs running.
+type agentClient struct {
+	recorder *recorder
+	diskCIDs []string
+}
+
+func newAgentClient(recorder *recorder, diskCIDs []string) bmac.AgentClient {
+	return &agentClient{
+		recorder: recorder,
+		diskCIDs: diskCIDs,
+	}
+}
+
+func (c *agentClient) Ping() (string, error) {
+	return "pong", nil
+}
+
+func (c *agentClient) Stop() error {
+	c.recorder.Record("agent: stop")
+	return nil
+}
+
+func (c *agentClient) Apply(applySpec bmas.ApplySpec) error {
+	jobs := []string{}
+	for _, template := range applySpec.Job.Templates {
+		jobs = append(jobs, template.Name+"/"+template.Version)
+	}
+	c.recorder.Record("agent: apply job '%s' with release jobs [%s]", applySpec.Job.Name, strings.Join(jobs, ", "))
+	return nil
+}
+
+func (c *agentClient) Start() error {
+	c.recorder.Record("agent: start")
+	return nil
+}
+
+func (c *agentClient) GetState() (bmac.AgentState, error) {
+	return bmac.AgentState{JobState: "running"}, nil
+}
+
+func (c *agentClient) MountDisk(diskCID string) error {
+	c.recorder.Record("agent: mount_disk '%s'", diskCID)
+	return nil
+}
+
+func (c *agentClient) UnmountDisk(diskCID string) error {
+	c.recorder.Record("agent: unmount_disk '%s'", diskCID)
+	return nil
+}
+
+func (c *agentClient) ListDisk() ([]string, error) {
+	return c.diskCIDs, nil
+}
+
+func (c *agentClient) MigrateDisk() error {
+	c.recorder.Record("agent: migrate_disk")
+	return nil
+}
+
+func (c *agentClient) FetchLogs(logType string, filters []string) (string, error) {
+	c.recorder.Record("agent: fetch_logs '%s'", logType)
+	return "<fetched-logs>", nil
+}
+
+func (c *agentClient) CompilePackage(packageSource bmac.BlobRef, compiledPackageDependencies []bmac.BlobRef) (bmac.BlobRef, error) {
+	c.recorder.Record("agent: compile_package '%s/%s'", packageSource.Name, packageSource.Version)
+	return bmac.BlobRef{
+		Name:        packageSource.Name,
+		Version:     packageSource.Version,
+		SHA1:        "<compiled-package-sha1>",
+		BlobstoreID: "<compiled-package-blob>",
+	}, nil
+}
+
+func (c *agentClient) RunErrand() (bmac.ErrandResult, error) {
+	c.recorder.Record("agent: run_errand")
+	return bmac.ErrandResult{}, nil
+}
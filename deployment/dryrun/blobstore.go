@@ -0,0 +1,26 @@
+package dryrun
+
+import (
+	bmblobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
+)
+
+// blobstore records the blobs that would be uploaded to the agent's blobstore, without uploading them.
+type blobstore struct {
+	recorder *recorder
+}
+
+func newBlobstore(recorder *recorder) bmblobstore.Blobstore {
+	return &blobstore{
+		recorder: recorder,
+	}
+}
+
+func (b *blobstore) Get(blobID, destinationPath string) error {
+	b.recorder.Record("blobstore: get '%s'", blobID)
+	return nil
+}
+
+func (b *blobstore) Save(sourcePath, blobID string) error {
+	b.recorder.Record("blobstore: save '%s'", blobID)
+	return nil
+}
@@ -0,0 +1,87 @@
+package dryrun
+
+import (
+	"fmt"
+
+	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+)
+
+// cloud records the CPI calls that would be made, without calling the CPI.
+// Created resources are given placeholder CIDs, and all VMs are assumed to exist.
+type cloud struct {
+	recorder *recorder
+	counts   map[string]int
+}
+
+func newCloud(recorder *recorder) bmcloud.Cloud {
+	return &cloud{
+		recorder: recorder,
+		counts:   map[string]int{},
+	}
+}
+
+func (c *cloud) CreateStemcell(imagePath string, cloudProperties map[string]interface{}) (string, error) {
+	cid := c.newCID("stemcell")
+	c.recorder.Record("cloud: create_stemcell -> '%s'", cid)
+	return cid, nil
+}
+
+func (c *cloud) DeleteStemcell(stemcellCID string) error {
+	c.recorder.Record("cloud: delete_stemcell '%s'", stemcellCID)
+	return nil
+}
+
+func (c *cloud) HasVM(vmCID string) (bool, error) {
+	return true, nil
+}
+
+func (c *cloud) CreateVM(
+	agentID string,
+	stemcellCID string,
+	cloudProperties map[string]interface{},
+	networksInterfaces map[string]map[string]interface{},
+	env map[string]interface{},
+) (string, error) {
+	cid := c.newCID("vm")
+	c.recorder.Record("cloud: create_vm from stemcell '%s' -> '%s'", stemcellCID, cid)
+	return cid, nil
+}
+
+func (c *cloud) DeleteVM(vmCID string) error {
+	c.recorder.Record("cloud: delete_vm '%s'", vmCID)
+	return nil
+}
+
+func (c *cloud) CreateDisk(size int, cloudProperties map[string]interface{}, vmCID string) (string, error) {
+	cid := c.newCID("disk")
+	c.recorder.Record("cloud: create_disk of size %d for VM '%s' -> '%s'", size, vmCID, cid)
+	return cid, nil
+}
+
+func (c *cloud) AttachDisk(vmCID, diskCID string) error {
+	c.recorder.Record("cloud: attach_disk '%s' to VM '%s'", diskCID, vmCID)
+	return nil
+}
+
+func (c *cloud) DetachDisk(vmCID, diskCID string) error {
+	c.recorder.Record("cloud: detach_disk '%s' from VM '%s'", diskCID, vmCID)
+	return nil
+}
+
+func (c *cloud) DeleteDisk(diskCID string) error {
+	c.recorder.Record("cloud: delete_disk '%s'", diskCID)
+	return nil
+}
+
+func (c *cloud) GetDisks(vmCID string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (c *cloud) String() string {
+	return "DryRunCloud{}"
+}
+
+func (c *cloud) newCID(resource string) string {
+	c.counts[resource]++
+	return fmt.Sprintf("<new-%s-%d>", resource, c.counts[resource])
+}
@@ -0,0 +1,75 @@
+package dryrun
+
+import (
+	"fmt"
+
+	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+)
+
+// Plan is the ordered list of steps a deploy would perform,
+// along with the CPI & agent calls each step would make.
+type Plan struct {
+	Steps []Step
+}
+
+type Step struct {
+	Stage string
+	Name  string
+
+	// SkipReason is set when the step would be skipped (e.g. the stemcell is already uploaded)
+	SkipReason string
+
+	Actions []string
+}
+
+func (s Step) Skipped() bool {
+	return s.SkipReason != ""
+}
+
+// recorder is an EventLogger that builds a Plan from the steps performed by the deployer
+type recorder struct {
+	plan Plan
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		plan: Plan{Steps: []Step{}},
+	}
+}
+
+func (r *recorder) NewStage(name string) bmeventlog.Stage {
+	return bmeventlog.NewStage(name, r)
+}
+
+func (r *recorder) AddEvent(event bmeventlog.Event) error {
+	switch event.State {
+	case bmeventlog.Started:
+		r.plan.Steps = append(r.plan.Steps, Step{
+			Stage:   event.Stage,
+			Name:    event.Task,
+			Actions: []string{},
+		})
+	case bmeventlog.Skipped:
+		r.currentStep().SkipReason = event.Message
+	}
+	return nil
+}
+
+func (r *recorder) StartStage(string) {}
+
+func (r *recorder) FinishStage(string) {}
+
+func (r *recorder) FailStage(string) {}
+
+// Record adds an action to the step currently being performed
+func (r *recorder) Record(format string, args ...interface{}) {
+	step := r.currentStep()
+	step.Actions = append(step.Actions, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) currentStep() *Step {
+	if len(r.plan.Steps) == 0 {
+		r.plan.Steps = append(r.plan.Steps, Step{Actions: []string{}})
+	}
+	return &r.plan.Steps[len(r.plan.Steps)-1]
+}
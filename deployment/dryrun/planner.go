@@ -0,0 +1,138 @@
+package dryrun
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmdepl "github.com/cloudfoundry/bosh-micro-cli/deployment"
+	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
+	bminstance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmsshtunnel "github.com/cloudfoundry/bosh-micro-cli/deployment/sshtunnel"
+	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
+	bmvm "github.com/cloudfoundry/bosh-micro-cli/deployment/vm"
+)
+
+// dryRunMbusURL is the mbus URL of the first instance, the other instances are reached with their IP as host
+const dryRunMbusURL = "https://dry-run:6868"
+
+type Planner interface {
+	Plan(deploymentConfig bmconfig.DeploymentFile, deploymentManifest bmdeplmanifest.Manifest, extractedStemcell bmstemcell.ExtractedStemcell, recreate bool, renderedJobs map[string]string) (Plan, error)
+}
+
+type planner struct {
+	instanceFactory  bminstance.Factory
+	sshTunnelFactory bmsshtunnel.Factory
+	uuidGenerator    boshuuid.Generator
+	fs               boshsys.FileSystem
+	logger           boshlog.Logger
+	logTag           string
+}
+
+// NewPlanner returns a Planner that runs the deployer against a recording cloud, agent & blobstore.
+// Deployment state changes are kept in memory, so the deployment config is never modified.
+func NewPlanner(
+	instanceFactory bminstance.Factory,
+	sshTunnelFactory bmsshtunnel.Factory,
+	uuidGenerator boshuuid.Generator,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) Planner {
+	return &planner{
+		instanceFactory:  instanceFactory,
+		sshTunnelFactory: sshTunnelFactory,
+		uuidGenerator:    uuidGenerator,
+		fs:               fs,
+		logger:           logger,
+		logTag:           "dryRunPlanner",
+	}
+}
+
+// Plan returns the steps performed so far, even if planning fails.
+func (p *planner) Plan(
+	deploymentConfig bmconfig.DeploymentFile,
+	deploymentManifest bmdeplmanifest.Manifest,
+	extractedStemcell bmstemcell.ExtractedStemcell,
+	recreate bool,
+	renderedJobs map[string]string,
+) (Plan, error) {
+	recorder := newRecorder()
+
+	deploymentConfigService := bmconfig.NewInMemoryDeploymentConfigService(deploymentConfig)
+	vmRepo := bmconfig.NewVMRepo(deploymentConfigService)
+	diskRepo := bmconfig.NewDiskRepo(deploymentConfigService, p.uuidGenerator)
+	stemcellRepo := bmconfig.NewStemcellRepo(deploymentConfigService, p.uuidGenerator)
+
+	currentDiskCIDs := []string{}
+	diskRecord, found, err := diskRepo.FindCurrent()
+	if err != nil {
+		return recorder.plan, bosherr.WrapError(err, "Finding current disk record")
+	}
+	if found {
+		currentDiskCIDs = append(currentDiskCIDs, diskRecord.CID)
+	}
+
+	// the agents of the other instances are reached at their IP, and list the disks of their instance
+	instanceRepo := bmconfig.NewInstanceRepo(deploymentConfigService)
+	instanceRecords, err := instanceRepo.Others()
+	if err != nil {
+		return recorder.plan, bosherr.WrapError(err, "Finding the other instances")
+	}
+	diskCIDsByIP := map[string][]string{}
+	for _, instanceRecord := range instanceRecords {
+		instanceDiskRepo := bmconfig.NewInstanceDiskRepo(deploymentConfigService, p.uuidGenerator, instanceRecord.JobName, instanceRecord.Index)
+		diskRecord, found, err := instanceDiskRepo.FindCurrent()
+		if err != nil {
+			return recorder.plan, bosherr.WrapErrorf(err, "Finding the current disk record of instance '%s/%d'", instanceRecord.JobName, instanceRecord.Index)
+		}
+
+		diskCIDsByIP[instanceRecord.IP] = []string{}
+		if found {
+			diskCIDsByIP[instanceRecord.IP] = []string{diskRecord.CID}
+		}
+	}
+
+	diskDeployer := bmvm.NewDiskDeployer(bmdisk.NewManagerFactory(diskRepo, p.logger), diskRepo, p.logger)
+	vmManagerFactory := bmvm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, deploymentConfigService, p.uuidGenerator, p.fs, p.logger)
+
+	deployer := bmdepl.NewDeployer(
+		bmstemcell.NewManagerFactory(stemcellRepo),
+		vmManagerFactory,
+		bminstance.NewManagerFactory(p.sshTunnelFactory, p.instanceFactory, p.logger),
+		bmdepl.NewFactory(0, 0),
+		newAgentClientFactory(recorder, currentDiskCIDs, diskCIDsByIP),
+		instanceRepo,
+		recorder,
+		p.logger,
+	)
+
+	cloud := newCloud(recorder)
+
+	// the recording agent reports running jobs immediately, so there is nothing to wait for
+	deploymentManifest.Update.UpdateWatchTime = bmdeplmanifest.WatchTime{Start: 0, End: 1000}
+
+	// one instance at a time keeps the steps in order
+	deploymentManifest.Update.MaxInFlight = 1
+
+	p.logger.Debug(p.logTag, "Planning deploy of '%s'", deploymentManifest.Name)
+	_, err = deployer.Deploy(
+		cloud,
+		deploymentManifest,
+		extractedStemcell,
+		bmdepl.DeployOptions{
+			DirectorID:   deploymentConfig.DirectorID,
+			MbusURL:      dryRunMbusURL,
+			Blobstore:    newBlobstore(recorder),
+			Recreate:     recreate,
+			RenderedJobs: renderedJobs,
+		},
+	)
+	if err != nil {
+		return recorder.plan, bosherr.WrapError(err, "Planning deploy")
+	}
+
+	return recorder.plan, nil
+}
@@ -0,0 +1,266 @@
+package dryrun_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/deployment/dryrun"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.google.com/p/gomock/gomock"
+	mock_instance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance/mocks"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-agent/uuid/fakes"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmas "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
+	bminstance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmsshtunnel "github.com/cloudfoundry/bosh-micro-cli/deployment/sshtunnel"
+	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
+)
+
+var _ = Describe("Planner", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	var (
+		mockStateBuilderFactory *mock_instance.MockStateBuilderFactory
+		mockStateBuilder        *mock_instance.MockStateBuilder
+		mockState               *mock_instance.MockState
+
+		deploymentManifest bmdeplmanifest.Manifest
+		extractedStemcell  bmstemcell.ExtractedStemcell
+
+		planner Planner
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := fakesys.NewFakeFileSystem()
+
+		mockStateBuilderFactory = mock_instance.NewMockStateBuilderFactory(mockCtrl)
+		mockStateBuilder = mock_instance.NewMockStateBuilder(mockCtrl)
+		mockState = mock_instance.NewMockState(mockCtrl)
+
+		mockStateBuilderFactory.EXPECT().NewStateBuilder(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockStateBuilder).AnyTimes()
+		mockStateBuilder.EXPECT().Build("fake-job-name", 0, gomock.Any()).Return(mockState, nil).AnyTimes()
+		mockStateBuilder.EXPECT().Build("fake-job-name", 1, gomock.Any()).Return(mockState, nil).AnyTimes()
+		mockState.EXPECT().ToApplySpec().Return(bmas.ApplySpec{
+			Job: bmas.Job{
+				Name: "fake-job-name",
+				Templates: []bmas.Blob{
+					{Name: "fake-release-job-name", Version: "fake-release-job-version"},
+				},
+			},
+		}).AnyTimes()
+
+		planner = NewPlanner(
+			bminstance.NewFactory(mockStateBuilderFactory),
+			bmsshtunnel.NewFactory(logger),
+			&fakeuuid.FakeGenerator{GeneratedUuid: "fake-uuid"},
+			fs,
+			logger,
+		)
+
+		deploymentManifest = bmdeplmanifest.Manifest{
+			Name: "fake-deployment-name",
+			Jobs: []bmdeplmanifest.Job{
+				{
+					Name:           "fake-job-name",
+					Instances:      1,
+					PersistentDisk: 1024,
+				},
+			},
+			ResourcePools: []bmdeplmanifest.ResourcePool{
+				{Name: "fake-resource-pool-name"},
+			},
+			Update: bmdeplmanifest.Update{
+				UpdateWatchTime: bmdeplmanifest.WatchTime{Start: 60000, End: 600000},
+			},
+		}
+
+		extractedStemcell = bmstemcell.NewExtractedStemcell(
+			bmstemcell.Manifest{
+				ImagePath:          "fake-image-path",
+				Name:               "fake-stemcell-name",
+				Version:            "fake-stemcell-version",
+				RawCloudProperties: map[interface{}]interface{}{},
+			},
+			bmstemcell.ApplySpec{},
+			"fake-extracted-path",
+			fs,
+		)
+	})
+
+	stepNames := func(plan Plan) []string {
+		names := []string{}
+		for _, step := range plan.Steps {
+			names = append(names, step.Stage+" > "+step.Name)
+		}
+		return names
+	}
+
+	Context("when nothing is deployed", func() {
+		It("plans to upload the stemcell and create the VM & disk", func() {
+			plan, err := planner.Plan(bmconfig.DeploymentFile{}, deploymentManifest, extractedStemcell, false, map[string]string{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(stepNames(plan)).To(Equal([]string{
+				"uploading stemcell > Uploading",
+				"deploying > Creating VM for instance 'fake-job-name/0' from stemcell '<new-stemcell-1>'",
+				"deploying > Waiting for the agent on VM '<new-vm-1>' to be ready",
+				"deploying > Creating disk",
+				"deploying > Attaching disk '<new-disk-1>' to VM '<new-vm-1>'",
+				"deploying > Updating instance 'fake-job-name/0'",
+				"deploying > Waiting for instance 'fake-job-name/0' to be running",
+			}))
+
+			Expect(plan.Steps[0].Actions).To(Equal([]string{"cloud: create_stemcell -> '<new-stemcell-1>'"}))
+			Expect(plan.Steps[1].Actions).To(Equal([]string{"cloud: create_vm from stemcell '<new-stemcell-1>' -> '<new-vm-1>'"}))
+			Expect(plan.Steps[3].Actions).To(Equal([]string{"cloud: create_disk of size 1024 for VM '<new-vm-1>' -> '<new-disk-1>'"}))
+			Expect(plan.Steps[4].Actions).To(Equal([]string{
+				"cloud: attach_disk '<new-disk-1>' to VM '<new-vm-1>'",
+				"agent: mount_disk '<new-disk-1>'",
+			}))
+			Expect(plan.Steps[5].Actions).To(Equal([]string{
+				"agent: stop",
+				"agent: apply job 'fake-job-name' with release jobs [fake-release-job-name/fake-release-job-version]",
+				"agent: start",
+			}))
+		})
+	})
+
+	Context("when the stemcell, VM and disk are already deployed", func() {
+		var deploymentConfig bmconfig.DeploymentFile
+
+		BeforeEach(func() {
+			deploymentConfig = bmconfig.DeploymentFile{
+				CurrentStemcellID: "fake-stemcell-id",
+				Instances:         []bmconfig.InstanceRecord{{VMCID: "fake-vm-cid", DiskID: "fake-disk-id"}},
+				Stemcells: []bmconfig.StemcellRecord{
+					{
+						ID:      "fake-stemcell-id",
+						Name:    "fake-stemcell-name",
+						Version: "fake-stemcell-version",
+						CID:     "fake-stemcell-cid",
+					},
+				},
+				Disks: []bmconfig.DiskRecord{
+					{
+						ID:              "fake-disk-id",
+						CID:             "fake-disk-cid",
+						Size:            1024,
+						CloudProperties: map[string]interface{}{},
+					},
+				},
+			}
+		})
+
+		It("plans to reuse the stemcell, recreate the VM and keep the disk", func() {
+			plan, err := planner.Plan(deploymentConfig, deploymentManifest, extractedStemcell, false, map[string]string{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(stepNames(plan)).To(Equal([]string{
+				"uploading stemcell > Uploading",
+				"deploying > Waiting for the agent on VM 'fake-vm-cid'",
+				"deploying > Stopping jobs on instance 'unknown/0'",
+				"deploying > Unmounting disk 'fake-disk-cid'",
+				"deploying > Deleting VM 'fake-vm-cid'",
+				"deploying > Creating VM for instance 'fake-job-name/0' from stemcell 'fake-stemcell-cid'",
+				"deploying > Waiting for the agent on VM '<new-vm-1>' to be ready",
+				"deploying > Attaching disk 'fake-disk-cid' to VM '<new-vm-1>'",
+				"deploying > Updating instance 'fake-job-name/0'",
+				"deploying > Waiting for instance 'fake-job-name/0' to be running",
+			}))
+
+			Expect(plan.Steps[0].Skipped()).To(BeTrue())
+			Expect(plan.Steps[0].SkipReason).To(Equal("Stemcell already uploaded"))
+			Expect(plan.Steps[0].Actions).To(BeEmpty())
+			Expect(plan.Steps[4].Actions).To(Equal([]string{"cloud: delete_vm 'fake-vm-cid'"}))
+		})
+
+		Context("when the disk size changes", func() {
+			BeforeEach(func() {
+				deploymentManifest.Jobs[0].PersistentDisk = 2048
+			})
+
+			It("plans to migrate the disk", func() {
+				plan, err := planner.Plan(deploymentConfig, deploymentManifest, extractedStemcell, false, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(stepNames(plan)).To(ContainElement("deploying > Migrating disk content from 'fake-disk-cid' to '<new-disk-1>'"))
+				Expect(stepNames(plan)).To(ContainElement("deploying > Deleting disk 'fake-disk-cid'"))
+			})
+		})
+
+		Context("when there is an other instance", func() {
+			BeforeEach(func() {
+				deploymentManifest.Jobs[0].Instances = 2
+				deploymentManifest.Jobs[0].Networks = []bmdeplmanifest.JobNetwork{
+					{Name: "fake-network-name", StaticIPs: []string{"10.0.0.10", "10.0.0.11"}},
+				}
+
+				deploymentConfig.Instances = append(deploymentConfig.Instances, bmconfig.InstanceRecord{
+					JobName: "fake-job-name",
+					Index:   1,
+					IP:      "10.0.0.11",
+					VMCID:   "fake-other-vm-cid",
+					DiskID:  "fake-other-disk-id",
+				})
+				deploymentConfig.Disks = append(deploymentConfig.Disks, bmconfig.DiskRecord{
+					ID:              "fake-other-disk-id",
+					CID:             "fake-other-disk-cid",
+					Size:            1024,
+					CloudProperties: map[string]interface{}{},
+				})
+			})
+
+			It("plans to move the disk of each instance to its new VM", func() {
+				plan, err := planner.Plan(deploymentConfig, deploymentManifest, extractedStemcell, false, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(stepNames(plan)).To(ContainElement("deploying > Unmounting disk 'fake-disk-cid'"))
+				Expect(stepNames(plan)).To(ContainElement("deploying > Unmounting disk 'fake-other-disk-cid'"))
+				Expect(stepNames(plan)).To(ContainElement("deploying > Attaching disk 'fake-disk-cid' to VM '<new-vm-1>'"))
+				Expect(stepNames(plan)).To(ContainElement("deploying > Attaching disk 'fake-other-disk-cid' to VM '<new-vm-2>'"))
+			})
+		})
+
+		It("does not modify the given deployment config", func() {
+			_, err := planner.Plan(deploymentConfig, deploymentManifest, extractedStemcell, false, map[string]string{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deploymentConfig.FirstInstance().VMCID).To(Equal("fake-vm-cid"))
+			Expect(deploymentConfig.Disks).To(HaveLen(1))
+		})
+	})
+
+	Context("when the deployer fails", func() {
+		BeforeEach(func() {
+			deploymentManifest.Jobs[0].Instances = 2
+		})
+
+		It("returns the steps planned so far", func() {
+			plan, err := planner.Plan(bmconfig.DeploymentFile{}, deploymentManifest, extractedStemcell, false, map[string]string{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("has no static IP for instance 1"))
+			Expect(stepNames(plan)).To(Equal([]string{
+				"uploading stemcell > Uploading",
+				"deploying > Creating VM for instance 'fake-job-name/0' from stemcell '<new-stemcell-1>'",
+				"deploying > Waiting for the agent on VM '<new-vm-1>' to be ready",
+				"deploying > Creating disk",
+				"deploying > Attaching disk '<new-disk-1>' to VM '<new-vm-1>'",
+				"deploying > Updating instance 'fake-job-name/0'",
+				"deploying > Waiting for instance 'fake-job-name/0' to be running",
+			}))
+		})
+	})
+})
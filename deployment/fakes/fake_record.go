@@ -5,33 +5,41 @@ import (
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 
+	bmdepl "github.com/cloudfoundry/bosh-micro-cli/deployment"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
 	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
 	bmtestutils "github.com/cloudfoundry/bosh-micro-cli/testutils"
 )
 
 type FakeRecord struct {
-	IsDeployedInputs   []IsDeployedInput
-	isDeployedBehavior map[string]isDeployedOutput
+	ChangesInputs   []ChangesInput
+	changesBehavior map[string]changesOutput
 
 	UpdateInputs   []UpdateInput
 	updateBehavior map[string]updateOutput
 }
 
-type IsDeployedInput struct {
-	ManifestPath string
-	Release      bmrel.Release
-	Stemcell     bmstemcell.ExtractedStemcell
+type ChangesInput struct {
+	Manifest             bmdeplmanifest.Manifest
+	InstallationManifest bminstallmanifest.Manifest
+	Releases             []bmrel.Release
+	Stemcell             bmstemcell.ExtractedStemcell
 }
 
-type isDeployedOutput struct {
-	isDeployed bool
-	err        error
+type changesOutput struct {
+	changes bmdepl.Changes
+	err     error
 }
 
 type UpdateInput struct {
-	ManifestPath string
-	Release      bmrel.Release
+	Manifest             bmdeplmanifest.Manifest
+	InstallationManifest bminstallmanifest.Manifest
+	Releases             []bmrel.Release
+	CPIRelease           bmrel.Release
+	Stemcell             bmstemcell.ExtractedStemcell
+	RenderedJobs         map[string]string
 }
 
 type updateOutput struct {
@@ -40,36 +48,53 @@ type updateOutput struct {
 
 func NewFakeRecord() *FakeRecord {
 	return &FakeRecord{
-		isDeployedBehavior: make(map[string]isDeployedOutput),
-		updateBehavior:     make(map[string]updateOutput),
+		changesBehavior: make(map[string]changesOutput),
+		updateBehavior:  make(map[string]updateOutput),
 	}
 }
 
-func (r *FakeRecord) IsDeployed(manifestPath string, release bmrel.Release, stemcell bmstemcell.ExtractedStemcell) (bool, error) {
-	input := IsDeployedInput{
-		ManifestPath: manifestPath,
-		Release:      release,
-		Stemcell:     stemcell,
+func (r *FakeRecord) Changes(
+	deploymentManifest bmdeplmanifest.Manifest,
+	installationManifest bminstallmanifest.Manifest,
+	releases []bmrel.Release,
+	stemcell bmstemcell.ExtractedStemcell,
+) (bmdepl.Changes, error) {
+	input := ChangesInput{
+		Manifest:             deploymentManifest,
+		InstallationManifest: installationManifest,
+		Releases:             releases,
+		Stemcell:             stemcell,
 	}
-	r.IsDeployedInputs = append(r.IsDeployedInputs, input)
+	r.ChangesInputs = append(r.ChangesInputs, input)
 
 	inputString, marshalErr := bmtestutils.MarshalToString(input)
 	if marshalErr != nil {
-		return false, bosherr.WrapError(marshalErr, "Marshaling IsDeployed input")
+		return bmdepl.Changes{}, bosherr.WrapError(marshalErr, "Marshaling Changes input")
 	}
 
-	output, found := r.isDeployedBehavior[inputString]
+	output, found := r.changesBehavior[inputString]
 	if !found {
-		return false, fmt.Errorf("Unsupported IsDeployed Input: %s\nExpected: %#v", inputString, r.isDeployedBehavior)
+		return bmdepl.Changes{}, fmt.Errorf("Unsupported Changes Input: %s\nExpected: %#v", inputString, r.changesBehavior)
 	}
 
-	return output.isDeployed, output.err
+	return output.changes, output.err
 }
 
-func (r *FakeRecord) Update(manifestPath string, release bmrel.Release) error {
+func (r *FakeRecord) Update(
+	deploymentManifest bmdeplmanifest.Manifest,
+	installationManifest bminstallmanifest.Manifest,
+	releases []bmrel.Release,
+	cpiRelease bmrel.Release,
+	stemcell bmstemcell.ExtractedStemcell,
+	renderedJobs map[string]string,
+) error {
 	input := UpdateInput{
-		ManifestPath: manifestPath,
-		Release:      release,
+		Manifest:             deploymentManifest,
+		InstallationManifest: installationManifest,
+		Releases:             releases,
+		CPIRelease:           cpiRelease,
+		Stemcell:             stemcell,
+		RenderedJobs:         renderedJobs,
 	}
 	r.UpdateInputs = append(r.UpdateInputs, input)
 
@@ -86,40 +111,50 @@ func (r *FakeRecord) Update(manifestPath string, release bmrel.Release) error {
 	return output.err
 }
 
-func (r *FakeRecord) SetIsDeployedBehavior(
-	manifestPath string,
-	release bmrel.Release,
+func (r *FakeRecord) SetChangesBehavior(
+	deploymentManifest bmdeplmanifest.Manifest,
+	installationManifest bminstallmanifest.Manifest,
+	releases []bmrel.Release,
 	stemcell bmstemcell.ExtractedStemcell,
-	isDeployed bool,
+	changes bmdepl.Changes,
 	err error,
 ) error {
-	input := IsDeployedInput{
-		ManifestPath: manifestPath,
-		Release:      release,
-		Stemcell:     stemcell,
+	input := ChangesInput{
+		Manifest:             deploymentManifest,
+		InstallationManifest: installationManifest,
+		Releases:             releases,
+		Stemcell:             stemcell,
 	}
 
 	inputString, marshalErr := bmtestutils.MarshalToString(input)
 	if marshalErr != nil {
-		return bosherr.WrapError(marshalErr, "Marshaling IsDeployed input")
+		return bosherr.WrapError(marshalErr, "Marshaling Changes input")
 	}
 
-	r.isDeployedBehavior[inputString] = isDeployedOutput{
-		isDeployed: isDeployed,
-		err:        err,
+	r.changesBehavior[inputString] = changesOutput{
+		changes: changes,
+		err:     err,
 	}
 
 	return nil
 }
 
 func (r *FakeRecord) SetUpdateBehavior(
-	manifestPath string,
-	release bmrel.Release,
+	deploymentManifest bmdeplmanifest.Manifest,
+	installationManifest bminstallmanifest.Manifest,
+	releases []bmrel.Release,
+	cpiRelease bmrel.Release,
+	stemcell bmstemcell.ExtractedStemcell,
+	renderedJobs map[string]string,
 	err error,
 ) error {
 	input := UpdateInput{
-		ManifestPath: manifestPath,
-		Release:      release,
+		Manifest:             deploymentManifest,
+		InstallationManifest: installationManifest,
+		Releases:             releases,
+		CPIRelease:           cpiRelease,
+		Stemcell:             stemcell,
+		RenderedJobs:         renderedJobs,
 	}
 
 	inputString, marshalErr := bmtestutils.MarshalToString(input)
@@ -8,6 +8,7 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 
 	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
+	bmas "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmsshtunnel "github.com/cloudfoundry/bosh-micro-cli/deployment/sshtunnel"
@@ -23,7 +24,11 @@ type Instance interface {
 	Disks() ([]bmdisk.Disk, error)
 	WaitUntilReady(bminstallmanifest.Registry, bminstallmanifest.SSHTunnel, bmeventlog.Stage) error
 	UpdateDisks(bmdeplmanifest.Manifest, bmeventlog.Stage) ([]bmdisk.Disk, error)
-	UpdateJobs(bmdeplmanifest.Manifest, bmstemcell.ApplySpec, bmeventlog.Stage) error
+	UpdateJobs(bmdeplmanifest.Manifest, bmeventlog.Stage) error
+	UpdateChangedJobs(deploymentManifest bmdeplmanifest.Manifest, renderedJobsFingerprint string, eventLoggerStage bmeventlog.Stage) error
+	RenderedJobsFingerprint() string
+	UpdateSnapshot(bmstemcell.CloudStemcell, bmdeplmanifest.Manifest) error
+	RestoreJobs(applySpec bmas.ApplySpec, deploymentManifest bmdeplmanifest.Manifest, eventLoggerStage bmeventlog.Stage) error
 	Delete(
 		pingTimeout time.Duration,
 		pingDelay time.Duration,
@@ -38,8 +43,15 @@ type instance struct {
 	vmManager            bmvm.Manager
 	sshTunnelFactory     bmsshtunnel.Factory
 	instanceStateBuilder StateBuilder
-	logger               boshlog.Logger
-	logTag               string
+
+	// renderedJobsFingerprint is the fingerprint of the rendered job templates running on the instance
+	renderedJobsFingerprint string
+
+	// applySpec is what the jobs running on the instance were applied with
+	applySpec bmas.ApplySpec
+
+	logger boshlog.Logger
+	logTag string
 }
 
 func NewInstance(
@@ -130,22 +142,92 @@ func (i *instance) UpdateDisks(deploymentManifest bmdeplmanifest.Manifest, event
 
 func (i *instance) UpdateJobs(
 	deploymentManifest bmdeplmanifest.Manifest,
-	stemcellApplySpec bmstemcell.ApplySpec,
 	eventLoggerStage bmeventlog.Stage,
 ) error {
-	instanceState, err := i.instanceStateBuilder.Build(i.jobName, i.id, deploymentManifest, stemcellApplySpec)
+	instanceState, err := i.instanceStateBuilder.Build(i.jobName, i.id, deploymentManifest)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Builing state for instance '%s/%d'", i.jobName, i.id)
 	}
 
+	return i.applyState(instanceState.ToApplySpec(), deploymentManifest, eventLoggerStage)
+}
+
+// UpdateChangedJobs updates the jobs unless their rendered templates still have the given fingerprint,
+// in which case the jobs are left running.
+func (i *instance) UpdateChangedJobs(
+	deploymentManifest bmdeplmanifest.Manifest,
+	renderedJobsFingerprint string,
+	eventLoggerStage bmeventlog.Stage,
+) error {
+	instanceState, err := i.instanceStateBuilder.Build(i.jobName, i.id, deploymentManifest)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Builing state for instance '%s/%d'", i.jobName, i.id)
+	}
+
+	newFingerprint := instanceState.ToApplySpec().ConfigurationHash
+	if renderedJobsFingerprint != "" && newFingerprint == renderedJobsFingerprint {
+		i.logger.Info(i.logTag, "Skipping update of instance '%s/%d': rendered job templates unchanged", i.jobName, i.id)
+		i.renderedJobsFingerprint = newFingerprint
+		i.applySpec = instanceState.ToApplySpec()
+		return nil
+	}
+
+	return i.applyState(instanceState.ToApplySpec(), deploymentManifest, eventLoggerStage)
+}
+
+// RestoreJobs re-deploys the jobs of a previous deploy, e.g. the last known good apply spec when rolling back.
+// The blobs of the apply spec may not exist in the blobstore of the VM, so the jobs are rebuilt
+// (uploading their templates & packages) from the deployment manifest of that deploy,
+// which requires the same release jobs as the apply spec.
+func (i *instance) RestoreJobs(
+	applySpec bmas.ApplySpec,
+	deploymentManifest bmdeplmanifest.Manifest,
+	eventLoggerStage bmeventlog.Stage,
+) error {
+	instanceState, err := i.instanceStateBuilder.Build(i.jobName, i.id, deploymentManifest)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Builing state for instance '%s/%d'", i.jobName, i.id)
+	}
+
+	rebuiltApplySpec := instanceState.ToApplySpec()
+	if !sameReleaseJobs(rebuiltApplySpec.Job.Templates, applySpec.Job.Templates) {
+		return bosherr.Errorf(
+			"Release jobs of instance '%s/%d' differ from the previous deploy: the releases of the previous deploy are required",
+			i.jobName,
+			i.id,
+		)
+	}
+
+	return i.applyState(rebuiltApplySpec, deploymentManifest, eventLoggerStage)
+}
+
+func (i *instance) RenderedJobsFingerprint() string {
+	return i.renderedJobsFingerprint
+}
+
+// UpdateSnapshot records the VM of the instance, created from the stemcell for the manifest,
+// as running successfully with the jobs applied last
+func (i *instance) UpdateSnapshot(cloudStemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest) error {
+	err := i.vmManager.UpdateSnapshot(cloudStemcell, deploymentManifest, i.jobName, i.id, i.applySpec)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Updating the snapshot of instance '%s/%d'", i.jobName, i.id)
+	}
+	return nil
+}
+
+func (i *instance) applyState(
+	applySpec bmas.ApplySpec,
+	deploymentManifest bmdeplmanifest.Manifest,
+	eventLoggerStage bmeventlog.Stage,
+) error {
 	stepName := fmt.Sprintf("Updating instance '%s/%d'", i.jobName, i.id)
-	err = eventLoggerStage.PerformStep(stepName, func() error {
+	err := eventLoggerStage.PerformStep(stepName, func() error {
 		err := i.vm.Stop()
 		if err != nil {
 			return bosherr.WrapError(err, "Stopping the agent")
 		}
 
-		err = i.vm.Apply(instanceState.ToApplySpec())
+		err = i.vm.Apply(applySpec)
 		if err != nil {
 			return bosherr.WrapError(err, "Applying the agent state")
 		}
@@ -161,7 +243,14 @@ func (i *instance) UpdateJobs(
 		return err
 	}
 
-	return i.waitUntilJobsAreRunning(deploymentManifest.Update.UpdateWatchTime, eventLoggerStage)
+	err = i.waitUntilJobsAreRunning(deploymentManifest.Update.UpdateWatchTime, eventLoggerStage)
+	if err != nil {
+		return err
+	}
+
+	i.renderedJobsFingerprint = applySpec.ConfigurationHash
+	i.applySpec = applySpec
+	return nil
 }
 
 func (i *instance) Delete(
@@ -258,3 +347,15 @@ func (i *instance) unmountDisks(eventLoggerStage bmeventlog.Stage) error {
 	}
 	return nil
 }
+
+func sameReleaseJobs(templates, otherTemplates []bmas.Blob) bool {
+	if len(templates) != len(otherTemplates) {
+		return false
+	}
+	for i, template := range templates {
+		if template.Name != otherTemplates[i].Name || template.Version != otherTemplates[i].Version {
+			return false
+		}
+	}
+	return true
+}
@@ -19,12 +19,12 @@ import (
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmsshtunnel "github.com/cloudfoundry/bosh-micro-cli/deployment/sshtunnel"
-	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
 	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
 	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
 
 	fakebmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk/fakes"
 	fakebmsshtunnel "github.com/cloudfoundry/bosh-micro-cli/deployment/sshtunnel/fakes"
+	fakebmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell/fakes"
 	fakebmvm "github.com/cloudfoundry/bosh-micro-cli/deployment/vm/fakes"
 	fakebmlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger/fakes"
 )
@@ -310,7 +310,6 @@ var _ = Describe("Instance", func() {
 
 	Describe("UpdateJobs", func() {
 		var (
-			stemcellApplySpec  bmstemcell.ApplySpec
 			deploymentJob      bmdeplmanifest.Job
 			deploymentManifest bmdeplmanifest.Manifest
 
@@ -339,20 +338,14 @@ var _ = Describe("Instance", func() {
 					Templates: []bmas.Blob{},
 				},
 				RenderedTemplatesArchive: bmas.RenderedTemplatesArchiveSpec{},
-				ConfigurationHash:        "",
+				ConfigurationHash:        "fake-rendered-jobs-fingerprint",
 			}
 
-			expectStateBuild = mockStateBuilder.EXPECT().Build(jobName, jobIndex, deploymentManifest, stemcellApplySpec).Return(mockState, nil).AnyTimes()
+			expectStateBuild = mockStateBuilder.EXPECT().Build(jobName, jobIndex, deploymentManifest).Return(mockState, nil).AnyTimes()
 			mockState.EXPECT().ToApplySpec().Return(applySpec).AnyTimes()
 		}
 
 		BeforeEach(func() {
-			//TODO: once we compile packages locally, we can ignore the stemcell apply spec
-			// stemcell apply spec is being ignored except for the packages
-			stemcellApplySpec = bmstemcell.ApplySpec{
-				Packages: map[string]bmstemcell.Blob{},
-			}
-
 			deploymentJob = bmdeplmanifest.Job{
 				Name: "fake-job-name",
 				Templates: []bmdeplmanifest.ReleaseJobRef{
@@ -405,12 +398,12 @@ var _ = Describe("Instance", func() {
 		It("builds a new instance state", func() {
 			expectStateBuild.Times(1)
 
-			err := instance.UpdateJobs(deploymentManifest, stemcellApplySpec, fakeStage)
+			err := instance.UpdateJobs(deploymentManifest, fakeStage)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
 		It("tells agent to stop jobs, apply a new spec (with new rendered jobs templates), and start jobs", func() {
-			err := instance.UpdateJobs(deploymentManifest, stemcellApplySpec, fakeStage)
+			err := instance.UpdateJobs(deploymentManifest, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeVM.StopCalled).To(Equal(1))
@@ -421,7 +414,7 @@ var _ = Describe("Instance", func() {
 		})
 
 		It("waits until agent reports state as running", func() {
-			err := instance.UpdateJobs(deploymentManifest, stemcellApplySpec, fakeStage)
+			err := instance.UpdateJobs(deploymentManifest, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeVM.WaitToBeRunningInputs).To(ContainElement(fakebmvm.WaitInput{
@@ -430,8 +423,107 @@ var _ = Describe("Instance", func() {
 			}))
 		})
 
+		It("records the fingerprint of the rendered job templates", func() {
+			err := instance.UpdateJobs(deploymentManifest, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(instance.RenderedJobsFingerprint()).To(Equal("fake-rendered-jobs-fingerprint"))
+		})
+
+		Context("when only updating changed jobs", func() {
+			It("leaves the jobs running when their rendered templates are unchanged", func() {
+				err := instance.UpdateChangedJobs(deploymentManifest, "fake-rendered-jobs-fingerprint", fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeVM.StopCalled).To(Equal(0))
+				Expect(fakeVM.ApplyInputs).To(BeEmpty())
+				Expect(fakeVM.StartCalled).To(Equal(0))
+				Expect(instance.RenderedJobsFingerprint()).To(Equal("fake-rendered-jobs-fingerprint"))
+			})
+
+			It("updates the jobs when their rendered templates changed", func() {
+				err := instance.UpdateChangedJobs(deploymentManifest, "fake-old-rendered-jobs-fingerprint", fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeVM.StopCalled).To(Equal(1))
+				Expect(fakeVM.ApplyInputs).To(Equal([]fakebmvm.ApplyInput{
+					{ApplySpec: applySpec},
+				}))
+				Expect(fakeVM.StartCalled).To(Equal(1))
+				Expect(instance.RenderedJobsFingerprint()).To(Equal("fake-rendered-jobs-fingerprint"))
+			})
+
+			It("updates the jobs when no fingerprint was recorded", func() {
+				err := instance.UpdateChangedJobs(deploymentManifest, "", fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeVM.StartCalled).To(Equal(1))
+			})
+		})
+
+		It("records the snapshot of the vm with the applied jobs", func() {
+			err := instance.UpdateJobs(deploymentManifest, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			cloudStemcell := fakebmstemcell.NewFakeCloudStemcell("fake-stemcell-cid", "fake-stemcell-name", "fake-stemcell-version")
+			err = instance.UpdateSnapshot(cloudStemcell, deploymentManifest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeVMManager.UpdateSnapshotInputs).To(Equal([]fakebmvm.UpdateSnapshotInput{
+				{
+					Stemcell:  cloudStemcell,
+					Manifest:  deploymentManifest,
+					JobName:   "fake-job-name",
+					Index:     0,
+					ApplySpec: applySpec,
+				},
+			}))
+		})
+
+		Context("when restoring the jobs of a previous deploy", func() {
+			var previousApplySpec bmas.ApplySpec
+
+			BeforeEach(func() {
+				previousApplySpec = bmas.ApplySpec{
+					Deployment:        "fake-deployment-name",
+					Job:               bmas.Job{Name: "fake-job-name", Templates: []bmas.Blob{}},
+					ConfigurationHash: "fake-previous-rendered-jobs-fingerprint",
+				}
+			})
+
+			It("rebuilds the instance state from the previous manifest & applies it", func() {
+				expectStateBuild.Times(1)
+
+				err := instance.RestoreJobs(previousApplySpec, deploymentManifest, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeVM.StopCalled).To(Equal(1))
+				Expect(fakeVM.ApplyInputs).To(Equal([]fakebmvm.ApplyInput{
+					{ApplySpec: applySpec},
+				}))
+				Expect(fakeVM.StartCalled).To(Equal(1))
+				Expect(fakeVM.WaitToBeRunningInputs).To(HaveLen(1))
+				Expect(instance.RenderedJobsFingerprint()).To(Equal("fake-rendered-jobs-fingerprint"))
+			})
+
+			Context("when the release jobs differ from the previous apply spec", func() {
+				BeforeEach(func() {
+					previousApplySpec.Job.Templates = []bmas.Blob{
+						{Name: "fake-release-job-name", Version: "fake-previous-release-job-version"},
+					}
+				})
+
+				It("returns an error without applying", func() {
+					err := instance.RestoreJobs(previousApplySpec, deploymentManifest, fakeStage)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("the releases of the previous deploy are required"))
+					Expect(fakeVM.ApplyInputs).To(BeEmpty())
+				})
+			})
+		})
+
 		It("logs start and stop events to the eventLogger", func() {
-			err := instance.UpdateJobs(deploymentManifest, stemcellApplySpec, fakeStage)
+			err := instance.UpdateJobs(deploymentManifest, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeStage.Steps).To(ContainElement(&fakebmlog.FakeStep{
@@ -456,7 +548,7 @@ var _ = Describe("Instance", func() {
 			})
 
 			It("returns an error", func() {
-				err := instance.UpdateJobs(deploymentManifest, stemcellApplySpec, fakeStage)
+				err := instance.UpdateJobs(deploymentManifest, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-template-err"))
 			})
@@ -468,7 +560,7 @@ var _ = Describe("Instance", func() {
 			})
 
 			It("logs start and stop events to the eventLogger", func() {
-				err := instance.UpdateJobs(deploymentManifest, stemcellApplySpec, fakeStage)
+				err := instance.UpdateJobs(deploymentManifest, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-stop-error"))
 
@@ -489,7 +581,7 @@ var _ = Describe("Instance", func() {
 			})
 
 			It("logs start and stop events to the eventLogger", func() {
-				err := instance.UpdateJobs(deploymentManifest, stemcellApplySpec, fakeStage)
+				err := instance.UpdateJobs(deploymentManifest, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-apply-error"))
 
@@ -510,7 +602,7 @@ var _ = Describe("Instance", func() {
 			})
 
 			It("logs start and stop events to the eventLogger", func() {
-				err := instance.UpdateJobs(deploymentManifest, stemcellApplySpec, fakeStage)
+				err := instance.UpdateJobs(deploymentManifest, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-start-error"))
 
@@ -531,7 +623,7 @@ var _ = Describe("Instance", func() {
 			})
 
 			It("logs start and stop events to the eventLogger", func() {
-				err := instance.UpdateJobs(deploymentManifest, stemcellApplySpec, fakeStage)
+				err := instance.UpdateJobs(deploymentManifest, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-wait-running-error"))
 
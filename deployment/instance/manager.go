@@ -20,6 +20,12 @@ import (
 
 type Manager interface {
 	FindCurrent() ([]Instance, error)
+	FindUpToDate(
+		jobName string,
+		id int,
+		deploymentManifest bmdeplmanifest.Manifest,
+		cloudStemcell bmstemcell.CloudStemcell,
+	) (Instance, bool, error)
 	Create(
 		jobName string,
 		id int,
@@ -29,6 +35,16 @@ type Manager interface {
 		sshTunnelConfig bminstallmanifest.SSHTunnel,
 		eventLoggerStage bmeventlog.Stage,
 	) (Instance, []bmdisk.Disk, error)
+	Rollback(
+		jobName string,
+		id int,
+		deploymentManifest bmdeplmanifest.Manifest,
+		registryConfig bminstallmanifest.Registry,
+		sshTunnelConfig bminstallmanifest.SSHTunnel,
+		pingTimeout time.Duration,
+		pingDelay time.Duration,
+		eventLoggerStage bmeventlog.Stage,
+	) (Instance, []bmdisk.Disk, error)
 	DeleteAll(
 		pingTimeout time.Duration,
 		pingDelay time.Duration,
@@ -94,6 +110,27 @@ func (m *manager) FindCurrent() ([]Instance, error) {
 	return instances, nil
 }
 
+// FindUpToDate returns the current instance when its VM does not need recreating for the job at the index,
+// i.e. when only the jobs of the instance may need updating
+func (m *manager) FindUpToDate(
+	jobName string,
+	id int,
+	deploymentManifest bmdeplmanifest.Manifest,
+	cloudStemcell bmstemcell.CloudStemcell,
+) (Instance, bool, error) {
+	vm, found, err := m.vmManager.FindUpToDate(cloudStemcell, deploymentManifest, jobName, id)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Finding up-to-date VM of instance '%s/%d'", jobName, id)
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	instance := m.instanceFactory.NewInstance(jobName, id, vm, m.vmManager, m.sshTunnelFactory, m.blobstore, m.logger)
+	return instance, true, nil
+}
+
 func (m *manager) Create(
 	jobName string,
 	id int,
@@ -107,7 +144,7 @@ func (m *manager) Create(
 	stepName := fmt.Sprintf("Creating VM for instance '%s/%d' from stemcell '%s'", jobName, id, cloudStemcell.CID())
 	err := eventLoggerStage.PerformStep(stepName, func() error {
 		var err error
-		vm, err = m.vmManager.Create(cloudStemcell, deploymentManifest)
+		vm, err = m.vmManager.Create(cloudStemcell, deploymentManifest, jobName, id)
 		if err != nil {
 			return bosherr.WrapError(err, "Creating VM")
 		}
@@ -136,6 +173,66 @@ func (m *manager) Create(
 	return instance, disks, err
 }
 
+// Rollback replaces the current VM of the instance, whose jobs failed to update, with a VM created the way the VM
+// of the last successful deploy was, then reattaches the current disk & restores the jobs of the last successful deploy
+func (m *manager) Rollback(
+	jobName string,
+	id int,
+	deploymentManifest bmdeplmanifest.Manifest,
+	registryConfig bminstallmanifest.Registry,
+	sshTunnelConfig bminstallmanifest.SSHTunnel,
+	pingTimeout time.Duration,
+	pingDelay time.Duration,
+	eventLoggerStage bmeventlog.Stage,
+) (Instance, []bmdisk.Disk, error) {
+	snapshot, found, err := m.vmManager.FindSnapshot()
+	if err != nil {
+		return nil, []bmdisk.Disk{}, bosherr.WrapErrorf(err, "Finding the snapshot of instance '%s/%d'", jobName, id)
+	}
+
+	if !found {
+		return nil, []bmdisk.Disk{}, bosherr.Errorf("Instance '%s/%d' has never been deployed successfully", jobName, id)
+	}
+
+	err = m.DeleteAll(pingTimeout, pingDelay, eventLoggerStage)
+	if err != nil {
+		return nil, []bmdisk.Disk{}, err
+	}
+
+	var vm bmvm.VM
+	stepName := fmt.Sprintf("Creating VM for instance '%s/%d' from stemcell '%s'", jobName, id, snapshot.StemcellCID)
+	err = eventLoggerStage.PerformStep(stepName, func() error {
+		var err error
+		vm, err = m.vmManager.CreateFromSnapshot(snapshot)
+		if err != nil {
+			return bosherr.WrapError(err, "Creating VM")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, []bmdisk.Disk{}, err
+	}
+
+	instance := m.instanceFactory.NewInstance(jobName, id, vm, m.vmManager, m.sshTunnelFactory, m.blobstore, m.logger)
+
+	if err := instance.WaitUntilReady(registryConfig, sshTunnelConfig, eventLoggerStage); err != nil {
+		return instance, []bmdisk.Disk{}, bosherr.WrapError(err, "Waiting until instance is ready")
+	}
+
+	// the current disk already matches the disk pool, the failed deploy having migrated it
+	disks, err := instance.UpdateDisks(deploymentManifest, eventLoggerStage)
+	if err != nil {
+		return instance, disks, bosherr.WrapError(err, "Reattaching instance disks")
+	}
+
+	err = instance.RestoreJobs(snapshot.ApplySpec, snapshot.DeploymentManifest, eventLoggerStage)
+	if err != nil {
+		return instance, disks, bosherr.WrapError(err, "Restoring the jobs of the last successful deploy")
+	}
+
+	return instance, disks, nil
+}
+
 func (m *manager) DeleteAll(
 	pingTimeout time.Duration,
 	pingDelay time.Duration,
@@ -19,10 +19,13 @@ import (
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmsshtunnel "github.com/cloudfoundry/bosh-micro-cli/deployment/sshtunnel"
+	bmvm "github.com/cloudfoundry/bosh-micro-cli/deployment/vm"
 	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
 	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
 
+	fakebmblobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore/fakes"
 	fakebmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud/fakes"
+	fakebmagentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/fakes"
 	fakebmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk/fakes"
 	fakebmsshtunnel "github.com/cloudfoundry/bosh-micro-cli/deployment/sshtunnel/fakes"
 	fakebmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell/fakes"
@@ -83,8 +86,8 @@ var _ = Describe("Manager", func() {
 			ConfigurationHash:        "",
 		}
 
-		mockStateBuilderFactory.EXPECT().NewStateBuilder(mockBlobstore).Return(mockStateBuilder).AnyTimes()
-		mockStateBuilder.EXPECT().Build(jobName, jobIndex, gomock.Any(), gomock.Any()).Return(mockState, nil).AnyTimes()
+		mockStateBuilderFactory.EXPECT().NewStateBuilder(mockBlobstore, gomock.Any(), gomock.Any()).Return(mockStateBuilder).AnyTimes()
+		mockStateBuilder.EXPECT().Build(jobName, jobIndex, gomock.Any()).Return(mockState, nil).AnyTimes()
 		mockState.EXPECT().ToApplySpec().Return(applySpec).AnyTimes()
 	}
 
@@ -202,6 +205,8 @@ var _ = Describe("Manager", func() {
 			Expect(fakeVMManager.CreateInput).To(Equal(fakebmvm.CreateInput{
 				Stemcell: fakeCloudStemcell,
 				Manifest: deploymentManifest,
+				JobName:  "fake-job-name",
+				Index:    0,
 			}))
 		})
 
@@ -433,4 +438,261 @@ var _ = Describe("Manager", func() {
 			})
 		})
 	})
+
+	Describe("FindUpToDate", func() {
+		var (
+			fakeVM             *fakebmvm.FakeVM
+			deploymentManifest bmdeplmanifest.Manifest
+			fakeCloudStemcell  *fakebmstemcell.FakeCloudStemcell
+		)
+
+		BeforeEach(func() {
+			deploymentManifest = bmdeplmanifest.Manifest{
+				Jobs: []bmdeplmanifest.Job{
+					{Name: "fake-job-name", Instances: 1},
+				},
+			}
+			fakeCloudStemcell = fakebmstemcell.NewFakeCloudStemcell("fake-stemcell-cid", "fake-stemcell-name", "fake-stemcell-version")
+			fakeVM = fakebmvm.NewFakeVM("fake-vm-cid")
+		})
+
+		It("returns an Instance that wraps the current VM when it is up to date", func() {
+			fakeVMManager.SetFindUpToDateBehavior(fakeVM, true, nil)
+
+			instance, found, err := manager.FindUpToDate("fake-job-name", 0, deploymentManifest, fakeCloudStemcell)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(instance).To(Equal(NewInstance(
+				"fake-job-name",
+				0,
+				fakeVM,
+				fakeVMManager,
+				fakeSSHTunnelFactory,
+				mockStateBuilder,
+				logger,
+			)))
+
+			Expect(fakeVMManager.FindUpToDateInput).To(Equal(fakebmvm.CreateInput{
+				Stemcell: fakeCloudStemcell,
+				Manifest: deploymentManifest,
+				JobName:  "fake-job-name",
+				Index:    0,
+			}))
+		})
+
+		It("does not return an Instance when the VM needs recreating", func() {
+			fakeVMManager.SetFindUpToDateBehavior(nil, false, nil)
+
+			_, found, err := manager.FindUpToDate("fake-job-name", 0, deploymentManifest, fakeCloudStemcell)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns an error when finding the VM fails", func() {
+			fakeVMManager.SetFindUpToDateBehavior(nil, false, errors.New("fake-find-error"))
+
+			_, _, err := manager.FindUpToDate("fake-job-name", 0, deploymentManifest, fakeCloudStemcell)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-find-error"))
+		})
+	})
+
+	Describe("Rollback", func() {
+		var (
+			brokenVM           *fakebmvm.FakeVM
+			fakeVM             *fakebmvm.FakeVM
+			diskPool           bmdeplmanifest.DiskPool
+			deploymentManifest bmdeplmanifest.Manifest
+			snapshot           bmvm.Snapshot
+			expectedDisk       *fakebmdisk.FakeDisk
+
+			mockRebuiltStateBuilder *mock_instance.MockStateBuilder
+			mockRebuiltState        *mock_instance.MockState
+			rebuiltApplySpec        bmas.ApplySpec
+			uploadedBlobs           *fakebmblobstore.FakeBlobstore
+
+			pingTimeout = 1 * time.Second
+			pingDelay   = 500 * time.Millisecond
+		)
+
+		BeforeEach(func() {
+			diskPool = bmdeplmanifest.DiskPool{
+				Name:     "fake-persistent-disk-pool-name",
+				DiskSize: 1024,
+			}
+			deploymentManifest = bmdeplmanifest.Manifest{
+				Update: bmdeplmanifest.Update{
+					UpdateWatchTime: bmdeplmanifest.WatchTime{
+						Start: 0,
+						End:   5478,
+					},
+				},
+				DiskPools: []bmdeplmanifest.DiskPool{
+					diskPool,
+				},
+				Jobs: []bmdeplmanifest.Job{
+					{
+						Name:               "fake-job-name",
+						PersistentDiskPool: "fake-persistent-disk-pool-name",
+						Instances:          1,
+					},
+				},
+			}
+
+			brokenVM = fakebmvm.NewFakeVM("fake-broken-vm-cid")
+			fakeVMManager.SetFindCurrentBehavior(brokenVM, true, nil)
+
+			previousDeploymentManifest := bmdeplmanifest.Manifest{
+				Name: "fake-deployment-name",
+				Jobs: []bmdeplmanifest.Job{
+					{Name: "fake-job-name", Instances: 1},
+				},
+			}
+			snapshot = bmvm.Snapshot{
+				StemcellCID: "fake-previous-stemcell-cid",
+				ApplySpec: bmas.ApplySpec{
+					Deployment: "fake-deployment-name",
+					Packages: map[string]bmas.Blob{
+						"fake-package-name": {Name: "fake-package-name", Version: "fake-package-version", BlobstoreID: "fake-deleted-package-blob-id"},
+					},
+					Job: bmas.Job{
+						Name: "fake-job-name",
+						Templates: []bmas.Blob{
+							{Name: "fake-release-job-name", Version: "fake-release-job-version", BlobstoreID: "fake-deleted-release-job-blob-id"},
+						},
+					},
+					RenderedTemplatesArchive: bmas.RenderedTemplatesArchiveSpec{BlobstoreID: "fake-deleted-rendered-templates-blob-id"},
+					ConfigurationHash:        "fake-previous-rendered-jobs-fingerprint",
+				},
+				DeploymentManifest: previousDeploymentManifest,
+			}
+			fakeVMManager.FindSnapshotSnapshot = snapshot
+			fakeVMManager.FindSnapshotFound = true
+
+			fakeVM = fakebmvm.NewFakeVM("fake-vm-cid")
+			fakeVM.AgentClientReturn = fakebmagentclient.NewFakeAgentClient()
+			fakeVMManager.CreateFromSnapshotVM = fakeVM
+
+			// the state builder of the new vm uploads the blobs of the jobs it builds
+			rebuiltApplySpec = bmas.ApplySpec{
+				Deployment: "fake-deployment-name",
+				Packages: map[string]bmas.Blob{
+					"fake-package-name": {Name: "fake-package-name", Version: "fake-package-version", BlobstoreID: "fake-package-blob-id"},
+				},
+				Job: bmas.Job{
+					Name: "fake-job-name",
+					Templates: []bmas.Blob{
+						{Name: "fake-release-job-name", Version: "fake-release-job-version", BlobstoreID: "fake-release-job-blob-id"},
+					},
+				},
+				RenderedTemplatesArchive: bmas.RenderedTemplatesArchiveSpec{BlobstoreID: "fake-rendered-templates-blob-id"},
+				ConfigurationHash:        "fake-previous-rendered-jobs-fingerprint",
+			}
+			uploadedBlobs = fakebmblobstore.NewFakeBlobstore()
+			mockRebuiltStateBuilder = mock_instance.NewMockStateBuilder(mockCtrl)
+			mockRebuiltState = mock_instance.NewMockState(mockCtrl)
+			mockStateBuilderFactory.EXPECT().NewStateBuilder(mockBlobstore, fakeVM.AgentClientReturn, gomock.Any()).Return(mockRebuiltStateBuilder).AnyTimes()
+			mockRebuiltStateBuilder.EXPECT().Build("fake-job-name", 0, previousDeploymentManifest).Do(func(_ string, _ int, _ bmdeplmanifest.Manifest) {
+				for _, blob := range rebuiltApplySpec.Packages {
+					uploadedBlobs.Save("fake-package-path", blob.BlobstoreID)
+				}
+				for _, blob := range rebuiltApplySpec.Job.Templates {
+					uploadedBlobs.Save("fake-release-job-path", blob.BlobstoreID)
+				}
+				uploadedBlobs.Save("fake-rendered-templates-path", rebuiltApplySpec.RenderedTemplatesArchive.BlobstoreID)
+			}).Return(mockRebuiltState, nil).AnyTimes()
+			mockRebuiltState.EXPECT().ToApplySpec().Return(rebuiltApplySpec).AnyTimes()
+
+			expectedDisk = fakebmdisk.NewFakeDisk("fake-disk-cid")
+			fakeVM.UpdateDisksDisks = []bmdisk.Disk{expectedDisk}
+		})
+
+		It("replaces the broken vm with a vm created from the snapshot", func() {
+			instance, _, err := manager.Rollback("fake-job-name", 0, deploymentManifest, bminstallmanifest.Registry{}, bminstallmanifest.SSHTunnel{}, pingTimeout, pingDelay, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instance.JobName()).To(Equal("fake-job-name"))
+
+			Expect(brokenVM.DeleteCalled).To(Equal(1))
+			Expect(fakeVMManager.CreateFromSnapshotInputs).To(Equal([]bmvm.Snapshot{snapshot}))
+			Expect(fakeVM.WaitUntilReadyInputs).To(HaveLen(1))
+
+			Expect(fakeStage.Steps).To(ContainElement(&fakebmlog.FakeStep{
+				Name: "Creating VM for instance 'fake-job-name/0' from stemcell 'fake-previous-stemcell-cid'",
+				States: []bmeventlog.EventState{
+					bmeventlog.Started,
+					bmeventlog.Finished,
+				},
+			}))
+		})
+
+		It("reattaches the current disk", func() {
+			_, disks, err := manager.Rollback("fake-job-name", 0, deploymentManifest, bminstallmanifest.Registry{}, bminstallmanifest.SSHTunnel{}, pingTimeout, pingDelay, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(disks).To(Equal([]bmdisk.Disk{expectedDisk}))
+
+			Expect(fakeVM.UpdateDisksInputs).To(Equal([]fakebmvm.UpdateDisksInput{
+				{
+					DiskPool: diskPool,
+					Stage:    fakeStage,
+				},
+			}))
+		})
+
+		It("restores the jobs of the last successful deploy, rebuilt on the new vm", func() {
+			instance, _, err := manager.Rollback("fake-job-name", 0, deploymentManifest, bminstallmanifest.Registry{}, bminstallmanifest.SSHTunnel{}, pingTimeout, pingDelay, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeVM.ApplyInputs).To(Equal([]fakebmvm.ApplyInput{
+				{ApplySpec: rebuiltApplySpec},
+			}))
+			Expect(fakeVM.StartCalled).To(Equal(1))
+			Expect(instance.RenderedJobsFingerprint()).To(Equal("fake-previous-rendered-jobs-fingerprint"))
+		})
+
+		It("applies only blobs that exist in the blobstore of the new vm", func() {
+			_, _, err := manager.Rollback("fake-job-name", 0, deploymentManifest, bminstallmanifest.Registry{}, bminstallmanifest.SSHTunnel{}, pingTimeout, pingDelay, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			uploadedBlobIDs := []string{}
+			for _, saveInput := range uploadedBlobs.SaveInputs {
+				uploadedBlobIDs = append(uploadedBlobIDs, saveInput.BlobID)
+			}
+
+			Expect(fakeVM.ApplyInputs).To(HaveLen(1))
+			appliedSpec := fakeVM.ApplyInputs[0].ApplySpec
+			for _, blob := range appliedSpec.Packages {
+				Expect(uploadedBlobIDs).To(ContainElement(blob.BlobstoreID))
+			}
+			for _, blob := range appliedSpec.Job.Templates {
+				Expect(uploadedBlobIDs).To(ContainElement(blob.BlobstoreID))
+			}
+			Expect(uploadedBlobIDs).To(ContainElement(appliedSpec.RenderedTemplatesArchive.BlobstoreID))
+		})
+
+		Context("when the instance has never been deployed successfully", func() {
+			BeforeEach(func() {
+				fakeVMManager.FindSnapshotFound = false
+			})
+
+			It("returns an error without deleting the broken vm", func() {
+				_, _, err := manager.Rollback("fake-job-name", 0, deploymentManifest, bminstallmanifest.Registry{}, bminstallmanifest.SSHTunnel{}, pingTimeout, pingDelay, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Instance 'fake-job-name/0' has never been deployed successfully"))
+
+				Expect(brokenVM.DeleteCalled).To(Equal(0))
+			})
+		})
+
+		Context("when creating the vm fails", func() {
+			BeforeEach(func() {
+				fakeVMManager.CreateFromSnapshotErr = errors.New("fake-create-error")
+			})
+
+			It("returns an error", func() {
+				_, _, err := manager.Rollback("fake-job-name", 0, deploymentManifest, bminstallmanifest.Registry{}, bminstallmanifest.SSHTunnel{}, pingTimeout, pingDelay, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-create-error"))
+			})
+		})
+	})
 })
@@ -1,17 +1,19 @@
 // Automatically generated by MockGen. DO NOT EDIT!
-// Source: github.com/cloudfoundry/bosh-micro-cli/deployment/instance (interfaces: Instance,Manager,StateBuilderFactory,StateBuilder,State)
+// Source: github.com/cloudfoundry/bosh-micro-cli/deployment/instance (interfaces: Instance,Manager,StateBuilderFactory,StateBuilder,PackageCompiler,State)
 
 package mocks
 
 import (
 	gomock "code.google.com/p/gomock/gomock"
 	blobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
+	agentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
 	applyspec "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
 	disk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	instance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
 	manifest0 "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	stemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
 	eventlogger "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	index "github.com/cloudfoundry/bosh-micro-cli/index"
 	manifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
 	time "time"
 )
@@ -78,6 +80,36 @@ func (_mr *_MockInstanceRecorder) JobName() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "JobName")
 }
 
+func (_m *MockInstance) RenderedJobsFingerprint() string {
+	ret := _m.ctrl.Call(_m, "RenderedJobsFingerprint")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockInstanceRecorder) RenderedJobsFingerprint() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RenderedJobsFingerprint")
+}
+
+func (_m *MockInstance) RestoreJobs(_param0 applyspec.ApplySpec, _param1 manifest0.Manifest, _param2 eventlogger.Stage) error {
+	ret := _m.ctrl.Call(_m, "RestoreJobs", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockInstanceRecorder) RestoreJobs(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RestoreJobs", arg0, arg1, arg2)
+}
+
+func (_m *MockInstance) UpdateChangedJobs(_param0 manifest0.Manifest, _param1 string, _param2 eventlogger.Stage) error {
+	ret := _m.ctrl.Call(_m, "UpdateChangedJobs", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockInstanceRecorder) UpdateChangedJobs(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateChangedJobs", arg0, arg1, arg2)
+}
+
 func (_m *MockInstance) UpdateDisks(_param0 manifest0.Manifest, _param1 eventlogger.Stage) ([]disk.Disk, error) {
 	ret := _m.ctrl.Call(_m, "UpdateDisks", _param0, _param1)
 	ret0, _ := ret[0].([]disk.Disk)
@@ -89,14 +121,24 @@ func (_mr *_MockInstanceRecorder) UpdateDisks(arg0, arg1 interface{}) *gomock.Ca
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateDisks", arg0, arg1)
 }
 
-func (_m *MockInstance) UpdateJobs(_param0 manifest0.Manifest, _param1 stemcell.ApplySpec, _param2 eventlogger.Stage) error {
-	ret := _m.ctrl.Call(_m, "UpdateJobs", _param0, _param1, _param2)
+func (_m *MockInstance) UpdateJobs(_param0 manifest0.Manifest, _param1 eventlogger.Stage) error {
+	ret := _m.ctrl.Call(_m, "UpdateJobs", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockInstanceRecorder) UpdateJobs(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateJobs", arg0, arg1)
+}
+
+func (_m *MockInstance) UpdateSnapshot(_param0 stemcell.CloudStemcell, _param1 manifest0.Manifest) error {
+	ret := _m.ctrl.Call(_m, "UpdateSnapshot", _param0, _param1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-func (_mr *_MockInstanceRecorder) UpdateJobs(arg0, arg1, arg2 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateJobs", arg0, arg1, arg2)
+func (_mr *_MockInstanceRecorder) UpdateSnapshot(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateSnapshot", arg0, arg1)
 }
 
 func (_m *MockInstance) WaitUntilReady(_param0 manifest.Registry, _param1 manifest.SSHTunnel, _param2 eventlogger.Stage) error {
@@ -163,6 +205,30 @@ func (_mr *_MockManagerRecorder) FindCurrent() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "FindCurrent")
 }
 
+func (_m *MockManager) FindUpToDate(_param0 string, _param1 int, _param2 manifest0.Manifest, _param3 stemcell.CloudStemcell) (instance.Instance, bool, error) {
+	ret := _m.ctrl.Call(_m, "FindUpToDate", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(instance.Instance)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockManagerRecorder) FindUpToDate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "FindUpToDate", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockManager) Rollback(_param0 string, _param1 int, _param2 manifest0.Manifest, _param3 manifest.Registry, _param4 manifest.SSHTunnel, _param5 time.Duration, _param6 time.Duration, _param7 eventlogger.Stage) (instance.Instance, []disk.Disk, error) {
+	ret := _m.ctrl.Call(_m, "Rollback", _param0, _param1, _param2, _param3, _param4, _param5, _param6, _param7)
+	ret0, _ := ret[0].(instance.Instance)
+	ret1, _ := ret[1].([]disk.Disk)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockManagerRecorder) Rollback(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Rollback", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+}
+
 // Mock of StateBuilderFactory interface
 type MockStateBuilderFactory struct {
 	ctrl     *gomock.Controller
@@ -184,14 +250,14 @@ func (_m *MockStateBuilderFactory) EXPECT() *_MockStateBuilderFactoryRecorder {
 	return _m.recorder
 }
 
-func (_m *MockStateBuilderFactory) NewStateBuilder(_param0 blobstore.Blobstore) instance.StateBuilder {
-	ret := _m.ctrl.Call(_m, "NewStateBuilder", _param0)
+func (_m *MockStateBuilderFactory) NewStateBuilder(_param0 blobstore.Blobstore, _param1 agentclient.AgentClient, _param2 index.Index) instance.StateBuilder {
+	ret := _m.ctrl.Call(_m, "NewStateBuilder", _param0, _param1, _param2)
 	ret0, _ := ret[0].(instance.StateBuilder)
 	return ret0
 }
 
-func (_mr *_MockStateBuilderFactoryRecorder) NewStateBuilder(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewStateBuilder", arg0)
+func (_mr *_MockStateBuilderFactoryRecorder) NewStateBuilder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewStateBuilder", arg0, arg1, arg2)
 }
 
 // Mock of StateBuilder interface
@@ -215,15 +281,47 @@ func (_m *MockStateBuilder) EXPECT() *_MockStateBuilderRecorder {
 	return _m.recorder
 }
 
-func (_m *MockStateBuilder) Build(_param0 string, _param1 int, _param2 manifest0.Manifest, _param3 stemcell.ApplySpec) (instance.State, error) {
-	ret := _m.ctrl.Call(_m, "Build", _param0, _param1, _param2, _param3)
+func (_m *MockStateBuilder) Build(_param0 string, _param1 int, _param2 manifest0.Manifest) (instance.State, error) {
+	ret := _m.ctrl.Call(_m, "Build", _param0, _param1, _param2)
 	ret0, _ := ret[0].(instance.State)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (_mr *_MockStateBuilderRecorder) Build(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "Build", arg0, arg1, arg2, arg3)
+func (_mr *_MockStateBuilderRecorder) Build(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Build", arg0, arg1, arg2)
+}
+
+// Mock of PackageCompiler interface
+type MockPackageCompiler struct {
+	ctrl     *gomock.Controller
+	recorder *_MockPackageCompilerRecorder
+}
+
+// Recorder for MockPackageCompiler (not exported)
+type _MockPackageCompilerRecorder struct {
+	mock *MockPackageCompiler
+}
+
+func NewMockPackageCompiler(ctrl *gomock.Controller) *MockPackageCompiler {
+	mock := &MockPackageCompiler{ctrl: ctrl}
+	mock.recorder = &_MockPackageCompilerRecorder{mock}
+	return mock
+}
+
+func (_m *MockPackageCompiler) EXPECT() *_MockPackageCompilerRecorder {
+	return _m.recorder
+}
+
+func (_m *MockPackageCompiler) Compile(_param0 []manifest0.ReleaseJobRef) ([]instance.PackageRef, error) {
+	ret := _m.ctrl.Call(_m, "Compile", _param0)
+	ret0, _ := ret[0].([]instance.PackageRef)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockPackageCompilerRecorder) Compile(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Compile", arg0)
 }
 
 // Mock of State interface
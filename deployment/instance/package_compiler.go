@@ -0,0 +1,219 @@
+package instance
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshcmd "github.com/cloudfoundry/bosh-agent/platform/commands"
+	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
+
+	bmblobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
+	bmcrypto "github.com/cloudfoundry/bosh-micro-cli/crypto"
+	bmagentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bminstallpkg "github.com/cloudfoundry/bosh-micro-cli/installation/pkg"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
+)
+
+// PackageCompiler compiles the packages needed by the release jobs of a deployment job on the VM of an instance
+type PackageCompiler interface {
+	Compile(jobRefs []bmdeplmanifest.ReleaseJobRef) ([]PackageRef, error)
+}
+
+type remotePackageCompiler struct {
+	releaseSetResolver  bmrelset.Resolver
+	blobstore           bmblobstore.Blobstore
+	agentClient         bmagentclient.AgentClient
+	compressor          boshcmd.Compressor
+	sha1Calculator      bmcrypto.SHA1Calculator
+	compiledPackageRepo bminstallpkg.CompiledPackageRepo
+	uuidGenerator       boshuuid.Generator
+	logger              boshlog.Logger
+	logTag              string
+}
+
+// NewRemotePackageCompiler returns a PackageCompiler that uploads the package sources to the blobstore of the agent,
+// and compiles them with the agent in dependency order. The compiled packages are saved in the compiledPackageRepo,
+// which must only be shared by compilers of the same blobstore.
+func NewRemotePackageCompiler(
+	releaseSetResolver bmrelset.Resolver,
+	blobstore bmblobstore.Blobstore,
+	agentClient bmagentclient.AgentClient,
+	compressor boshcmd.Compressor,
+	sha1Calculator bmcrypto.SHA1Calculator,
+	compiledPackageRepo bminstallpkg.CompiledPackageRepo,
+	uuidGenerator boshuuid.Generator,
+	logger boshlog.Logger,
+) PackageCompiler {
+	return &remotePackageCompiler{
+		releaseSetResolver:  releaseSetResolver,
+		blobstore:           blobstore,
+		agentClient:         agentClient,
+		compressor:          compressor,
+		sha1Calculator:      sha1Calculator,
+		compiledPackageRepo: compiledPackageRepo,
+		uuidGenerator:       uuidGenerator,
+		logger:              logger,
+		logTag:              "remotePackageCompiler",
+	}
+}
+
+func (c *remotePackageCompiler) Compile(jobRefs []bmdeplmanifest.ReleaseJobRef) ([]PackageRef, error) {
+	compiledPackages := []PackageRef{}
+
+	releaseNames := []string{}
+	jobNamesByRelease := map[string][]string{}
+	for _, jobRef := range jobRefs {
+		if _, found := jobNamesByRelease[jobRef.Release]; !found {
+			releaseNames = append(releaseNames, jobRef.Release)
+		}
+		jobNamesByRelease[jobRef.Release] = append(jobNamesByRelease[jobRef.Release], jobRef.Name)
+	}
+
+	// packages only depend on packages of the same release
+	for _, releaseName := range releaseNames {
+		release, err := c.releaseSetResolver.Find(releaseName)
+		if err != nil {
+			return compiledPackages, bosherr.WrapErrorf(err, "Resolving release '%s'", releaseName)
+		}
+
+		neededPackages, err := c.neededPackages(release, jobNamesByRelease[releaseName])
+		if err != nil {
+			return compiledPackages, err
+		}
+
+		packages, err := bminstallpkg.NewDependencyAnalysis().DeterminePackageCompilationOrder(release)
+		if err != nil {
+			return compiledPackages, bosherr.WrapErrorf(err, "Resolving compilation order of release '%s'", releaseName)
+		}
+
+		for _, pkg := range packages {
+			if _, needed := neededPackages[pkg]; !needed {
+				continue
+			}
+
+			compiledPackage, err := c.compilePackage(pkg)
+			if err != nil {
+				return compiledPackages, bosherr.WrapErrorf(err, "Compiling package '%s/%s'", pkg.Name, pkg.Fingerprint)
+			}
+			compiledPackages = append(compiledPackages, compiledPackage)
+		}
+	}
+
+	return compiledPackages, nil
+}
+
+// neededPackages returns the packages of the release jobs, with their dependencies
+func (c *remotePackageCompiler) neededPackages(release bmrel.Release, jobNames []string) (map[*bmrel.Package]struct{}, error) {
+	neededPackages := map[*bmrel.Package]struct{}{}
+	for _, jobName := range jobNames {
+		releaseJob, found := release.FindJobByName(jobName)
+		if !found {
+			return neededPackages, bosherr.Errorf("Finding job '%s' in release '%s'", jobName, release.Name())
+		}
+
+		for _, pkg := range releaseJob.Packages {
+			neededPackages[pkg] = struct{}{}
+			for _, dependency := range bminstallpkg.ResolveDependencies(pkg) {
+				neededPackages[dependency] = struct{}{}
+			}
+		}
+	}
+	return neededPackages, nil
+}
+
+func (c *remotePackageCompiler) compilePackage(pkg *bmrel.Package) (PackageRef, error) {
+	record, found, err := c.compiledPackageRepo.Find(*pkg)
+	if err != nil {
+		return PackageRef{}, bosherr.WrapError(err, "Finding compiled package")
+	}
+	if found {
+		c.logger.Debug(c.logTag, "Using compiled package '%s/%s' from blob '%s'", pkg.Name, pkg.Fingerprint, record.BlobID)
+		return c.packageRef(pkg, record), nil
+	}
+
+	packageSource, err := c.uploadPackageSource(pkg)
+	if err != nil {
+		return PackageRef{}, err
+	}
+
+	// dependencies come first in the compilation order, so they are already compiled
+	compiledDependencies := []bmagentclient.BlobRef{}
+	for _, dependency := range pkg.Dependencies {
+		dependencyRecord, found, err := c.compiledPackageRepo.Find(*dependency)
+		if err != nil {
+			return PackageRef{}, bosherr.WrapErrorf(err, "Finding compiled dependency '%s'", dependency.Name)
+		}
+		if !found {
+			return PackageRef{}, bosherr.Errorf("Dependency '%s' is not compiled", dependency.Name)
+		}
+
+		compiledDependencies = append(compiledDependencies, bmagentclient.BlobRef{
+			Name:        dependency.Name,
+			Version:     dependency.Fingerprint,
+			SHA1:        dependencyRecord.BlobSHA1,
+			BlobstoreID: dependencyRecord.BlobID,
+		})
+	}
+
+	compiledPackageRef, err := c.agentClient.CompilePackage(packageSource, compiledDependencies)
+	if err != nil {
+		return PackageRef{}, bosherr.WrapError(err, "Compiling package on the agent")
+	}
+
+	record = bminstallpkg.CompiledPackageRecord{
+		BlobID:   compiledPackageRef.BlobstoreID,
+		BlobSHA1: compiledPackageRef.SHA1,
+	}
+	err = c.compiledPackageRepo.Save(*pkg, record)
+	if err != nil {
+		return PackageRef{}, bosherr.WrapError(err, "Saving compiled package")
+	}
+
+	return c.packageRef(pkg, record), nil
+}
+
+func (c *remotePackageCompiler) uploadPackageSource(pkg *bmrel.Package) (bmagentclient.BlobRef, error) {
+	archivePath, err := c.compressor.CompressFilesInDir(pkg.ExtractedPath)
+	if err != nil {
+		return bmagentclient.BlobRef{}, bosherr.WrapError(err, "Compressing package source")
+	}
+	defer func() {
+		if err := c.compressor.CleanUp(archivePath); err != nil {
+			c.logger.Warn(c.logTag, "Failed to clean up package source archive '%s': %s", archivePath, err.Error())
+		}
+	}()
+
+	archiveSHA1, err := c.sha1Calculator.Calculate(archivePath)
+	if err != nil {
+		return bmagentclient.BlobRef{}, bosherr.WrapError(err, "Calculating package source SHA1")
+	}
+
+	blobID, err := c.uuidGenerator.Generate()
+	if err != nil {
+		return bmagentclient.BlobRef{}, bosherr.WrapError(err, "Generating Blob ID")
+	}
+
+	err = c.blobstore.Save(archivePath, blobID)
+	if err != nil {
+		return bmagentclient.BlobRef{}, bosherr.WrapErrorf(err, "Uploading package source blob at '%s'", archivePath)
+	}
+
+	return bmagentclient.BlobRef{
+		Name:        pkg.Name,
+		Version:     pkg.Fingerprint,
+		SHA1:        archiveSHA1,
+		BlobstoreID: blobID,
+	}, nil
+}
+
+func (c *remotePackageCompiler) packageRef(pkg *bmrel.Package, record bminstallpkg.CompiledPackageRecord) PackageRef {
+	return PackageRef{
+		Name:    pkg.Name,
+		Version: pkg.Fingerprint,
+		Archive: BlobRef{
+			SHA1:        record.BlobSHA1,
+			BlobstoreID: record.BlobID,
+		},
+	}
+}
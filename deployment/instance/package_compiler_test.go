@@ -0,0 +1,192 @@
+package instance_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.google.com/p/gomock/gomock"
+	mock_blobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore/mocks"
+	mock_agentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/mocks"
+	mock_release_set "github.com/cloudfoundry/bosh-micro-cli/release/set/mocks"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	fakeboshcmd "github.com/cloudfoundry/bosh-agent/platform/commands/fakes"
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+	fakeboshuuid "github.com/cloudfoundry/bosh-agent/uuid/fakes"
+	fakebmcrypto "github.com/cloudfoundry/bosh-micro-cli/crypto/fakes"
+
+	bmagentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	bmindex "github.com/cloudfoundry/bosh-micro-cli/index"
+	bminstallpkg "github.com/cloudfoundry/bosh-micro-cli/installation/pkg"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
+)
+
+var _ = Describe("PackageCompiler", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	var (
+		mockReleaseSetResolver *mock_release_set.MockResolver
+		mockBlobstore          *mock_blobstore.MockBlobstore
+		mockAgentClient        *mock_agentclient.MockAgentClient
+
+		fakeCompressor     *fakeboshcmd.FakeCompressor
+		fakeSHA1Calculator *fakebmcrypto.FakeSha1Calculator
+		fakeUUIDGenerator  *fakeboshuuid.FakeGenerator
+
+		jobRefs []bmdeplmanifest.ReleaseJobRef
+
+		packageCompiler PackageCompiler
+	)
+
+	BeforeEach(func() {
+		mockReleaseSetResolver = mock_release_set.NewMockResolver(mockCtrl)
+		mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
+		mockAgentClient = mock_agentclient.NewMockAgentClient(mockCtrl)
+
+		fakeCompressor = fakeboshcmd.NewFakeCompressor()
+		fakeCompressor.CompressFilesInDirTarballPath = "/fake-package-source.tgz"
+
+		fakeSHA1Calculator = fakebmcrypto.NewFakeSha1Calculator()
+		fakeSHA1Calculator.SetCalculateBehavior(map[string]fakebmcrypto.CalculateInput{
+			"/fake-package-source.tgz": {Sha1: "fake-source-sha1"},
+		})
+
+		fakeUUIDGenerator = fakeboshuuid.NewFakeGenerator()
+		fakeUUIDGenerator.GeneratedUuid = "fake-source-blob-id"
+
+		rubyPackage := &bmrel.Package{
+			Name:          "ruby",
+			Fingerprint:   "fake-ruby-fingerprint",
+			ExtractedPath: "/fake-extracted-release/packages/ruby",
+		}
+		cpiPackage := &bmrel.Package{
+			Name:          "cpi",
+			Fingerprint:   "fake-cpi-fingerprint",
+			ExtractedPath: "/fake-extracted-release/packages/cpi",
+			Dependencies:  []*bmrel.Package{rubyPackage},
+		}
+		unusedPackage := &bmrel.Package{
+			Name:          "unused",
+			Fingerprint:   "fake-unused-fingerprint",
+			ExtractedPath: "/fake-extracted-release/packages/unused",
+		}
+		release := bmrel.NewRelease(
+			"fake-release-name",
+			"1.0",
+			[]bmrel.Job{
+				{
+					Name:     "fake-release-job-name",
+					Packages: []*bmrel.Package{cpiPackage},
+				},
+			},
+			[]*bmrel.Package{cpiPackage, unusedPackage, rubyPackage},
+			"/fake-extracted-release",
+			fakesys.NewFakeFileSystem(),
+		)
+		mockReleaseSetResolver.EXPECT().Find("fake-release-name").Return(release, nil).AnyTimes()
+
+		jobRefs = []bmdeplmanifest.ReleaseJobRef{
+			{Name: "fake-release-job-name", Release: "fake-release-name"},
+		}
+
+		packageCompiler = NewRemotePackageCompiler(
+			mockReleaseSetResolver,
+			mockBlobstore,
+			mockAgentClient,
+			fakeCompressor,
+			fakeSHA1Calculator,
+			bminstallpkg.NewCompiledPackageRepo(bmindex.NewInMemoryIndex()),
+			fakeUUIDGenerator,
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+	})
+
+	var sourceRef = func(name string) bmagentclient.BlobRef {
+		return bmagentclient.BlobRef{
+			Name:        name,
+			Version:     "fake-" + name + "-fingerprint",
+			SHA1:        "fake-source-sha1",
+			BlobstoreID: "fake-source-blob-id",
+		}
+	}
+
+	var compiledRef = func(name string) bmagentclient.BlobRef {
+		return bmagentclient.BlobRef{
+			Name:        name,
+			Version:     "fake-" + name + "-fingerprint",
+			SHA1:        "fake-compiled-" + name + "-sha1",
+			BlobstoreID: "fake-compiled-" + name + "-blob-id",
+		}
+	}
+
+	It("compiles the packages of the jobs on the agent, dependencies first", func() {
+		mockBlobstore.EXPECT().Save("/fake-package-source.tgz", "fake-source-blob-id").Times(2)
+		gomock.InOrder(
+			mockAgentClient.EXPECT().CompilePackage(sourceRef("ruby"), []bmagentclient.BlobRef{}).Return(compiledRef("ruby"), nil),
+			mockAgentClient.EXPECT().CompilePackage(sourceRef("cpi"), []bmagentclient.BlobRef{compiledRef("ruby")}).Return(compiledRef("cpi"), nil),
+		)
+
+		compiledPackages, err := packageCompiler.Compile(jobRefs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(compiledPackages).To(Equal([]PackageRef{
+			{
+				Name:    "ruby",
+				Version: "fake-ruby-fingerprint",
+				Archive: BlobRef{SHA1: "fake-compiled-ruby-sha1", BlobstoreID: "fake-compiled-ruby-blob-id"},
+			},
+			{
+				Name:    "cpi",
+				Version: "fake-cpi-fingerprint",
+				Archive: BlobRef{SHA1: "fake-compiled-cpi-sha1", BlobstoreID: "fake-compiled-cpi-blob-id"},
+			},
+		}))
+		Expect(fakeCompressor.CleanUpTarballPath).To(Equal("/fake-package-source.tgz"))
+	})
+
+	It("does not compile packages that were already compiled", func() {
+		mockBlobstore.EXPECT().Save("/fake-package-source.tgz", "fake-source-blob-id").Times(2)
+		mockAgentClient.EXPECT().CompilePackage(sourceRef("ruby"), gomock.Any()).Return(compiledRef("ruby"), nil).Times(1)
+		mockAgentClient.EXPECT().CompilePackage(sourceRef("cpi"), gomock.Any()).Return(compiledRef("cpi"), nil).Times(1)
+
+		firstCompiledPackages, err := packageCompiler.Compile(jobRefs)
+		Expect(err).ToNot(HaveOccurred())
+
+		secondCompiledPackages, err := packageCompiler.Compile(jobRefs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secondCompiledPackages).To(Equal(firstCompiledPackages))
+	})
+
+	It("returns an error when the release cannot be found", func() {
+		jobRefs = []bmdeplmanifest.ReleaseJobRef{
+			{Name: "fake-release-job-name", Release: "fake-missing-release-name"},
+		}
+		mockReleaseSetResolver.EXPECT().Find("fake-missing-release-name").Return(nil, bosherr.Error("fake-find-error"))
+
+		_, err := packageCompiler.Compile(jobRefs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Resolving release 'fake-missing-release-name'"))
+	})
+
+	It("returns an error when the agent fails to compile a package", func() {
+		mockBlobstore.EXPECT().Save("/fake-package-source.tgz", "fake-source-blob-id")
+		mockAgentClient.EXPECT().CompilePackage(sourceRef("ruby"), gomock.Any()).Return(bmagentclient.BlobRef{}, bosherr.Error("fake-compile-error"))
+
+		_, err := packageCompiler.Compile(jobRefs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Compiling package 'ruby/fake-ruby-fingerprint'"))
+		Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
+	})
+})
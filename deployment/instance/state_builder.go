@@ -8,17 +8,17 @@ import (
 	bmblobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmdeplrel "github.com/cloudfoundry/bosh-micro-cli/deployment/release"
-	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
 	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
 	bmtemplate "github.com/cloudfoundry/bosh-micro-cli/templatescompiler"
 )
 
 type StateBuilder interface {
-	Build(jobName string, instanceID int, deploymentManifest bmdeplmanifest.Manifest, stemcellApplySpec bmstemcell.ApplySpec) (State, error)
+	Build(jobName string, instanceID int, deploymentManifest bmdeplmanifest.Manifest) (State, error)
 }
 
 type stateBuilder struct {
 	releaseJobResolver        bmdeplrel.JobResolver
+	packageCompiler           PackageCompiler
 	jobListRenderer           bmtemplate.JobListRenderer
 	renderedJobListCompressor bmtemplate.RenderedJobListCompressor
 	blobstore                 bmblobstore.Blobstore
@@ -29,6 +29,7 @@ type stateBuilder struct {
 
 func NewStateBuilder(
 	releaseJobResolver bmdeplrel.JobResolver,
+	packageCompiler PackageCompiler,
 	jobListRenderer bmtemplate.JobListRenderer,
 	renderedJobListCompressor bmtemplate.RenderedJobListCompressor,
 	blobstore bmblobstore.Blobstore,
@@ -37,6 +38,7 @@ func NewStateBuilder(
 ) StateBuilder {
 	return &stateBuilder{
 		releaseJobResolver:        releaseJobResolver,
+		packageCompiler:           packageCompiler,
 		jobListRenderer:           jobListRenderer,
 		renderedJobListCompressor: renderedJobListCompressor,
 		blobstore:                 blobstore,
@@ -46,7 +48,7 @@ func NewStateBuilder(
 	}
 }
 
-func (b *stateBuilder) Build(jobName string, instanceID int, deploymentManifest bmdeplmanifest.Manifest, stemcellApplySpec bmstemcell.ApplySpec) (State, error) {
+func (b *stateBuilder) Build(jobName string, instanceID int, deploymentManifest bmdeplmanifest.Manifest) (State, error) {
 	deploymentJob, found := deploymentManifest.FindJobByName(jobName)
 	if !found {
 		return nil, bosherr.Errorf("Job '%s' not found in deployment manifest", jobName)
@@ -57,7 +59,7 @@ func (b *stateBuilder) Build(jobName string, instanceID int, deploymentManifest
 		return nil, bosherr.Errorf("Resolving jobs for instance '%s/%d'", jobName, instanceID)
 	}
 
-	jobProperties, err := deploymentJob.Properties()
+	jobProperties, err := deploymentManifest.JobProperties(jobName)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Stringifying job properties for instance '%s/%d'", jobName, instanceID)
 	}
@@ -79,7 +81,7 @@ func (b *stateBuilder) Build(jobName string, instanceID int, deploymentManifest
 		return nil, err
 	}
 
-	networkInterfaces, err := deploymentManifest.NetworkInterfaces(deploymentJob.Name)
+	networkInterfaces, err := deploymentManifest.NetworkInterfaces(deploymentJob.Name, instanceID)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Finding networks for job '%s", jobName)
 	}
@@ -102,18 +104,9 @@ func (b *stateBuilder) Build(jobName string, instanceID int, deploymentManifest
 		}
 	}
 
-	// convert map to array
-	stemcellPackages := stemcellApplySpec.Packages
-	compiledPackages := make([]PackageRef, 0, len(stemcellPackages))
-	for _, stemcellPackage := range stemcellPackages {
-		compiledPackages = append(compiledPackages, PackageRef{
-			Name:    stemcellPackage.Name,
-			Version: stemcellPackage.Version,
-			Archive: BlobRef{
-				SHA1:        stemcellPackage.SHA1,
-				BlobstoreID: stemcellPackage.BlobstoreID,
-			},
-		})
+	compiledPackages, err := b.packageCompiler.Compile(deploymentJob.Templates)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Compiling packages for instance '%s/%d'", jobName, instanceID)
 	}
 
 	renderedJobListArchiveBlobRef := BlobRef{
@@ -2,44 +2,77 @@ package instance
 
 import (
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshcmd "github.com/cloudfoundry/bosh-agent/platform/commands"
 	boshuuid "github.com/cloudfoundry/bosh-agent/uuid"
 
 	bmblobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
+	bmcrypto "github.com/cloudfoundry/bosh-micro-cli/crypto"
+	bmagentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
 	bmdeplrel "github.com/cloudfoundry/bosh-micro-cli/deployment/release"
+	bmindex "github.com/cloudfoundry/bosh-micro-cli/index"
+	bminstallpkg "github.com/cloudfoundry/bosh-micro-cli/installation/pkg"
+	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
 	bmtemplate "github.com/cloudfoundry/bosh-micro-cli/templatescompiler"
 )
 
 type StateBuilderFactory interface {
-	NewStateBuilder(bmblobstore.Blobstore) StateBuilder
+	NewStateBuilder(bmblobstore.Blobstore, bmagentclient.AgentClient, bmindex.Index) StateBuilder
 }
 
 type stateBuilderFactory struct {
+	releaseSetResolver        bmrelset.Resolver
 	releaseJobResolver        bmdeplrel.JobResolver
 	jobRenderer               bmtemplate.JobListRenderer
 	renderedJobListCompressor bmtemplate.RenderedJobListCompressor
+	compressor                boshcmd.Compressor
+	sha1Calculator            bmcrypto.SHA1Calculator
 	uuidGenerator             boshuuid.Generator
 	logger                    boshlog.Logger
 }
 
 func NewStateBuilderFactory(
+	releaseSetResolver bmrelset.Resolver,
 	releaseJobResolver bmdeplrel.JobResolver,
 	jobRenderer bmtemplate.JobListRenderer,
 	renderedJobListCompressor bmtemplate.RenderedJobListCompressor,
+	compressor boshcmd.Compressor,
+	sha1Calculator bmcrypto.SHA1Calculator,
 	uuidGenerator boshuuid.Generator,
 	logger boshlog.Logger,
 ) StateBuilderFactory {
 	return &stateBuilderFactory{
+		releaseSetResolver:        releaseSetResolver,
 		releaseJobResolver:        releaseJobResolver,
 		jobRenderer:               jobRenderer,
 		renderedJobListCompressor: renderedJobListCompressor,
+		compressor:                compressor,
+		sha1Calculator:            sha1Calculator,
 		uuidGenerator:             uuidGenerator,
 		logger:                    logger,
 	}
 }
 
-func (f *stateBuilderFactory) NewStateBuilder(blobstore bmblobstore.Blobstore) StateBuilder {
+// NewStateBuilder returns a StateBuilder that uploads to the blobstore of the agent & compiles packages with it.
+// Compiled packages are cached in the compiled package index of the VM of the agent, since they are lost with the VM.
+func (f *stateBuilderFactory) NewStateBuilder(
+	blobstore bmblobstore.Blobstore,
+	agentClient bmagentclient.AgentClient,
+	compiledPackageIndex bmindex.Index,
+) StateBuilder {
+	packageCompiler := NewRemotePackageCompiler(
+		f.releaseSetResolver,
+		blobstore,
+		agentClient,
+		f.compressor,
+		f.sha1Calculator,
+		bminstallpkg.NewCompiledPackageRepo(compiledPackageIndex),
+		f.uuidGenerator,
+		f.logger,
+	)
+
 	return NewStateBuilder(
 		f.releaseJobResolver,
+		packageCompiler,
 		f.jobRenderer,
 		f.renderedJobListCompressor,
 		blobstore,
@@ -18,7 +18,6 @@ import (
 
 	bmas "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
-	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
 	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
 )
 
@@ -37,6 +36,7 @@ var _ = Describe("StateBuilder", func() {
 		logger boshlog.Logger
 
 		mockReleaseJobResolver *mock_deployment_release.MockJobResolver
+		mockPackageCompiler    *mock_instance.MockPackageCompiler
 		mockJobListRenderer    *mock_template.MockJobListRenderer
 		mockCompressor         *mock_template.MockRenderedJobListCompressor
 		mockBlobstore          *mock_blobstore.MockBlobstore
@@ -52,6 +52,7 @@ var _ = Describe("StateBuilder", func() {
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 
 		mockReleaseJobResolver = mock_deployment_release.NewMockJobResolver(mockCtrl)
+		mockPackageCompiler = mock_instance.NewMockPackageCompiler(mockCtrl)
 		mockJobListRenderer = mock_template.NewMockJobListRenderer(mockCtrl)
 		mockCompressor = mock_template.NewMockRenderedJobListCompressor(mockCtrl)
 		mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
@@ -70,7 +71,6 @@ var _ = Describe("StateBuilder", func() {
 			jobName            string
 			instanceID         int
 			deploymentManifest bmdeplmanifest.Manifest
-			stemcellApplySpec  bmstemcell.ApplySpec
 		)
 
 		BeforeEach(func() {
@@ -101,6 +101,10 @@ var _ = Describe("StateBuilder", func() {
 						},
 					},
 				},
+				RawProperties: map[interface{}]interface{}{
+					"fake-job-property":    "fake-global-property-value",
+					"fake-global-property": "fake-global-property-value",
+				},
 				Networks: []bmdeplmanifest.Network{
 					{
 						Name: "fake-network-name",
@@ -113,25 +117,9 @@ var _ = Describe("StateBuilder", func() {
 				},
 			}
 
-			stemcellApplySpec = bmstemcell.ApplySpec{
-				Packages: map[string]bmstemcell.Blob{
-					"cpi": bmstemcell.Blob{
-						Name:        "cpi",
-						Version:     "fake-fingerprint-cpi",
-						SHA1:        "fake-sha1-cpi",
-						BlobstoreID: "fake-package-blob-id-cpi",
-					},
-					"ruby": bmstemcell.Blob{
-						Name:        "ruby",
-						Version:     "fake-fingerprint-ruby",
-						SHA1:        "fake-sha1-ruby",
-						BlobstoreID: "fake-package-blob-id-ruby",
-					},
-				},
-			}
-
 			stateBuilder = NewStateBuilder(
 				mockReleaseJobResolver,
+				mockPackageCompiler,
 				mockJobListRenderer,
 				mockCompressor,
 				mockBlobstore,
@@ -147,9 +135,30 @@ var _ = Describe("StateBuilder", func() {
 			}
 			mockReleaseJobResolver.EXPECT().Resolve("fake-release-job-name", "fake-release-name").Return(releaseJob, nil)
 
+			compiledPackages := []PackageRef{
+				{
+					Name:    "cpi",
+					Version: "fake-fingerprint-cpi",
+					Archive: BlobRef{
+						SHA1:        "fake-sha1-cpi",
+						BlobstoreID: "fake-package-blob-id-cpi",
+					},
+				},
+				{
+					Name:    "ruby",
+					Version: "fake-fingerprint-ruby",
+					Archive: BlobRef{
+						SHA1:        "fake-sha1-ruby",
+						BlobstoreID: "fake-package-blob-id-ruby",
+					},
+				},
+			}
+			mockPackageCompiler.EXPECT().Compile(deploymentManifest.Jobs[0].Templates).Return(compiledPackages, nil)
+
 			releaseJobs := []bmrel.Job{releaseJob}
 			jobProperties := map[string]interface{}{
-				"fake-job-property": "fake-job-property-value",
+				"fake-job-property":    "fake-job-property-value",
+				"fake-global-property": "fake-global-property-value",
 			}
 			mockJobListRenderer.EXPECT().Render(releaseJobs, jobProperties, "fake-deployment-name").Return(mockRenderedJobList, nil)
 
@@ -169,15 +178,16 @@ var _ = Describe("StateBuilder", func() {
 		})
 
 		It("builds a new instance state with zero-to-many networks", func() {
-			state, err := stateBuilder.Build(jobName, instanceID, deploymentManifest, stemcellApplySpec)
+			state, err := stateBuilder.Build(jobName, instanceID, deploymentManifest)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(state.NetworkInterfaces()).To(HaveLen(1))
 			Expect(state.NetworkInterfaces()).To(ContainElement(NetworkRef{
 				Name: "fake-network-name",
 				Interface: map[string]interface{}{
-					"ip":   "fake-network-ip",
-					"type": "fake-network-type",
+					"ip":      "fake-network-ip",
+					"type":    "fake-network-type",
+					"default": []string{"dns", "gateway"},
 					"cloud_properties": map[string]interface{}{
 						"fake-network-cloud-property": "fake-network-cloud-property-value",
 					},
@@ -186,7 +196,7 @@ var _ = Describe("StateBuilder", func() {
 		})
 
 		It("builds a new instance state with zero-to-many rendered jobs from one or more releases", func() {
-			state, err := stateBuilder.Build(jobName, instanceID, deploymentManifest, stemcellApplySpec)
+			state, err := stateBuilder.Build(jobName, instanceID, deploymentManifest)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(state.RenderedJobs()).To(HaveLen(1))
@@ -203,7 +213,7 @@ var _ = Describe("StateBuilder", func() {
 		})
 
 		It("builds a new instance state with zero-to-many compiled packages from one or more releases", func() {
-			state, err := stateBuilder.Build(jobName, instanceID, deploymentManifest, stemcellApplySpec)
+			state, err := stateBuilder.Build(jobName, instanceID, deploymentManifest)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(state.CompiledPackages()).To(HaveLen(2))
@@ -226,7 +236,7 @@ var _ = Describe("StateBuilder", func() {
 		})
 
 		It("builds an instance state that can be converted to an ApplySpec", func() {
-			state, err := stateBuilder.Build(jobName, instanceID, deploymentManifest, stemcellApplySpec)
+			state, err := stateBuilder.Build(jobName, instanceID, deploymentManifest)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(state.ToApplySpec()).To(Equal(bmas.ApplySpec{
@@ -234,8 +244,9 @@ var _ = Describe("StateBuilder", func() {
 				Index:      0,
 				Networks: map[string]interface{}{
 					"fake-network-name": map[string]interface{}{
-						"ip":   "fake-network-ip",
-						"type": "fake-network-type",
+						"ip":      "fake-network-ip",
+						"type":    "fake-network-type",
+						"default": []string{"dns", "gateway"},
 						"cloud_properties": map[string]interface{}{
 							"fake-network-cloud-property": "fake-network-cloud-property-value",
 						},
@@ -3,6 +3,7 @@ package deployment
 import (
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	bminstance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
@@ -14,11 +15,16 @@ type Manager interface {
 	Cleanup(bmeventlog.Stage) error
 }
 
+// OtherInstanceManagerFunc returns the instance manager of a recorded instance after the first one
+type OtherInstanceManagerFunc func(bmconfig.InstanceRecord) (bminstance.Manager, error)
+
 type manager struct {
-	instanceManager   bminstance.Manager
-	diskManager       bmdisk.Manager
-	stemcellManager   bmstemcell.Manager
-	deploymentFactory Factory
+	instanceManager      bminstance.Manager
+	diskManager          bmdisk.Manager
+	stemcellManager      bmstemcell.Manager
+	deploymentFactory    Factory
+	instanceRepo         bmconfig.InstanceRepo
+	otherInstanceManager OtherInstanceManagerFunc
 }
 
 func NewManager(
@@ -26,21 +32,45 @@ func NewManager(
 	diskManager bmdisk.Manager,
 	stemcellManager bmstemcell.Manager,
 	deploymentFactory Factory,
+	instanceRepo bmconfig.InstanceRepo,
+	otherInstanceManager OtherInstanceManagerFunc,
 ) Manager {
 	return &manager{
-		instanceManager:   instanceManager,
-		diskManager:       diskManager,
-		stemcellManager:   stemcellManager,
-		deploymentFactory: deploymentFactory,
+		instanceManager:      instanceManager,
+		diskManager:          diskManager,
+		stemcellManager:      stemcellManager,
+		deploymentFactory:    deploymentFactory,
+		instanceRepo:         instanceRepo,
+		otherInstanceManager: otherInstanceManager,
 	}
 }
 
+// FindCurrent returns the deployment of the first instance & of the recorded other instances.
+// The disks of the other instances become unused once Cleanup forgets their records.
 func (m *manager) FindCurrent() (deployment Deployment, found bool, err error) {
 	instances, err := m.instanceManager.FindCurrent()
 	if err != nil {
 		return nil, false, bosherr.WrapError(err, "Finding current deployment instances")
 	}
 
+	instanceRecords, err := m.instanceRepo.Others()
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Finding current deployment instance records")
+	}
+
+	for _, instanceRecord := range instanceRecords {
+		otherInstanceManager, err := m.otherInstanceManager(instanceRecord)
+		if err != nil {
+			return nil, false, err
+		}
+
+		otherInstances, err := otherInstanceManager.FindCurrent()
+		if err != nil {
+			return nil, false, bosherr.WrapErrorf(err, "Finding current instance '%s/%d'", instanceRecord.JobName, instanceRecord.Index)
+		}
+		instances = append(instances, otherInstances...)
+	}
+
 	disks, err := m.diskManager.FindCurrent()
 	if err != nil {
 		return nil, false, bosherr.WrapError(err, "Finding current deployment disks")
@@ -58,7 +88,19 @@ func (m *manager) FindCurrent() (deployment Deployment, found bool, err error) {
 	return m.deploymentFactory.NewDeployment(instances, disks, stemcells), true, nil
 }
 
+// Cleanup forgets the instances after the first one, then deletes the unused disks & stemcells
 func (m *manager) Cleanup(stage bmeventlog.Stage) error {
+	instanceRecords, err := m.instanceRepo.Others()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding instance records")
+	}
+
+	for _, instanceRecord := range instanceRecords {
+		if err := m.instanceRepo.Delete(instanceRecord.JobName, instanceRecord.Index); err != nil {
+			return bosherr.WrapErrorf(err, "Deleting the record of instance '%s/%d'", instanceRecord.JobName, instanceRecord.Index)
+		}
+	}
+
 	if err := m.diskManager.DeleteUnused(stage); err != nil {
 		return err
 	}
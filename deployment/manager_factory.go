@@ -3,7 +3,8 @@ package deployment
 import (
 	bmblobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
 	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
-	bmac "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	bminstance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
@@ -11,7 +12,7 @@ import (
 )
 
 type ManagerFactory interface {
-	NewManager(bmcloud.Cloud, bmac.AgentClient, bmblobstore.Blobstore) Manager
+	NewManager(cloud bmcloud.Cloud, directorID string, mbusURL string, blobstore bmblobstore.Blobstore) Manager
 }
 
 type managerFactory struct {
@@ -20,6 +21,8 @@ type managerFactory struct {
 	diskManagerFactory     bmdisk.ManagerFactory
 	stemcellManagerFactory bmstemcell.ManagerFactory
 	deploymentFactory      Factory
+	agentClientFactory     bmhttpagent.AgentClientFactory
+	instanceRepo           bmconfig.InstanceRepo
 }
 
 func NewManagerFactory(
@@ -28,6 +31,8 @@ func NewManagerFactory(
 	diskManagerFactory bmdisk.ManagerFactory,
 	stemcellManagerFactory bmstemcell.ManagerFactory,
 	deploymentFactory Factory,
+	agentClientFactory bmhttpagent.AgentClientFactory,
+	instanceRepo bmconfig.InstanceRepo,
 ) ManagerFactory {
 	return &managerFactory{
 		vmManagerFactory:       vmManagerFactory,
@@ -35,14 +40,23 @@ func NewManagerFactory(
 		diskManagerFactory:     diskManagerFactory,
 		stemcellManagerFactory: stemcellManagerFactory,
 		deploymentFactory:      deploymentFactory,
+		agentClientFactory:     agentClientFactory,
+		instanceRepo:           instanceRepo,
 	}
 }
 
-func (f *managerFactory) NewManager(cloud bmcloud.Cloud, agentClient bmac.AgentClient, blobstore bmblobstore.Blobstore) Manager {
+// NewManager returns a Manager of the first instance, reached at the mbus URL,
+// & of the other instances, reached at the mbus URL with their IP as host.
+func (f *managerFactory) NewManager(cloud bmcloud.Cloud, directorID string, mbusURL string, blobstore bmblobstore.Blobstore) Manager {
+	agentClient := f.agentClientFactory.NewAgentClient(directorID, mbusURL)
 	vmManager := f.vmManagerFactory.NewManager(cloud, agentClient)
 	instanceManager := f.instanceManagerFactory.NewManager(cloud, vmManager, blobstore)
 	diskManager := f.diskManagerFactory.NewManager(cloud)
 	stemcellManager := f.stemcellManagerFactory.NewManager(cloud)
 
-	return NewManager(instanceManager, diskManager, stemcellManager, f.deploymentFactory)
+	otherInstanceManager := func(instanceRecord bmconfig.InstanceRecord) (bminstance.Manager, error) {
+		return newOtherInstanceManager(f.vmManagerFactory, f.instanceManagerFactory, f.agentClientFactory, cloud, instanceRecord, directorID, mbusURL, blobstore)
+	}
+
+	return NewManager(instanceManager, diskManager, stemcellManager, f.deploymentFactory, f.instanceRepo, otherInstanceManager)
 }
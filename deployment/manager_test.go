@@ -9,6 +9,7 @@ import (
 	"code.google.com/p/gomock/gomock"
 	mock_blobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore/mocks"
 	mock_cloud "github.com/cloudfoundry/bosh-micro-cli/cloud/mocks"
+	mock_httpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http/mocks"
 	mock_agentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/mocks"
 	mock_disk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk/mocks"
 	mock_instance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance/mocks"
@@ -54,6 +55,10 @@ var _ = Describe("Manager", func() {
 
 			deploymentManager Manager
 
+			instanceRepo               bmconfig.InstanceRepo
+			mockOtherInstanceManager   *mock_instance.MockManager
+			otherInstanceManagerInputs []bmconfig.InstanceRecord
+
 			expectedInstances []bminstance.Instance
 			expectedDisks     []bmdisk.Disk
 			expectedStemcells []bmstemcell.CloudStemcell
@@ -68,19 +73,31 @@ var _ = Describe("Manager", func() {
 			mockDeploymentFactory = mock_deployment.NewMockFactory(mockCtrl)
 			mockDeployment = mock_deployment.NewMockDeployment(mockCtrl)
 
+			instanceRepo = bmconfig.NewInstanceRepo(bmconfig.NewInMemoryDeploymentConfigService(bmconfig.DeploymentFile{}))
+			mockOtherInstanceManager = mock_instance.NewMockManager(mockCtrl)
+			otherInstanceManagerInputs = []bmconfig.InstanceRecord{}
+
 			expectedInstances = []bminstance.Instance{}
 			expectedDisks = []bmdisk.Disk{}
 			expectedStemcells = []bmstemcell.CloudStemcell{}
 		})
 
 		JustBeforeEach(func() {
-			mockInstanceManager.EXPECT().FindCurrent().Return(expectedInstances, nil)
+			firstInstances := expectedInstances
+			if len(firstInstances) > 0 {
+				firstInstances = expectedInstances[:1]
+			}
+			mockInstanceManager.EXPECT().FindCurrent().Return(firstInstances, nil)
 			mockDiskManager.EXPECT().FindCurrent().Return(expectedDisks, nil)
 			mockStemcellManager.EXPECT().FindCurrent().Return(expectedStemcells, nil)
 
 			expectNewDeployment = mockDeploymentFactory.EXPECT().NewDeployment(expectedInstances, expectedDisks, expectedStemcells).Return(mockDeployment).AnyTimes()
 
-			deploymentManager = NewManager(mockInstanceManager, mockDiskManager, mockStemcellManager, mockDeploymentFactory)
+			otherInstanceManager := func(instanceRecord bmconfig.InstanceRecord) (bminstance.Manager, error) {
+				otherInstanceManagerInputs = append(otherInstanceManagerInputs, instanceRecord)
+				return mockOtherInstanceManager, nil
+			}
+			deploymentManager = NewManager(mockInstanceManager, mockDiskManager, mockStemcellManager, mockDeploymentFactory, instanceRepo, otherInstanceManager)
 		})
 
 		Context("when no current instances, disks, or stemcells exist", func() {
@@ -107,6 +124,29 @@ var _ = Describe("Manager", func() {
 			})
 		})
 
+		Context("when other instances are recorded", func() {
+			BeforeEach(func() {
+				expectedInstances = append(expectedInstances, mock_instance.NewMockInstance(mockCtrl), mock_instance.NewMockInstance(mockCtrl))
+
+				_, err := instanceRepo.Save("fake-job-name", 1, "10.0.0.11")
+				Expect(err).ToNot(HaveOccurred())
+
+				mockOtherInstanceManager.EXPECT().FindCurrent().Return(expectedInstances[1:], nil)
+			})
+
+			It("returns a deployment that also wraps the current other instances", func() {
+				expectNewDeployment.Times(1)
+
+				deployment, found, err := deploymentManager.FindCurrent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(deployment).To(Equal(mockDeployment))
+				Expect(otherInstanceManagerInputs).To(Equal([]bmconfig.InstanceRecord{
+					{JobName: "fake-job-name", Index: 1, IP: "10.0.0.11"},
+				}))
+			})
+		})
+
 		Context("when current disk exist", func() {
 			BeforeEach(func() {
 				disk := mock_disk.NewMockDisk(mockCtrl)
@@ -158,8 +198,9 @@ var _ = Describe("Manager", func() {
 			diskRepo                bmconfig.DiskRepo
 			stemcellRepo            bmconfig.StemcellRepo
 
-			mockCloud       *mock_cloud.MockCloud
-			mockAgentClient *mock_agentclient.MockAgentClient
+			mockCloud              *mock_cloud.MockCloud
+			mockAgentClient        *mock_agentclient.MockAgentClient
+			mockAgentClientFactory *mock_httpagent.MockAgentClientFactory
 
 			deploymentConfigPath = "/deployment.json"
 
@@ -184,6 +225,8 @@ var _ = Describe("Manager", func() {
 
 			mockCloud = mock_cloud.NewMockCloud(mockCtrl)
 			mockAgentClient = mock_agentclient.NewMockAgentClient(mockCtrl)
+			mockAgentClientFactory = mock_httpagent.NewMockAgentClientFactory(mockCtrl)
+			mockAgentClientFactory.EXPECT().NewAgentClient("fake-director-id", "https://fake-mbus-host:6868").Return(mockAgentClient).AnyTimes()
 
 			fakeStage = fakebmeventlog.NewFakeStage()
 		})
@@ -192,7 +235,7 @@ var _ = Describe("Manager", func() {
 			diskManagerFactory := bmdisk.NewManagerFactory(diskRepo, logger)
 			diskDeployer := bmvm.NewDiskDeployer(diskManagerFactory, diskRepo, logger)
 
-			vmManagerFactory := bmvm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+			vmManagerFactory := bmvm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, deploymentConfigService, fakeUUIDGenerator, fs, logger)
 			sshTunnelFactory := bmsshtunnel.NewFactory(logger)
 
 			mockStateBuilderFactory = mock_instance.NewMockStateBuilderFactory(mockCtrl)
@@ -203,8 +246,8 @@ var _ = Describe("Manager", func() {
 
 			mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
 
-			deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceManagerFactory, diskManagerFactory, stemcellManagerFactory, mockDeploymentFactory)
-			deploymentManager = deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore)
+			deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceManagerFactory, diskManagerFactory, stemcellManagerFactory, mockDeploymentFactory, mockAgentClientFactory, bmconfig.NewInstanceRepo(deploymentConfigService))
+			deploymentManager = deploymentManagerFactory.NewManager(mockCloud, "fake-director-id", "https://fake-mbus-host:6868", mockBlobstore)
 		})
 
 		Context("no orphan disk or stemcell records exist", func() {
@@ -249,6 +292,38 @@ var _ = Describe("Manager", func() {
 			})
 		})
 
+		Context("when other instances are recorded", func() {
+			var instanceRepo bmconfig.InstanceRepo
+
+			BeforeEach(func() {
+				instanceRepo = bmconfig.NewInstanceRepo(deploymentConfigService)
+				_, err := instanceRepo.Save("fake-job-name", 1, "10.0.0.11")
+				Expect(err).ToNot(HaveOccurred())
+
+				otherDiskRecord, err := diskRepo.Save("other-disk-cid", 100, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				otherDiskRepo := bmconfig.NewInstanceDiskRepo(deploymentConfigService, fakeRepoUUIDGenerator, "fake-job-name", 1)
+				err = otherDiskRepo.UpdateCurrent(otherDiskRecord.ID)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("forgets the other instances & deletes their disks", func() {
+				mockCloud.EXPECT().DeleteDisk("other-disk-cid")
+
+				err := deploymentManager.Cleanup(fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+
+				instanceRecords, err := instanceRepo.Others()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(instanceRecords).To(BeEmpty())
+
+				diskRecords, err := diskRepo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diskRecords).To(BeEmpty())
+			})
+		})
+
 		Context("orphan disk records exist", func() {
 			BeforeEach(func() {
 				_, err := diskRepo.Save("orphan-disk-cid", 100, nil)
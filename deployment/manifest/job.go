@@ -10,6 +10,7 @@ type Job struct {
 	Lifecycle          JobLifecycle
 	Templates          []ReleaseJobRef
 	Networks           []JobNetwork
+	ResourcePool       string                      `yaml:"resource_pool"`
 	PersistentDisk     int                         `yaml:"persistent_disk"`
 	PersistentDiskPool string                      `yaml:"persistent_disk_pool"`
 	RawProperties      map[interface{}]interface{} `yaml:"properties"`
@@ -40,6 +41,14 @@ const (
 	NetworkDefaultGateway NetworkDefault = "gateway"
 )
 
+// networkDefaults are the settings a job network can be the default for, in the order of the network spec
+var networkDefaults = []NetworkDefault{NetworkDefaultDNS, NetworkDefaultGateway}
+
+// IsErrand returns true if the job is run on demand with run-errand, instead of being deployed
+func (j Job) IsErrand() bool {
+	return j.Lifecycle == JobLifecycleErrand
+}
+
 func (j *Job) Properties() (map[string]interface{}, error) {
 	return bmkeystr.NewKeyStringifier().ConvertMap(j.RawProperties)
 }
@@ -1,6 +1,8 @@
 package manifest
 
 import (
+	"strings"
+
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	bmkeystr "github.com/cloudfoundry/bosh-micro-cli/keystringifier"
 )
@@ -17,15 +19,40 @@ type Manifest struct {
 
 type Update struct {
 	UpdateWatchTime WatchTime
+
+	// MaxInFlight is the maximum number of instances created at the same time
+	MaxInFlight int
 }
 
 func (d Manifest) Properties() (map[string]interface{}, error) {
 	return bmkeystr.NewKeyStringifier().ConvertMap(d.RawProperties)
 }
 
-// NetworkInterfaces returns a map of network names to network interfaces.
+// JobProperties returns the global properties deep-merged with the properties of the job, the job's values winning.
+// Defaults of the release jobs are not included, they are resolved when rendering the templates.
+func (d Manifest) JobProperties(jobName string) (map[string]interface{}, error) {
+	job, found := d.FindJobByName(jobName)
+	if !found {
+		return map[string]interface{}{}, bosherr.Errorf("Could not find job with name: %s", jobName)
+	}
+
+	globalProperties, err := d.Properties()
+	if err != nil {
+		return map[string]interface{}{}, bosherr.WrapError(err, "Stringifying global properties")
+	}
+
+	jobProperties, err := job.Properties()
+	if err != nil {
+		return map[string]interface{}{}, bosherr.WrapErrorf(err, "Stringifying properties of job '%s'", jobName)
+	}
+
+	return mergeProperties(globalProperties, jobProperties), nil
+}
+
+// NetworkInterfaces returns a map of network names to network interfaces of an instance of the job,
+// which gets the static IP at its index on each network.
 // We can't use map[string]NetworkInterface, because it's impossible to down-cast to what the cloud client requires.
-func (d Manifest) NetworkInterfaces(jobName string) (map[string]map[string]interface{}, error) {
+func (d Manifest) NetworkInterfaces(jobName string, index int) (map[string]map[string]interface{}, error) {
 	job, found := d.FindJobByName(jobName)
 	if !found {
 		return map[string]map[string]interface{}{}, bosherr.Errorf("Could not find job with name: %s", jobName)
@@ -33,23 +60,101 @@ func (d Manifest) NetworkInterfaces(jobName string) (map[string]map[string]inter
 
 	networkMap := d.networkMap()
 
+	defaultNetworks, err := d.defaultNetworks(job)
+	if err != nil {
+		return map[string]map[string]interface{}{}, bosherr.WrapErrorf(err, "Finding default networks of job '%s'", jobName)
+	}
+
 	ifaceMap := map[string]map[string]interface{}{}
-	var err error
 	for _, jobNetwork := range job.Networks {
 		network := networkMap[jobNetwork.Name]
 		staticIPs := jobNetwork.StaticIPs
-		if len(staticIPs) > 0 {
-			network.IP = staticIPs[0]
+		if len(staticIPs) > index {
+			network.IP = staticIPs[index]
 		}
-		ifaceMap[jobNetwork.Name], err = network.Interface()
+		iface, err := network.Interface()
 		if err != nil {
 			return map[string]map[string]interface{}{}, bosherr.WrapError(err, "Building network spec")
 		}
+
+		defaults := []string{}
+		for _, networkDefault := range networkDefaults {
+			if defaultNetworks[networkDefault] == jobNetwork.Name {
+				defaults = append(defaults, string(networkDefault))
+			}
+		}
+		if len(defaults) > 0 {
+			iface["default"] = defaults
+		}
+
+		ifaceMap[jobNetwork.Name] = iface
 	}
 
 	return ifaceMap, nil
 }
 
+// InstanceIP returns the static IP of an instance of the job on its default gateway network
+func (d Manifest) InstanceIP(jobName string, index int) (string, error) {
+	job, found := d.FindJobByName(jobName)
+	if !found {
+		return "", bosherr.Errorf("Could not find job with name: %s", jobName)
+	}
+
+	defaultNetworks, err := d.defaultNetworks(job)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Finding default networks of job '%s'", jobName)
+	}
+
+	gatewayNetwork := defaultNetworks[NetworkDefaultGateway]
+	for _, jobNetwork := range job.Networks {
+		if jobNetwork.Name == gatewayNetwork && len(jobNetwork.StaticIPs) > index {
+			return jobNetwork.StaticIPs[index], nil
+		}
+	}
+
+	return "", bosherr.Errorf("Job '%s' has no static IP for instance %d on its default gateway network", jobName, index)
+}
+
+// defaultNetworks returns the name of the job network owning each default ('dns' & 'gateway'):
+// the network setting it with 'default', or else the only network of the job that is not a vip network.
+// It returns an error when a default is set on more than one network,
+// or is not set while more than one network could own it, the way the director does.
+func (d Manifest) defaultNetworks(job Job) (map[NetworkDefault]string, error) {
+	networkMap := d.networkMap()
+
+	eligibleNames := []string{}
+	for _, jobNetwork := range job.Networks {
+		if networkMap[jobNetwork.Name].Type != VIP {
+			eligibleNames = append(eligibleNames, jobNetwork.Name)
+		}
+	}
+
+	defaultNetworks := map[NetworkDefault]string{}
+	for _, networkDefault := range networkDefaults {
+		names := []string{}
+		for _, jobNetwork := range job.Networks {
+			for _, value := range jobNetwork.Default {
+				if value == networkDefault {
+					names = append(names, jobNetwork.Name)
+				}
+			}
+		}
+
+		switch {
+		case len(names) == 1:
+			defaultNetworks[networkDefault] = names[0]
+		case len(names) > 1:
+			return defaultNetworks, bosherr.Errorf("must set default '%s' on only one network, but set it on '%s'", networkDefault, strings.Join(names, "', '"))
+		case len(eligibleNames) == 1:
+			defaultNetworks[networkDefault] = eligibleNames[0]
+		case len(eligibleNames) > 1:
+			return defaultNetworks, bosherr.Errorf("must set default '%s' on one of '%s', since more than one network is configured", networkDefault, strings.Join(eligibleNames, "', '"))
+		}
+	}
+
+	return defaultNetworks, nil
+}
+
 func (d Manifest) DiskPool(jobName string) (DiskPool, error) {
 	job, found := d.FindJobByName(jobName)
 	if !found {
@@ -77,6 +182,29 @@ func (d Manifest) DiskPool(jobName string) (DiskPool, error) {
 	return DiskPool{}, nil
 }
 
+// ResourcePool returns the resource pool of the job, which may be omitted when there is only one
+func (d Manifest) ResourcePool(jobName string) (ResourcePool, error) {
+	job, found := d.FindJobByName(jobName)
+	if !found {
+		return ResourcePool{}, bosherr.Errorf("Could not find job with name: %s", jobName)
+	}
+
+	if job.ResourcePool == "" {
+		if len(d.ResourcePools) != 1 {
+			return ResourcePool{}, bosherr.Errorf("Job '%s' must set a resource pool, since there are %d", jobName, len(d.ResourcePools))
+		}
+		return d.ResourcePools[0], nil
+	}
+
+	for _, resourcePool := range d.ResourcePools {
+		if resourcePool.Name == job.ResourcePool {
+			return resourcePool, nil
+		}
+	}
+
+	return ResourcePool{}, bosherr.Errorf("Could not find resource pool '%s' for job '%s'", job.ResourcePool, jobName)
+}
+
 func (d Manifest) networkMap() map[string]Network {
 	result := map[string]Network{}
 	for _, network := range d.Networks {
@@ -94,3 +222,23 @@ func (d Manifest) FindJobByName(jobName string) (Job, bool) {
 
 	return Job{}, false
 }
+
+// mergeProperties returns a copy of the base properties with the overrides merged in, recursively for nested maps
+func mergeProperties(base, overrides map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range overrides {
+		baseMap, baseIsMap := result[key].(map[string]interface{})
+		overrideMap, overrideIsMap := value.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			result[key] = mergeProperties(baseMap, overrideMap)
+			continue
+		}
+		result[key] = value
+	}
+
+	return result
+}
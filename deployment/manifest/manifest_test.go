@@ -45,6 +45,7 @@ var _ = Describe("Manifest", func() {
 								{
 									Name:      "fake-network-name",
 									StaticIPs: []string{"5.6.7.8"},
+									Default:   []NetworkDefault{"dns", "gateway"},
 								},
 								{
 									Name:      "fake-manual-network-name",
@@ -57,11 +58,12 @@ var _ = Describe("Manifest", func() {
 			})
 
 			It("is a map of the network names to network interfaces", func() {
-				Expect(deploymentManifest.NetworkInterfaces("fake-job-name")).To(Equal(map[string]map[string]interface{}{
+				Expect(deploymentManifest.NetworkInterfaces("fake-job-name", 0)).To(Equal(map[string]map[string]interface{}{
 					"fake-network-name": map[string]interface{}{
 						"type":             "dynamic",
 						"ip":               "5.6.7.8",
 						"cloud_properties": map[string]interface{}{},
+						"default":          []string{"dns", "gateway"},
 					},
 					"fake-manual-network-name": map[string]interface{}{
 						"type":             "manual",
@@ -75,6 +77,95 @@ var _ = Describe("Manifest", func() {
 					},
 				}))
 			})
+
+			It("uses the static IPs at the index of the instance", func() {
+				deploymentManifest.Jobs[0].Networks[1].StaticIPs = []string{"5.6.7.8", "5.6.7.10"}
+
+				networkInterfaces, err := deploymentManifest.NetworkInterfaces("fake-job-name", 1)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(networkInterfaces["fake-network-name"]["ip"]).To(Equal("5.6.7.10"))
+				Expect(networkInterfaces["vip"]["ip"]).To(Equal(""))
+			})
+		})
+
+		Context("when the job has one network that is not a vip network", func() {
+			BeforeEach(func() {
+				deploymentManifest = Manifest{
+					Networks: []Network{
+						{Name: "fake-network-name", Type: "dynamic"},
+						{Name: "vip", Type: "vip"},
+					},
+					Jobs: []Job{
+						{
+							Name: "fake-job-name",
+							Networks: []JobNetwork{
+								{Name: "vip", StaticIPs: []string{"1.2.3.4"}},
+								{Name: "fake-network-name"},
+							},
+						},
+					},
+				}
+			})
+
+			It("makes it the default for dns & gateway", func() {
+				networkInterfaces, err := deploymentManifest.NetworkInterfaces("fake-job-name", 0)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(networkInterfaces["fake-network-name"]["default"]).To(Equal([]string{"dns", "gateway"}))
+				Expect(networkInterfaces["vip"]).ToNot(HaveKey("default"))
+			})
+		})
+
+		Context("when the defaults are split between networks", func() {
+			BeforeEach(func() {
+				deploymentManifest = Manifest{
+					Networks: []Network{
+						{Name: "fake-network-1", Type: "dynamic"},
+						{Name: "fake-network-2", Type: "manual"},
+					},
+					Jobs: []Job{
+						{
+							Name: "fake-job-name",
+							Networks: []JobNetwork{
+								{Name: "fake-network-1", Default: []NetworkDefault{"gateway"}},
+								{Name: "fake-network-2", Default: []NetworkDefault{"dns"}},
+							},
+						},
+					},
+				}
+			})
+
+			It("sets each default on its network", func() {
+				networkInterfaces, err := deploymentManifest.NetworkInterfaces("fake-job-name", 0)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(networkInterfaces["fake-network-1"]["default"]).To(Equal([]string{"gateway"}))
+				Expect(networkInterfaces["fake-network-2"]["default"]).To(Equal([]string{"dns"}))
+			})
+		})
+
+		Context("when the defaults are ambiguous", func() {
+			BeforeEach(func() {
+				deploymentManifest = Manifest{
+					Networks: []Network{
+						{Name: "fake-network-1", Type: "dynamic"},
+						{Name: "fake-network-2", Type: "manual"},
+					},
+					Jobs: []Job{
+						{
+							Name: "fake-job-name",
+							Networks: []JobNetwork{
+								{Name: "fake-network-1", Default: []NetworkDefault{"dns"}},
+								{Name: "fake-network-2"},
+							},
+						},
+					},
+				}
+			})
+
+			It("returns an error", func() {
+				_, err := deploymentManifest.NetworkInterfaces("fake-job-name", 0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("must set default 'gateway' on one of 'fake-network-1', 'fake-network-2', since more than one network is configured"))
+			})
 		})
 
 		Context("when the deployment does not have networks", func() {
@@ -90,7 +181,7 @@ var _ = Describe("Manifest", func() {
 			})
 
 			It("is an empty map", func() {
-				Expect(deploymentManifest.NetworkInterfaces("fake-job-name")).To(Equal(map[string]map[string]interface{}{}))
+				Expect(deploymentManifest.NetworkInterfaces("fake-job-name", 0)).To(Equal(map[string]map[string]interface{}{}))
 			})
 		})
 
@@ -100,7 +191,7 @@ var _ = Describe("Manifest", func() {
 			})
 
 			It("returns an error", func() {
-				networkInterfaces, err := deploymentManifest.NetworkInterfaces("fake-job-name")
+				networkInterfaces, err := deploymentManifest.NetworkInterfaces("fake-job-name", 0)
 				Expect(networkInterfaces).To(Equal(map[string]map[string]interface{}{}))
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("Could not find job with name: fake-job-name"))
@@ -108,6 +199,77 @@ var _ = Describe("Manifest", func() {
 		})
 	})
 
+	Describe("InstanceIP", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				Networks: []Network{
+					{Name: "vip", Type: "vip"},
+					{Name: "fake-network-name", Type: "manual"},
+				},
+				Jobs: []Job{
+					{
+						Name: "fake-job-name",
+						Networks: []JobNetwork{
+							{Name: "vip", StaticIPs: []string{"1.2.3.4", "1.2.3.5"}},
+							{Name: "fake-network-name", StaticIPs: []string{"10.0.0.4", "10.0.0.5"}},
+						},
+					},
+				},
+			}
+		})
+
+		It("returns the static IP of the instance on the default gateway network", func() {
+			Expect(deploymentManifest.InstanceIP("fake-job-name", 1)).To(Equal("10.0.0.5"))
+		})
+
+		It("returns an error when the instance has no static IP on the default gateway network", func() {
+			_, err := deploymentManifest.InstanceIP("fake-job-name", 2)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Job 'fake-job-name' has no static IP for instance 2 on its default gateway network"))
+		})
+	})
+
+	Describe("ResourcePool", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				ResourcePools: []ResourcePool{
+					{Name: "fake-resource-pool-name"},
+				},
+				Jobs: []Job{
+					{Name: "fake-job-name"},
+				},
+			}
+		})
+
+		It("returns the only resource pool when the job does not set one", func() {
+			Expect(deploymentManifest.ResourcePool("fake-job-name")).To(Equal(ResourcePool{Name: "fake-resource-pool-name"}))
+		})
+
+		Context("when there is more than one resource pool", func() {
+			BeforeEach(func() {
+				deploymentManifest.ResourcePools = append(deploymentManifest.ResourcePools, ResourcePool{Name: "fake-other-resource-pool-name"})
+			})
+
+			It("returns the resource pool of the job", func() {
+				deploymentManifest.Jobs[0].ResourcePool = "fake-other-resource-pool-name"
+				Expect(deploymentManifest.ResourcePool("fake-job-name")).To(Equal(ResourcePool{Name: "fake-other-resource-pool-name"}))
+			})
+
+			It("returns an error when the job does not set one", func() {
+				_, err := deploymentManifest.ResourcePool("fake-job-name")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Job 'fake-job-name' must set a resource pool, since there are 2"))
+			})
+		})
+
+		It("returns an error when the resource pool of the job does not exist", func() {
+			deploymentManifest.Jobs[0].ResourcePool = "fake-missing-resource-pool-name"
+			_, err := deploymentManifest.ResourcePool("fake-job-name")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Could not find resource pool 'fake-missing-resource-pool-name' for job 'fake-job-name'"))
+		})
+	})
+
 	Describe("DiskPool", func() {
 		Context("when the deployment has disk_pools", func() {
 			BeforeEach(func() {
@@ -248,4 +410,60 @@ var _ = Describe("Manifest", func() {
 			})
 		})
 	})
+
+	Describe("JobProperties", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				RawProperties: map[interface{}]interface{}{
+					"director": map[interface{}]interface{}{
+						"name": "fake-global-name",
+						"port": 25555,
+					},
+					"global-only": "fake-global-value",
+				},
+				Jobs: []Job{
+					{
+						Name: "fake-job-name",
+						RawProperties: map[interface{}]interface{}{
+							"director": map[interface{}]interface{}{
+								"name": "fake-job-name",
+							},
+							"job-only": "fake-job-value",
+						},
+					},
+				},
+			}
+		})
+
+		It("deep-merges the global properties with the job properties, the job's values winning", func() {
+			properties, err := deploymentManifest.JobProperties("fake-job-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(properties).To(Equal(map[string]interface{}{
+				"director": map[string]interface{}{
+					"name": "fake-job-name",
+					"port": 25555,
+				},
+				"global-only": "fake-global-value",
+				"job-only":    "fake-job-value",
+			}))
+		})
+
+		It("does not change the global properties", func() {
+			_, err := deploymentManifest.JobProperties("fake-job-name")
+			Expect(err).ToNot(HaveOccurred())
+
+			properties, err := deploymentManifest.Properties()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(properties["director"]).To(Equal(map[string]interface{}{
+				"name": "fake-global-name",
+				"port": 25555,
+			}))
+		})
+
+		It("returns an error when the job does not exist", func() {
+			_, err := deploymentManifest.JobProperties("missing-job-name")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Could not find job with name: missing-job-name"))
+		})
+	})
 })
@@ -1,6 +1,8 @@
 package manifest
 
 import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+
 	bmkeystr "github.com/cloudfoundry/bosh-micro-cli/keystringifier"
 )
 
@@ -26,35 +28,73 @@ type Network struct {
 	Netmask            string                      `yaml:"netmask"`
 	Gateway            string                      `yaml:"gateway"`
 	DNS                []string                    `yaml:"dns"`
+	Subnets            []Subnet                    `yaml:"subnets"`
 }
 
 func (n Network) CloudProperties() (map[string]interface{}, error) {
 	return bmkeystr.NewKeyStringifier().ConvertMap(n.RawCloudProperties)
 }
 
+// Interface returns the network spec of the IP.
+// The netmask, gateway, dns & cloud properties of a manual network with subnets come from the subnet of the IP.
 func (n Network) Interface() (NetworkInterface, error) {
 	cloudProperties, err := n.CloudProperties()
 	if err != nil {
 		return NetworkInterface{}, err
 	}
 
+	netmask, gateway, dns := n.Netmask, n.Gateway, n.DNS
+	if n.Type == Manual && len(n.Subnets) > 0 {
+		subnet, found := n.subnetFor(n.IP)
+		if !found {
+			return NetworkInterface{}, bosherr.Errorf("Finding the subnet of IP '%s' in network '%s'", n.IP, n.Name)
+		}
+
+		netmask, err = subnet.Netmask()
+		if err != nil {
+			return NetworkInterface{}, err
+		}
+		gateway, dns = subnet.Gateway, subnet.DNS
+
+		if len(subnet.RawCloudProperties) > 0 {
+			cloudProperties, err = subnet.CloudProperties()
+			if err != nil {
+				return NetworkInterface{}, err
+			}
+		}
+	}
+
 	iface := NetworkInterface{
 		"type":             n.Type.String(),
 		"ip":               n.IP,
 		"cloud_properties": cloudProperties,
 	}
 
-	if n.Netmask != "" {
-		iface["netmask"] = n.Netmask
+	if netmask != "" {
+		iface["netmask"] = netmask
 	}
 
-	if n.Gateway != "" {
-		iface["gateway"] = n.Gateway
+	if gateway != "" {
+		iface["gateway"] = gateway
 	}
 
-	if len(n.DNS) > 0 {
-		iface["dns"] = n.DNS
+	if len(dns) > 0 {
+		iface["dns"] = dns
 	}
 
 	return iface, nil
 }
+
+// subnetFor returns the subnet containing the IP, or the only subnet when there is no IP
+func (n Network) subnetFor(ip string) (Subnet, bool) {
+	if ip == "" && len(n.Subnets) == 1 {
+		return n.Subnets[0], true
+	}
+
+	for _, subnet := range n.Subnets {
+		if subnet.Contains(ip) {
+			return subnet, true
+		}
+	}
+	return Subnet{}, false
+}
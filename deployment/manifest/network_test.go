@@ -63,5 +63,49 @@ var _ = Describe("Network", func() {
 				},
 			}))
 		})
+
+		Context("when the network is a manual network with subnets", func() {
+			BeforeEach(func() {
+				network = Network{
+					Name: "fake-name",
+					Type: Manual,
+					IP:   "10.0.1.5",
+					Subnets: []Subnet{
+						{
+							Range:   "10.0.0.0/24",
+							Gateway: "10.0.0.1",
+						},
+						{
+							Range:   "10.0.1.0/25",
+							Gateway: "10.0.1.1",
+							DNS:     []string{"8.8.8.8"},
+							RawCloudProperties: map[interface{}]interface{}{
+								"subnet": "subnet-1234",
+							},
+						},
+					},
+				}
+			})
+
+			It("uses the netmask derived from the range, the gateway, dns & cloud properties of the subnet of the IP", func() {
+				iface, err := network.Interface()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(iface).To(Equal(NetworkInterface{
+					"type":             "manual",
+					"ip":               "10.0.1.5",
+					"netmask":          "255.255.255.128",
+					"gateway":          "10.0.1.1",
+					"dns":              []string{"8.8.8.8"},
+					"cloud_properties": map[string]interface{}{"subnet": "subnet-1234"},
+				}))
+			})
+
+			It("returns an error when the IP is in none of the subnets", func() {
+				network.IP = "10.0.2.5"
+				_, err := network.Interface()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Finding the subnet of IP '10.0.2.5' in network 'fake-name'"))
+			})
+		})
 	})
 })
@@ -6,6 +6,8 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 type Parser interface {
@@ -13,9 +15,10 @@ type Parser interface {
 }
 
 type parser struct {
-	fs     boshsys.FileSystem
-	logger boshlog.Logger
-	logTag string
+	fs           boshsys.FileSystem
+	interpolator bmvars.Interpolator
+	logger       boshlog.Logger
+	logTag       string
 }
 
 type manifest struct {
@@ -25,10 +28,12 @@ type manifest struct {
 	ResourcePools []ResourcePool `yaml:"resource_pools"`
 	DiskPools     []DiskPool     `yaml:"disk_pools"`
 	Jobs          []Job
+	Properties    map[interface{}]interface{}
 }
 
 type UpdateSpec struct {
 	UpdateWatchTime *string `yaml:"update_watch_time"`
+	MaxInFlight     *int    `yaml:"max_in_flight"`
 }
 
 var boshDeploymentDefaults = Manifest{
@@ -37,14 +42,16 @@ var boshDeploymentDefaults = Manifest{
 			Start: 0,
 			End:   300000,
 		},
+		MaxInFlight: 1,
 	},
 }
 
-func NewParser(fs boshsys.FileSystem, logger boshlog.Logger) Parser {
+func NewParser(fs boshsys.FileSystem, interpolator bmvars.Interpolator, logger boshlog.Logger) Parser {
 	return &parser{
-		fs:     fs,
-		logger: logger,
-		logTag: "deploymentParser",
+		fs:           fs,
+		interpolator: interpolator,
+		logger:       logger,
+		logTag:       "deploymentParser",
 	}
 }
 
@@ -54,6 +61,11 @@ func (p *parser) Parse(path string) (Manifest, error) {
 		return Manifest{}, bosherr.WrapErrorf(err, "Reading file %s", path)
 	}
 
+	contents, err = p.interpolator.Interpolate(contents)
+	if err != nil {
+		return Manifest{}, bosherr.WrapErrorf(err, "Interpolating deployment manifest '%s'", path)
+	}
+
 	comboManifest := manifest{}
 	err = candiedyaml.Unmarshal(contents, &comboManifest)
 	if err != nil {
@@ -76,6 +88,7 @@ func (p *parser) parseDeploymentManifest(depManifest manifest) (Manifest, error)
 	deployment.ResourcePools = depManifest.ResourcePools
 	deployment.DiskPools = depManifest.DiskPools
 	deployment.Jobs = depManifest.Jobs
+	deployment.RawProperties = depManifest.Properties
 
 	if depManifest.Update.UpdateWatchTime != nil {
 		updateWatchTime, err := NewWatchTime(*depManifest.Update.UpdateWatchTime)
@@ -83,9 +96,11 @@ func (p *parser) parseDeploymentManifest(depManifest manifest) (Manifest, error)
 			return Manifest{}, bosherr.WrapError(err, "Parsing update watch time")
 		}
 
-		deployment.Update = Update{
-			UpdateWatchTime: updateWatchTime,
-		}
+		deployment.Update.UpdateWatchTime = updateWatchTime
+	}
+
+	if depManifest.Update.MaxInFlight != nil {
+		deployment.Update.MaxInFlight = *depManifest.Update.MaxInFlight
 	}
 
 	return deployment, nil
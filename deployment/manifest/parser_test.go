@@ -11,6 +11,8 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 
 	. "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 var _ = Describe("Parser", func() {
@@ -24,7 +26,7 @@ var _ = Describe("Parser", func() {
 		comboManifestPath = "fake-deployment-path"
 		fakeFs = fakesys.NewFakeFileSystem()
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		parser = NewParser(fakeFs, logger)
+		parser = NewParser(fakeFs, bmvars.NewInterpolator(bmvars.Variables{}), logger)
 	})
 
 	Context("when combo manifest path does not exist", func() {
@@ -56,6 +58,7 @@ var _ = Describe("Parser", func() {
 name: fake-deployment-name
 update:
   update_watch_time: 2000-7000
+  max_in_flight: 2
 resource_pools:
 - name: fake-resource-pool-name
   env:
@@ -82,9 +85,12 @@ jobs:
     static_ips: [1.2.3.4]
   persistent_disk: 1024
   persistent_disk_pool: fake-disk-pool-name
+  resource_pool: fake-resource-pool-name
   properties:
     fake-prop-key:
       nested-prop-key: fake-prop-value
+properties:
+  fake-global-prop-key: fake-global-prop-value
 `
 		fakeFs.WriteFileString(comboManifestPath, contents)
 	})
@@ -100,6 +106,7 @@ jobs:
 					Start: 2000,
 					End:   7000,
 				},
+				MaxInFlight: 2,
 			},
 			Networks: []Network{
 				{
@@ -147,6 +154,7 @@ jobs:
 					},
 					PersistentDisk:     1024,
 					PersistentDiskPool: "fake-disk-pool-name",
+					ResourcePool:       "fake-resource-pool-name",
 					RawProperties: map[interface{}]interface{}{
 						"fake-prop-key": map[interface{}]interface{}{
 							"nested-prop-key": "fake-prop-value",
@@ -154,10 +162,13 @@ jobs:
 					},
 				},
 			},
+			RawProperties: map[interface{}]interface{}{
+				"fake-global-prop-key": "fake-global-prop-value",
+			},
 		}))
 	})
 
-	Context("when update watch time is not set", func() {
+	Context("when update is not set", func() {
 		BeforeEach(func() {
 			contents := `
 ---
@@ -173,6 +184,77 @@ name: fake-deployment-name
 			Expect(deploymentManifest.Name).To(Equal("fake-deployment-name"))
 			Expect(deploymentManifest.Update.UpdateWatchTime.Start).To(Equal(0))
 			Expect(deploymentManifest.Update.UpdateWatchTime.End).To(Equal(300000))
+			Expect(deploymentManifest.Update.MaxInFlight).To(Equal(1))
+		})
+	})
+
+	Context("when a network has subnets", func() {
+		BeforeEach(func() {
+			contents := `
+---
+name: fake-deployment-name
+networks:
+- name: fake-network-name
+  type: manual
+  subnets:
+  - range: 10.0.0.0/24
+    gateway: 10.0.0.1
+    dns: [8.8.8.8]
+    reserved: [10.0.0.2 - 10.0.0.9]
+    static: [10.0.0.10 - 10.0.0.20]
+    cloud_properties: {subnet: subnet-1234}
+`
+			fakeFs.WriteFileString(comboManifestPath, contents)
+		})
+
+		It("parses the subnets", func() {
+			deploymentManifest, err := parser.Parse(comboManifestPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentManifest.Networks[0].Subnets).To(Equal([]Subnet{
+				{
+					Range:              "10.0.0.0/24",
+					Gateway:            "10.0.0.1",
+					DNS:                []string{"8.8.8.8"},
+					Reserved:           []string{"10.0.0.2 - 10.0.0.9"},
+					Static:             []string{"10.0.0.10 - 10.0.0.20"},
+					RawCloudProperties: map[interface{}]interface{}{"subnet": "subnet-1234"},
+				},
+			}))
+		})
+	})
+
+	Context("when the manifest has variable placeholders", func() {
+		BeforeEach(func() {
+			contents := `
+---
+name: ((deployment_name))
+networks:
+- name: fake-network-name
+  type: manual
+  ip: ((network.ip))
+  gateway: 10.0.0.1
+`
+			fakeFs.WriteFileString(comboManifestPath, contents)
+		})
+
+		It("interpolates the variables", func() {
+			parser = NewParser(fakeFs, bmvars.NewInterpolator(bmvars.Variables{
+				"deployment_name": "fake-deployment-name",
+				"network":         map[interface{}]interface{}{"ip": "10.0.0.2"},
+			}), boshlog.NewLogger(boshlog.LevelNone))
+
+			deploymentManifest, err := parser.Parse(comboManifestPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentManifest.Name).To(Equal("fake-deployment-name"))
+			Expect(deploymentManifest.Networks[0].IP).To(Equal("10.0.0.2"))
+		})
+
+		It("returns an error naming the missing variables", func() {
+			_, err := parser.Parse(comboManifestPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Interpolating deployment manifest 'fake-deployment-path'"))
+			Expect(err.Error()).To(ContainSubstring("Variable 'deployment_name' is not set, used at 'name'"))
+			Expect(err.Error()).To(ContainSubstring("Variable 'network.ip' is not set, used at 'networks[0].ip'"))
 		})
 	})
 })
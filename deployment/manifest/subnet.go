@@ -0,0 +1,99 @@
+package manifest
+
+import (
+	"bytes"
+	"net"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+
+	bmkeystr "github.com/cloudfoundry/bosh-micro-cli/keystringifier"
+)
+
+// Subnet is a subnet of a manual network, as in the full BOSH manifest schema.
+// Reserved & Static are lists of IPs or IP ranges, e.g. "10.0.0.2 - 10.0.0.9".
+type Subnet struct {
+	Range              string                      `yaml:"range"`
+	Gateway            string                      `yaml:"gateway"`
+	DNS                []string                    `yaml:"dns"`
+	Reserved           []string                    `yaml:"reserved"`
+	Static             []string                    `yaml:"static"`
+	RawCloudProperties map[interface{}]interface{} `yaml:"cloud_properties"`
+}
+
+func (s Subnet) CloudProperties() (map[string]interface{}, error) {
+	return bmkeystr.NewKeyStringifier().ConvertMap(s.RawCloudProperties)
+}
+
+// Netmask returns the netmask of the range, e.g. "255.255.255.0" for "10.0.0.0/24"
+func (s Subnet) Netmask() (string, error) {
+	_, ipNet, err := net.ParseCIDR(s.Range)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing subnet range '%s'", s.Range)
+	}
+	return net.IP(ipNet.Mask).String(), nil
+}
+
+// Contains returns true if the IP is inside the range of the subnet
+func (s Subnet) Contains(ip string) bool {
+	_, ipNet, err := net.ParseCIDR(s.Range)
+	if err != nil {
+		return false
+	}
+	parsedIP := net.ParseIP(ip)
+	return parsedIP != nil && ipNet.Contains(parsedIP)
+}
+
+// IsReserved returns true if the IP is in one of the reserved ranges of the subnet
+func (s Subnet) IsReserved(ip string) bool {
+	return ipRangesContain(s.Reserved, ip)
+}
+
+// IsStatic returns true if the IP is in one of the static ranges of the subnet
+func (s Subnet) IsStatic(ip string) bool {
+	return ipRangesContain(s.Static, ip)
+}
+
+// ipRange is an inclusive range of IPs
+type ipRange struct {
+	first net.IP
+	last  net.IP
+}
+
+// parseIPRange parses a single IP, or a range of IPs like "10.0.0.2 - 10.0.0.9"
+func parseIPRange(value string) (ipRange, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) > 2 {
+		return ipRange{}, bosherr.Errorf("Invalid IP range '%s'", value)
+	}
+
+	first := net.ParseIP(strings.TrimSpace(parts[0]))
+	last := net.ParseIP(strings.TrimSpace(parts[len(parts)-1]))
+	if first == nil || last == nil {
+		return ipRange{}, bosherr.Errorf("Invalid IP range '%s'", value)
+	}
+	if bytes.Compare(first.To16(), last.To16()) > 0 {
+		return ipRange{}, bosherr.Errorf("Invalid IP range '%s': first IP is after last IP", value)
+	}
+
+	return ipRange{first: first, last: last}, nil
+}
+
+func (r ipRange) contains(ip net.IP) bool {
+	return bytes.Compare(r.first.To16(), ip.To16()) <= 0 && bytes.Compare(ip.To16(), r.last.To16()) <= 0
+}
+
+func ipRangesContain(values []string, ip string) bool {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return false
+	}
+
+	for _, value := range values {
+		r, err := parseIPRange(value)
+		if err == nil && r.contains(parsedIP) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+)
+
+var _ = Describe("Subnet", func() {
+	var subnet Subnet
+
+	BeforeEach(func() {
+		subnet = Subnet{
+			Range:    "10.0.0.0/24",
+			Gateway:  "10.0.0.1",
+			Reserved: []string{"10.0.0.2 - 10.0.0.9", "10.0.0.255"},
+			Static:   []string{"10.0.0.10-10.0.0.20"},
+		}
+	})
+
+	Describe("Netmask", func() {
+		It("is derived from the range", func() {
+			Expect(subnet.Netmask()).To(Equal("255.255.255.0"))
+
+			subnet.Range = "10.0.0.0/20"
+			Expect(subnet.Netmask()).To(Equal("255.255.240.0"))
+		})
+
+		It("returns an error when the range is not a CIDR", func() {
+			subnet.Range = "10.0.0.0"
+			_, err := subnet.Netmask()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing subnet range '10.0.0.0'"))
+		})
+	})
+
+	Describe("Contains", func() {
+		It("is true for IPs inside the range", func() {
+			Expect(subnet.Contains("10.0.0.5")).To(BeTrue())
+			Expect(subnet.Contains("10.0.1.5")).To(BeFalse())
+			Expect(subnet.Contains("not-an-ip")).To(BeFalse())
+		})
+	})
+
+	Describe("IsReserved", func() {
+		It("is true for IPs of the reserved IPs & ranges, bounds included", func() {
+			Expect(subnet.IsReserved("10.0.0.2")).To(BeTrue())
+			Expect(subnet.IsReserved("10.0.0.9")).To(BeTrue())
+			Expect(subnet.IsReserved("10.0.0.255")).To(BeTrue())
+			Expect(subnet.IsReserved("10.0.0.10")).To(BeFalse())
+		})
+	})
+
+	Describe("IsStatic", func() {
+		It("is true for IPs of the static ranges", func() {
+			Expect(subnet.IsStatic("10.0.0.15")).To(BeTrue())
+			Expect(subnet.IsStatic("10.0.0.21")).To(BeFalse())
+		})
+	})
+})
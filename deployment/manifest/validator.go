@@ -16,7 +16,9 @@ type Validator interface {
 }
 
 type validator struct {
-	logger          boshlog.Logger
+	logger boshlog.Logger
+
+	// releaseResolver is nil when release references are not validated
 	releaseResolver bmrelset.Resolver
 }
 
@@ -27,6 +29,13 @@ func NewValidator(logger boshlog.Logger, releaseResolver bmrelset.Resolver) Vali
 	}
 }
 
+// NewValidatorWithoutReleases returns a Validator that does not check that job templates refer to available releases
+func NewValidatorWithoutReleases(logger boshlog.Logger) Validator {
+	return &validator{
+		logger: logger,
+	}
+}
+
 func (v *validator) Validate(deploymentManifest Manifest) error {
 	errs := []error{}
 	if v.isBlank(deploymentManifest.Name) {
@@ -43,10 +52,14 @@ func (v *validator) Validate(deploymentManifest Manifest) error {
 		if _, err := network.CloudProperties(); err != nil {
 			errs = append(errs, bosherr.Errorf("networks[%d].cloud_properties must have only string keys", idx))
 		}
+		if len(network.Subnets) > 0 && network.Type != Manual {
+			errs = append(errs, bosherr.Errorf("networks[%d].subnets are only supported on manual networks", idx))
+		}
+		errs = append(errs, v.validateSubnets(idx, network.Subnets)...)
 	}
 
-	if len(deploymentManifest.ResourcePools) != 1 {
-		errs = append(errs, bosherr.Error("resource_pools must be of size 1"))
+	if len(deploymentManifest.ResourcePools) == 0 {
+		errs = append(errs, bosherr.Error("resource_pools must be a non-empty array"))
 	}
 
 	for idx, resourcePool := range deploymentManifest.ResourcePools {
@@ -78,14 +91,28 @@ func (v *validator) Validate(deploymentManifest Manifest) error {
 		}
 	}
 
-	if len(deploymentManifest.Jobs) > 1 {
-		errs = append(errs, bosherr.Error("jobs must be of size 1"))
+	if deploymentManifest.Update.MaxInFlight <= 0 {
+		errs = append(errs, bosherr.Error("update.max_in_flight must be > 0"))
 	}
 
+	jobNames := map[string]struct{}{}
+	staticIPs := map[string]struct{}{}
 	for idx, job := range deploymentManifest.Jobs {
 		if v.isBlank(job.Name) {
 			errs = append(errs, bosherr.Errorf("jobs[%d].name must be provided", idx))
 		}
+		if _, found := jobNames[job.Name]; found {
+			errs = append(errs, bosherr.Errorf("jobs[%d].name '%s' must be unique", idx, job.Name))
+		}
+		jobNames[job.Name] = struct{}{}
+
+		if job.ResourcePool != "" {
+			if _, ok := v.resourcePoolNames(deploymentManifest)[job.ResourcePool]; !ok {
+				errs = append(errs, bosherr.Errorf("jobs[%d].resource_pool must be the name of a resource pool", idx))
+			}
+		} else if len(deploymentManifest.ResourcePools) > 1 {
+			errs = append(errs, bosherr.Errorf("jobs[%d].resource_pool must be provided, since there is more than one resource pool", idx))
+		}
 		if job.PersistentDisk < 0 {
 			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disk must be >= 0", idx))
 		}
@@ -105,9 +132,32 @@ func (v *validator) Validate(deploymentManifest Manifest) error {
 				errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].name must be provided", idx, networkIdx))
 			}
 
+			network := deploymentManifest.networkMap()[jobNetwork.Name]
+			if len(jobNetwork.StaticIPs) > 0 && len(jobNetwork.StaticIPs) < job.Instances {
+				errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].static_ips must have an IP for each of the %d instances", idx, networkIdx, job.Instances))
+			}
 			for ipIdx, ip := range jobNetwork.StaticIPs {
 				if !v.isValidIP(ip) {
 					errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].static_ips[%d] must be a valid IP", idx, networkIdx, ipIdx))
+					continue
+				}
+				if _, found := staticIPs[ip]; found {
+					errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].static_ips[%d] '%s' must be unique", idx, networkIdx, ipIdx, ip))
+				}
+				staticIPs[ip] = struct{}{}
+
+				if network.Type != Manual || len(network.Subnets) == 0 {
+					continue
+				}
+
+				subnet, found := network.subnetFor(ip)
+				switch {
+				case !found:
+					errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].static_ips[%d] must be inside the range of a subnet of network '%s'", idx, networkIdx, ipIdx, network.Name))
+				case subnet.IsReserved(ip):
+					errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].static_ips[%d] must not be in the reserved ranges of subnet '%s'", idx, networkIdx, ipIdx, subnet.Range))
+				case len(subnet.Static) > 0 && !subnet.IsStatic(ip):
+					errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].static_ips[%d] must be in the static ranges of subnet '%s'", idx, networkIdx, ipIdx, subnet.Range))
 				}
 			}
 
@@ -116,10 +166,32 @@ func (v *validator) Validate(deploymentManifest Manifest) error {
 					errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].default[%d] must be 'dns' or 'gateway'", idx, networkIdx, defaultIdx))
 				}
 			}
+
+			if len(jobNetwork.Default) > 0 && network.Type == VIP {
+				errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].default must not be set on a vip network", idx, networkIdx))
+			}
 		}
 
-		if job.Lifecycle != "" && job.Lifecycle != JobLifecycleService {
-			errs = append(errs, bosherr.Errorf("jobs[%d].lifecycle must be 'service' ('%s' not supported)", idx, job.Lifecycle))
+		if _, err := deploymentManifest.defaultNetworks(job); err != nil {
+			errs = append(errs, bosherr.Errorf("jobs[%d].networks %s", idx, err.Error()))
+		} else if !job.IsErrand() {
+			// the agent of the first instance is reached through the mbus URL, the others at their static IPs
+			firstIndex := 0
+			if idx == 0 {
+				firstIndex = 1
+			}
+			for index := firstIndex; index < job.Instances; index++ {
+				if _, err := deploymentManifest.InstanceIP(job.Name, index); err != nil {
+					errs = append(errs, bosherr.Errorf("jobs[%d].networks must give instance %d a static IP on the default gateway network, to reach its agent", idx, index))
+					break
+				}
+			}
+		}
+
+		if job.Lifecycle != "" && job.Lifecycle != JobLifecycleService && job.Lifecycle != JobLifecycleErrand {
+			errs = append(errs, bosherr.Errorf("jobs[%d].lifecycle must be 'service' or 'errand' ('%s' not supported)", idx, job.Lifecycle))
+		} else if idx == 0 && job.IsErrand() {
+			errs = append(errs, bosherr.Error("jobs[0].lifecycle must be 'service', since the first job runs on the VM reached through the mbus URL"))
 		}
 
 		if _, err := job.Properties(); err != nil {
@@ -138,7 +210,7 @@ func (v *validator) Validate(deploymentManifest Manifest) error {
 
 			if v.isBlank(template.Release) {
 				errs = append(errs, bosherr.Errorf("jobs[%d].templates[%d].release must be provided", idx, templateIdx))
-			} else {
+			} else if v.releaseResolver != nil {
 				release, err := v.releaseResolver.Find(template.Release)
 				if err != nil {
 					errs = append(errs, bosherr.WrapErrorf(err, "jobs[%d].templates[%d].release must refer to an available release", idx, templateIdx))
@@ -163,6 +235,63 @@ func (v *validator) Validate(deploymentManifest Manifest) error {
 	return nil
 }
 
+// validateSubnets checks the ranges of the subnets of a manual network, and that they do not overlap
+func (v *validator) validateSubnets(networkIdx int, subnets []Subnet) []error {
+	errs := []error{}
+	ranges := make([]*net.IPNet, len(subnets))
+
+	for idx, subnet := range subnets {
+		_, ipNet, err := net.ParseCIDR(subnet.Range)
+		if err != nil {
+			errs = append(errs, bosherr.Errorf("networks[%d].subnets[%d].range must be a valid CIDR", networkIdx, idx))
+			continue
+		}
+		ranges[idx] = ipNet
+
+		if !subnet.Contains(subnet.Gateway) {
+			errs = append(errs, bosherr.Errorf("networks[%d].subnets[%d].gateway must be an IP inside the range '%s'", networkIdx, idx, subnet.Range))
+		}
+
+		for dnsIdx, dns := range subnet.DNS {
+			if !v.isValidIP(dns) {
+				errs = append(errs, bosherr.Errorf("networks[%d].subnets[%d].dns[%d] must be a valid IP", networkIdx, idx, dnsIdx))
+			}
+		}
+
+		for rangeIdx, value := range subnet.Reserved {
+			if err := v.validateIPRange(ipNet, value); err != nil {
+				errs = append(errs, bosherr.Errorf("networks[%d].subnets[%d].reserved[%d] %s", networkIdx, idx, rangeIdx, err.Error()))
+			}
+		}
+
+		for rangeIdx, value := range subnet.Static {
+			if err := v.validateIPRange(ipNet, value); err != nil {
+				errs = append(errs, bosherr.Errorf("networks[%d].subnets[%d].static[%d] %s", networkIdx, idx, rangeIdx, err.Error()))
+			}
+		}
+
+		for otherIdx := 0; otherIdx < idx; otherIdx++ {
+			other := ranges[otherIdx]
+			if other != nil && (other.Contains(ipNet.IP) || ipNet.Contains(other.IP)) {
+				errs = append(errs, bosherr.Errorf("networks[%d].subnets[%d].range must not overlap networks[%d].subnets[%d].range", networkIdx, idx, networkIdx, otherIdx))
+			}
+		}
+	}
+
+	return errs
+}
+
+func (v *validator) validateIPRange(ipNet *net.IPNet, value string) error {
+	r, err := parseIPRange(value)
+	if err != nil {
+		return bosherr.Error("must be an IP or an IP range like '10.0.0.2 - 10.0.0.9'")
+	}
+	if !ipNet.Contains(r.first) || !ipNet.Contains(r.last) {
+		return bosherr.Errorf("must be inside the range '%s'", ipNet)
+	}
+	return nil
+}
+
 func (v *validator) isBlank(str string) bool {
 	return str == "" || strings.TrimSpace(str) == ""
 }
@@ -175,6 +304,14 @@ func (v *validator) networkNames(deploymentManifest Manifest) map[string]struct{
 	return names
 }
 
+func (v *validator) resourcePoolNames(deploymentManifest Manifest) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, resourcePool := range deploymentManifest.ResourcePools {
+		names[resourcePool.Name] = struct{}{}
+	}
+	return names
+}
+
 func (v *validator) diskPoolNames(deploymentManifest Manifest) map[string]struct{} {
 	names := make(map[string]struct{})
 	for _, diskPool := range deploymentManifest.DiskPools {
@@ -39,6 +39,9 @@ var _ = Describe("Validator", func() {
 
 		validManifest = Manifest{
 			Name: "fake-deployment-name",
+			Update: Update{
+				MaxInFlight: 1,
+			},
 			Networks: []Network{
 				{
 					Name: "fake-network-name",
@@ -143,17 +146,50 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("name must be provided"))
 		})
 
-		It("validates that there is only one resource pool", func() {
-			deploymentManifest := Manifest{
-				ResourcePools: []ResourcePool{
-					{},
-					{},
-				},
-			}
+		It("validates that there is a resource pool", func() {
+			deploymentManifest := Manifest{}
+
+			err := validator.Validate(deploymentManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("resource_pools must be a non-empty array"))
+		})
+
+		It("validates that max_in_flight is positive", func() {
+			deploymentManifest := Manifest{}
 
 			err := validator.Validate(deploymentManifest)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("resource_pools must be of size 1"))
+			Expect(err.Error()).To(ContainSubstring("update.max_in_flight must be > 0"))
+		})
+
+		Context("when there is more than one resource pool", func() {
+			BeforeEach(func() {
+				validManifest.ResourcePools = append(validManifest.ResourcePools, ResourcePool{
+					Name:    "fake-other-resource-pool-name",
+					Network: "fake-network-name",
+				})
+			})
+
+			It("validates that jobs refer to a resource pool", func() {
+				err := validator.Validate(validManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[0].resource_pool must be provided, since there is more than one resource pool"))
+			})
+
+			It("does not error if jobs refer to a resource pool", func() {
+				validManifest.Jobs[0].ResourcePool = "fake-other-resource-pool-name"
+
+				err := validator.Validate(validManifest)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		It("validates that the resource pool of a job exists", func() {
+			validManifest.Jobs[0].ResourcePool = "fake-missing-resource-pool-name"
+
+			err := validator.Validate(validManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].resource_pool must be the name of a resource pool"))
 		})
 
 		It("validates resource pool name", func() {
@@ -366,17 +402,151 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("networks[0].cloud_properties must have only string keys"))
 		})
 
-		It("validates that there is only one job", func() {
-			deploymentManifest := Manifest{
-				Jobs: []Job{
-					{},
-					{},
-				},
-			}
+		Context("when a manual network has subnets", func() {
+			var network Network
 
-			err := validator.Validate(deploymentManifest)
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("jobs must be of size 1"))
+			BeforeEach(func() {
+				network = Network{
+					Name: "fake-manual-network",
+					Type: Manual,
+					Subnets: []Subnet{
+						{
+							Range:    "10.0.0.0/24",
+							Gateway:  "10.0.0.1",
+							DNS:      []string{"10.0.0.2"},
+							Reserved: []string{"10.0.0.2 - 10.0.0.9"},
+							Static:   []string{"10.0.0.10 - 10.0.0.20"},
+						},
+					},
+				}
+			})
+
+			validateWithStaticIP := func(ip string) error {
+				validManifest.Networks = append(validManifest.Networks, network)
+				validManifest.Jobs[0].Networks = []JobNetwork{
+					{
+						Name:      "fake-manual-network",
+						StaticIPs: []string{ip},
+					},
+				}
+				return validator.Validate(validManifest)
+			}
+
+			It("does not error when the static IP is in the static ranges", func() {
+				Expect(validateWithStaticIP("10.0.0.15")).ToNot(HaveOccurred())
+			})
+
+			It("validates the static IP is inside a subnet range", func() {
+				err := validateWithStaticIP("10.0.1.15")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[0].networks[0].static_ips[0] must be inside the range of a subnet of network 'fake-manual-network'"))
+			})
+
+			It("validates the static IP is not reserved", func() {
+				err := validateWithStaticIP("10.0.0.5")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[0].networks[0].static_ips[0] must not be in the reserved ranges of subnet '10.0.0.0/24'"))
+			})
+
+			It("validates the static IP is in the static ranges", func() {
+				err := validateWithStaticIP("10.0.0.30")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[0].networks[0].static_ips[0] must be in the static ranges of subnet '10.0.0.0/24'"))
+			})
+
+			It("validates the subnet range", func() {
+				network.Subnets[0].Range = "10.0.0.0"
+				err := validateWithStaticIP("10.0.0.15")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("networks[1].subnets[0].range must be a valid CIDR"))
+			})
+
+			It("validates the gateway is inside the range", func() {
+				network.Subnets[0].Gateway = "10.0.1.1"
+				err := validateWithStaticIP("10.0.0.15")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("networks[1].subnets[0].gateway must be an IP inside the range '10.0.0.0/24'"))
+			})
+
+			It("validates the dns IPs", func() {
+				network.Subnets[0].DNS = []string{"not-an-ip"}
+				err := validateWithStaticIP("10.0.0.15")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("networks[1].subnets[0].dns[0] must be a valid IP"))
+			})
+
+			It("validates the reserved & static ranges", func() {
+				network.Subnets[0].Reserved = []string{"10.0.0.9 - 10.0.0.2"}
+				network.Subnets[0].Static = []string{"10.0.0.10 - 10.0.1.20"}
+				err := validateWithStaticIP("10.0.0.15")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("networks[1].subnets[0].reserved[0] must be an IP or an IP range like '10.0.0.2 - 10.0.0.9'"))
+				Expect(err.Error()).To(ContainSubstring("networks[1].subnets[0].static[0] must be inside the range '10.0.0.0/24'"))
+			})
+
+			It("validates the subnets do not overlap", func() {
+				network.Subnets = append(network.Subnets, Subnet{Range: "10.0.0.128/25", Gateway: "10.0.0.129"})
+				err := validateWithStaticIP("10.0.0.15")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("networks[1].subnets[1].range must not overlap networks[1].subnets[0].range"))
+			})
+
+			It("validates subnets are only set on manual networks", func() {
+				network.Type = Dynamic
+				err := validateWithStaticIP("10.0.0.15")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("networks[1].subnets are only supported on manual networks"))
+			})
+		})
+
+		Context("when there is more than one job with more than one instance", func() {
+			BeforeEach(func() {
+				validManifest.Jobs[0].Instances = 2
+				validManifest.Jobs[0].Networks[0].StaticIPs = []string{"127.0.0.1", "127.0.0.2"}
+
+				otherJob := validManifest.Jobs[0]
+				otherJob.Name = "fake-other-job-name"
+				otherJob.Instances = 1
+				otherJob.Networks = []JobNetwork{{Name: "fake-network-name", StaticIPs: []string{"127.0.0.3"}}}
+				validManifest.Jobs = append(validManifest.Jobs, otherJob)
+			})
+
+			It("does not error", func() {
+				err := validator.Validate(validManifest)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("validates that job names are unique", func() {
+				validManifest.Jobs[1].Name = "fake-job-name"
+
+				err := validator.Validate(validManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[1].name 'fake-job-name' must be unique"))
+			})
+
+			It("validates that static IPs are unique", func() {
+				validManifest.Jobs[1].Networks[0].StaticIPs = []string{"127.0.0.2"}
+
+				err := validator.Validate(validManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[1].networks[0].static_ips[0] '127.0.0.2' must be unique"))
+			})
+
+			It("validates that there is a static IP for each instance", func() {
+				validManifest.Jobs[0].Networks[0].StaticIPs = []string{"127.0.0.1"}
+
+				err := validator.Validate(validManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[0].networks[0].static_ips must have an IP for each of the 2 instances"))
+			})
+
+			It("validates that the instances reached at their own IPs have one on the default gateway network", func() {
+				validManifest.Jobs[1].Networks[0].StaticIPs = []string{}
+
+				err := validator.Validate(validManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[1].networks must give instance 0 a static IP on the default gateway network, to reach its agent"))
+			})
 		})
 
 		It("validates job name", func() {
@@ -525,18 +695,105 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("jobs[0].networks[0].default[0] must be 'dns' or 'gateway'"))
 		})
 
+		It("validates job network default is not set on a vip network", func() {
+			deploymentManifest := Manifest{
+				Networks: []Network{
+					{Name: "vip", Type: "vip"},
+				},
+				Jobs: []Job{
+					{
+						Networks: []JobNetwork{
+							{Name: "vip", Default: []NetworkDefault{"gateway"}},
+						},
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].networks[0].default must not be set on a vip network"))
+		})
+
+		It("validates job network defaults are set on one network when the job has more than one network", func() {
+			deploymentManifest := Manifest{
+				Networks: []Network{
+					{Name: "fake-network-1", Type: "dynamic"},
+					{Name: "fake-network-2", Type: "manual"},
+					{Name: "vip", Type: "vip"},
+				},
+				Jobs: []Job{
+					{
+						Networks: []JobNetwork{
+							{Name: "fake-network-1"},
+							{Name: "fake-network-2"},
+							{Name: "vip"},
+						},
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].networks must set default 'dns' on one of 'fake-network-1', 'fake-network-2', since more than one network is configured"))
+		})
+
+		It("validates job network defaults are not set on more than one network", func() {
+			deploymentManifest := Manifest{
+				Networks: []Network{
+					{Name: "fake-network-1", Type: "dynamic"},
+					{Name: "fake-network-2", Type: "manual"},
+				},
+				Jobs: []Job{
+					{
+						Networks: []JobNetwork{
+							{Name: "fake-network-1", Default: []NetworkDefault{"dns", "gateway"}},
+							{Name: "fake-network-2", Default: []NetworkDefault{"dns"}},
+						},
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].networks must set default 'dns' on only one network, but set it on 'fake-network-1', 'fake-network-2'"))
+		})
+
 		It("validates job lifecycle", func() {
 			deploymentManifest := Manifest{
 				Jobs: []Job{
 					{
-						Lifecycle: "errand",
+						Lifecycle: "fake-lifecycle",
 					},
 				},
 			}
 
 			err := validator.Validate(deploymentManifest)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("jobs[0].lifecycle must be 'service' ('errand' not supported)"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].lifecycle must be 'service' or 'errand' ('fake-lifecycle' not supported)"))
+		})
+
+		It("validates that the first job is not an errand", func() {
+			deploymentManifest := validManifest
+			deploymentManifest.Jobs = []Job{validManifest.Jobs[0]}
+			deploymentManifest.Jobs[0].Lifecycle = "errand"
+
+			err := validator.Validate(deploymentManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].lifecycle must be 'service'"))
+		})
+
+		It("permits errand jobs after the first job, without static IPs", func() {
+			errandJob := validManifest.Jobs[0]
+			errandJob.Name = "fake-errand-name"
+			errandJob.Lifecycle = "errand"
+			errandJob.Instances = 1
+			errandJob.Networks = []JobNetwork{{Name: "fake-network-name"}}
+
+			deploymentManifest := validManifest
+			deploymentManifest.Jobs = []Job{validManifest.Jobs[0], errandJob}
+
+			err := validator.Validate(deploymentManifest)
+			Expect(err).ToNot(HaveOccurred())
 		})
 
 		It("validates job properties", func() {
@@ -658,4 +915,31 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("properties must have only string keys"))
 		})
 	})
+
+	Context("when created without releases", func() {
+		JustBeforeEach(func() {
+			validator = NewValidatorWithoutReleases(logger)
+		})
+
+		It("does not validate that job templates reference an available release", func() {
+			deploymentManifest := validManifest
+			deploymentManifest.Jobs[0].Templates = []ReleaseJobRef{
+				{Name: "fake-job-name", Release: "fake-other-release-name"},
+			}
+
+			err := validator.Validate(deploymentManifest)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("validates job templates reference a release", func() {
+			deploymentManifest := validManifest
+			deploymentManifest.Jobs[0].Templates = []ReleaseJobRef{
+				{Name: "fake-job-name"},
+			}
+
+			err := validator.Validate(deploymentManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].templates[0].release must be provided"))
+		})
+	})
 })
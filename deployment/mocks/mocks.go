@@ -8,14 +8,11 @@ import (
 	blobstore "github.com/cloudfoundry/bosh-micro-cli/blobstore"
 	cloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
 	deployment "github.com/cloudfoundry/bosh-micro-cli/deployment"
-	agentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
 	disk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	instance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
-	manifest0 "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
+	manifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	stemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
-	vm "github.com/cloudfoundry/bosh-micro-cli/deployment/vm"
 	eventlogger "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
-	manifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
 )
 
 // Mock of Deployment interface
@@ -49,6 +46,16 @@ func (_mr *_MockDeploymentRecorder) Delete(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Delete", arg0)
 }
 
+func (_m *MockDeployment) RenderedJobFingerprints() map[string]string {
+	ret := _m.ctrl.Call(_m, "RenderedJobFingerprints")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+func (_mr *_MockDeploymentRecorder) RenderedJobFingerprints() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RenderedJobFingerprints")
+}
+
 // Mock of Factory interface
 type MockFactory struct {
 	ctrl     *gomock.Controller
@@ -101,15 +108,15 @@ func (_m *MockDeployer) EXPECT() *_MockDeployerRecorder {
 	return _m.recorder
 }
 
-func (_m *MockDeployer) Deploy(_param0 cloud.Cloud, _param1 manifest0.Manifest, _param2 stemcell.ExtractedStemcell, _param3 manifest.Registry, _param4 manifest.SSHTunnel, _param5 vm.Manager, _param6 blobstore.Blobstore) (deployment.Deployment, error) {
-	ret := _m.ctrl.Call(_m, "Deploy", _param0, _param1, _param2, _param3, _param4, _param5, _param6)
+func (_m *MockDeployer) Deploy(_param0 cloud.Cloud, _param1 manifest.Manifest, _param2 stemcell.ExtractedStemcell, _param3 deployment.DeployOptions) (deployment.Deployment, error) {
+	ret := _m.ctrl.Call(_m, "Deploy", _param0, _param1, _param2, _param3)
 	ret0, _ := ret[0].(deployment.Deployment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (_mr *_MockDeployerRecorder) Deploy(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "Deploy", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+func (_mr *_MockDeployerRecorder) Deploy(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Deploy", arg0, arg1, arg2, arg3)
 }
 
 // Mock of Manager interface
@@ -176,12 +183,12 @@ func (_m *MockManagerFactory) EXPECT() *_MockManagerFactoryRecorder {
 	return _m.recorder
 }
 
-func (_m *MockManagerFactory) NewManager(_param0 cloud.Cloud, _param1 agentclient.AgentClient, _param2 blobstore.Blobstore) deployment.Manager {
-	ret := _m.ctrl.Call(_m, "NewManager", _param0, _param1, _param2)
+func (_m *MockManagerFactory) NewManager(_param0 cloud.Cloud, _param1 string, _param2 string, _param3 blobstore.Blobstore) deployment.Manager {
+	ret := _m.ctrl.Call(_m, "NewManager", _param0, _param1, _param2, _param3)
 	ret0, _ := ret[0].(deployment.Manager)
 	return ret0
 }
 
-func (_mr *_MockManagerFactoryRecorder) NewManager(arg0, arg1, arg2 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewManager", arg0, arg1, arg2)
+func (_mr *_MockManagerFactoryRecorder) NewManager(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewManager", arg0, arg1, arg2, arg3)
 }
@@ -1,104 +1,159 @@
 package deployment
 
 import (
+	"crypto/sha1"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
-	bmcrypto "github.com/cloudfoundry/bosh-micro-cli/crypto"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
 	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
 )
 
 type Record interface {
-	IsDeployed(manifestPath string, release bmrel.Release, stemcell bmstemcell.ExtractedStemcell) (bool, error)
-	Update(manifestPath string, release bmrel.Release) error
+	Changes(
+		deploymentManifest bmdeplmanifest.Manifest,
+		installationManifest bminstallmanifest.Manifest,
+		releases []bmrel.Release,
+		stemcell bmstemcell.ExtractedStemcell,
+	) (Changes, error)
+	Update(
+		deploymentManifest bmdeplmanifest.Manifest,
+		installationManifest bminstallmanifest.Manifest,
+		releases []bmrel.Release,
+		cpiRelease bmrel.Release,
+		stemcell bmstemcell.ExtractedStemcell,
+		renderedJobs map[string]string,
+	) error
+}
+
+// Changes are the components of the deployment that changed since the last deploy.
+type Changes struct {
+	ManifestSections []string
+	Releases         []string
+	Stemcell         bool
+
+	// RenderedJobs are the fingerprints of the rendered job templates of the last deploy, by instance name.
+	// They are only kept when no release changed, as they do not cover the packages of the jobs.
+	RenderedJobs map[string]string
+}
+
+func (c Changes) IsEmpty() bool {
+	return len(c.ManifestSections) == 0 && len(c.Releases) == 0 && !c.Stemcell
+}
+
+func (c Changes) String() string {
+	changes := []string{}
+	for _, section := range c.ManifestSections {
+		changes = append(changes, fmt.Sprintf("manifest section '%s'", section))
+	}
+	for _, release := range c.Releases {
+		changes = append(changes, fmt.Sprintf("release '%s'", release))
+	}
+	if c.Stemcell {
+		changes = append(changes, "stemcell")
+	}
+	return strings.Join(changes, ", ")
 }
 
 type deploymentRecord struct {
 	deploymentRepo bmconfig.DeploymentRepo
 	releaseRepo    bmconfig.ReleaseRepo
-	stemcellRepo   bmconfig.StemcellRepo
-	sha1Calculator bmcrypto.SHA1Calculator
 }
 
 func NewRecord(
 	deploymentRepo bmconfig.DeploymentRepo,
 	releaseRepo bmconfig.ReleaseRepo,
-	stemcellRepo bmconfig.StemcellRepo,
-	sha1Calculator bmcrypto.SHA1Calculator,
 ) Record {
 	return &deploymentRecord{
 		deploymentRepo: deploymentRepo,
 		releaseRepo:    releaseRepo,
-		stemcellRepo:   stemcellRepo,
-		sha1Calculator: sha1Calculator,
 	}
 }
 
-func (v *deploymentRecord) IsDeployed(manifestPath string, release bmrel.Release, stemcell bmstemcell.ExtractedStemcell) (bool, error) {
-	manifestSHA1, found, err := v.deploymentRepo.FindCurrent()
+// Changes compares the fingerprints of the manifest sections, releases & stemcell with the ones of the last deploy.
+// Everything has changed when nothing was deployed yet.
+func (v *deploymentRecord) Changes(
+	deploymentManifest bmdeplmanifest.Manifest,
+	installationManifest bminstallmanifest.Manifest,
+	releases []bmrel.Release,
+	stemcell bmstemcell.ExtractedStemcell,
+) (Changes, error) {
+	current, _, err := v.deploymentRepo.FindCurrent()
 	if err != nil {
-		return false, bosherr.WrapError(err, "Finding sha1 of currently deployed manifest")
+		return Changes{}, bosherr.WrapError(err, "Finding fingerprints of the current deployment")
 	}
 
-	if !found {
-		return false, nil
-	}
-
-	newSHA1, err := v.sha1Calculator.Calculate(manifestPath)
-	if err != nil {
-		return false, bosherr.WrapError(err, "Calculating sha1 of current deployment manifest")
-	}
+	changes := Changes{}
 
-	if manifestSHA1 != newSHA1 {
-		return false, nil
+	manifestFingerprints := fingerprintManifest(deploymentManifest, installationManifest)
+	for _, section := range manifestSections {
+		if manifestFingerprints[section] != current.Manifest[section] {
+			changes.ManifestSections = append(changes.ManifestSections, section)
+		}
 	}
 
-	currentStemcell, found, err := v.stemcellRepo.FindCurrent()
-	if err != nil {
-		return false, bosherr.WrapError(err, "Finding currently deployed stemcell")
+	currentReleases := map[string]bmconfig.ReleaseFingerprints{}
+	for _, releaseFingerprints := range current.Releases {
+		currentReleases[releaseFingerprints.Name] = releaseFingerprints
 	}
-
-	if !found {
-		return false, nil
+	newReleases := map[string]bmconfig.ReleaseFingerprints{}
+	for _, releaseFingerprints := range fingerprintReleases(releases) {
+		newReleases[releaseFingerprints.Name] = releaseFingerprints
 	}
-
-	if currentStemcell.Name != stemcell.Manifest().Name || currentStemcell.Version != stemcell.Manifest().Version {
-		return false, nil
+	for name, releaseFingerprints := range newReleases {
+		if !reflect.DeepEqual(releaseFingerprints, currentReleases[name]) {
+			changes.Releases = append(changes.Releases, name)
+		}
 	}
-
-	currentRelease, found, err := v.releaseRepo.FindCurrent()
-	if err != nil {
-		return false, bosherr.WrapError(err, "Finding currently deployed release")
+	for name := range currentReleases {
+		if _, found := newReleases[name]; !found {
+			changes.Releases = append(changes.Releases, name)
+		}
 	}
+	sort.Strings(changes.Releases)
 
-	if !found {
-		return false, nil
-	}
+	changes.Stemcell = stemcell.Manifest().SHA1 != current.StemcellSHA1
 
-	if currentRelease.Name != release.Name() || currentRelease.Version != release.Version() {
-		return false, nil
+	if len(changes.Releases) == 0 {
+		changes.RenderedJobs = current.RenderedJobs
 	}
 
-	return true, nil
+	return changes, nil
 }
 
-func (v *deploymentRecord) Update(manifestPath string, release bmrel.Release) error {
-	manifestSHA1, err := v.sha1Calculator.Calculate(manifestPath)
-	if err != nil {
-		return bosherr.WrapError(err, "Calculating sha1 of current deployment manifest")
+// Update records the fingerprints of the deployed components & the current CPI release
+func (v *deploymentRecord) Update(
+	deploymentManifest bmdeplmanifest.Manifest,
+	installationManifest bminstallmanifest.Manifest,
+	releases []bmrel.Release,
+	cpiRelease bmrel.Release,
+	stemcell bmstemcell.ExtractedStemcell,
+	renderedJobs map[string]string,
+) error {
+	fingerprints := bmconfig.Fingerprints{
+		Manifest:     fingerprintManifest(deploymentManifest, installationManifest),
+		Releases:     fingerprintReleases(releases),
+		StemcellSHA1: stemcell.Manifest().SHA1,
+		RenderedJobs: renderedJobs,
 	}
 
-	err = v.deploymentRepo.UpdateCurrent(manifestSHA1)
+	err := v.deploymentRepo.UpdateCurrent(fingerprints)
 	if err != nil {
-		return bosherr.WrapError(err, "Saving sha1 of deployed manifest")
+		return bosherr.WrapError(err, "Saving fingerprints of the deployment")
 	}
 
-	releaseRecord, found, err := v.releaseRepo.Find(release.Name(), release.Version())
+	releaseRecord, found, err := v.releaseRepo.Find(cpiRelease.Name(), cpiRelease.Version())
 	if !found {
-		releaseRecord, err = v.releaseRepo.Save(release.Name(), release.Version())
+		releaseRecord, err = v.releaseRepo.Save(cpiRelease.Name(), cpiRelease.Version())
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Saving release record with name: '%s', version: '%s'", release.Name(), release.Version())
+			return bosherr.WrapErrorf(err, "Saving release record with name: '%s', version: '%s'", cpiRelease.Name(), cpiRelease.Version())
 		}
 	}
 
@@ -109,3 +164,51 @@ func (v *deploymentRecord) Update(manifestPath string, release bmrel.Release) er
 
 	return nil
 }
+
+// manifestSections are the sections of the deployment manifest, in the order changes are reported
+var manifestSections = []string{"name", "properties", "update", "networks", "resource_pools", "disk_pools", "jobs", "cloud_provider"}
+
+// fingerprintManifest fingerprints each section of the parsed manifest, the installation being the cloud_provider section,
+// so that formatting & comments of the manifest file do not count as changes
+func fingerprintManifest(deploymentManifest bmdeplmanifest.Manifest, installationManifest bminstallmanifest.Manifest) map[string]string {
+	sections := map[string]interface{}{
+		"name":           deploymentManifest.Name,
+		"properties":     deploymentManifest.RawProperties,
+		"update":         deploymentManifest.Update,
+		"networks":       deploymentManifest.Networks,
+		"resource_pools": deploymentManifest.ResourcePools,
+		"disk_pools":     deploymentManifest.DiskPools,
+		"jobs":           deploymentManifest.Jobs,
+		"cloud_provider": installationManifest,
+	}
+
+	fingerprints := map[string]string{}
+	for name, section := range sections {
+		// map keys are printed sorted, which keeps fingerprints stable
+		fingerprints[name] = fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("%#v", section))))
+	}
+	return fingerprints
+}
+
+func fingerprintReleases(releases []bmrel.Release) []bmconfig.ReleaseFingerprints {
+	fingerprints := make([]bmconfig.ReleaseFingerprints, len(releases), len(releases))
+	for i, release := range releases {
+		jobs := map[string]string{}
+		for _, job := range release.Jobs() {
+			jobs[job.Name] = job.Fingerprint
+		}
+
+		packages := map[string]string{}
+		for _, pkg := range release.Packages() {
+			packages[pkg.Name] = pkg.Fingerprint
+		}
+
+		fingerprints[i] = bmconfig.ReleaseFingerprints{
+			Name:     release.Name(),
+			Version:  release.Version(),
+			Jobs:     jobs,
+			Packages: packages,
+		}
+	}
+	return fingerprints
+}
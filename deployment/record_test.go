@@ -8,251 +8,220 @@ import (
 
 	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
 	fakebmconfig "github.com/cloudfoundry/bosh-micro-cli/config/fakes"
-	fakebmcrypto "github.com/cloudfoundry/bosh-micro-cli/crypto/fakes"
 	fakebmrel "github.com/cloudfoundry/bosh-micro-cli/release/fakes"
 
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
+	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
 
 	. "github.com/cloudfoundry/bosh-micro-cli/deployment"
 )
 
 var _ = Describe("Record", func() {
 	var (
-		fakeRelease        *fakebmrel.FakeRelease
-		stemcell           bmstemcell.ExtractedStemcell
-		deploymentRepo     *fakebmconfig.FakeDeploymentRepo
-		releaseRepo        *fakebmconfig.FakeReleaseRepo
-		stemcellRepo       *fakebmconfig.FakeStemcellRepo
-		fakeSHA1Calculator *fakebmcrypto.FakeSha1Calculator
-		deploymentRecord   Record
+		fakeRelease          *fakebmrel.FakeRelease
+		fakeOtherRelease     *fakebmrel.FakeRelease
+		releases             []bmrel.Release
+		stemcell             bmstemcell.ExtractedStemcell
+		deploymentManifest   bmdeplmanifest.Manifest
+		installationManifest bminstallmanifest.Manifest
+		renderedJobs         map[string]string
+		deploymentRepo       *fakebmconfig.FakeDeploymentRepo
+		releaseRepo          *fakebmconfig.FakeReleaseRepo
+		deploymentRecord     Record
 	)
 
-	BeforeEach(func() {
-		fakeRelease = &fakebmrel.FakeRelease{
-			ReleaseName:    "fake-release-name",
-			ReleaseVersion: "fake-release-version",
-		}
-		fakeFS := fakesys.NewFakeFileSystem()
-		stemcell = bmstemcell.NewExtractedStemcell(
+	var newStemcell = func(sha1 string) bmstemcell.ExtractedStemcell {
+		return bmstemcell.NewExtractedStemcell(
 			bmstemcell.Manifest{
 				Name:    "fake-stemcell-name",
 				Version: "fake-stemcell-version",
+				SHA1:    sha1,
 			},
 			bmstemcell.ApplySpec{},
 			"fake-extracted-path",
-			fakeFS,
+			fakesys.NewFakeFileSystem(),
 		)
+	}
+
+	BeforeEach(func() {
+		fakeRelease = &fakebmrel.FakeRelease{
+			ReleaseName:     "fake-release-name",
+			ReleaseVersion:  "fake-release-version",
+			ReleaseJobs:     []bmrel.Job{{Name: "fake-job-name", Fingerprint: "fake-job-fingerprint"}},
+			ReleasePackages: []*bmrel.Package{{Name: "fake-package-name", Fingerprint: "fake-package-fingerprint"}},
+		}
+		fakeOtherRelease = &fakebmrel.FakeRelease{
+			ReleaseName:    "fake-other-release-name",
+			ReleaseVersion: "fake-other-release-version",
+		}
+		releases = []bmrel.Release{fakeRelease, fakeOtherRelease}
+		stemcell = newStemcell("fake-stemcell-sha1")
+
+		deploymentManifest = bmdeplmanifest.Manifest{
+			Name: "fake-deployment-name",
+			RawProperties: map[interface{}]interface{}{
+				"fake-property-key": "fake-property-value",
+			},
+			Jobs: []bmdeplmanifest.Job{
+				{Name: "fake-job-name", Instances: 1},
+			},
+		}
+		installationManifest = bminstallmanifest.Manifest{
+			Name:    "fake-deployment-name",
+			Release: "fake-release-name",
+		}
+		renderedJobs = map[string]string{"fake-job-name/0": "fake-rendered-jobs-fingerprint"}
+
 		deploymentRepo = fakebmconfig.NewFakeDeploymentRepo()
 		releaseRepo = fakebmconfig.NewFakeReleaseRepo()
-		stemcellRepo = fakebmconfig.NewFakeStemcellRepo()
-		fakeSHA1Calculator = fakebmcrypto.NewFakeSha1Calculator()
-		deploymentRecord = NewRecord(deploymentRepo, releaseRepo, stemcellRepo, fakeSHA1Calculator)
+		deploymentRecord = NewRecord(deploymentRepo, releaseRepo)
+
+		releaseRepo.SetFindBehavior("fake-release-name", "fake-release-version", bmconfig.ReleaseRecord{ID: "fake-release-id"}, true, nil)
 	})
 
-	Describe("IsDeployed", func() {
+	Describe("Changes", func() {
 		BeforeEach(func() {
-			stemcellRecord := bmconfig.StemcellRecord{
-				ID:      "fake-stemcell-id",
-				Name:    "fake-stemcell-name",
-				Version: "fake-stemcell-version",
-				CID:     "fake-stemcell-cid",
-			}
-			stemcellRepo.SetFindCurrentBehavior(stemcellRecord, true, nil)
-
-			releaseRecord := bmconfig.ReleaseRecord{
-				ID:      "fake-release-id",
-				Name:    "fake-release-name",
-				Version: "fake-release-version",
-			}
-			releaseRepo.SetFindCurrentBehavior(releaseRecord, true, nil)
-
-			deploymentRepo.SetFindCurrentBehavior("fake-manifest-sha1", true, nil)
-			fakeSHA1Calculator.SetCalculateBehavior(map[string]fakebmcrypto.CalculateInput{
-				"fake-manifest-path": fakebmcrypto.CalculateInput{
-					Sha1: "fake-manifest-sha1",
-					Err:  nil,
-				},
-			})
+			err := deploymentRecord.Update(deploymentManifest, installationManifest, releases, fakeRelease, stemcell, renderedJobs)
+			Expect(err).ToNot(HaveOccurred())
+			deploymentRepo.SetFindCurrentBehavior(deploymentRepo.UpdateCurrentFingerprints, true, nil)
 		})
 
-		It("returns true", func() {
-			isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
+		It("returns no changes when the same deployment was deployed", func() {
+			changes, err := deploymentRecord.Changes(deploymentManifest, installationManifest, releases, stemcell)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(isDeployed).To(BeTrue())
+			Expect(changes.IsEmpty()).To(BeTrue())
+			Expect(changes.RenderedJobs).To(Equal(renderedJobs))
 		})
 
-		Context("when getting current deployment manifest sha1 fails", func() {
-			BeforeEach(func() {
-				deploymentRepo.SetFindCurrentBehavior("fake-manifest-path", true, errors.New("fake-find-error"))
-			})
+		It("returns the changed sections of the manifest", func() {
+			deploymentManifest.RawProperties = map[interface{}]interface{}{
+				"fake-property-key": "fake-other-property-value",
+			}
+			installationManifest.Mbus = "https://fake-mbus-url"
 
-			It("returns an error", func() {
-				_, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("fake-find-error"))
-			})
+			changes, err := deploymentRecord.Changes(deploymentManifest, installationManifest, releases, stemcell)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes.ManifestSections).To(Equal([]string{"properties", "cloud_provider"}))
+			Expect(changes.Releases).To(BeEmpty())
+			Expect(changes.Stemcell).To(BeFalse())
+			Expect(changes.RenderedJobs).To(Equal(renderedJobs))
+			Expect(changes.String()).To(Equal("manifest section 'properties', manifest section 'cloud_provider'"))
 		})
 
-		Context("when no deployment is set", func() {
-			BeforeEach(func() {
-				deploymentRepo.SetFindCurrentBehavior("", false, nil)
-			})
+		It("returns the releases whose jobs changed without a new version", func() {
+			fakeRelease.ReleaseJobs = []bmrel.Job{{Name: "fake-job-name", Fingerprint: "fake-new-job-fingerprint"}}
 
-			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(isDeployed).To(BeFalse())
-			})
+			changes, err := deploymentRecord.Changes(deploymentManifest, installationManifest, releases, stemcell)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes.ManifestSections).To(BeEmpty())
+			Expect(changes.Releases).To(Equal([]string{"fake-release-name"}))
 		})
 
-		Context("when calculating the deployment manifest sha1 fails", func() {
-			BeforeEach(func() {
-				fakeSHA1Calculator.SetCalculateBehavior(map[string]fakebmcrypto.CalculateInput{
-					"fake-manifest-path": fakebmcrypto.CalculateInput{
-						Sha1: "",
-						Err:  errors.New("fake-calculate-error"),
-					},
-				})
-			})
+		It("returns the releases whose packages changed without a new version", func() {
+			fakeRelease.ReleasePackages = []*bmrel.Package{{Name: "fake-package-name", Fingerprint: "fake-new-package-fingerprint"}}
 
-			It("returns an error", func() {
-				_, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("fake-calculate-error"))
-			})
+			changes, err := deploymentRecord.Changes(deploymentManifest, installationManifest, releases, stemcell)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes.Releases).To(Equal([]string{"fake-release-name"}))
 		})
 
-		Context("when a different deployment manifest is currently deployed", func() {
-			BeforeEach(func() {
-				deploymentRepo.SetFindCurrentBehavior("fake-manifest-sha1-2", true, nil)
-			})
-
-			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(isDeployed).To(BeFalse())
-			})
+		It("returns the releases that are not deployed anymore", func() {
+			changes, err := deploymentRecord.Changes(deploymentManifest, installationManifest, []bmrel.Release{fakeRelease}, stemcell)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes.Releases).To(Equal([]string{"fake-other-release-name"}))
 		})
 
-		Context("when finding the currently deployed stemcell fails", func() {
-			BeforeEach(func() {
-				stemcellRepo.SetFindCurrentBehavior(bmconfig.StemcellRecord{}, false, errors.New("fake-find-error"))
-			})
+		It("does not return the rendered job fingerprints when a release changed", func() {
+			fakeOtherRelease.ReleaseVersion = "fake-new-release-version"
 
-			It("returns an error", func() {
-				_, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("fake-find-error"))
-			})
+			changes, err := deploymentRecord.Changes(deploymentManifest, installationManifest, releases, stemcell)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes.Releases).To(Equal([]string{"fake-other-release-name"}))
+			Expect(changes.RenderedJobs).To(BeNil())
 		})
 
-		Context("when no stemcell is currently deployed", func() {
-			BeforeEach(func() {
-				stemcellRepo.SetFindCurrentBehavior(bmconfig.StemcellRecord{}, false, nil)
-			})
-
-			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(isDeployed).To(BeFalse())
-			})
+		It("returns whether the stemcell changed", func() {
+			changes, err := deploymentRecord.Changes(deploymentManifest, installationManifest, releases, newStemcell("fake-new-stemcell-sha1"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes.Stemcell).To(BeTrue())
+			Expect(changes.IsEmpty()).To(BeFalse())
 		})
 
-		Context("when a different stemcell is currently deployed", func() {
+		Context("when nothing was deployed", func() {
 			BeforeEach(func() {
-				stemcellRecord := bmconfig.StemcellRecord{
-					ID:      "fake-stemcell-id-2",
-					Name:    "fake-stemcell-name-2",
-					Version: "fake-stemcell-version-2",
-					CID:     "fake-stemcell-cid-2",
-				}
-				stemcellRepo.SetFindCurrentBehavior(stemcellRecord, true, nil)
+				deploymentRepo.SetFindCurrentBehavior(bmconfig.Fingerprints{}, false, nil)
 			})
 
-			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
+			It("returns everything as changed", func() {
+				changes, err := deploymentRecord.Changes(deploymentManifest, installationManifest, releases, stemcell)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(isDeployed).To(BeFalse())
+				Expect(changes.ManifestSections).To(Equal([]string{"name", "properties", "update", "networks", "resource_pools", "disk_pools", "jobs", "cloud_provider"}))
+				Expect(changes.Releases).To(Equal([]string{"fake-other-release-name", "fake-release-name"}))
+				Expect(changes.Stemcell).To(BeTrue())
 			})
 		})
 
-		Context("when finding the currently deployed release fails", func() {
+		Context("when finding the current fingerprints fails", func() {
 			BeforeEach(func() {
-				releaseRepo.SetFindCurrentBehavior(bmconfig.ReleaseRecord{}, false, errors.New("fake-find-error"))
+				deploymentRepo.SetFindCurrentBehavior(bmconfig.Fingerprints{}, false, errors.New("fake-find-error"))
 			})
 
 			It("returns an error", func() {
-				_, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
+				_, err := deploymentRecord.Changes(deploymentManifest, installationManifest, releases, stemcell)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-find-error"))
 			})
 		})
-
-		Context("when no release is currently deployed", func() {
-			BeforeEach(func() {
-				releaseRepo.SetFindCurrentBehavior(bmconfig.ReleaseRecord{}, false, nil)
-			})
-
-			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(isDeployed).To(BeFalse())
-			})
-		})
-
-		Context("when a different release is currently deployed", func() {
-			BeforeEach(func() {
-				releaseRecord := bmconfig.ReleaseRecord{
-					ID:      "fake-release-id-2",
-					Name:    "fake-release-name-2",
-					Version: "fake-release-version-2",
-				}
-				releaseRepo.SetFindCurrentBehavior(releaseRecord, true, nil)
-			})
-
-			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-path", fakeRelease, stemcell)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(isDeployed).To(BeFalse())
-			})
-		})
 	})
 
 	Describe("Update", func() {
-		var (
-			deployedRelease bmconfig.ReleaseRecord
-		)
+		It("records the fingerprints of the deployment", func() {
+			err := deploymentRecord.Update(deploymentManifest, installationManifest, releases, fakeRelease, stemcell, renderedJobs)
+			Expect(err).ToNot(HaveOccurred())
 
-		BeforeEach(func() {
-			fakeSHA1Calculator.SetCalculateBehavior(map[string]fakebmcrypto.CalculateInput{
-				"fake-manifest-path": fakebmcrypto.CalculateInput{
-					Sha1: "fake-manifest-sha1",
-					Err:  nil,
+			fingerprints := deploymentRepo.UpdateCurrentFingerprints
+			Expect(fingerprints.Manifest).To(HaveLen(8))
+			Expect(fingerprints.Releases).To(Equal([]bmconfig.ReleaseFingerprints{
+				{
+					Name:     "fake-release-name",
+					Version:  "fake-release-version",
+					Jobs:     map[string]string{"fake-job-name": "fake-job-fingerprint"},
+					Packages: map[string]string{"fake-package-name": "fake-package-fingerprint"},
 				},
-			})
-
-			deployedRelease = bmconfig.ReleaseRecord{
-				ID:      "fake-release-id",
-				Name:    "fake-release-name",
-				Version: "fake-release-version",
-			}
-			releaseRepo.SetFindBehavior("fake-release-name", "fake-release-version", deployedRelease, true, nil)
+				{
+					Name:     "fake-other-release-name",
+					Version:  "fake-other-release-version",
+					Jobs:     map[string]string{},
+					Packages: map[string]string{},
+				},
+			}))
+			Expect(fingerprints.StemcellSHA1).To(Equal("fake-stemcell-sha1"))
+			Expect(fingerprints.RenderedJobs).To(Equal(renderedJobs))
 		})
 
-		It("calculates and updates sha1 of currently deployed manifest", func() {
-			err := deploymentRecord.Update("fake-manifest-path", fakeRelease)
+		It("does not change the manifest fingerprints when the parsed manifest is the same", func() {
+			err := deploymentRecord.Update(deploymentManifest, installationManifest, releases, fakeRelease, stemcell, renderedJobs)
+			Expect(err).ToNot(HaveOccurred())
+			firstFingerprints := deploymentRepo.UpdateCurrentFingerprints.Manifest
+
+			deploymentManifest.RawProperties = map[interface{}]interface{}{
+				"fake-property-key": "fake-property-value",
+			}
+			err = deploymentRecord.Update(deploymentManifest, installationManifest, releases, fakeRelease, stemcell, renderedJobs)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(deploymentRepo.UpdateCurrentManifestSHA1).To(Equal("fake-manifest-sha1"))
+			Expect(deploymentRepo.UpdateCurrentFingerprints.Manifest).To(Equal(firstFingerprints))
 		})
 
-		It("updates currently deployed release", func() {
-			err := deploymentRecord.Update("fake-manifest-path", fakeRelease)
+		It("updates the currently deployed cpi release", func() {
+			err := deploymentRecord.Update(deploymentManifest, installationManifest, releases, fakeRelease, stemcell, renderedJobs)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(releaseRepo.UpdateCurrentRecordID).To(Equal("fake-release-id"))
 		})
 
-		Context("when release is not in release repo", func() {
+		Context("when the cpi release is not in the release repo", func() {
 			BeforeEach(func() {
 				releaseRepo.SetFindBehavior("fake-release-name", "fake-release-version", bmconfig.ReleaseRecord{}, false, nil)
 				savedRelease := bmconfig.ReleaseRecord{
@@ -264,8 +233,8 @@ var _ = Describe("Record", func() {
 				releaseRepo.SetSaveBehavior("fake-release-name", "fake-release-version", savedRelease, nil)
 			})
 
-			It("saves release to release repo", func() {
-				err := deploymentRecord.Update("fake-manifest-path", fakeRelease)
+			It("saves the release to the release repo", func() {
+				err := deploymentRecord.Update(deploymentManifest, installationManifest, releases, fakeRelease, stemcell, renderedJobs)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(releaseRepo.UpdateCurrentRecordID).To(Equal("fake-saved-release-id"))
 
@@ -283,49 +252,20 @@ var _ = Describe("Record", func() {
 				})
 
 				It("returns an error", func() {
-					err := deploymentRecord.Update("fake-manifest-path", fakeRelease)
+					err := deploymentRecord.Update(deploymentManifest, installationManifest, releases, fakeRelease, stemcell, renderedJobs)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-save-error"))
 				})
 			})
 		})
 
-		Context("when calculating the deployment manifest sha1 fails", func() {
-			BeforeEach(func() {
-				fakeSHA1Calculator.SetCalculateBehavior(map[string]fakebmcrypto.CalculateInput{
-					"fake-manifest-path": fakebmcrypto.CalculateInput{
-						Sha1: "",
-						Err:  errors.New("fake-calculate-error"),
-					},
-				})
-			})
-
-			It("returns an error", func() {
-				err := deploymentRecord.Update("fake-manifest-path", fakeRelease)
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("fake-calculate-error"))
-			})
-		})
-
-		Context("when updating currently deployed manifest sha1 fails", func() {
+		Context("when saving the fingerprints fails", func() {
 			BeforeEach(func() {
 				deploymentRepo.UpdateCurrentErr = errors.New("fake-update-error")
 			})
 
 			It("returns an error", func() {
-				err := deploymentRecord.Update("fake-manifest-path", fakeRelease)
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("fake-update-error"))
-			})
-		})
-
-		Context("when updating current release record fails", func() {
-			BeforeEach(func() {
-				releaseRepo.UpdateCurrentErr = errors.New("fake-update-error")
-			})
-
-			It("returns an error", func() {
-				err := deploymentRecord.Update("fake-manifest-path", fakeRelease)
+				err := deploymentRecord.Update(deploymentManifest, installationManifest, releases, fakeRelease, stemcell, renderedJobs)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-update-error"))
 			})
@@ -0,0 +1,46 @@
+package sshclient
+
+import (
+	"io/ioutil"
+
+	"code.google.com/p/go.crypto/ssh"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+)
+
+// NewAuthMethods returns the ssh auth methods for the given private key file and/or password
+func NewAuthMethods(privateKeyPath string, password string) ([]ssh.AuthMethod, error) {
+	authMethods := []ssh.AuthMethod{}
+
+	if privateKeyPath != "" {
+		keyContents, err := ioutil.ReadFile(privateKeyPath)
+		if err != nil {
+			return authMethods, bosherr.WrapError(err, "Reading private key file")
+		}
+
+		signer, err := ssh.ParsePrivateKey(keyContents)
+		if err != nil {
+			return authMethods, bosherr.WrapError(err, "Parsing private key file")
+		}
+
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+
+	if password != "" {
+		keyboardInteractiveChallenge := func(
+			user,
+			instruction string,
+			questions []string,
+			echos []bool,
+		) (answers []string, err error) {
+			if len(questions) == 0 {
+				return []string{}, nil
+			}
+			return []string{password}, nil
+		}
+		authMethods = append(authMethods, ssh.KeyboardInteractive(keyboardInteractiveChallenge))
+		authMethods = append(authMethods, ssh.Password(password))
+	}
+
+	return authMethods, nil
+}
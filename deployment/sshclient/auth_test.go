@@ -0,0 +1,54 @@
+package sshclient_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/deployment/sshclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"io/ioutil"
+	"os"
+)
+
+var _ = Describe("NewAuthMethods", func() {
+	It("returns no auth methods when no credentials are given", func() {
+		authMethods, err := NewAuthMethods("", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authMethods).To(BeEmpty())
+	})
+
+	It("returns keyboard interactive & password auth methods when a password is given", func() {
+		authMethods, err := NewAuthMethods("", "fake-password")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authMethods).To(HaveLen(2))
+	})
+
+	Context("when a private key is given", func() {
+		var privateKeyPath string
+
+		BeforeEach(func() {
+			privateKeyFile, err := ioutil.TempFile("", "sshclient-test-private-key")
+			Expect(err).ToNot(HaveOccurred())
+			privateKeyPath = privateKeyFile.Name()
+			privateKeyFile.Close()
+		})
+
+		AfterEach(func() {
+			os.Remove(privateKeyPath)
+		})
+
+		It("returns an error when the private key file does not exist", func() {
+			_, err := NewAuthMethods("/fake-missing-private-key", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading private key file"))
+		})
+
+		It("returns an error when the private key cannot be parsed", func() {
+			err := ioutil.WriteFile(privateKeyPath, []byte("fake-private-key"), os.ModePerm)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = NewAuthMethods(privateKeyPath, "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing private key file"))
+		})
+	})
+})
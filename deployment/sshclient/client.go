@@ -0,0 +1,134 @@
+package sshclient
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"code.google.com/p/go.crypto/ssh"
+	"code.google.com/p/go.crypto/ssh/terminal"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+)
+
+type Client interface {
+	// Shell opens an interactive session, with a PTY if stdin is a terminal
+	Shell() error
+
+	// Run executes a single command, streaming its output
+	Run(command string) error
+}
+
+type Options struct {
+	Host       string
+	Port       int
+	User       string
+	PrivateKey string
+	Password   string
+}
+
+type client struct {
+	options Options
+	stdin   *os.File
+	stdout  io.Writer
+	stderr  io.Writer
+	logger  boshlog.Logger
+	logTag  string
+}
+
+func NewClient(
+	options Options,
+	stdin *os.File,
+	stdout io.Writer,
+	stderr io.Writer,
+	logger boshlog.Logger,
+) Client {
+	return &client{
+		options: options,
+		stdin:   stdin,
+		stdout:  stdout,
+		stderr:  stderr,
+		logger:  logger,
+		logTag:  "sshClient",
+	}
+}
+
+func (c *client) Shell() error {
+	return c.withSession(func(session *ssh.Session) error {
+		fd := int(c.stdin.Fd())
+		if terminal.IsTerminal(fd) {
+			width, height, err := terminal.GetSize(fd)
+			if err != nil {
+				return bosherr.WrapError(err, "Getting terminal size")
+			}
+
+			term := os.Getenv("TERM")
+			if term == "" {
+				term = "xterm"
+			}
+
+			c.logger.Debug(c.logTag, "Requesting %s pty (%dx%d)", term, width, height)
+			err = session.RequestPty(term, height, width, ssh.TerminalModes{ssh.ECHO: 1})
+			if err != nil {
+				return bosherr.WrapError(err, "Requesting pty")
+			}
+
+			oldState, err := terminal.MakeRaw(fd)
+			if err != nil {
+				return bosherr.WrapError(err, "Setting terminal to raw mode")
+			}
+			defer terminal.Restore(fd, oldState)
+		}
+
+		err := session.Shell()
+		if err != nil {
+			return bosherr.WrapError(err, "Starting shell")
+		}
+
+		return session.Wait()
+	})
+}
+
+func (c *client) Run(command string) error {
+	return c.withSession(func(session *ssh.Session) error {
+		c.logger.Debug(c.logTag, "Running command '%s'", command)
+		err := session.Run(command)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Running command '%s'", command)
+		}
+		return nil
+	})
+}
+
+func (c *client) withSession(sessionFunc func(*ssh.Session) error) error {
+	authMethods, err := NewAuthMethods(c.options.PrivateKey, c.options.Password)
+	if err != nil {
+		return err
+	}
+
+	sshConfig := &ssh.ClientConfig{
+		User: c.options.User,
+		Auth: authMethods,
+	}
+
+	remoteAddr := fmt.Sprintf("%s:%d", c.options.Host, c.options.Port)
+	c.logger.Debug(c.logTag, "Dialing remote server at %s", remoteAddr)
+	conn, err := ssh.Dial("tcp", remoteAddr, sshConfig)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Dialing remote server at %s", remoteAddr)
+	}
+	defer conn.Close()
+
+	session, err := conn.NewSession()
+	if err != nil {
+		return bosherr.WrapError(err, "Opening ssh session")
+	}
+	defer session.Close()
+
+	session.Stdin = c.stdin
+	session.Stdout = c.stdout
+	session.Stderr = c.stderr
+
+	return sessionFunc(session)
+}
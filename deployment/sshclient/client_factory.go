@@ -0,0 +1,26 @@
+package sshclient
+
+import (
+	"os"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+)
+
+type Factory interface {
+	NewClient(Options) Client
+}
+
+type factory struct {
+	logger boshlog.Logger
+}
+
+// NewFactory returns a Factory of clients attached to the standard input & output of the process
+func NewFactory(logger boshlog.Logger) Factory {
+	return &factory{
+		logger: logger,
+	}
+}
+
+func (f *factory) NewClient(options Options) Client {
+	return NewClient(options, os.Stdin, os.Stdout, os.Stderr, f.logger)
+}
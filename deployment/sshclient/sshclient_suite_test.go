@@ -0,0 +1,13 @@
+package sshclient_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSSHClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SSHClient Suite")
+}
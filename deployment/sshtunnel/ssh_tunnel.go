@@ -4,12 +4,13 @@ import (
 	"code.google.com/p/go.crypto/ssh"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net"
 	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	bmsshclient "github.com/cloudfoundry/bosh-micro-cli/deployment/sshclient"
 )
 
 type SSHTunnel interface {
@@ -27,42 +28,11 @@ type sshTunnel struct {
 }
 
 func (s *sshTunnel) Start(readyErrCh chan<- error, errCh chan<- error) {
-	authMethods := []ssh.AuthMethod{}
-
-	if s.options.PrivateKey != "" {
-		s.logger.Debug(s.logTag, "Reading private key file")
-		keyContents, err := ioutil.ReadFile(s.options.PrivateKey)
-		if err != nil {
-			readyErrCh <- bosherr.WrapError(err, "Reading private key file")
-			return
-		}
-
-		s.logger.Debug(s.logTag, "Parsing private key file")
-		signer, err := ssh.ParsePrivateKey(keyContents)
-		if err != nil {
-			readyErrCh <- bosherr.WrapError(err, "Parsing private key file")
-			return
-		}
-
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
-	}
-
-	if s.options.Password != "" {
-		s.logger.Debug(s.logTag, "Adding password auth method to ssh tunnel config")
-
-		keyboardInteractiveChallenge := func(
-			user,
-			instruction string,
-			questions []string,
-			echos []bool,
-		) (answers []string, err error) {
-			if len(questions) == 0 {
-				return []string{}, nil
-			}
-			return []string{s.options.Password}, nil
-		}
-		authMethods = append(authMethods, ssh.KeyboardInteractive(keyboardInteractiveChallenge))
-		authMethods = append(authMethods, ssh.Password(s.options.Password))
+	s.logger.Debug(s.logTag, "Building ssh auth methods")
+	authMethods, err := bmsshclient.NewAuthMethods(s.options.PrivateKey, s.options.Password)
+	if err != nil {
+		readyErrCh <- err
+		return
 	}
 
 	sshConfig := &ssh.ClientConfig{
@@ -74,7 +44,6 @@ func (s *sshTunnel) Start(readyErrCh chan<- error, errCh chan<- error) {
 	remoteAddr := fmt.Sprintf("%s:%d", s.options.Host, s.options.Port)
 
 	var conn *ssh.Client
-	var err error
 	for i := 0; i < s.startDialMaxTries; i++ {
 		conn, err = ssh.Dial(
 			"tcp",
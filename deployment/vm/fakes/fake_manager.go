@@ -1,6 +1,7 @@
 package fakes
 
 import (
+	bmas "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
 	bmvm "github.com/cloudfoundry/bosh-micro-cli/deployment/vm"
@@ -9,6 +10,16 @@ import (
 type CreateInput struct {
 	Stemcell bmstemcell.CloudStemcell
 	Manifest bmdeplmanifest.Manifest
+	JobName  string
+	Index    int
+}
+
+type UpdateSnapshotInput struct {
+	Stemcell  bmstemcell.CloudStemcell
+	Manifest  bmdeplmanifest.Manifest
+	JobName   string
+	Index     int
+	ApplySpec bmas.ApplySpec
 }
 
 type FakeManager struct {
@@ -16,7 +27,21 @@ type FakeManager struct {
 	CreateVM    bmvm.VM
 	CreateErr   error
 
-	findCurrentBehaviour findCurrentOutput
+	FindUpToDateInput CreateInput
+
+	FindSnapshotSnapshot bmvm.Snapshot
+	FindSnapshotFound    bool
+	FindSnapshotErr      error
+
+	UpdateSnapshotInputs []UpdateSnapshotInput
+	UpdateSnapshotErr    error
+
+	CreateFromSnapshotInputs []bmvm.Snapshot
+	CreateFromSnapshotVM     bmvm.VM
+	CreateFromSnapshotErr    error
+
+	findCurrentBehaviour  findCurrentOutput
+	findUpToDateBehaviour findCurrentOutput
 }
 
 type findCurrentOutput struct {
@@ -33,16 +58,48 @@ func (m *FakeManager) FindCurrent() (bmvm.VM, bool, error) {
 	return m.findCurrentBehaviour.vm, m.findCurrentBehaviour.found, m.findCurrentBehaviour.err
 }
 
-func (m *FakeManager) Create(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest) (bmvm.VM, error) {
+func (m *FakeManager) FindUpToDate(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int) (bmvm.VM, bool, error) {
+	m.FindUpToDateInput = CreateInput{
+		Stemcell: stemcell,
+		Manifest: deploymentManifest,
+		JobName:  jobName,
+		Index:    index,
+	}
+	return m.findUpToDateBehaviour.vm, m.findUpToDateBehaviour.found, m.findUpToDateBehaviour.err
+}
+
+func (m *FakeManager) Create(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int) (bmvm.VM, error) {
 	input := CreateInput{
 		Stemcell: stemcell,
 		Manifest: deploymentManifest,
+		JobName:  jobName,
+		Index:    index,
 	}
 	m.CreateInput = input
 
 	return m.CreateVM, m.CreateErr
 }
 
+func (m *FakeManager) FindSnapshot() (bmvm.Snapshot, bool, error) {
+	return m.FindSnapshotSnapshot, m.FindSnapshotFound, m.FindSnapshotErr
+}
+
+func (m *FakeManager) UpdateSnapshot(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int, applySpec bmas.ApplySpec) error {
+	m.UpdateSnapshotInputs = append(m.UpdateSnapshotInputs, UpdateSnapshotInput{
+		Stemcell:  stemcell,
+		Manifest:  deploymentManifest,
+		JobName:   jobName,
+		Index:     index,
+		ApplySpec: applySpec,
+	})
+	return m.UpdateSnapshotErr
+}
+
+func (m *FakeManager) CreateFromSnapshot(snapshot bmvm.Snapshot) (bmvm.VM, error) {
+	m.CreateFromSnapshotInputs = append(m.CreateFromSnapshotInputs, snapshot)
+	return m.CreateFromSnapshotVM, m.CreateFromSnapshotErr
+}
+
 func (m *FakeManager) SetFindCurrentBehavior(vm bmvm.VM, found bool, err error) {
 	m.findCurrentBehaviour = findCurrentOutput{
 		vm:    vm,
@@ -50,3 +107,11 @@ func (m *FakeManager) SetFindCurrentBehavior(vm bmvm.VM, found bool, err error)
 		err:   err,
 	}
 }
+
+func (m *FakeManager) SetFindUpToDateBehavior(vm bmvm.VM, found bool, err error) {
+	m.findUpToDateBehaviour = findCurrentOutput{
+		vm:    vm,
+		found: found,
+		err:   err,
+	}
+}
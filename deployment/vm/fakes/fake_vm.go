@@ -3,15 +3,21 @@ package fakes
 import (
 	"time"
 
+	bmagentclient "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
 	bmas "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bmindex "github.com/cloudfoundry/bosh-micro-cli/index"
 )
 
 type FakeVM struct {
 	cid string
 
+	AgentClientReturn bmagentclient.AgentClient
+
+	CompiledPackageIndexReturn bmindex.Index
+
 	ExistsCalled int
 	ExistsFound  bool
 	ExistsErr    error
@@ -104,6 +110,14 @@ func (vm *FakeVM) CID() string {
 	return vm.cid
 }
 
+func (vm *FakeVM) AgentClient() bmagentclient.AgentClient {
+	return vm.AgentClientReturn
+}
+
+func (vm *FakeVM) CompiledPackageIndex() bmindex.Index {
+	return vm.CompiledPackageIndexReturn
+}
+
 func (vm *FakeVM) Exists() (bool, error) {
 	vm.ExistsCalled++
 	return vm.ExistsFound, vm.ExistsErr
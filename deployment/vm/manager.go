@@ -1,6 +1,12 @@
 package vm
 
 import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 	boshsys "github.com/cloudfoundry/bosh-agent/system"
@@ -10,13 +16,30 @@ import (
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
 	bmac "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
 	bmhttpagent "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient/http"
+	bmas "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
 )
 
 type Manager interface {
 	FindCurrent() (VM, bool, error)
-	Create(bmstemcell.CloudStemcell, bmdeplmanifest.Manifest) (VM, error)
+	FindUpToDate(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int) (VM, bool, error)
+	Create(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int) (VM, error)
+	FindSnapshot() (Snapshot, bool, error)
+	UpdateSnapshot(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int, applySpec bmas.ApplySpec) error
+	CreateFromSnapshot(Snapshot) (VM, error)
+}
+
+// Snapshot is what the current VM last ran successfully with:
+// the stemcell, cloud properties, networks & env it was created with, the apply spec of its jobs,
+// and the deployment manifest the jobs were built from
+type Snapshot struct {
+	StemcellCID        string
+	CloudProperties    map[string]interface{}
+	Networks           map[string]map[string]interface{}
+	Env                map[string]interface{}
+	ApplySpec          bmas.ApplySpec
+	DeploymentManifest bmdeplmanifest.Manifest
 }
 
 type manager struct {
@@ -79,23 +102,172 @@ func (m *manager) FindCurrent() (VM, bool, error) {
 	return vm, true, err
 }
 
-func (m *manager) Create(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest) (VM, error) {
-	microBoshJobName := deploymentManifest.Jobs[0].Name
-	networkInterfaces, err := deploymentManifest.NetworkInterfaces(microBoshJobName)
-	m.logger.Debug(m.logTag, "Creating VM with network interfaces: %#v", networkInterfaces)
+// FindUpToDate returns the current VM when it still exists, and was created with the same stemcell,
+// resource pool cloud properties & env, and networks as the VM that Create would create, so that only its jobs need updating
+func (m *manager) FindUpToDate(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int) (VM, bool, error) {
+	vm, found, err := m.FindCurrent()
+	if err != nil || !found {
+		return vm, found, err
+	}
+
+	currentFingerprint, err := m.vmRepo.FindCurrentFingerprint()
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Getting network spec")
+		return nil, false, bosherr.WrapError(err, "Finding current vm fingerprint")
 	}
 
-	resourcePool := deploymentManifest.ResourcePools[0]
-	cloudProperties, err := resourcePool.CloudProperties()
+	spec, err := m.newVMSpec(stemcell, deploymentManifest, jobName, index)
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Getting cloud properties")
+		return nil, false, err
 	}
 
-	env, err := resourcePool.Env()
+	fingerprint, err := spec.Fingerprint()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if currentFingerprint != fingerprint {
+		m.logger.Debug(m.logTag, "VM '%s' is out of date: fingerprint '%s' differs from '%s'", vm.CID(), currentFingerprint, fingerprint)
+		return nil, false, nil
+	}
+
+	// a VM deleted outside of bosh needs recreating
+	exists, err := vm.Exists()
+	if err != nil {
+		return nil, false, err
+	}
+	if !exists {
+		m.logger.Debug(m.logTag, "VM '%s' is up to date, but does not exist anymore", vm.CID())
+		return nil, false, nil
+	}
+
+	return vm, true, nil
+}
+
+// Create creates the VM of the instance of the job at the index, with the resource pool of the job
+func (m *manager) Create(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int) (VM, error) {
+	spec, err := m.newVMSpec(stemcell, deploymentManifest, jobName, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.createVM(spec)
+}
+
+// FindSnapshot returns what the current VM last ran successfully with, not found before the first successful deploy
+func (m *manager) FindSnapshot() (Snapshot, bool, error) {
+	snapshotRecord, found, err := m.vmRepo.FindCurrentSnapshot()
+	if err != nil {
+		return Snapshot{}, false, bosherr.WrapError(err, "Finding current vm snapshot")
+	}
+
+	if !found {
+		return Snapshot{}, false, nil
+	}
+
+	applySpec := bmas.ApplySpec{}
+	err = json.Unmarshal(snapshotRecord.ApplySpec, &applySpec)
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Getting resource pool env")
+		return Snapshot{}, false, bosherr.WrapError(err, "Unmarshalling apply spec of current vm snapshot")
+	}
+
+	deploymentManifest := bmdeplmanifest.Manifest{}
+	if snapshotRecord.Manifest != "" {
+		err = candiedyaml.Unmarshal([]byte(snapshotRecord.Manifest), &deploymentManifest)
+		if err != nil {
+			return Snapshot{}, false, bosherr.WrapError(err, "Unmarshalling deployment manifest of current vm snapshot")
+		}
+	}
+
+	snapshot := Snapshot{
+		StemcellCID:        snapshotRecord.StemcellCID,
+		CloudProperties:    snapshotRecord.CloudProperties,
+		Networks:           snapshotRecord.Networks,
+		Env:                snapshotRecord.Env,
+		ApplySpec:          applySpec,
+		DeploymentManifest: deploymentManifest,
+	}
+	return snapshot, true, nil
+}
+
+// UpdateSnapshot records that the current VM, created for the instance of the job at the index,
+// runs successfully with the apply spec built from the deployment manifest
+func (m *manager) UpdateSnapshot(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int, applySpec bmas.ApplySpec) error {
+	spec, err := m.newVMSpec(stemcell, deploymentManifest, jobName, index)
+	if err != nil {
+		return err
+	}
+
+	applySpecJSON, err := json.Marshal(applySpec)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling apply spec")
+	}
+
+	manifestYAML, err := candiedyaml.Marshal(deploymentManifest)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling deployment manifest")
+	}
+
+	snapshotRecord := bmconfig.VMSnapshot{
+		StemcellCID:     spec.StemcellCID,
+		CloudProperties: spec.CloudProperties,
+		Networks:        spec.Networks,
+		Env:             spec.Env,
+		ApplySpec:       applySpecJSON,
+		Manifest:        string(manifestYAML),
+	}
+
+	err = m.vmRepo.UpdateCurrentSnapshot(snapshotRecord)
+	if err != nil {
+		return bosherr.WrapError(err, "Updating current vm snapshot")
+	}
+
+	return nil
+}
+
+// CreateFromSnapshot creates the VM the snapshot VM was created as, and promotes the stemcell of the snapshot back as current
+func (m *manager) CreateFromSnapshot(snapshot Snapshot) (VM, error) {
+	stemcellRecords, err := m.stemcellRepo.All()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding stemcell records")
+	}
+
+	var stemcellRecord bmconfig.StemcellRecord
+	found := false
+	for _, record := range stemcellRecords {
+		if record.CID == snapshot.StemcellCID {
+			stemcellRecord = record
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, bosherr.Errorf("Stemcell '%s' of the vm snapshot does not exist in repo", snapshot.StemcellCID)
+	}
+
+	spec := vmSpec{
+		StemcellCID:     snapshot.StemcellCID,
+		CloudProperties: snapshot.CloudProperties,
+		Networks:        snapshot.Networks,
+		Env:             snapshot.Env,
+	}
+
+	vm, err := m.createVM(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.stemcellRepo.UpdateCurrent(stemcellRecord.ID)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Updating current stemcell")
+	}
+
+	return vm, nil
+}
+
+func (m *manager) createVM(spec vmSpec) (VM, error) {
+	fingerprint, err := spec.Fingerprint()
+	if err != nil {
+		return nil, err
 	}
 
 	agentID, err := m.uuidGenerator.Generate()
@@ -103,9 +275,9 @@ func (m *manager) Create(stemcell bmstemcell.CloudStemcell, deploymentManifest b
 		return nil, bosherr.WrapError(err, "Generating agent ID")
 	}
 
-	cid, err := m.cloud.CreateVM(agentID, stemcell.CID(), cloudProperties, networkInterfaces, env)
+	cid, err := m.cloud.CreateVM(agentID, spec.StemcellCID, spec.CloudProperties, spec.Networks, spec.Env)
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Creating vm with stemcell cid '%s'", stemcell.CID())
+		return nil, bosherr.WrapErrorf(err, "Creating vm with stemcell cid '%s'", spec.StemcellCID)
 	}
 
 	err = m.vmRepo.UpdateCurrent(cid)
@@ -113,6 +285,11 @@ func (m *manager) Create(stemcell bmstemcell.CloudStemcell, deploymentManifest b
 		return nil, bosherr.WrapError(err, "Updating current vm record")
 	}
 
+	err = m.vmRepo.UpdateCurrentFingerprint(fingerprint)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Updating current vm fingerprint")
+	}
+
 	vm := NewVM(
 		cid,
 		m.vmRepo,
@@ -126,3 +303,49 @@ func (m *manager) Create(stemcell bmstemcell.CloudStemcell, deploymentManifest b
 
 	return vm, nil
 }
+
+// vmSpec is what a VM is created with; a VM needs recreating when it changes
+type vmSpec struct {
+	StemcellCID     string                            `json:"stemcell_cid"`
+	CloudProperties map[string]interface{}            `json:"cloud_properties"`
+	Networks        map[string]map[string]interface{} `json:"networks"`
+	Env             map[string]interface{}            `json:"env"`
+}
+
+func (s vmSpec) Fingerprint() (string, error) {
+	specJSON, err := json.Marshal(s)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Marshalling vm spec")
+	}
+	return fmt.Sprintf("%x", sha1.Sum(specJSON)), nil
+}
+
+func (m *manager) newVMSpec(stemcell bmstemcell.CloudStemcell, deploymentManifest bmdeplmanifest.Manifest, jobName string, index int) (vmSpec, error) {
+	networkInterfaces, err := deploymentManifest.NetworkInterfaces(jobName, index)
+	m.logger.Debug(m.logTag, "VM network interfaces: %#v", networkInterfaces)
+	if err != nil {
+		return vmSpec{}, bosherr.WrapError(err, "Getting network spec")
+	}
+
+	resourcePool, err := deploymentManifest.ResourcePool(jobName)
+	if err != nil {
+		return vmSpec{}, bosherr.WrapError(err, "Getting resource pool")
+	}
+
+	cloudProperties, err := resourcePool.CloudProperties()
+	if err != nil {
+		return vmSpec{}, bosherr.WrapError(err, "Getting cloud properties")
+	}
+
+	env, err := resourcePool.Env()
+	if err != nil {
+		return vmSpec{}, bosherr.WrapError(err, "Getting resource pool env")
+	}
+
+	return vmSpec{
+		StemcellCID:     stemcell.CID(),
+		CloudProperties: cloudProperties,
+		Networks:        networkInterfaces,
+		Env:             env,
+	}, nil
+}
@@ -8,16 +8,19 @@ import (
 	bmcloud "github.com/cloudfoundry/bosh-micro-cli/cloud"
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
 	bmac "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
+	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 )
 
 type ManagerFactory interface {
 	NewManager(cloud bmcloud.Cloud, agentClient bmac.AgentClient) Manager
+	NewInstanceManager(cloud bmcloud.Cloud, agentClient bmac.AgentClient, jobName string, index int) Manager
 }
 
 type managerFactory struct {
 	vmRepo        bmconfig.VMRepo
 	stemcellRepo  bmconfig.StemcellRepo
 	diskDeployer  DiskDeployer
+	configService bmconfig.DeploymentConfigService
 	uuidGenerator boshuuid.Generator
 	fs            boshsys.FileSystem
 	logger        boshlog.Logger
@@ -27,6 +30,7 @@ func NewManagerFactory(
 	vmRepo bmconfig.VMRepo,
 	stemcellRepo bmconfig.StemcellRepo,
 	diskDeployer DiskDeployer,
+	configService bmconfig.DeploymentConfigService,
 	uuidGenerator boshuuid.Generator,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
@@ -35,6 +39,7 @@ func NewManagerFactory(
 		vmRepo:        vmRepo,
 		stemcellRepo:  stemcellRepo,
 		diskDeployer:  diskDeployer,
+		configService: configService,
 		uuidGenerator: uuidGenerator,
 		fs:            fs,
 		logger:        logger,
@@ -53,3 +58,21 @@ func (f *managerFactory) NewManager(cloud bmcloud.Cloud, agentClient bmac.AgentC
 		f.logger,
 	)
 }
+
+// NewInstanceManager returns the manager of the VM of an instance after the first one,
+// which keeps the VM & disk in the record of the instance
+func (f *managerFactory) NewInstanceManager(cloud bmcloud.Cloud, agentClient bmac.AgentClient, jobName string, index int) Manager {
+	diskRepo := bmconfig.NewInstanceDiskRepo(f.configService, f.uuidGenerator, jobName, index)
+	diskDeployer := NewDiskDeployer(bmdisk.NewManagerFactory(diskRepo, f.logger), diskRepo, f.logger)
+
+	return NewManager(
+		bmconfig.NewInstanceVMRepo(f.configService, jobName, index),
+		f.stemcellRepo,
+		diskDeployer,
+		agentClient,
+		cloud,
+		f.uuidGenerator,
+		f.fs,
+		f.logger,
+	)
+}
@@ -8,6 +8,7 @@ import (
 
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bmas "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell"
 
@@ -55,6 +56,7 @@ var _ = Describe("Manager", func() {
 			fakeVMRepo,
 			stemcellRepo,
 			fakeDiskDeployer,
+			configService,
 			fakeUUIDGenerator,
 			fs,
 			logger,
@@ -66,6 +68,7 @@ var _ = Describe("Manager", func() {
 				"type":             "dynamic",
 				"ip":               "fake-micro-ip",
 				"cloud_properties": map[string]interface{}{},
+				"default":          []string{"dns", "gateway"},
 			},
 		}
 		expectedCloudProperties = map[string]interface{}{
@@ -112,7 +115,7 @@ var _ = Describe("Manager", func() {
 
 	Describe("Create", func() {
 		It("creates a VM", func() {
-			vm, err := manager.Create(stemcell, deploymentManifest)
+			vm, err := manager.Create(stemcell, deploymentManifest, "fake-job", 0)
 			Expect(err).ToNot(HaveOccurred())
 			expectedVM := NewVM(
 				"fake-vm-cid",
@@ -138,22 +141,247 @@ var _ = Describe("Manager", func() {
 		})
 
 		It("updates the current vm record", func() {
-			_, err := manager.Create(stemcell, deploymentManifest)
+			_, err := manager.Create(stemcell, deploymentManifest, "fake-job", 0)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(fakeVMRepo.UpdateCurrentCID).To(Equal("fake-vm-cid"))
 		})
 
+		It("records the fingerprint of the stemcell, cloud properties, env & networks of the vm", func() {
+			_, err := manager.Create(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeVMRepo.UpdateCurrentFingerprintFingerprint).ToNot(BeEmpty())
+		})
+
 		Context("when creating the vm fails", func() {
 			BeforeEach(func() {
 				fakeCloud.CreateVMErr = errors.New("fake-create-error")
 			})
 
 			It("returns an error", func() {
-				_, err := manager.Create(stemcell, deploymentManifest)
+				_, err := manager.Create(stemcell, deploymentManifest, "fake-job", 0)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-create-error"))
 			})
 		})
 	})
+
+	Describe("FindUpToDate", func() {
+		var createdFingerprint string
+
+		BeforeEach(func() {
+			_, err := manager.Create(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			createdFingerprint = fakeVMRepo.UpdateCurrentFingerprintFingerprint
+
+			fakeVMRepo.SetFindCurrentBehavior("fake-vm-cid", true, nil)
+			fakeVMRepo.FindCurrentFingerprintFingerprint = createdFingerprint
+			fakeCloud.HasVMFound = true
+		})
+
+		It("returns the current vm when it was created from the same stemcell, resource pool & networks", func() {
+			vm, found, err := manager.FindUpToDate(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(vm.CID()).To(Equal("fake-vm-cid"))
+		})
+
+		It("does not return the current vm when the stemcell changed", func() {
+			otherStemcell := bmstemcell.NewCloudStemcell(bmconfig.StemcellRecord{CID: "fake-other-stemcell-cid"}, stemcellRepo, fakeCloud)
+
+			_, found, err := manager.FindUpToDate(otherStemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not return the current vm when the resource pool cloud properties changed", func() {
+			deploymentManifest.ResourcePools[0].RawCloudProperties = map[interface{}]interface{}{
+				"fake-cloud-property-key": "fake-other-cloud-property-value",
+			}
+
+			_, found, err := manager.FindUpToDate(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not return the current vm when the resource pool env changed", func() {
+			deploymentManifest.ResourcePools[0].RawEnv = map[interface{}]interface{}{}
+
+			_, found, err := manager.FindUpToDate(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not return the current vm when its networks changed", func() {
+			deploymentManifest.Jobs[0].Networks[0].StaticIPs = []string{"fake-other-micro-ip"}
+
+			_, found, err := manager.FindUpToDate(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not return the current vm when it was deleted outside of bosh", func() {
+			fakeCloud.HasVMFound = false
+
+			_, found, err := manager.FindUpToDate(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not return the current vm when it has no recorded fingerprint", func() {
+			fakeVMRepo.FindCurrentFingerprintFingerprint = ""
+
+			_, found, err := manager.FindUpToDate(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not return a vm when there is no current vm", func() {
+			fakeVMRepo.SetFindCurrentBehavior("", false, nil)
+
+			_, found, err := manager.FindUpToDate(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("UpdateSnapshot", func() {
+		var applySpec bmas.ApplySpec
+
+		BeforeEach(func() {
+			applySpec = bmas.ApplySpec{
+				Deployment:        "fake-deployment",
+				ConfigurationHash: "fake-configuration-hash",
+			}
+		})
+
+		It("records the stemcell, cloud properties, env & networks of the vm with the apply spec", func() {
+			err := manager.UpdateSnapshot(stemcell, deploymentManifest, "fake-job", 0, applySpec)
+			Expect(err).ToNot(HaveOccurred())
+
+			snapshotRecord := fakeVMRepo.UpdateCurrentSnapshotSnapshot
+			Expect(snapshotRecord.StemcellCID).To(Equal("fake-stemcell-cid"))
+			Expect(snapshotRecord.CloudProperties).To(Equal(expectedCloudProperties))
+			Expect(snapshotRecord.Networks).To(Equal(expectedNetworkInterfaces))
+			Expect(snapshotRecord.Env).To(Equal(expectedEnv))
+			Expect(snapshotRecord.ApplySpec).To(MatchJSON(`{
+				"deployment": "fake-deployment",
+				"index": 0,
+				"packages": null,
+				"networks": null,
+				"job": {"name": "", "templates": null},
+				"rendered_templates_archive": {"blobstore_id": "", "sha1": ""},
+				"configuration_hash": "fake-configuration-hash"
+			}`))
+		})
+
+		It("is found as the snapshot of the current vm", func() {
+			err := manager.UpdateSnapshot(stemcell, deploymentManifest, "fake-job", 0, applySpec)
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeVMRepo.FindCurrentSnapshotSnapshot = fakeVMRepo.UpdateCurrentSnapshotSnapshot
+			fakeVMRepo.FindCurrentSnapshotFound = true
+
+			snapshot, found, err := manager.FindSnapshot()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(snapshot.StemcellCID).To(Equal("fake-stemcell-cid"))
+			Expect(snapshot.ApplySpec).To(Equal(applySpec))
+			Expect(snapshot.DeploymentManifest.Name).To(Equal(deploymentManifest.Name))
+			Expect(snapshot.DeploymentManifest.Jobs).To(HaveLen(1))
+			Expect(snapshot.DeploymentManifest.Jobs[0].Name).To(Equal("fake-job"))
+			Expect(snapshot.DeploymentManifest.Jobs[0].Networks[0].StaticIPs).To(Equal([]string{"fake-micro-ip"}))
+		})
+
+		Context("when updating the vm snapshot fails", func() {
+			BeforeEach(func() {
+				fakeVMRepo.UpdateCurrentSnapshotErr = errors.New("fake-update-snapshot-error")
+			})
+
+			It("returns an error", func() {
+				err := manager.UpdateSnapshot(stemcell, deploymentManifest, "fake-job", 0, applySpec)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-update-snapshot-error"))
+			})
+		})
+	})
+
+	Describe("FindSnapshot", func() {
+		It("does not return a snapshot before the first successful deploy", func() {
+			_, found, err := manager.FindSnapshot()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("CreateFromSnapshot", func() {
+		var snapshot Snapshot
+
+		BeforeEach(func() {
+			_, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			otherStemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-other-stemcell-version", "fake-other-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			err = stemcellRepo.UpdateCurrent(otherStemcellRecord.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			snapshot = Snapshot{
+				StemcellCID:     "fake-stemcell-cid",
+				CloudProperties: expectedCloudProperties,
+				Networks:        expectedNetworkInterfaces,
+				Env:             expectedEnv,
+			}
+		})
+
+		It("creates the vm the snapshot vm was created as", func() {
+			vm, err := manager.CreateFromSnapshot(snapshot)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vm.CID()).To(Equal("fake-vm-cid"))
+
+			Expect(fakeCloud.CreateVMInput).To(Equal(
+				fakebmcloud.CreateVMInput{
+					AgentID:            "fake-uuid-3",
+					StemcellCID:        "fake-stemcell-cid",
+					CloudProperties:    expectedCloudProperties,
+					NetworksInterfaces: expectedNetworkInterfaces,
+					Env:                expectedEnv,
+				},
+			))
+			Expect(fakeVMRepo.UpdateCurrentCID).To(Equal("fake-vm-cid"))
+		})
+
+		It("records the fingerprint the vm would have when created from the manifest", func() {
+			_, err := manager.Create(stemcell, deploymentManifest, "fake-job", 0)
+			Expect(err).ToNot(HaveOccurred())
+			createdFingerprint := fakeVMRepo.UpdateCurrentFingerprintFingerprint
+
+			_, err = manager.CreateFromSnapshot(snapshot)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeVMRepo.UpdateCurrentFingerprintFingerprint).To(Equal(createdFingerprint))
+		})
+
+		It("promotes the stemcell of the snapshot as current", func() {
+			_, err := manager.CreateFromSnapshot(snapshot)
+			Expect(err).ToNot(HaveOccurred())
+
+			currentStemcell, found, err := stemcellRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(currentStemcell.CID).To(Equal("fake-stemcell-cid"))
+		})
+
+		Context("when the stemcell of the snapshot does not exist anymore", func() {
+			BeforeEach(func() {
+				snapshot.StemcellCID = "fake-deleted-stemcell-cid"
+			})
+
+			It("returns an error", func() {
+				_, err := manager.CreateFromSnapshot(snapshot)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Stemcell 'fake-deleted-stemcell-cid' of the vm snapshot does not exist"))
+				Expect(fakeCloud.CreateVMInput).To(Equal(fakebmcloud.CreateVMInput{}))
+			})
+		})
+	})
 })
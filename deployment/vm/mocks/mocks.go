@@ -40,3 +40,13 @@ func (_m *MockManagerFactory) NewManager(_param0 cloud.Cloud, _param1 agentclien
 func (_mr *_MockManagerFactoryRecorder) NewManager(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewManager", arg0, arg1)
 }
+
+func (_m *MockManagerFactory) NewInstanceManager(_param0 cloud.Cloud, _param1 agentclient.AgentClient, _param2 string, _param3 int) vm.Manager {
+	ret := _m.ctrl.Call(_m, "NewInstanceManager", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(vm.Manager)
+	return ret0
+}
+
+func (_mr *_MockManagerFactoryRecorder) NewInstanceManager(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewInstanceManager", arg0, arg1, arg2, arg3)
+}
@@ -16,10 +16,13 @@ import (
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
 	bmeventlog "github.com/cloudfoundry/bosh-micro-cli/eventlogger"
+	bmindex "github.com/cloudfoundry/bosh-micro-cli/index"
 )
 
 type VM interface {
 	CID() string
+	AgentClient() bmagentclient.AgentClient
+	CompiledPackageIndex() bmindex.Index
 	Exists() (bool, error)
 	WaitUntilReady(timeout time.Duration, delay time.Duration) error
 	Start() error
@@ -74,6 +77,15 @@ func (vm *vm) CID() string {
 	return vm.cid
 }
 
+func (vm *vm) AgentClient() bmagentclient.AgentClient {
+	return vm.agentClient
+}
+
+// CompiledPackageIndex returns the index of the packages compiled on the VM, which are lost with the VM
+func (vm *vm) CompiledPackageIndex() bmindex.Index {
+	return vm.vmRepo.CompiledPackageIndex(vm.cid)
+}
+
 func (vm *vm) Exists() (bool, error) {
 	exists, err := vm.cloud.HasVM(vm.cid)
 	if err != nil {
@@ -128,8 +140,17 @@ func (vm *vm) UpdateDisks(diskPool bmdeplmanifest.DiskPool, eventLoggerStage bme
 }
 
 func (vm *vm) WaitToBeRunning(maxAttempts int, delay time.Duration) error {
-	agentGetStateRetryable := bmagentclient.NewGetStateRetryable(vm.agentClient)
-	agentGetStateRetryStrategy := boshretry.NewAttemptRetryStrategy(maxAttempts, delay, agentGetStateRetryable, vm.logger)
+	return WaitForAgentToBeRunning(vm.agentClient, maxAttempts, delay, vm.logger)
+}
+
+// WaitForAgentToBeRunning retries get_state until the agent reports its jobs running.
+// It always tries at least once, even when the watch time is shorter than the delay.
+func WaitForAgentToBeRunning(agentClient bmagentclient.AgentClient, maxAttempts int, delay time.Duration, logger boshlog.Logger) error {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	agentGetStateRetryable := bmagentclient.NewGetStateRetryable(agentClient)
+	agentGetStateRetryStrategy := boshretry.NewAttemptRetryStrategy(maxAttempts, delay, agentGetStateRetryable, logger)
 	return agentGetStateRetryStrategy.Try()
 }
 
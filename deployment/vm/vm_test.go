@@ -276,6 +276,12 @@ var _ = Describe("VM", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(fakeAgentClient.GetStateCalledTimes).To(Equal(3))
 		})
+
+		It("tries at least once when no attempts are left for the watch time", func() {
+			err := vm.WaitToBeRunning(0, 0)
+			Expect(err).To(HaveOccurred())
+			Expect(fakeAgentClient.GetStateCalledTimes).To(Equal(1))
+		})
 	})
 
 	Describe("AttachDisk", func() {
@@ -2,6 +2,7 @@ package eventlogger
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
@@ -36,12 +37,16 @@ type EventLogger interface {
 	AddEvent(event Event) error
 	StartStage(string)
 	FinishStage(string)
+	FailStage(string)
 }
 
 type eventLogger struct {
 	ui           bmui.UI
 	startedTasks map[string]time.Time
 	filters      []EventFilter
+
+	// lock guards the started tasks of the steps performed at the same time, e.g. by instances created in parallel
+	lock sync.Mutex
 }
 
 func NewEventLogger(ui bmui.UI) EventLogger {
@@ -65,6 +70,9 @@ func NewEventLoggerWithFilters(ui bmui.UI, filters []EventFilter) EventLogger {
 }
 
 func (e *eventLogger) AddEvent(event Event) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
 	if e.filters != nil && len(e.filters) > 0 {
 		for _, filter := range e.filters {
 			filter.Filter(&event)
@@ -95,6 +103,10 @@ func (e *eventLogger) FinishStage(name string) {
 	e.ui.Sayln(fmt.Sprintf("Done %s", name))
 }
 
+func (e *eventLogger) FailStage(name string) {
+	e.ui.Sayln(fmt.Sprintf("Failed %s", name))
+}
+
 func (e *eventLogger) StartStage(name string) {
 	e.ui.Sayln("")
 	e.ui.Sayln(fmt.Sprintf("Started %s", name))
@@ -29,7 +29,7 @@ var _ = Describe("EventLogger", func() {
 		uiOut = bytes.NewBufferString("")
 		uiErr = bytes.NewBufferString("")
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		ui = bmui.NewUI(uiOut, uiErr, logger)
+		ui = bmui.NewUI(bytes.NewBufferString(""), uiOut, uiErr, logger)
 		eventLogger = NewEventLogger(ui)
 	})
 
@@ -146,6 +146,16 @@ var _ = Describe("EventLogger", func() {
 			})
 		})
 
+		Context("when the stage failed", func() {
+			It("tells UI to print out that the stage failed", func() {
+				eventLogger.StartStage("fake-stage")
+				eventLogger.FailStage("fake-stage")
+
+				output := uiOut.String()
+				Expect(output).To(ContainSubstring("Failed fake-stage\n"))
+			})
+		})
+
 		Context("when task is skipped", func() {
 			It("tells UI to print out a skipped message", func() {
 				now := time.Now()
@@ -13,6 +13,7 @@ type FakeEventLogger struct {
 
 	StartStageInputs  []string
 	FinishStageInputs []string
+	FailStageInputs   []string
 }
 
 type NewStageInput struct {
@@ -50,6 +51,10 @@ func (fl *FakeEventLogger) FinishStage(name string) {
 	fl.FinishStageInputs = append(fl.FinishStageInputs, name)
 }
 
+func (fl *FakeEventLogger) FailStage(name string) {
+	fl.FailStageInputs = append(fl.FailStageInputs, name)
+}
+
 func (fl *FakeEventLogger) SetNewStageBehavior(stage bmeventlog.Stage) {
 	fl.newStageStage = stage
 }
@@ -11,6 +11,7 @@ type FakeStage struct {
 
 	Started  bool
 	Finished bool
+	Failed   bool
 }
 
 func NewFakeStage() *FakeStage {
@@ -60,3 +61,7 @@ func (s *FakeStage) Start() {
 func (s *FakeStage) Finish() {
 	s.Finished = true
 }
+
+func (s *FakeStage) Fail() {
+	s.Failed = true
+}
@@ -20,6 +20,7 @@ type Stage interface {
 	Name() string
 	Start()
 	Finish()
+	Fail()
 }
 
 type stage struct {
@@ -71,3 +72,7 @@ func (s *stage) Start() {
 func (s *stage) Finish() {
 	s.eventLogger.FinishStage(s.name)
 }
+
+func (s *stage) Fail() {
+	s.eventLogger.FailStage(s.name)
+}
@@ -0,0 +1,45 @@
+package index
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+)
+
+// InMemoryIndex is an Index that is not persisted, for records that are only valid while the cli runs
+type InMemoryIndex struct {
+	entries map[string][]byte
+}
+
+func NewInMemoryIndex() InMemoryIndex {
+	return InMemoryIndex{entries: map[string][]byte{}}
+}
+
+func (ri InMemoryIndex) Find(key interface{}, entry interface{}) error {
+	rawKey, err := json.Marshal(key)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling index key")
+	}
+
+	rawValue, found := ri.entries[string(rawKey)]
+	if !found {
+		return ErrNotFound
+	}
+
+	return json.Unmarshal(rawValue, entry)
+}
+
+func (ri InMemoryIndex) Save(key interface{}, entry interface{}) error {
+	rawKey, err := json.Marshal(key)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling index key")
+	}
+
+	rawValue, err := json.Marshal(entry)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling index entry")
+	}
+
+	ri.entries[string(rawKey)] = rawValue
+	return nil
+}
@@ -0,0 +1,52 @@
+package index_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/index"
+)
+
+var _ = Describe("InMemoryIndex", func() {
+	var (
+		index InMemoryIndex
+	)
+
+	BeforeEach(func() {
+		index = NewInMemoryIndex()
+	})
+
+	Describe("Save/Find", func() {
+		It("finds the item saved with the same key", func() {
+			err := index.Save(Key{Key: "key-1"}, Value{Name: "value-1", Count: 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			var value Value
+			err = index.Find(Key{Key: "key-1"}, &value)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(Value{Name: "value-1", Count: 1}))
+		})
+
+		It("returns ErrNotFound if item is not found by key", func() {
+			err := index.Save(Key{Key: "key-1"}, Value{Name: "value-1", Count: 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			var value Value
+			err = index.Find(Key{Key: "key-2"}, &value)
+			Expect(err).To(Equal(ErrNotFound))
+			Expect(value).To(Equal(Value{}))
+		})
+
+		It("overwrites the item saved with the same key", func() {
+			err := index.Save(Key{Key: "key-1"}, Value{Name: "value-1", Count: 1})
+			Expect(err).ToNot(HaveOccurred())
+			err = index.Save(Key{Key: "key-1"}, Value{Name: "value-2", Count: 2})
+			Expect(err).ToNot(HaveOccurred())
+
+			var value Value
+			err = index.Find(Key{Key: "key-1"}, &value)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(Value{Name: "value-2", Count: 2}))
+		})
+	})
+})
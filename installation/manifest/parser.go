@@ -6,6 +6,8 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 type Parser interface {
@@ -13,9 +15,10 @@ type Parser interface {
 }
 
 type parser struct {
-	fs     boshsys.FileSystem
-	logger boshlog.Logger
-	logTag string
+	fs           boshsys.FileSystem
+	interpolator bmvars.Interpolator
+	logger       boshlog.Logger
+	logTag       string
 }
 
 type manifest struct {
@@ -32,11 +35,12 @@ type installation struct {
 	Mbus            string
 }
 
-func NewParser(fs boshsys.FileSystem, logger boshlog.Logger) Parser {
+func NewParser(fs boshsys.FileSystem, interpolator bmvars.Interpolator, logger boshlog.Logger) Parser {
 	return &parser{
-		fs:     fs,
-		logger: logger,
-		logTag: "deploymentParser",
+		fs:           fs,
+		interpolator: interpolator,
+		logger:       logger,
+		logTag:       "deploymentParser",
 	}
 }
 
@@ -46,6 +50,11 @@ func (p *parser) Parse(path string) (Manifest, error) {
 		return Manifest{}, bosherr.WrapErrorf(err, "Reading file %s", path)
 	}
 
+	contents, err = p.interpolator.Interpolate(contents)
+	if err != nil {
+		return Manifest{}, bosherr.WrapErrorf(err, "Interpolating installation manifest '%s'", path)
+	}
+
 	comboManifest := manifest{}
 	err = candiedyaml.Unmarshal(contents, &comboManifest)
 	if err != nil {
@@ -11,6 +11,8 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
 
 	. "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 var _ = Describe("Parser", func() {
@@ -24,7 +26,7 @@ var _ = Describe("Parser", func() {
 		comboManifestPath = "fake-deployment-path"
 		fakeFs = fakesys.NewFakeFileSystem()
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		parser = NewParser(fakeFs, logger)
+		parser = NewParser(fakeFs, bmvars.NewInterpolator(bmvars.Variables{}), logger)
 
 		contents := `
 ---
@@ -14,7 +14,9 @@ type Validator interface {
 }
 
 type validator struct {
-	logger          boshlog.Logger
+	logger boshlog.Logger
+
+	// releaseResolver is nil when release references are not validated
 	releaseResolver bmrelset.Resolver
 }
 
@@ -25,15 +27,24 @@ func NewValidator(logger boshlog.Logger, releaseResolver bmrelset.Resolver) Vali
 	}
 }
 
+// NewValidatorWithoutReleases returns a Validator that does not check that the CPI release is available
+func NewValidatorWithoutReleases(logger boshlog.Logger) Validator {
+	return &validator{
+		logger: logger,
+	}
+}
+
 func (v *validator) Validate(manifest Manifest) error {
 	cpiReleaseName := manifest.Release
 	if v.isBlank(cpiReleaseName) {
 		return bosherr.Error("cloud_provider.release must be provided")
 	}
 
-	_, err := v.releaseResolver.Find(cpiReleaseName)
-	if err != nil {
-		return bosherr.WrapErrorf(err, "cloud_provider.release '%s' must refer to a provided release", cpiReleaseName)
+	if v.releaseResolver != nil {
+		_, err := v.releaseResolver.Find(cpiReleaseName)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "cloud_provider.release '%s' must refer to a provided release", cpiReleaseName)
+		}
 	}
 
 	return nil
@@ -88,4 +88,29 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("cloud_provider.release 'not-provided-valid-release-name' must refer to a provided release"))
 		})
 	})
+
+	Context("when created without releases", func() {
+		JustBeforeEach(func() {
+			validator = NewValidatorWithoutReleases(logger)
+		})
+
+		It("does not validate that the release is available", func() {
+			manifest := Manifest{
+				Release: "not-provided-valid-release-name",
+			}
+
+			err := validator.Validate(manifest)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("validates release is not blank", func() {
+			manifest := Manifest{
+				Release: " ",
+			}
+
+			err := validator.Validate(manifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.release must be provided"))
+		})
+	})
 })
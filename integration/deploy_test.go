@@ -32,6 +32,7 @@ import (
 	bmac "github.com/cloudfoundry/bosh-micro-cli/deployment/agentclient"
 	bmas "github.com/cloudfoundry/bosh-micro-cli/deployment/applyspec"
 	bmdisk "github.com/cloudfoundry/bosh-micro-cli/deployment/disk"
+	bmdryrun "github.com/cloudfoundry/bosh-micro-cli/deployment/dryrun"
 	bmhttp "github.com/cloudfoundry/bosh-micro-cli/deployment/httpclient"
 	bminstance "github.com/cloudfoundry/bosh-micro-cli/deployment/instance"
 	bmdeplmanifest "github.com/cloudfoundry/bosh-micro-cli/deployment/manifest"
@@ -42,14 +43,16 @@ import (
 	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
 	bminstalljob "github.com/cloudfoundry/bosh-micro-cli/installation/job"
 	bminstallmanifest "github.com/cloudfoundry/bosh-micro-cli/installation/manifest"
+	bmpatch "github.com/cloudfoundry/bosh-micro-cli/patch"
 	bmregistry "github.com/cloudfoundry/bosh-micro-cli/registry"
 	bmrel "github.com/cloudfoundry/bosh-micro-cli/release"
 	bmrelset "github.com/cloudfoundry/bosh-micro-cli/release/set"
 	bmrelsetmanifest "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
 
-	fakebmcrypto "github.com/cloudfoundry/bosh-micro-cli/crypto/fakes"
 	fakebmstemcell "github.com/cloudfoundry/bosh-micro-cli/deployment/stemcell/fakes"
 	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 var _ = Describe("bosh-micro", func() {
@@ -91,7 +94,6 @@ var _ = Describe("bosh-micro", func() {
 			fakeUUIDGenerator       *fakeuuid.FakeGenerator
 			fakeRepoUUIDGenerator   *fakeuuid.FakeGenerator
 			fakeAgentIDGenerator    *fakeuuid.FakeGenerator
-			fakeSHA1Calculator      *fakebmcrypto.FakeSha1Calculator
 			deploymentConfigService bmconfig.DeploymentConfigService
 			vmRepo                  bmconfig.VMRepo
 			diskRepo                bmconfig.DiskRepo
@@ -129,6 +131,7 @@ var _ = Describe("bosh-micro", func() {
 					"type":             "dynamic",
 					"ip":               "",
 					"cloud_properties": cloudProperties,
+					"default":          []string{"dns", "gateway"},
 				},
 			}
 			agentRunningState = bmac.AgentState{JobState: "running"}
@@ -171,10 +174,6 @@ cloud_provider:
     password: fake-registry-password
 `)
 			Expect(err).ToNot(HaveOccurred())
-
-			fakeSHA1Calculator.SetCalculateBehavior(map[string]fakebmcrypto.CalculateInput{
-				deploymentManifestPath: {Sha1: "fake-deployment-sha1-1"},
-			})
 		}
 
 		var writeDeploymentManifestWithLargerDisk = func() {
@@ -210,10 +209,6 @@ cloud_provider:
     password: fake-registry-password
 `)
 			Expect(err).ToNot(HaveOccurred())
-
-			fakeSHA1Calculator.SetCalculateBehavior(map[string]fakebmcrypto.CalculateInput{
-				deploymentManifestPath: {Sha1: "fake-deployment-sha1-2"},
-			})
 		}
 
 		var writeCPIReleaseTarball = func() {
@@ -312,6 +307,7 @@ cloud_provider:
 						"cloud_properties": map[string]interface{}{},
 						"type":             "dynamic",
 						"ip":               "",
+						"default":          []interface{}{"dns", "gateway"},
 					},
 				},
 				Job: bmas.Job{
@@ -322,21 +318,21 @@ cloud_provider:
 				ConfigurationHash:        "",
 			}
 
-			mockStateBuilderFactory.EXPECT().NewStateBuilder(mockBlobstore).Return(mockStateBuilder).AnyTimes()
-			mockStateBuilder.EXPECT().Build(jobName, jobIndex, gomock.Any(), gomock.Any()).Return(mockState, nil).AnyTimes()
+			mockStateBuilderFactory.EXPECT().NewStateBuilder(mockBlobstore, gomock.Any(), gomock.Any()).Return(mockStateBuilder).AnyTimes()
+			mockStateBuilder.EXPECT().Build(jobName, jobIndex, gomock.Any()).Return(mockState, nil).AnyTimes()
 			mockState.EXPECT().ToApplySpec().Return(applySpec).AnyTimes()
 		}
 
 		var newDeployCmd = func() Cmd {
-			deploymentParser := bmdeplmanifest.NewParser(fs, logger)
-			releaseSetParser := bmrelsetmanifest.NewParser(fs, logger)
-			installationParser := bminstallmanifest.NewParser(fs, logger)
+			deploymentParser := bmdeplmanifest.NewParser(fs, bmvars.NewInterpolator(bmvars.Variables{}), logger)
+			releaseSetParser := bmrelsetmanifest.NewParser(fs, bmvars.NewInterpolator(bmvars.Variables{}), logger)
+			installationParser := bminstallmanifest.NewParser(fs, bmvars.NewInterpolator(bmvars.Variables{}), logger)
 
 			releaseSetValidator := bmrelsetmanifest.NewValidator(logger, releaseResolver)
 			installationValidator := bminstallmanifest.NewValidator(logger, releaseResolver)
 			deploymentValidator := bmdeplmanifest.NewValidator(logger, releaseResolver)
 
-			deploymentRecord := bmdepl.NewRecord(deploymentRepo, releaseRepo, stemcellRepo, fakeSHA1Calculator)
+			deploymentRecord := bmdepl.NewRecord(deploymentRepo, releaseRepo)
 
 			instanceFactory := bminstance.NewFactory(mockStateBuilderFactory)
 			instanceManagerFactory := bminstance.NewManagerFactory(sshTunnelFactory, instanceFactory, logger)
@@ -350,6 +346,8 @@ cloud_provider:
 				vmManagerFactory,
 				instanceManagerFactory,
 				deploymentFactory,
+				mockAgentClientFactory,
+				bmconfig.NewInstanceRepo(deploymentConfigService),
 				eventLogger,
 				logger,
 			)
@@ -361,6 +359,7 @@ cloud_provider:
 				releaseSetParser,
 				installationParser,
 				deploymentParser,
+				bmpatch.NewPatcher(fs, logger),
 				deploymentConfigService,
 				releaseSetValidator,
 				installationValidator,
@@ -370,12 +369,11 @@ cloud_provider:
 				releaseManager,
 				releaseResolver,
 				mockCloudFactory,
-				mockAgentClientFactory,
-				vmManagerFactory,
 				fakeStemcellExtractor,
 				deploymentRecord,
 				mockBlobstoreFactory,
 				deployer,
+				bmdryrun.NewPlanner(instanceFactory, sshTunnelFactory, fakeRepoUUIDGenerator, fs, logger),
 				eventLogger,
 				logger,
 			)
@@ -403,6 +401,34 @@ cloud_provider:
 			)
 		}
 
+		var expectDeployWithDiskMigrationInPlace = func() {
+			vmCID := "fake-vm-cid-1"
+			oldDiskCID := "fake-disk-cid-1"
+			newDiskCID := "fake-disk-cid-2"
+			newDiskSize := 2048
+
+			gomock.InOrder(
+				// the vm is up to date, only its disk & jobs are updated
+				mockCloud.EXPECT().HasVM(vmCID).Return(true, nil),
+
+				// attach both disks and migrate
+				mockCloud.EXPECT().AttachDisk(vmCID, oldDiskCID),
+				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockCloud.EXPECT().CreateDisk(newDiskSize, cloudProperties, vmCID).Return(newDiskCID, nil),
+				mockCloud.EXPECT().AttachDisk(vmCID, newDiskCID),
+				mockAgentClient.EXPECT().MountDisk(newDiskCID),
+				mockAgentClient.EXPECT().MigrateDisk(),
+				mockCloud.EXPECT().DetachDisk(vmCID, oldDiskCID),
+				mockCloud.EXPECT().DeleteDisk(oldDiskCID),
+
+				// start jobs & wait for running
+				mockAgentClient.EXPECT().Stop(),
+				mockAgentClient.EXPECT().Apply(applySpec),
+				mockAgentClient.EXPECT().Start(),
+				mockAgentClient.EXPECT().GetState().Return(agentRunningState, nil),
+			)
+		}
+
 		var expectDeployWithDiskMigration = func() {
 			agentID := "fake-uuid-1"
 			oldVMCID := "fake-vm-cid-1"
@@ -456,6 +482,8 @@ cloud_provider:
 			expectDeleteVM1 = mockCloud.EXPECT().DeleteVM(oldVMCID)
 
 			gomock.InOrder(
+				// a missing vm is not up to date
+				mockCloud.EXPECT().HasVM(oldVMCID).Return(false, nil),
 				mockCloud.EXPECT().HasVM(oldVMCID).Return(false, nil),
 
 				// delete old vm (without talking to agent) so that the cpi can clean up related resources
@@ -484,27 +512,14 @@ cloud_provider:
 		}
 
 		var expectDeployWithNoDiskToMigrate = func() {
-			agentID := "fake-uuid-1"
-			oldVMCID := "fake-vm-cid-1"
-			newVMCID := "fake-vm-cid-2"
+			vmCID := "fake-vm-cid-1"
 			oldDiskCID := "fake-disk-cid-1"
 
 			gomock.InOrder(
-				mockCloud.EXPECT().HasVM(oldVMCID).Return(true, nil),
-
-				// shutdown old vm
-				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
-				mockAgentClient.EXPECT().Stop(),
-				mockAgentClient.EXPECT().ListDisk().Return([]string{oldDiskCID}, nil),
-				mockAgentClient.EXPECT().UnmountDisk(oldDiskCID),
-				mockCloud.EXPECT().DeleteVM(oldVMCID),
-
-				// create new vm
-				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env).Return(newVMCID, nil),
-				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockCloud.EXPECT().HasVM(vmCID).Return(true, nil),
 
 				// attaching a missing disk will fail
-				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID).Return(bmcloud.NewCPIError("attach_disk", bmcloud.CmdError{
+				mockCloud.EXPECT().AttachDisk(vmCID, oldDiskCID).Return(bmcloud.NewCPIError("attach_disk", bmcloud.CmdError{
 					Type:    bmcloud.DiskNotFoundError,
 					Message: "fake-disk-not-found-message",
 				})),
@@ -512,67 +527,41 @@ cloud_provider:
 		}
 
 		var expectDeployWithDiskMigrationFailure = func() {
-			agentID := "fake-uuid-1"
-			oldVMCID := "fake-vm-cid-1"
-			newVMCID := "fake-vm-cid-2"
+			vmCID := "fake-vm-cid-1"
 			oldDiskCID := "fake-disk-cid-1"
 			newDiskCID := "fake-disk-cid-2"
 			newDiskSize := 2048
 
 			gomock.InOrder(
-				mockCloud.EXPECT().HasVM(oldVMCID).Return(true, nil),
-
-				// shutdown old vm
-				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
-				mockAgentClient.EXPECT().Stop(),
-				mockAgentClient.EXPECT().ListDisk().Return([]string{oldDiskCID}, nil),
-				mockAgentClient.EXPECT().UnmountDisk(oldDiskCID),
-				mockCloud.EXPECT().DeleteVM(oldVMCID),
-
-				// create new vm
-				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env).Return(newVMCID, nil),
-				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockCloud.EXPECT().HasVM(vmCID).Return(true, nil),
 
 				// attach both disks and migrate (with error)
-				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
+				mockCloud.EXPECT().AttachDisk(vmCID, oldDiskCID),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
-				mockCloud.EXPECT().CreateDisk(newDiskSize, cloudProperties, newVMCID).Return(newDiskCID, nil),
-				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
+				mockCloud.EXPECT().CreateDisk(newDiskSize, cloudProperties, vmCID).Return(newDiskCID, nil),
+				mockCloud.EXPECT().AttachDisk(vmCID, newDiskCID),
 				mockAgentClient.EXPECT().MountDisk(newDiskCID),
 				mockAgentClient.EXPECT().MigrateDisk().Return(errors.New("fake-migration-error")),
 			)
 		}
 
 		var expectDeployWithDiskMigrationRepair = func() {
-			agentID := "fake-uuid-2"
-			oldVMCID := "fake-vm-cid-2"
-			newVMCID := "fake-vm-cid-3"
+			vmCID := "fake-vm-cid-1"
 			oldDiskCID := "fake-disk-cid-1"
 			newDiskCID := "fake-disk-cid-3"
 			newDiskSize := 2048
 
 			gomock.InOrder(
-				mockCloud.EXPECT().HasVM(oldVMCID).Return(true, nil),
-
-				// shutdown old vm
-				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
-				mockAgentClient.EXPECT().Stop(),
-				mockAgentClient.EXPECT().ListDisk().Return([]string{oldDiskCID}, nil),
-				mockAgentClient.EXPECT().UnmountDisk(oldDiskCID),
-				mockCloud.EXPECT().DeleteVM(oldVMCID),
-
-				// create new vm
-				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env).Return(newVMCID, nil),
-				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockCloud.EXPECT().HasVM(vmCID).Return(true, nil),
 
 				// attach both disks and migrate
-				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
+				mockCloud.EXPECT().AttachDisk(vmCID, oldDiskCID),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
-				mockCloud.EXPECT().CreateDisk(newDiskSize, cloudProperties, newVMCID).Return(newDiskCID, nil),
-				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
+				mockCloud.EXPECT().CreateDisk(newDiskSize, cloudProperties, vmCID).Return(newDiskCID, nil),
+				mockCloud.EXPECT().AttachDisk(vmCID, newDiskCID),
 				mockAgentClient.EXPECT().MountDisk(newDiskCID),
 				mockAgentClient.EXPECT().MigrateDisk(),
-				mockCloud.EXPECT().DetachDisk(newVMCID, oldDiskCID),
+				mockCloud.EXPECT().DetachDisk(vmCID, oldDiskCID),
 				mockCloud.EXPECT().DeleteDisk(oldDiskCID),
 
 				// start jobs & wait for running
@@ -645,8 +634,6 @@ cloud_provider:
 			deploymentConfigService = bmconfig.NewFileSystemDeploymentConfigService(deploymentConfigPath, fs, fakeUUIDGenerator, logger)
 			fakeAgentIDGenerator = fakeuuid.NewFakeGenerator()
 
-			fakeSHA1Calculator = fakebmcrypto.NewFakeSha1Calculator()
-
 			mockInstaller = mock_install.NewMockInstaller(mockCtrl)
 			mockInstallerFactory = mock_install.NewMockInstallerFactory(mockCtrl)
 			mockCloudFactory = mock_cloud.NewMockFactory(mockCtrl)
@@ -697,6 +684,7 @@ cloud_provider:
 				vmRepo,
 				stemcellRepo,
 				diskDeployer,
+				deploymentConfigService,
 				fakeAgentIDGenerator,
 				fs,
 				logger,
@@ -815,13 +803,22 @@ cloud_provider:
 					writeDeploymentManifestWithLargerDisk()
 				})
 
-				It("migrates the disk content", func() {
-					expectDeployWithDiskMigration()
+				It("migrates the disk content on the existing VM", func() {
+					expectDeployWithDiskMigrationInPlace()
 
 					err := newDeployCmd().Run([]string{stemcellTarballPath, cpiReleaseTarballPath})
 					Expect(err).ToNot(HaveOccurred())
 				})
 
+				Context("when --recreate is given", func() {
+					It("migrates the disk content to a new VM", func() {
+						expectDeployWithDiskMigration()
+
+						err := newDeployCmd().Run([]string{"--recreate", stemcellTarballPath, cpiReleaseTarballPath})
+						Expect(err).ToNot(HaveOccurred())
+					})
+				})
+
 				Context("when current VM has been deleted manually (outside of bosh)", func() {
 					It("migrates the disk content, but does not shutdown the old VM", func() {
 						expectDeployWithDiskMigrationMissingVM()
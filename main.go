@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
@@ -14,21 +16,58 @@ import (
 	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
 
 	bmui "github.com/cloudfoundry/bosh-micro-cli/ui"
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 const mainLogTag = "main"
 
 func main() {
-	logger := newLogger()
+	globalOptions, args, globalFlagsErr := bmcmd.ParseGlobalFlags(os.Args[1:])
+
+	logger := newLogger(globalOptions.LogLevel)
 	defer logger.HandlePanic("Main")
+
+	ui := newUI(globalOptions, logger)
+	if globalFlagsErr != nil {
+		ui.Error("Invalid usage - " + globalFlagsErr.Error())
+		ui.Sayln("Run 'bosh-micro help' for the global flags.")
+		fail(globalFlagsErr, logger)
+	}
+
 	fileSystem := boshsys.NewOsFileSystem(logger)
 	workspaceRootPath := path.Join(os.Getenv("HOME"), ".bosh_micro")
 	userConfigPath := path.Join(os.Getenv("HOME"), ".bosh_micro.json")
 	config, configService := loadUserConfig(userConfigPath, fileSystem, logger)
 
-	uuidGenerator := boshuuid.NewGenerator()
+	if globalOptions.StatePath != "" {
+		statePath, err := filepath.Abs(globalOptions.StatePath)
+		if err != nil {
+			fail(bosherr.WrapErrorf(err, "Getting absolute path to state file '%s'", globalOptions.StatePath), logger)
+		}
+		config.StatePath = statePath
+	}
 
-	ui := bmui.NewUI(os.Stdout, os.Stderr, logger)
+	migrated, err := bmconfig.MigrateLegacyDeploymentConfig(config, fileSystem, logger)
+	if err != nil {
+		fail(err, logger)
+	}
+	if migrated {
+		ui.Sayln(fmt.Sprintf("Deployment state migrated from '%s' to '%s'", config.LegacyDeploymentConfigPath(), config.DeploymentConfigPath()))
+	}
+
+	variables, err := bmvars.NewLoader(fileSystem, logger).Load(bmvars.Sources{
+		EnvPrefixes: globalOptions.VarsEnvPrefixes,
+		Environ:     os.Environ(),
+		Files:       globalOptions.VarsFiles,
+		Vars:        globalOptions.Vars,
+	})
+	if err != nil {
+		err = bosherr.WrapError(err, "Loading manifest variables")
+		ui.Error(err.Error())
+		fail(err, logger)
+	}
+
+	uuidGenerator := boshuuid.NewGenerator()
 
 	cmdFactory := bmcmd.NewFactory(
 		config,
@@ -38,24 +77,31 @@ func main() {
 		logger,
 		uuidGenerator,
 		workspaceRootPath,
+		variables,
 	)
 
 	cmdRunner := bmcmd.NewRunner(cmdFactory)
 
-	err := cmdRunner.Run(os.Args[1:])
+	err = cmdRunner.Run(args)
 	if err != nil {
 		fail(err, logger)
 	}
 }
 
-func newLogger() boshlog.Logger {
-	logLevelString := os.Getenv("BOSH_MICRO_LOG_LEVEL")
+// newLogger returns a logger at the given level, or else at the BOSH_MICRO_LOG_LEVEL level
+func newLogger(logLevelString string) boshlog.Logger {
+	source := "--log-level"
+	if logLevelString == "" {
+		logLevelString = os.Getenv("BOSH_MICRO_LOG_LEVEL")
+		source = "BOSH_MICRO_LOG_LEVEL"
+	}
+
 	level := boshlog.LevelNone
 	if logLevelString != "" {
 		var err error
 		level, err = boshlog.Levelify(logLevelString)
 		if err != nil {
-			err = bosherr.WrapError(err, "Invalid BOSH_MICRO_LOG_LEVEL value")
+			err = bosherr.WrapErrorf(err, "Invalid %s value", source)
 			logger := boshlog.NewLogger(boshlog.LevelError)
 			fail(err, logger)
 		}
@@ -69,6 +115,20 @@ func newLogger() boshlog.Logger {
 	return boshlog.NewLogger(level)
 }
 
+func newUI(globalOptions bmcmd.GlobalOptions, logger boshlog.Logger) bmui.UI {
+	var ui bmui.UI
+	if globalOptions.JSON {
+		ui = bmui.NewJSONUI(os.Stdin, os.Stdout, os.Stderr, logger)
+	} else {
+		ui = bmui.NewUI(os.Stdin, os.Stdout, os.Stderr, logger)
+	}
+
+	if globalOptions.NonInteractive {
+		ui = bmui.NewNonInteractiveUI(ui)
+	}
+	return ui
+}
+
 func newFileLogger(logPath string, level boshlog.LogLevel) boshlog.Logger {
 	// Log file logger errors to the STDERR logger
 	logger := boshlog.NewLogger(boshlog.LevelError)
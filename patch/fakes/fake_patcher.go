@@ -0,0 +1,22 @@
+package fakes
+
+type FakePatcher struct {
+	OpsFilePaths []string
+
+	PatchContents []byte
+	PatchResult   []byte
+	PatchErr      error
+}
+
+func NewFakePatcher() *FakePatcher {
+	return &FakePatcher{}
+}
+
+func (p *FakePatcher) UseOpsFiles(paths []string) {
+	p.OpsFilePaths = paths
+}
+
+func (p *FakePatcher) Patch(contents []byte) ([]byte, error) {
+	p.PatchContents = contents
+	return p.PatchResult, p.PatchErr
+}
@@ -0,0 +1,277 @@
+package patch
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+)
+
+// Op changes a YAML document
+type Op interface {
+	Apply(document interface{}) (interface{}, error)
+}
+
+// Ops are applied in order, each to the result of the previous one
+type Ops []Op
+
+// ReplaceOp sets the value at the path, creating it when its last token is a map key, "-" or an optional token
+type ReplaceOp struct {
+	Path  Pointer
+	Value interface{}
+}
+
+// RemoveOp deletes the value at the path
+type RemoveOp struct {
+	Path Pointer
+}
+
+type opDefinition struct {
+	Type  string      `yaml:"type"`
+	Path  string      `yaml:"path"`
+	Value interface{} `yaml:"value"`
+}
+
+// ParseOps parses a YAML list of ops, e.g. "- {type: replace, path: /name, value: bosh}"
+func ParseOps(contents []byte) (Ops, error) {
+	definitions := []opDefinition{}
+	err := candiedyaml.Unmarshal(contents, &definitions)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling ops")
+	}
+
+	ops := Ops{}
+	for index, definition := range definitions {
+		pointer, err := NewPointer(definition.Path)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing path of op %d", index)
+		}
+
+		switch definition.Type {
+		case "replace":
+			ops = append(ops, ReplaceOp{Path: pointer, Value: definition.Value})
+		case "remove":
+			ops = append(ops, RemoveOp{Path: pointer})
+		default:
+			return nil, bosherr.Errorf("Unknown type '%s' of op %d, expected 'replace' or 'remove'", definition.Type, index)
+		}
+	}
+
+	return ops, nil
+}
+
+func (o Ops) Apply(document interface{}) (interface{}, error) {
+	var err error
+	for index, op := range o {
+		document, err = op.Apply(document)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Applying op %d", index)
+		}
+	}
+	return document, nil
+}
+
+func (o ReplaceOp) Apply(document interface{}) (interface{}, error) {
+	return o.replace(document, o.Path.tokens)
+}
+
+func (o ReplaceOp) replace(node interface{}, tokens []token) (interface{}, error) {
+	if len(tokens) == 0 {
+		return o.Value, nil
+	}
+	last := len(tokens) == 1
+
+	switch typedToken := tokens[0].(type) {
+	case keyToken:
+		nodeMap, ok := node.(map[interface{}]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find a map at key '%s' of path '%s'", typedToken.key, o.Path)
+		}
+
+		child, found := nodeMap[typedToken.key]
+		if !found && !last {
+			if !typedToken.optional {
+				return nil, bosherr.Errorf("Expected to find a map key '%s' for path '%s'", typedToken.key, o.Path)
+			}
+			child = newContainer(tokens[1])
+		}
+
+		value, err := o.replace(child, tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		nodeMap[typedToken.key] = value
+		return nodeMap, nil
+
+	case indexToken:
+		nodeArray, ok := node.([]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find an array at index '%d' of path '%s'", typedToken.index, o.Path)
+		}
+		if typedToken.index < 0 || typedToken.index >= len(nodeArray) {
+			return nil, bosherr.Errorf("Expected to find array index '%d' for path '%s' but found %d item(s)", typedToken.index, o.Path, len(nodeArray))
+		}
+
+		value, err := o.replace(nodeArray[typedToken.index], tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		nodeArray[typedToken.index] = value
+		return nodeArray, nil
+
+	case afterLastIndexToken:
+		nodeArray, ok := node.([]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find an array at '-' of path '%s'", o.Path)
+		}
+
+		var child interface{}
+		if !last {
+			child = newContainer(tokens[1])
+		}
+		value, err := o.replace(child, tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		return append(nodeArray, value), nil
+
+	case matchingIndexToken:
+		nodeArray, ok := node.([]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find an array at '%s=%s' of path '%s'", typedToken.key, typedToken.value, o.Path)
+		}
+
+		indexes := matchingIndexes(nodeArray, typedToken)
+		if len(indexes) == 0 && typedToken.optional {
+			nodeArray = append(nodeArray, map[interface{}]interface{}{typedToken.key: typedToken.value})
+			indexes = []int{len(nodeArray) - 1}
+		}
+		if len(indexes) != 1 {
+			return nil, bosherr.Errorf("Expected to find exactly one array item matching '%s=%s' for path '%s' but found %d", typedToken.key, typedToken.value, o.Path, len(indexes))
+		}
+
+		value, err := o.replace(nodeArray[indexes[0]], tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		nodeArray[indexes[0]] = value
+		return nodeArray, nil
+	}
+
+	return nil, bosherr.Errorf("Unknown token in path '%s'", o.Path)
+}
+
+func (o RemoveOp) Apply(document interface{}) (interface{}, error) {
+	if len(o.Path.tokens) == 0 {
+		return nil, bosherr.Errorf("Expected path '%s' to address a value to remove", o.Path)
+	}
+	return o.remove(document, o.Path.tokens)
+}
+
+func (o RemoveOp) remove(node interface{}, tokens []token) (interface{}, error) {
+	last := len(tokens) == 1
+
+	switch typedToken := tokens[0].(type) {
+	case keyToken:
+		nodeMap, ok := node.(map[interface{}]interface{})
+		if !ok {
+			if node == nil && typedToken.optional {
+				return node, nil
+			}
+			return nil, bosherr.Errorf("Expected to find a map at key '%s' of path '%s'", typedToken.key, o.Path)
+		}
+
+		child, found := nodeMap[typedToken.key]
+		if !found {
+			if typedToken.optional {
+				return nodeMap, nil
+			}
+			return nil, bosherr.Errorf("Expected to find a map key '%s' for path '%s'", typedToken.key, o.Path)
+		}
+
+		if last {
+			delete(nodeMap, typedToken.key)
+			return nodeMap, nil
+		}
+
+		value, err := o.remove(child, tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		nodeMap[typedToken.key] = value
+		return nodeMap, nil
+
+	case indexToken:
+		nodeArray, ok := node.([]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected to find an array at index '%d' of path '%s'", typedToken.index, o.Path)
+		}
+		if typedToken.index < 0 || typedToken.index >= len(nodeArray) {
+			return nil, bosherr.Errorf("Expected to find array index '%d' for path '%s' but found %d item(s)", typedToken.index, o.Path, len(nodeArray))
+		}
+
+		return o.removeAt(nodeArray, typedToken.index, tokens)
+
+	case matchingIndexToken:
+		nodeArray, ok := node.([]interface{})
+		if !ok {
+			if node == nil && typedToken.optional {
+				return node, nil
+			}
+			return nil, bosherr.Errorf("Expected to find an array at '%s=%s' of path '%s'", typedToken.key, typedToken.value, o.Path)
+		}
+
+		indexes := matchingIndexes(nodeArray, typedToken)
+		if len(indexes) == 0 && typedToken.optional {
+			return nodeArray, nil
+		}
+		if len(indexes) != 1 {
+			return nil, bosherr.Errorf("Expected to find exactly one array item matching '%s=%s' for path '%s' but found %d", typedToken.key, typedToken.value, o.Path, len(indexes))
+		}
+
+		return o.removeAt(nodeArray, indexes[0], tokens)
+
+	case afterLastIndexToken:
+		return nil, bosherr.Errorf("Expected path '%s' not to use '-' to remove a value", o.Path)
+	}
+
+	return nil, bosherr.Errorf("Unknown token in path '%s'", o.Path)
+}
+
+// removeAt removes the array item at the index when it is the last token, or else removes inside it
+func (o RemoveOp) removeAt(nodeArray []interface{}, index int, tokens []token) (interface{}, error) {
+	if len(tokens) == 1 {
+		return append(nodeArray[:index], nodeArray[index+1:]...), nil
+	}
+
+	value, err := o.remove(nodeArray[index], tokens[1:])
+	if err != nil {
+		return nil, err
+	}
+	nodeArray[index] = value
+	return nodeArray, nil
+}
+
+// newContainer returns the empty map or array addressed by the token, to create a missing optional value
+func newContainer(next token) interface{} {
+	if _, ok := next.(keyToken); ok {
+		return map[interface{}]interface{}{}
+	}
+	return []interface{}{}
+}
+
+// matchingIndexes returns the indexes of the array items that are maps with the key set to the value
+func matchingIndexes(nodeArray []interface{}, matchingToken matchingIndexToken) []int {
+	indexes := []int{}
+	for index, item := range nodeArray {
+		itemMap, ok := item.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		value, found := itemMap[matchingToken.key]
+		if found && fmt.Sprint(value) == matchingToken.value {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,140 @@
+package patch_test
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ops", func() {
+	var document interface{}
+
+	BeforeEach(func() {
+		document = nil
+		err := candiedyaml.Unmarshal([]byte(`---
+name: fake-deployment
+jobs:
+- name: bosh
+  properties:
+    director: {name: fake-director}
+- name: other
+networks: [a, b, c]
+`), &document)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	apply := func(opsYAML string) (interface{}, error) {
+		ops, err := ParseOps([]byte(opsYAML))
+		Expect(err).ToNot(HaveOccurred())
+		return ops.Apply(document)
+	}
+
+	find := func(document interface{}, keys ...interface{}) interface{} {
+		for _, key := range keys {
+			switch typedKey := key.(type) {
+			case string:
+				document = document.(map[interface{}]interface{})[typedKey]
+			case int:
+				document = document.([]interface{})[typedKey]
+			}
+		}
+		return document
+	}
+
+	Describe("replace", func() {
+		It("replaces values addressed by matching array items & map keys", func() {
+			result, err := apply(`- {type: replace, path: /jobs/name=bosh/properties/director/name, value: new-director}`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(find(result, "jobs", 0, "properties", "director", "name")).To(Equal("new-director"))
+		})
+
+		It("replaces array items by index & appends with '-'", func() {
+			result, err := apply(`
+- {type: replace, path: /networks/1, value: x}
+- {type: replace, path: /networks/-, value: d}
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(find(result, "networks")).To(Equal([]interface{}{"a", "x", "c", "d"}))
+		})
+
+		It("adds a missing last map key", func() {
+			result, err := apply(`- {type: replace, path: /jobs/name=other/instances, value: 1}`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(find(result, "jobs", 1, "instances")).To(Equal(int64(1)))
+		})
+
+		It("creates missing optional map keys & array items", func() {
+			result, err := apply(`
+- type: replace
+  path: /jobs/name=new?/properties?/a
+  value: b
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(find(result, "jobs", 2)).To(Equal(map[interface{}]interface{}{
+				"name":       "new",
+				"properties": map[interface{}]interface{}{"a": "b"},
+			}))
+		})
+
+		It("returns an error for missing map keys", func() {
+			_, err := apply(`- {type: replace, path: /missing/key, value: 1}`)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Applying op 0"))
+			Expect(err.Error()).To(ContainSubstring("Expected to find a map key 'missing' for path '/missing/key'"))
+		})
+
+		It("returns an error when no array item matches", func() {
+			_, err := apply(`- {type: replace, path: /jobs/name=missing/properties, value: {}}`)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find exactly one array item matching 'name=missing' for path '/jobs/name=missing/properties' but found 0"))
+		})
+
+		It("returns an error for out of range indexes", func() {
+			_, err := apply(`- {type: replace, path: /networks/3, value: d}`)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find array index '3' for path '/networks/3' but found 3 item(s)"))
+		})
+	})
+
+	Describe("remove", func() {
+		It("removes map keys & array items", func() {
+			result, err := apply(`
+- {type: remove, path: /jobs/name=bosh/properties/director}
+- {type: remove, path: /jobs/name=other}
+- {type: remove, path: /networks/0}
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(find(result, "jobs")).To(HaveLen(1))
+			Expect(find(result, "jobs", 0, "properties")).To(Equal(map[interface{}]interface{}{}))
+			Expect(find(result, "networks")).To(Equal([]interface{}{"b", "c"}))
+		})
+
+		It("ignores missing optional values", func() {
+			_, err := apply(`
+- type: remove
+  path: /missing?
+`)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error for missing map keys", func() {
+			_, err := apply(`- {type: remove, path: /missing}`)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find a map key 'missing' for path '/missing'"))
+		})
+	})
+
+	It("returns an error for unknown op types", func() {
+		_, err := ParseOps([]byte(`- {type: fake-type, path: /name}`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unknown type 'fake-type' of op 0, expected 'replace' or 'remove'"))
+	})
+
+	It("returns an error for paths not starting with '/'", func() {
+		_, err := ParseOps([]byte(`- {type: remove, path: name}`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Path 'name' must start with '/'"))
+	})
+})
@@ -0,0 +1,96 @@
+package patch
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
+)
+
+// Patcher applies the ops of the ops files given to a command (e.g. 'deploy -o ops.yml') to manifests
+type Patcher interface {
+	UseOpsFiles(paths []string)
+	Patch(contents []byte) ([]byte, error)
+}
+
+type patcher struct {
+	opsFilePaths []string
+	fs           boshsys.FileSystem
+	logger       boshlog.Logger
+	logTag       string
+}
+
+func NewPatcher(fs boshsys.FileSystem, logger boshlog.Logger) Patcher {
+	return &patcher{
+		fs:     fs,
+		logger: logger,
+		logTag: "patcher",
+	}
+}
+
+func (p *patcher) UseOpsFiles(paths []string) {
+	p.opsFilePaths = paths
+}
+
+// Patch returns the contents unchanged without ops files
+func (p *patcher) Patch(contents []byte) ([]byte, error) {
+	if len(p.opsFilePaths) == 0 {
+		return contents, nil
+	}
+
+	var document interface{}
+	err := candiedyaml.Unmarshal(contents, &document)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling YAML to patch")
+	}
+
+	for _, opsFilePath := range p.opsFilePaths {
+		opsContents, err := p.fs.ReadFile(opsFilePath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading ops file '%s'", opsFilePath)
+		}
+
+		ops, err := ParseOps(opsContents)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing ops file '%s'", opsFilePath)
+		}
+
+		p.logger.Debug(p.logTag, "Applying %d op(s) of ops file '%s'", len(ops), opsFilePath)
+		document, err = ops.Apply(document)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Applying ops file '%s'", opsFilePath)
+		}
+	}
+
+	patchedContents, err := candiedyaml.Marshal(document)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling patched YAML")
+	}
+
+	return patchedContents, nil
+}
+
+type patchingInterpolator struct {
+	patcher      Patcher
+	interpolator bmvars.Interpolator
+}
+
+// NewPatchingInterpolator returns an interpolator that patches the contents before interpolating them,
+// so that ops may use ((name)) placeholders
+func NewPatchingInterpolator(patcher Patcher, interpolator bmvars.Interpolator) bmvars.Interpolator {
+	return &patchingInterpolator{
+		patcher:      patcher,
+		interpolator: interpolator,
+	}
+}
+
+func (i *patchingInterpolator) Interpolate(contents []byte) ([]byte, error) {
+	patchedContents, err := i.patcher.Patch(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.interpolator.Interpolate(patchedContents)
+}
@@ -0,0 +1,68 @@
+package patch_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
+)
+
+var _ = Describe("Patcher", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		patcher Patcher
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		patcher = NewPatcher(fs, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("returns the contents unchanged without ops files", func() {
+		Expect(patcher.Patch([]byte("name:   fake-name\n"))).To(Equal([]byte("name:   fake-name\n")))
+	})
+
+	It("applies the ops files in order", func() {
+		fs.WriteFileString("/ops-1.yml", "- {type: replace, path: /name, value: name-1}\n- type: replace\n  path: /disk?\n  value: 1024\n")
+		fs.WriteFileString("/ops-2.yml", "- {type: replace, path: /name, value: name-2}\n")
+		patcher.UseOpsFiles([]string{"/ops-1.yml", "/ops-2.yml"})
+
+		patched, err := patcher.Patch([]byte("name: fake-name\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(patched)).To(ContainSubstring("name: name-2"))
+		Expect(string(patched)).To(ContainSubstring("disk: 1024"))
+	})
+
+	It("returns an error naming the ops file that fails", func() {
+		fs.WriteFileString("/ops.yml", "- {type: remove, path: /missing}\n")
+		patcher.UseOpsFiles([]string{"/ops.yml"})
+
+		_, err := patcher.Patch([]byte("name: fake-name\n"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Applying ops file '/ops.yml'"))
+	})
+
+	It("returns an error when an ops file cannot be read", func() {
+		patcher.UseOpsFiles([]string{"/missing-ops.yml"})
+
+		_, err := patcher.Patch([]byte("name: fake-name\n"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reading ops file '/missing-ops.yml'"))
+	})
+
+	Describe("PatchingInterpolator", func() {
+		It("interpolates the placeholders of the ops", func() {
+			fs.WriteFileString("/ops.yml", "- {type: replace, path: /name, value: ((name))}\n")
+			patcher.UseOpsFiles([]string{"/ops.yml"})
+
+			interpolator := NewPatchingInterpolator(patcher, bmvars.NewInterpolator(bmvars.Variables{"name": "fake-name"}))
+			interpolated, err := interpolator.Interpolate([]byte("name: other-name\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(interpolated)).To(ContainSubstring("name: fake-name"))
+		})
+	})
+})
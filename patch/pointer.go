@@ -0,0 +1,76 @@
+package patch
+
+import (
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+)
+
+// Pointer addresses a value of a YAML document, e.g. "/jobs/name=bosh/properties/director/name".
+// Its tokens are map keys, array indexes, "-" (after the last array item)
+// or "key=value" (the array item that is a map with the key set to the value).
+// Key tokens with a "?" suffix are optional: they are created when missing.
+type Pointer struct {
+	path   string
+	tokens []token
+}
+
+type token interface{}
+
+type keyToken struct {
+	key      string
+	optional bool
+}
+
+type indexToken struct {
+	index int
+}
+
+type afterLastIndexToken struct{}
+
+type matchingIndexToken struct {
+	key      string
+	value    string
+	optional bool
+}
+
+func NewPointer(path string) (Pointer, error) {
+	if !strings.HasPrefix(path, "/") {
+		return Pointer{}, bosherr.Errorf("Path '%s' must start with '/'", path)
+	}
+
+	pointer := Pointer{path: path}
+	for _, part := range strings.Split(path[1:], "/") {
+		part = strings.Replace(strings.Replace(part, "~1", "/", -1), "~0", "~", -1)
+		if part == "" {
+			return Pointer{}, bosherr.Errorf("Path '%s' must not have empty tokens", path)
+		}
+
+		optional := strings.HasSuffix(part, "?")
+		part = strings.TrimSuffix(part, "?")
+
+		if part == "-" {
+			pointer.tokens = append(pointer.tokens, afterLastIndexToken{})
+			continue
+		}
+
+		if index, err := strconv.Atoi(part); err == nil {
+			pointer.tokens = append(pointer.tokens, indexToken{index: index})
+			continue
+		}
+
+		if keyValue := strings.SplitN(part, "=", 2); len(keyValue) == 2 {
+			pointer.tokens = append(pointer.tokens, matchingIndexToken{key: keyValue[0], value: keyValue[1], optional: optional})
+			continue
+		}
+
+		pointer.tokens = append(pointer.tokens, keyToken{key: part, optional: optional})
+	}
+
+	return pointer, nil
+}
+
+func (p Pointer) String() string {
+	return p.path
+}
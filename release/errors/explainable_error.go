@@ -25,3 +25,8 @@ func (e ExplainableError) Error() string {
 
 	return output
 }
+
+// Errors returns the individual reasons
+func (e ExplainableError) Errors() []error {
+	return e.errors
+}
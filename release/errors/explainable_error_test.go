@@ -25,4 +25,12 @@ var _ = Describe("explainableError", func() {
 			})
 		})
 	})
+
+	Describe("Errors", func() {
+		It("returns each reason", func() {
+			reasons := []error{errors.New("reason 1"), errors.New("reason 2")}
+			err := NewExplainableError(reasons)
+			Expect(err.(ExplainableError).Errors()).To(Equal(reasons))
+		})
+	})
 })
@@ -8,6 +8,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-agent/system"
 
 	bmrelmanifest "github.com/cloudfoundry/bosh-micro-cli/release/manifest"
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 type Parser interface {
@@ -15,20 +16,22 @@ type Parser interface {
 }
 
 type parser struct {
-	fs     boshsys.FileSystem
-	logger boshlog.Logger
-	logTag string
+	fs           boshsys.FileSystem
+	interpolator bmvars.Interpolator
+	logger       boshlog.Logger
+	logTag       string
 }
 
 type manifest struct {
 	Releases []bmrelmanifest.ReleaseRef
 }
 
-func NewParser(fs boshsys.FileSystem, logger boshlog.Logger) Parser {
+func NewParser(fs boshsys.FileSystem, interpolator bmvars.Interpolator, logger boshlog.Logger) Parser {
 	return &parser{
-		fs:     fs,
-		logger: logger,
-		logTag: "releaseSetParser",
+		fs:           fs,
+		interpolator: interpolator,
+		logger:       logger,
+		logTag:       "releaseSetParser",
 	}
 }
 
@@ -38,6 +41,11 @@ func (p *parser) Parse(path string) (Manifest, error) {
 		return Manifest{}, bosherr.WrapErrorf(err, "Reading file %s", path)
 	}
 
+	contents, err = p.interpolator.Interpolate(contents)
+	if err != nil {
+		return Manifest{}, bosherr.WrapErrorf(err, "Interpolating release set manifest '%s'", path)
+	}
+
 	comboManifest := manifest{}
 	err = candiedyaml.Unmarshal(contents, &comboManifest)
 	if err != nil {
@@ -13,6 +13,8 @@ import (
 	bmrelmanifest "github.com/cloudfoundry/bosh-micro-cli/release/manifest"
 
 	. "github.com/cloudfoundry/bosh-micro-cli/release/set/manifest"
+
+	bmvars "github.com/cloudfoundry/bosh-micro-cli/vars"
 )
 
 var _ = Describe("Parser", func() {
@@ -26,7 +28,7 @@ var _ = Describe("Parser", func() {
 		comboManifestPath = "fake-deployment-path"
 		fakeFs = fakesys.NewFakeFileSystem()
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		parser = NewParser(fakeFs, logger)
+		parser = NewParser(fakeFs, bmvars.NewInterpolator(bmvars.Variables{}), logger)
 	})
 
 	Context("when combo manifest path does not exist", func() {
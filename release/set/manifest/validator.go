@@ -17,7 +17,9 @@ type Validator interface {
 }
 
 type validator struct {
-	logger          boshlog.Logger
+	logger boshlog.Logger
+
+	// releaseResolver is nil when release references are not validated
 	releaseResolver bmrelset.Resolver
 }
 
@@ -28,6 +30,13 @@ func NewValidator(logger boshlog.Logger, releaseResolver bmrelset.Resolver) Vali
 	}
 }
 
+// NewValidatorWithoutReleases returns a Validator that does not check that the referenced releases are available
+func NewValidatorWithoutReleases(logger boshlog.Logger) Validator {
+	return &validator{
+		logger: logger,
+	}
+}
+
 func (v *validator) Validate(manifest Manifest) error {
 	errs := []error{}
 	releaseNames := map[string]struct{}{}
@@ -48,10 +57,12 @@ func (v *validator) Validate(manifest Manifest) error {
 		}
 	}
 
-	for releaseIdx, release := range manifest.Releases {
-		_, err := v.releaseResolver.Find(release.Name)
-		if err != nil {
-			errs = append(errs, bosherr.WrapErrorf(err, "releases[%d] must refer to an available release", releaseIdx))
+	if v.releaseResolver != nil {
+		for releaseIdx, release := range manifest.Releases {
+			_, err := v.releaseResolver.Find(release.Name)
+			if err != nil {
+				errs = append(errs, bosherr.WrapErrorf(err, "releases[%d] must refer to an available release", releaseIdx))
+			}
 		}
 	}
 
@@ -113,4 +113,31 @@ var _ = Describe("Validator", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+
+	Context("when created without releases", func() {
+		JustBeforeEach(func() {
+			validator = NewValidatorWithoutReleases(logger)
+		})
+
+		It("does not validate that releases are available", func() {
+			manifest := Manifest{
+				Releases: []bmrelmanifest.ReleaseRef{
+					{Name: "fake-other-release-name", Version: "1.0"},
+				},
+			}
+
+			err := validator.Validate(manifest)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("validates releases have names", func() {
+			manifest := Manifest{
+				Releases: []bmrelmanifest.ReleaseRef{{}},
+			}
+
+			err := validator.Validate(manifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("releases[0].name must be provided"))
+		})
+	})
 })
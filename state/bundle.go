@@ -0,0 +1,395 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshcmd "github.com/cloudfoundry/bosh-agent/platform/commands"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+
+	bmconfig "github.com/cloudfoundry/bosh-micro-cli/config"
+	bminstall "github.com/cloudfoundry/bosh-micro-cli/installation"
+	bmerr "github.com/cloudfoundry/bosh-micro-cli/release/errors"
+)
+
+// BundleVersion is the version of the state bundle layout written by Export.
+// Import refuses bundles of any other version.
+const BundleVersion = 1
+
+const (
+	bundleMetadataFileName   = "bundle.json"
+	bundleDeploymentFileName = "deployment.json"
+	bundleInstallationDir    = "installation"
+)
+
+type bundleMetadata struct {
+	Version int `json:"version"`
+}
+
+// indexEntry matches the entries of the compiled packages & templates indices.
+// Both record the blob they refer to as BlobID.
+type indexEntry struct {
+	Key   map[string]interface{}
+	Value struct {
+		BlobID   string
+		BlobSHA1 string
+	}
+}
+
+// Bundler packages the deployment state file, the installation indices
+// and the blobs they reference into one tarball, and unpacks it again.
+type Bundler interface {
+	Export(deploymentConfigPath, bundlePath string) error
+	Import(bundlePath, deploymentConfigPath string, force bool) error
+}
+
+type bundler struct {
+	fs                boshsys.FileSystem
+	compressor        boshcmd.Compressor
+	workspaceRootPath string
+	logger            boshlog.Logger
+	logTag            string
+}
+
+func NewBundler(
+	fs boshsys.FileSystem,
+	compressor boshcmd.Compressor,
+	workspaceRootPath string,
+	logger boshlog.Logger,
+) Bundler {
+	return &bundler{
+		fs:                fs,
+		compressor:        compressor,
+		workspaceRootPath: workspaceRootPath,
+		logger:            logger,
+		logTag:            "stateBundler",
+	}
+}
+
+func (b *bundler) Export(deploymentConfigPath, bundlePath string) error {
+	if !b.fs.FileExists(deploymentConfigPath) {
+		return bosherr.Errorf("No deployment state found at '%s'", deploymentConfigPath)
+	}
+
+	deploymentFile, err := b.readDeploymentFile(deploymentConfigPath)
+	if err != nil {
+		return err
+	}
+
+	bundleDir, err := b.fs.TempDir("bosh-micro-state-bundle")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating state bundle dir")
+	}
+	defer b.removeAll(bundleDir)
+
+	err = b.writeMetadata(filepath.Join(bundleDir, bundleMetadataFileName))
+	if err != nil {
+		return err
+	}
+
+	err = b.copyFile(deploymentConfigPath, filepath.Join(bundleDir, bundleDeploymentFileName))
+	if err != nil {
+		return err
+	}
+
+	target := b.installationTarget(deploymentFile)
+	bundleTarget := bminstall.NewTarget(filepath.Join(bundleDir, bundleInstallationDir))
+
+	for _, indexPaths := range b.indexPaths(target, bundleTarget) {
+		srcIndexPath, dstIndexPath := indexPaths[0], indexPaths[1]
+		if !b.fs.FileExists(srcIndexPath) {
+			continue
+		}
+
+		err = b.copyFile(srcIndexPath, dstIndexPath)
+		if err != nil {
+			return err
+		}
+
+		blobIDs, err := b.readIndexBlobIDs(srcIndexPath)
+		if err != nil {
+			return err
+		}
+
+		for _, blobID := range blobIDs {
+			srcBlobPath := filepath.Join(target.BlobstorePath(), blobID)
+			if !b.fs.FileExists(srcBlobPath) {
+				return bosherr.Errorf("Blob '%s' referenced by '%s' not found", blobID, srcIndexPath)
+			}
+
+			err = b.copyFile(srcBlobPath, filepath.Join(bundleTarget.BlobstorePath(), blobID))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	tarballPath, err := b.compressor.CompressFilesInDir(bundleDir)
+	if err != nil {
+		return bosherr.WrapError(err, "Compressing state bundle")
+	}
+	defer func() {
+		if err := b.compressor.CleanUp(tarballPath); err != nil {
+			b.logger.Warn(b.logTag, "Failed to clean up tarball '%s': %s", tarballPath, err.Error())
+		}
+	}()
+
+	return b.copyFile(tarballPath, bundlePath)
+}
+
+func (b *bundler) Import(bundlePath, deploymentConfigPath string, force bool) error {
+	if !b.fs.FileExists(bundlePath) {
+		return bosherr.Errorf("State bundle '%s' does not exist", bundlePath)
+	}
+
+	bundleDir, err := b.fs.TempDir("bosh-micro-state-bundle")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating state bundle dir")
+	}
+	defer b.removeAll(bundleDir)
+
+	err = b.compressor.DecompressFileToDir(bundlePath, bundleDir, boshcmd.CompressorOptions{})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Extracting state bundle '%s'", bundlePath)
+	}
+
+	err = b.checkMetadata(filepath.Join(bundleDir, bundleMetadataFileName))
+	if err != nil {
+		return err
+	}
+
+	bundleDeploymentPath := filepath.Join(bundleDir, bundleDeploymentFileName)
+	deploymentFile, err := b.readDeploymentFile(bundleDeploymentPath)
+	if err != nil {
+		return err
+	}
+
+	target := b.installationTarget(deploymentFile)
+	bundleTarget := bminstall.NewTarget(filepath.Join(bundleDir, bundleInstallationDir))
+
+	// pairs of bundle path & destination path
+	files := [][2]string{{bundleDeploymentPath, deploymentConfigPath}}
+	for _, indexPaths := range b.indexPaths(target, bundleTarget) {
+		dstIndexPath, srcIndexPath := indexPaths[0], indexPaths[1]
+		if !b.fs.FileExists(srcIndexPath) {
+			continue
+		}
+
+		// the installation ID & blob IDs come from the bundle, they must not lead outside of the workspace
+		err = checkPathComponent("installation ID", deploymentFile.InstallationID)
+		if err != nil {
+			return err
+		}
+		files = append(files, [2]string{srcIndexPath, dstIndexPath})
+
+		blobIDs, err := b.readIndexBlobIDs(srcIndexPath)
+		if err != nil {
+			return err
+		}
+		for _, blobID := range blobIDs {
+			err = checkPathComponent("blob ID", blobID)
+			if err != nil {
+				return err
+			}
+
+			dstBlobPath := filepath.Join(target.BlobstorePath(), blobID)
+			err = checkContained(target.BlobstorePath(), dstBlobPath)
+			if err != nil {
+				return err
+			}
+
+			files = append(files, [2]string{
+				filepath.Join(bundleTarget.BlobstorePath(), blobID),
+				dstBlobPath,
+			})
+		}
+	}
+
+	if !force {
+		err = b.checkConflicts(files)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		err = b.copyFile(file[0], file[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkConflicts returns an error listing every destination file that already exists with different contents.
+// The deployment state & the indices are compared as JSON, so that formatting differences are ignored.
+func (b *bundler) checkConflicts(files [][2]string) error {
+	errs := []error{}
+
+	for _, file := range files {
+		srcPath, dstPath := file[0], file[1]
+		if !b.fs.FileExists(dstPath) {
+			continue
+		}
+
+		srcContents, err := b.fs.ReadFile(srcPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading '%s'", srcPath)
+		}
+
+		dstContents, err := b.fs.ReadFile(dstPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading '%s'", dstPath)
+		}
+
+		if !b.sameContents(srcPath, srcContents, dstContents) {
+			errs = append(errs, bosherr.Errorf("'%s' already exists with different contents", dstPath))
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.WrapError(bmerr.NewExplainableError(errs), "Importing state bundle would overwrite existing state, use --force to overwrite it")
+	}
+
+	return nil
+}
+
+func (b *bundler) sameContents(path string, contents1, contents2 []byte) bool {
+	if filepath.Ext(path) != ".json" {
+		return bytes.Equal(contents1, contents2)
+	}
+
+	var parsed1, parsed2 interface{}
+	if json.Unmarshal(contents1, &parsed1) != nil || json.Unmarshal(contents2, &parsed2) != nil {
+		return bytes.Equal(contents1, contents2)
+	}
+	return reflect.DeepEqual(parsed1, parsed2)
+}
+
+// checkPathComponent returns an error unless the value read from a bundle is a single path component,
+// so that joining it to a path stays within that path
+func checkPathComponent(name, value string) error {
+	if value == "" || value == "." || value == ".." || strings.ContainsAny(value, "/\\") || strings.ContainsRune(value, filepath.Separator) {
+		return bosherr.Errorf("Invalid %s '%s' in state bundle", name, value)
+	}
+	return nil
+}
+
+// checkContained returns an error unless the path is within the root path
+func checkContained(rootPath, path string) error {
+	relPath, err := filepath.Rel(rootPath, path)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return bosherr.Errorf("Path '%s' is outside of '%s'", path, rootPath)
+	}
+	return nil
+}
+
+func (b *bundler) installationTarget(deploymentFile bmconfig.DeploymentFile) bminstall.Target {
+	return bminstall.NewTarget(filepath.Join(b.workspaceRootPath, deploymentFile.InstallationID))
+}
+
+// indexPaths returns pairs of matching index paths in the two targets
+func (b *bundler) indexPaths(target1, target2 bminstall.Target) [][2]string {
+	return [][2]string{
+		{target1.CompiledPackagedIndexPath(), target2.CompiledPackagedIndexPath()},
+		{target1.TemplatesIndexPath(), target2.TemplatesIndexPath()},
+	}
+}
+
+func (b *bundler) readDeploymentFile(path string) (bmconfig.DeploymentFile, error) {
+	deploymentFile := bmconfig.DeploymentFile{}
+
+	contents, err := b.fs.ReadFile(path)
+	if err != nil {
+		return deploymentFile, bosherr.WrapErrorf(err, "Reading deployment state '%s'", path)
+	}
+
+	err = json.Unmarshal(contents, &deploymentFile)
+	if err != nil {
+		return deploymentFile, bosherr.WrapErrorf(err, "Unmarshalling deployment state '%s'", path)
+	}
+
+	return deploymentFile, nil
+}
+
+func (b *bundler) readIndexBlobIDs(path string) ([]string, error) {
+	contents, err := b.fs.ReadFile(path)
+	if err != nil {
+		return []string{}, bosherr.WrapErrorf(err, "Reading index '%s'", path)
+	}
+
+	entries := []indexEntry{}
+	err = json.Unmarshal(contents, &entries)
+	if err != nil {
+		return []string{}, bosherr.WrapErrorf(err, "Unmarshalling index '%s'", path)
+	}
+
+	blobIDs := []string{}
+	for _, entry := range entries {
+		if entry.Value.BlobID != "" {
+			blobIDs = append(blobIDs, entry.Value.BlobID)
+		}
+	}
+	return blobIDs, nil
+}
+
+func (b *bundler) writeMetadata(path string) error {
+	contents, err := json.Marshal(bundleMetadata{Version: BundleVersion})
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling state bundle metadata")
+	}
+
+	err = b.fs.WriteFile(path, contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing state bundle metadata '%s'", path)
+	}
+	return nil
+}
+
+func (b *bundler) checkMetadata(path string) error {
+	if !b.fs.FileExists(path) {
+		return bosherr.Error("Not a state bundle: missing bundle.json")
+	}
+
+	contents, err := b.fs.ReadFile(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading state bundle metadata '%s'", path)
+	}
+
+	metadata := bundleMetadata{}
+	err = json.Unmarshal(contents, &metadata)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling state bundle metadata '%s'", path)
+	}
+
+	if metadata.Version != BundleVersion {
+		return bosherr.Errorf("Unsupported state bundle version %d, expected %d", metadata.Version, BundleVersion)
+	}
+	return nil
+}
+
+func (b *bundler) copyFile(srcPath, dstPath string) error {
+	err := b.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating dir '%s'", filepath.Dir(dstPath))
+	}
+
+	err = b.fs.CopyFile(srcPath, dstPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Copying '%s' to '%s'", srcPath, dstPath)
+	}
+	return nil
+}
+
+func (b *bundler) removeAll(path string) {
+	if err := b.fs.RemoveAll(path); err != nil {
+		b.logger.Warn(b.logTag, "Failed to remove '%s': %s", path, err.Error())
+	}
+}
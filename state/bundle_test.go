@@ -0,0 +1,210 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/state"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshcmd "github.com/cloudfoundry/bosh-agent/platform/commands"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+)
+
+var _ = Describe("Bundler", func() {
+	var (
+		fs         boshsys.FileSystem
+		compressor boshcmd.Compressor
+		tempDir    string
+
+		srcWorkspace string
+		srcConfig    string
+		dstWorkspace string
+		dstConfig    string
+		bundlePath   string
+
+		deploymentJSON = `{"director_id":"fake-director-id","installation_id":"fake-installation-id","current_vm_cid":"fake-vm-cid"}`
+		packagesJSON   = `[{"Key":{"PackageName":"fake-package"},"Value":{"BlobID":"fake-package-blob-id","BlobSHA1":"fake-sha1"}}]`
+		templatesJSON  = `[{"Key":{"JobName":"fake-job"},"Value":{"BlobID":"fake-template-blob-id","BlobSHA1":"fake-sha1"}}]`
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		compressor = boshcmd.NewTarballCompressor(boshsys.NewExecCmdRunner(logger), fs)
+
+		var err error
+		tempDir, err = fs.TempDir("bundle-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		srcWorkspace = filepath.Join(tempDir, "src-workspace")
+		srcConfig = filepath.Join(tempDir, "src", "deployment.json")
+		dstWorkspace = filepath.Join(tempDir, "dst-workspace")
+		dstConfig = filepath.Join(tempDir, "dst", "deployment.json")
+		bundlePath = filepath.Join(tempDir, "state.tgz")
+
+		installationPath := filepath.Join(srcWorkspace, "fake-installation-id")
+		Expect(fs.MkdirAll(filepath.Join(installationPath, "blobs"), os.ModePerm)).ToNot(HaveOccurred())
+		Expect(fs.MkdirAll(filepath.Dir(srcConfig), os.ModePerm)).ToNot(HaveOccurred())
+		Expect(fs.WriteFileString(srcConfig, deploymentJSON)).ToNot(HaveOccurred())
+		Expect(fs.WriteFileString(filepath.Join(installationPath, "compiled_packages.json"), packagesJSON)).ToNot(HaveOccurred())
+		Expect(fs.WriteFileString(filepath.Join(installationPath, "templates.json"), templatesJSON)).ToNot(HaveOccurred())
+		Expect(fs.WriteFileString(filepath.Join(installationPath, "blobs", "fake-package-blob-id"), "fake-package-blob")).ToNot(HaveOccurred())
+		Expect(fs.WriteFileString(filepath.Join(installationPath, "blobs", "fake-template-blob-id"), "fake-template-blob")).ToNot(HaveOccurred())
+		Expect(fs.WriteFileString(filepath.Join(installationPath, "blobs", "fake-unreferenced-blob-id"), "fake-unreferenced-blob")).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(fs.RemoveAll(tempDir)).ToNot(HaveOccurred())
+	})
+
+	var readFile = func(path string) string {
+		contents, err := fs.ReadFileString(path)
+		Expect(err).ToNot(HaveOccurred())
+		return contents
+	}
+
+	It("exports the state and imports it into another workspace", func() {
+		err := NewBundler(fs, compressor, srcWorkspace, boshlog.NewLogger(boshlog.LevelNone)).Export(srcConfig, bundlePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.FileExists(bundlePath)).To(BeTrue())
+
+		err = NewBundler(fs, compressor, dstWorkspace, boshlog.NewLogger(boshlog.LevelNone)).Import(bundlePath, dstConfig, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		installationPath := filepath.Join(dstWorkspace, "fake-installation-id")
+		Expect(readFile(dstConfig)).To(Equal(deploymentJSON))
+		Expect(readFile(filepath.Join(installationPath, "compiled_packages.json"))).To(Equal(packagesJSON))
+		Expect(readFile(filepath.Join(installationPath, "templates.json"))).To(Equal(templatesJSON))
+		Expect(readFile(filepath.Join(installationPath, "blobs", "fake-package-blob-id"))).To(Equal("fake-package-blob"))
+		Expect(readFile(filepath.Join(installationPath, "blobs", "fake-template-blob-id"))).To(Equal("fake-template-blob"))
+		Expect(fs.FileExists(filepath.Join(installationPath, "blobs", "fake-unreferenced-blob-id"))).To(BeFalse())
+	})
+
+	Describe("Export", func() {
+		It("returns an error when there is no deployment state", func() {
+			err := NewBundler(fs, compressor, srcWorkspace, boshlog.NewLogger(boshlog.LevelNone)).Export(dstConfig, bundlePath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No deployment state found"))
+		})
+
+		It("returns an error when a referenced blob is missing", func() {
+			Expect(fs.RemoveAll(filepath.Join(srcWorkspace, "fake-installation-id", "blobs", "fake-package-blob-id"))).ToNot(HaveOccurred())
+
+			err := NewBundler(fs, compressor, srcWorkspace, boshlog.NewLogger(boshlog.LevelNone)).Export(srcConfig, bundlePath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Blob 'fake-package-blob-id'"))
+		})
+	})
+
+	Describe("Import", func() {
+		var bundler Bundler
+
+		BeforeEach(func() {
+			err := NewBundler(fs, compressor, srcWorkspace, boshlog.NewLogger(boshlog.LevelNone)).Export(srcConfig, bundlePath)
+			Expect(err).ToNot(HaveOccurred())
+
+			bundler = NewBundler(fs, compressor, dstWorkspace, boshlog.NewLogger(boshlog.LevelNone))
+		})
+
+		It("allows importing the same bundle twice", func() {
+			Expect(bundler.Import(bundlePath, dstConfig, false)).ToNot(HaveOccurred())
+			Expect(bundler.Import(bundlePath, dstConfig, false)).ToNot(HaveOccurred())
+		})
+
+		Context("when existing state differs", func() {
+			BeforeEach(func() {
+				blobsPath := filepath.Join(dstWorkspace, "fake-installation-id", "blobs")
+				Expect(fs.MkdirAll(blobsPath, os.ModePerm)).ToNot(HaveOccurred())
+				Expect(fs.WriteFileString(filepath.Join(blobsPath, "fake-package-blob-id"), "other-blob")).ToNot(HaveOccurred())
+				Expect(fs.MkdirAll(filepath.Dir(dstConfig), os.ModePerm)).ToNot(HaveOccurred())
+				Expect(fs.WriteFileString(dstConfig, `{"director_id":"other-director-id"}`)).ToNot(HaveOccurred())
+			})
+
+			It("returns an error listing every conflict without importing", func() {
+				err := bundler.Import(bundlePath, dstConfig, false)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("use --force"))
+				Expect(err.Error()).To(ContainSubstring("deployment.json' already exists with different contents"))
+				Expect(err.Error()).To(ContainSubstring("fake-package-blob-id' already exists with different contents"))
+
+				Expect(readFile(dstConfig)).To(Equal(`{"director_id":"other-director-id"}`))
+				Expect(fs.FileExists(filepath.Join(dstWorkspace, "fake-installation-id", "templates.json"))).To(BeFalse())
+			})
+
+			It("overwrites the existing state when forced", func() {
+				err := bundler.Import(bundlePath, dstConfig, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(readFile(dstConfig)).To(Equal(deploymentJSON))
+			})
+		})
+
+		It("returns an error when the bundle has an unsupported version", func() {
+			bundleDir := filepath.Join(tempDir, "bad-bundle")
+			Expect(fs.MkdirAll(bundleDir, os.ModePerm)).ToNot(HaveOccurred())
+			Expect(fs.WriteFileString(filepath.Join(bundleDir, "bundle.json"), `{"version":99}`)).ToNot(HaveOccurred())
+			tarballPath, err := compressor.CompressFilesInDir(bundleDir)
+			Expect(err).ToNot(HaveOccurred())
+			defer compressor.CleanUp(tarballPath)
+
+			err = bundler.Import(tarballPath, dstConfig, false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unsupported state bundle version 99"))
+		})
+
+		Context("when the bundle references paths outside of the workspace", func() {
+			var craftBundle = func(deploymentJSON, packagesJSON string, blobPath string) string {
+				bundleDir := filepath.Join(tempDir, "crafted-bundle")
+				Expect(fs.MkdirAll(filepath.Join(bundleDir, "installation", "blobs"), os.ModePerm)).ToNot(HaveOccurred())
+				Expect(fs.WriteFileString(filepath.Join(bundleDir, "bundle.json"), `{"version":1}`)).ToNot(HaveOccurred())
+				Expect(fs.WriteFileString(filepath.Join(bundleDir, "deployment.json"), deploymentJSON)).ToNot(HaveOccurred())
+				Expect(fs.WriteFileString(filepath.Join(bundleDir, "installation", "compiled_packages.json"), packagesJSON)).ToNot(HaveOccurred())
+				Expect(fs.WriteFileString(filepath.Join(bundleDir, "installation", "blobs", blobPath), "fake-evil-blob")).ToNot(HaveOccurred())
+
+				tarballPath, err := compressor.CompressFilesInDir(bundleDir)
+				Expect(err).ToNot(HaveOccurred())
+				return tarballPath
+			}
+
+			It("returns an error for a blob ID leading outside of the blobstore without importing", func() {
+				tarballPath := craftBundle(
+					deploymentJSON,
+					`[{"Key":{"PackageName":"fake-package"},"Value":{"BlobID":"../../fake-evil-blob","BlobSHA1":"fake-sha1"}}]`,
+					"../../fake-evil-blob",
+				)
+				defer compressor.CleanUp(tarballPath)
+
+				err := bundler.Import(tarballPath, dstConfig, true)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Invalid blob ID '../../fake-evil-blob' in state bundle"))
+
+				Expect(fs.FileExists(dstConfig)).To(BeFalse())
+				Expect(fs.FileExists(filepath.Join(dstWorkspace, "fake-evil-blob"))).To(BeFalse())
+			})
+
+			It("returns an error for an installation ID leading outside of the workspace without importing", func() {
+				tarballPath := craftBundle(
+					`{"director_id":"fake-director-id","installation_id":"../fake-evil-installation"}`,
+					packagesJSON,
+					"fake-package-blob-id",
+				)
+				defer compressor.CleanUp(tarballPath)
+
+				err := bundler.Import(tarballPath, dstConfig, true)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Invalid installation ID '../fake-evil-installation' in state bundle"))
+
+				Expect(fs.FileExists(dstConfig)).To(BeFalse())
+				Expect(fs.FileExists(filepath.Join(tempDir, "fake-evil-installation"))).To(BeFalse())
+			})
+		})
+
+		It("returns an error when the bundle does not exist", func() {
+			err := bundler.Import(filepath.Join(tempDir, "missing.tgz"), dstConfig, false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not exist"))
+		})
+	})
+})
@@ -3,6 +3,10 @@ package fakes
 type FakeUI struct {
 	Said   []string
 	Errors []string
+
+	Asked   []string
+	Answers []string
+	AskErr  error
 }
 
 func (ui *FakeUI) Say(message string) {
@@ -16,3 +20,17 @@ func (ui *FakeUI) Sayln(message string) {
 func (ui *FakeUI) Error(message string) {
 	ui.Errors = append(ui.Errors, message)
 }
+
+// Ask records the question and returns the next preset answer
+func (ui *FakeUI) Ask(question string) (string, error) {
+	ui.Asked = append(ui.Asked, question)
+	if ui.AskErr != nil {
+		return "", ui.AskErr
+	}
+	if len(ui.Answers) == 0 {
+		return "", nil
+	}
+	answer := ui.Answers[0]
+	ui.Answers = ui.Answers[1:]
+	return answer, nil
+}
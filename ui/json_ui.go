@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+)
+
+// jsonLine is one line of the JSON UI output
+type jsonLine struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type jsonUI struct {
+	stdIn   *bufio.Reader
+	stdOut  io.Writer
+	stdErr  io.Writer
+	pending string
+	logger  boshlog.Logger
+}
+
+// NewJSONUI returns a UI that writes each line of output or error as a JSON object,
+// e.g. {"type":"output","message":"..."}, for consumption by scripts.
+// Text said without a newline is held until the line is completed.
+func NewJSONUI(stdIn io.Reader, stdOut, stdErr io.Writer, logger boshlog.Logger) UI {
+	return &jsonUI{
+		stdIn:  bufio.NewReader(stdIn),
+		stdOut: stdOut,
+		stdErr: stdErr,
+		logger: logger,
+	}
+}
+
+func (u *jsonUI) Say(message string) {
+	u.pending += message
+}
+
+func (u *jsonUI) Sayln(message string) {
+	message = u.pending + message
+	u.pending = ""
+	u.write(u.stdOut, "output", message)
+}
+
+func (u *jsonUI) Error(message string) {
+	u.write(u.stdErr, "error", message)
+}
+
+func (u *jsonUI) Ask(question string) (string, error) {
+	question = u.pending + question
+	u.pending = ""
+	u.write(u.stdOut, "question", question)
+
+	answer, err := u.stdIn.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", bosherr.WrapError(err, "Reading from STDIN")
+	}
+
+	return strings.TrimSpace(answer), nil
+}
+
+func (u *jsonUI) write(writer io.Writer, lineType, message string) {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+
+	err := encoder.Encode(jsonLine{Type: lineType, Message: message})
+	if err != nil {
+		u.logger.Error(logTag, bosherr.WrapErrorf(err, "Writing JSON %s: %s", lineType, message).Error())
+	}
+}
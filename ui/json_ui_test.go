@@ -0,0 +1,61 @@
+package ui_test
+
+import (
+	"bytes"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/ui"
+)
+
+var _ = Describe("JSONUI", func() {
+	var ui UI
+	var stdIn, stdOut, stdErr *bytes.Buffer
+
+	BeforeEach(func() {
+		stdIn = bytes.NewBufferString("")
+		stdOut = bytes.NewBufferString("")
+		stdErr = bytes.NewBufferString("")
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		ui = NewJSONUI(stdIn, stdOut, stdErr, logger)
+	})
+
+	Context("#Sayln", func() {
+		It("writes an output line to std out", func() {
+			ui.Sayln("fake \"output\" <fake-arg>")
+			Expect(stdOut.String()).To(Equal(`{"type":"output","message":"fake \"output\" <fake-arg>"}` + "\n"))
+		})
+	})
+
+	Context("#Say", func() {
+		It("holds the text until the line is completed", func() {
+			ui.Say("fake-start ")
+			Expect(stdOut.String()).To(BeEmpty())
+
+			ui.Sayln("fake-end")
+			Expect(stdOut.String()).To(Equal(`{"type":"output","message":"fake-start fake-end"}` + "\n"))
+		})
+	})
+
+	Context("#Error", func() {
+		It("writes an error line to std err", func() {
+			ui.Error("fake-error")
+			Expect(stdErr.String()).To(Equal(`{"type":"error","message":"fake-error"}` + "\n"))
+		})
+	})
+
+	Context("#Ask", func() {
+		It("writes a question line and returns the trimmed answer", func() {
+			stdIn.WriteString(" fake-answer \n")
+
+			answer, err := ui.Ask("fake-question? ")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(answer).To(Equal("fake-answer"))
+			Expect(stdOut.String()).To(Equal(`{"type":"question","message":"fake-question? "}` + "\n"))
+		})
+	})
+})
@@ -0,0 +1,18 @@
+package ui
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+)
+
+type nonInteractiveUI struct {
+	UI
+}
+
+// NewNonInteractiveUI wraps a UI so that asking for input fails instead of waiting for it
+func NewNonInteractiveUI(ui UI) UI {
+	return &nonInteractiveUI{UI: ui}
+}
+
+func (u *nonInteractiveUI) Ask(question string) (string, error) {
+	return "", bosherr.Errorf("Cannot ask '%s' in non-interactive mode", question)
+}
@@ -0,0 +1,36 @@
+package ui_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/ui"
+
+	fakeui "github.com/cloudfoundry/bosh-micro-cli/ui/fakes"
+)
+
+var _ = Describe("NonInteractiveUI", func() {
+	var ui UI
+	var fakeUI *fakeui.FakeUI
+
+	BeforeEach(func() {
+		fakeUI = &fakeui.FakeUI{}
+		ui = NewNonInteractiveUI(fakeUI)
+	})
+
+	It("delegates output & errors to the wrapped UI", func() {
+		ui.Sayln("fake-output")
+		ui.Error("fake-error")
+		Expect(fakeUI.Said).To(Equal([]string{"fake-output"}))
+		Expect(fakeUI.Errors).To(Equal([]string{"fake-error"}))
+	})
+
+	It("fails to ask without reading an answer", func() {
+		fakeUI.Answers = []string{"fake-answer"}
+
+		_, err := ui.Ask("fake-question? ")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("non-interactive"))
+		Expect(fakeUI.Asked).To(BeEmpty())
+	})
+})
@@ -1,8 +1,10 @@
 package ui
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-agent/errors"
 	boshlog "github.com/cloudfoundry/bosh-agent/logger"
@@ -12,9 +14,13 @@ type UI interface {
 	Say(string)
 	Sayln(string)
 	Error(string)
+
+	// Ask prints the question and returns the trimmed line read in reply
+	Ask(question string) (string, error)
 }
 
 type ui struct {
+	stdIn  *bufio.Reader
 	stdOut io.Writer
 	stdErr io.Writer
 	logger boshlog.Logger
@@ -22,8 +28,9 @@ type ui struct {
 
 const logTag = "ui"
 
-func NewUI(stdOut, stdErr io.Writer, logger boshlog.Logger) UI {
+func NewUI(stdIn io.Reader, stdOut, stdErr io.Writer, logger boshlog.Logger) UI {
 	return &ui{
+		stdIn:  bufio.NewReader(stdIn),
 		stdOut: stdOut,
 		stdErr: stdErr,
 		logger: logger,
@@ -50,3 +57,14 @@ func (u *ui) Error(message string) {
 		u.logger.Error(logTag, bosherr.WrapErrorf(err, "Writing to STDERR: %s", message).Error())
 	}
 }
+
+func (u *ui) Ask(question string) (string, error) {
+	u.Say(question)
+
+	answer, err := u.stdIn.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", bosherr.WrapError(err, "Reading from STDIN")
+	}
+
+	return strings.TrimSpace(answer), nil
+}
@@ -13,14 +13,15 @@ import (
 
 var _ = Describe("UI", func() {
 	var ui UI
-	var stdOut, stdErr *bytes.Buffer
+	var stdIn, stdOut, stdErr *bytes.Buffer
 
 	BeforeEach(func() {
+		stdIn = bytes.NewBufferString("")
 		stdOut = bytes.NewBufferString("")
 		stdErr = bytes.NewBufferString("")
 
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		ui = NewUI(stdOut, stdErr, logger)
+		ui = NewUI(stdIn, stdOut, stdErr, logger)
 	})
 
 	Context("#Sayln", func() {
@@ -44,4 +45,29 @@ var _ = Describe("UI", func() {
 			Expect(stdOut.String()).NotTo(ContainSubstring("\n"))
 		})
 	})
+
+	Context("#Ask", func() {
+		It("prints the question and returns the trimmed answer", func() {
+			stdIn.WriteString(" fake-answer \nfake-next-answer\n")
+
+			answer, err := ui.Ask("fake-question? ")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(answer).To(Equal("fake-answer"))
+			Expect(stdOut.String()).To(Equal("fake-question? "))
+		})
+
+		It("returns the last line when it has no trailing newline", func() {
+			stdIn.WriteString("fake-answer")
+
+			answer, err := ui.Ask("fake-question? ")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(answer).To(Equal("fake-answer"))
+		})
+
+		It("returns an error when there is nothing left to read", func() {
+			_, err := ui.Ask("fake-question? ")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading from STDIN"))
+		})
+	})
 })
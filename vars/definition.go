@@ -0,0 +1,68 @@
+package vars
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+)
+
+// Definition is an entry of the `variables` section of a manifest,
+// describing how to generate the value of a variable that is not set
+type Definition struct {
+	Name    string                      `yaml:"name"`
+	Type    string                      `yaml:"type"`
+	Options map[interface{}]interface{} `yaml:"options"`
+}
+
+// decodeOptions unmarshals the options of the definition into the typed options struct
+func (d Definition) decodeOptions(options interface{}) error {
+	if len(d.Options) == 0 {
+		return nil
+	}
+
+	contents, err := candiedyaml.Marshal(d.Options)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling options of variable '%s'", d.Name)
+	}
+
+	err = candiedyaml.Unmarshal(contents, options)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling options of variable '%s'", d.Name)
+	}
+
+	return nil
+}
+
+// parseDefinitions returns the definitions of the `variables` section of the document, if any
+func parseDefinitions(document interface{}) ([]Definition, error) {
+	documentMap, ok := document.(map[interface{}]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	section, found := documentMap["variables"]
+	if !found || section == nil {
+		return nil, nil
+	}
+
+	contents, err := candiedyaml.Marshal(section)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling variables section")
+	}
+
+	definitions := []Definition{}
+	err = candiedyaml.Unmarshal(contents, &definitions)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling variables section")
+	}
+
+	for index, definition := range definitions {
+		if definition.Name == "" {
+			return nil, bosherr.Errorf("Variable definition variables[%d] must have a name", index)
+		}
+		if definition.Type == "" {
+			return nil, bosherr.Errorf("Variable definition '%s' must have a type", definition.Name)
+		}
+	}
+
+	return definitions, nil
+}
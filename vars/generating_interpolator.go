@@ -0,0 +1,103 @@
+package vars
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	bmerr "github.com/cloudfoundry/bosh-micro-cli/release/errors"
+)
+
+type generatingInterpolator struct {
+	variables Variables
+	store     Store
+	generator Generator
+	logger    boshlog.Logger
+	logTag    string
+}
+
+// NewGeneratingInterpolator returns an interpolator that also uses the variables of the store,
+// and generates the variables defined in the `variables` section of the contents that are not set yet,
+// saving them to the store for later interpolations.
+// The given variables take precedence over the stored variables.
+func NewGeneratingInterpolator(variables Variables, store Store, generator Generator, logger boshlog.Logger) Interpolator {
+	return &generatingInterpolator{
+		variables: variables,
+		store:     store,
+		generator: generator,
+		logger:    logger,
+		logTag:    "generatingInterpolator",
+	}
+}
+
+func (i *generatingInterpolator) Interpolate(contents []byte) ([]byte, error) {
+	if !placeholderRegexp.Match(contents) {
+		return contents, nil
+	}
+
+	storedVariables, err := i.store.Load()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading generated variables")
+	}
+	variables := storedVariables.Merge(i.variables)
+
+	definitions, err := i.definitions(contents, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	generatedVariables := Variables{}
+	for _, definition := range definitions {
+		if _, found := variables[definition.Name]; found {
+			continue
+		}
+
+		i.logger.Debug(i.logTag, "Generating variable '%s' of type '%s'", definition.Name, definition.Type)
+		value, err := i.generator.Generate(definition, variables)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Generating variable '%s'", definition.Name)
+		}
+		variables[definition.Name] = value
+		generatedVariables[definition.Name] = value
+	}
+
+	if len(generatedVariables) > 0 {
+		err = i.store.Save(storedVariables.Merge(generatedVariables))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Saving generated variables")
+		}
+	}
+
+	return NewInterpolator(variables).Interpolate(contents)
+}
+
+// definitions returns the variable definitions of the contents,
+// with placeholders in their options replaced by the known variables
+func (i *generatingInterpolator) definitions(contents []byte, variables Variables) ([]Definition, error) {
+	var document interface{}
+	err := candiedyaml.Unmarshal(contents, &document)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling YAML for interpolation")
+	}
+
+	documentMap, ok := document.(map[interface{}]interface{})
+	if !ok || documentMap["variables"] == nil {
+		return nil, nil
+	}
+
+	usages := &usages{paths: map[string][]string{}}
+	interpolator := &interpolator{variables: variables}
+	documentMap["variables"] = interpolator.interpolateValue(documentMap["variables"], "variables", usages)
+
+	errs := usages.errors()
+	if len(errs) > 0 {
+		return nil, bosherr.WrapError(bmerr.NewExplainableError(errs), "Interpolating variable definitions")
+	}
+
+	definitions, err := parseDefinitions(documentMap)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing variable definitions")
+	}
+
+	return definitions, nil
+}
@@ -0,0 +1,275 @@
+package vars
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"code.google.com/p/go.crypto/ssh"
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshtime "github.com/cloudfoundry/bosh-agent/time"
+)
+
+const (
+	passwordLength   = 20
+	passwordAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	rsaKeyBits       = 2048
+)
+
+// Generator generates the value of a variable from its definition.
+// Supported types are "password", "rsa", "ssh" & "certificate".
+type Generator interface {
+	Generate(definition Definition, variables Variables) (interface{}, error)
+}
+
+type certificateOptions struct {
+	CommonName       string   `yaml:"common_name"`
+	Organization     string   `yaml:"organization"`
+	AlternativeNames []string `yaml:"alternative_names"`
+	IsCA             bool     `yaml:"is_ca"`
+	CA               string   `yaml:"ca"`
+	ExtendedKeyUsage []string `yaml:"extended_key_usage"`
+	Duration         int      `yaml:"duration"`
+}
+
+type generator struct {
+	timeService boshtime.Service
+}
+
+func NewGenerator(timeService boshtime.Service) Generator {
+	return &generator{
+		timeService: timeService,
+	}
+}
+
+func (g *generator) Generate(definition Definition, variables Variables) (interface{}, error) {
+	switch definition.Type {
+	case "password":
+		return g.generatePassword()
+	case "rsa":
+		return g.generateRSAKey()
+	case "ssh":
+		return g.generateSSHKey()
+	case "certificate":
+		return g.generateCertificate(definition, variables)
+	default:
+		return nil, bosherr.Errorf("Unsupported variable type '%s'", definition.Type)
+	}
+}
+
+func (g *generator) generatePassword() (interface{}, error) {
+	bytes := make([]byte, passwordLength)
+	alphabetLength := big.NewInt(int64(len(passwordAlphabet)))
+	for i := range bytes {
+		index, err := rand.Int(rand.Reader, alphabetLength)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Generating random password")
+		}
+		bytes[i] = passwordAlphabet[index.Int64()]
+	}
+	return string(bytes), nil
+}
+
+func (g *generator) generateRSAKey() (interface{}, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating RSA key")
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling RSA public key")
+	}
+
+	return map[interface{}]interface{}{
+		"private_key": encodePrivateKey(privateKey),
+		"public_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes})),
+	}, nil
+}
+
+func (g *generator) generateSSHKey() (interface{}, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating SSH key")
+	}
+
+	publicKey, err := ssh.NewPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Converting SSH public key")
+	}
+
+	return map[interface{}]interface{}{
+		"private_key":            encodePrivateKey(privateKey),
+		"public_key":             strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))),
+		"public_key_fingerprint": fingerprint(publicKey),
+	}, nil
+}
+
+// generateCertificate returns a certificate signed by the CA of the "ca" option,
+// or else a self-signed certificate that is its own CA
+func (g *generator) generateCertificate(definition Definition, variables Variables) (interface{}, error) {
+	options := certificateOptions{}
+	err := definition.decodeOptions(&options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.CommonName == "" && len(options.AlternativeNames) == 0 {
+		return nil, bosherr.Errorf("Certificate must have a 'common_name' or 'alternative_names' option")
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating certificate key")
+	}
+
+	template, err := g.certificateTemplate(options)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := template
+	var parentKey interface{} = privateKey
+	caPEM := ""
+	if options.CA != "" {
+		caValue, found := variables.Find(options.CA)
+		if !found {
+			return nil, bosherr.Errorf("CA variable '%s' is not set", options.CA)
+		}
+
+		parent, parentKey, caPEM, err = parseCA(options.CA, caValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	certificateBytes, err := x509.CreateCertificate(rand.Reader, template, parent, &privateKey.PublicKey, parentKey)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating certificate")
+	}
+
+	certificatePEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateBytes}))
+	if caPEM == "" {
+		caPEM = certificatePEM
+	}
+
+	return map[interface{}]interface{}{
+		"ca":          caPEM,
+		"certificate": certificatePEM,
+		"private_key": encodePrivateKey(privateKey),
+	}, nil
+}
+
+func (g *generator) certificateTemplate(options certificateOptions) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating certificate serial number")
+	}
+
+	organization := options.Organization
+	if organization == "" {
+		organization = "bosh-micro"
+	}
+
+	duration := options.Duration
+	if duration == 0 {
+		duration = 365
+	}
+
+	notBefore := g.timeService.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   options.CommonName,
+			Organization: []string{organization},
+		},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(time.Duration(duration) * 24 * time.Hour),
+		BasicConstraintsValid: true,
+	}
+
+	for _, name := range options.AlternativeNames {
+		ip := net.ParseIP(name)
+		if ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	if options.IsCA {
+		template.IsCA = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		return template, nil
+	}
+
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	if len(options.ExtendedKeyUsage) == 0 {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	for _, usage := range options.ExtendedKeyUsage {
+		switch usage {
+		case "server_auth":
+			template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+		case "client_auth":
+			template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+		default:
+			return nil, bosherr.Errorf("Unsupported extended key usage '%s', expected 'server_auth' or 'client_auth'", usage)
+		}
+	}
+
+	return template, nil
+}
+
+// parseCA returns the certificate, private key & certificate PEM of the CA variable value
+func parseCA(name string, value interface{}) (*x509.Certificate, interface{}, string, error) {
+	certificateValue, _ := findKey(value, "certificate")
+	certificatePEM, _ := certificateValue.(string)
+	privateKeyValue, _ := findKey(value, "private_key")
+	privateKeyPEM, _ := privateKeyValue.(string)
+	if certificatePEM == "" || privateKeyPEM == "" {
+		return nil, nil, "", bosherr.Errorf("CA variable '%s' must have a 'certificate' & a 'private_key'", name)
+	}
+
+	certificateBlock, _ := pem.Decode([]byte(certificatePEM))
+	if certificateBlock == nil {
+		return nil, nil, "", bosherr.Errorf("Decoding certificate of CA variable '%s'", name)
+	}
+	certificate, err := x509.ParseCertificate(certificateBlock.Bytes)
+	if err != nil {
+		return nil, nil, "", bosherr.WrapErrorf(err, "Parsing certificate of CA variable '%s'", name)
+	}
+
+	privateKeyBlock, _ := pem.Decode([]byte(privateKeyPEM))
+	if privateKeyBlock == nil {
+		return nil, nil, "", bosherr.Errorf("Decoding private key of CA variable '%s'", name)
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBlock.Bytes)
+	if err != nil {
+		return nil, nil, "", bosherr.WrapErrorf(err, "Parsing private key of CA variable '%s'", name)
+	}
+
+	return certificate, privateKey, certificatePEM, nil
+}
+
+func encodePrivateKey(privateKey *rsa.PrivateKey) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+}
+
+// fingerprint returns the MD5 fingerprint of the SSH public key, e.g. "aa:bb:..."
+func fingerprint(publicKey ssh.PublicKey) string {
+	sum := md5.Sum(publicKey.Marshal())
+	hexBytes := make([]string, len(sum))
+	for i, b := range sum {
+		hexBytes[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(hexBytes, ":")
+}
@@ -0,0 +1,136 @@
+package vars_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"strings"
+	"time"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/vars"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	faketime "github.com/cloudfoundry/bosh-agent/time/fakes"
+)
+
+var _ = Describe("Generator", func() {
+	var (
+		generator Generator
+		now       time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2015, time.March, 1, 0, 0, 0, 0, time.UTC)
+		generator = NewGenerator(&faketime.FakeService{NowTimes: []time.Time{now, now, now}})
+	})
+
+	parseCertificate := func(value interface{}, key string) *x509.Certificate {
+		certificatePEM := value.(map[interface{}]interface{})[key].(string)
+		block, _ := pem.Decode([]byte(certificatePEM))
+		Expect(block).ToNot(BeNil())
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+		return certificate
+	}
+
+	It("generates random passwords", func() {
+		password, err := generator.Generate(Definition{Name: "password", Type: "password"}, Variables{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(password).To(MatchRegexp("^[a-z0-9]{20}$"))
+
+		otherPassword, err := generator.Generate(Definition{Name: "password", Type: "password"}, Variables{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(otherPassword).ToNot(Equal(password))
+	})
+
+	It("generates RSA key pairs", func() {
+		value, err := generator.Generate(Definition{Name: "key", Type: "rsa"}, Variables{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(HaveKey("private_key"))
+		Expect(value.(map[interface{}]interface{})["public_key"]).To(ContainSubstring("BEGIN PUBLIC KEY"))
+	})
+
+	It("generates SSH key pairs", func() {
+		value, err := generator.Generate(Definition{Name: "ssh", Type: "ssh"}, Variables{})
+		Expect(err).ToNot(HaveOccurred())
+
+		key := value.(map[interface{}]interface{})
+		Expect(key["private_key"]).To(ContainSubstring("BEGIN RSA PRIVATE KEY"))
+		Expect(strings.HasPrefix(key["public_key"].(string), "ssh-rsa ")).To(BeTrue())
+		Expect(key["public_key_fingerprint"]).To(MatchRegexp("^([0-9a-f]{2}:){15}[0-9a-f]{2}$"))
+	})
+
+	Describe("certificates", func() {
+		var caDefinition Definition
+
+		BeforeEach(func() {
+			caDefinition = Definition{
+				Name:    "default_ca",
+				Type:    "certificate",
+				Options: map[interface{}]interface{}{"is_ca": true, "common_name": "fake-ca"},
+			}
+		})
+
+		It("generates self-signed CAs", func() {
+			value, err := generator.Generate(caDefinition, Variables{})
+			Expect(err).ToNot(HaveOccurred())
+
+			certificate := parseCertificate(value, "certificate")
+			Expect(certificate.IsCA).To(BeTrue())
+			Expect(certificate.Subject.CommonName).To(Equal("fake-ca"))
+			Expect(certificate.NotBefore).To(Equal(now))
+			Expect(certificate.NotAfter).To(Equal(now.Add(365 * 24 * time.Hour)))
+			Expect(value.(map[interface{}]interface{})["ca"]).To(Equal(value.(map[interface{}]interface{})["certificate"]))
+		})
+
+		It("generates certificates with alternative names signed by the CA variable", func() {
+			ca, err := generator.Generate(caDefinition, Variables{})
+			Expect(err).ToNot(HaveOccurred())
+
+			value, err := generator.Generate(Definition{
+				Name: "director_ssl",
+				Type: "certificate",
+				Options: map[interface{}]interface{}{
+					"ca":                "default_ca",
+					"common_name":       "10.0.0.6",
+					"alternative_names": []interface{}{"10.0.0.6", "director.example.com"},
+				},
+			}, Variables{"default_ca": ca})
+			Expect(err).ToNot(HaveOccurred())
+
+			certificate := parseCertificate(value, "certificate")
+			Expect(certificate.IsCA).To(BeFalse())
+			Expect(certificate.IPAddresses).To(HaveLen(1))
+			Expect(certificate.IPAddresses[0].Equal(net.ParseIP("10.0.0.6"))).To(BeTrue())
+			Expect(certificate.DNSNames).To(Equal([]string{"director.example.com"}))
+			Expect(certificate.ExtKeyUsage).To(Equal([]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}))
+
+			caCertificate := parseCertificate(ca, "certificate")
+			Expect(certificate.CheckSignatureFrom(caCertificate)).ToNot(HaveOccurred())
+			Expect(value.(map[interface{}]interface{})["ca"]).To(Equal(ca.(map[interface{}]interface{})["certificate"]))
+		})
+
+		It("returns an error when the CA variable is not set", func() {
+			_, err := generator.Generate(Definition{
+				Name:    "director_ssl",
+				Type:    "certificate",
+				Options: map[interface{}]interface{}{"ca": "default_ca", "common_name": "fake-name"},
+			}, Variables{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("CA variable 'default_ca' is not set"))
+		})
+
+		It("returns an error without common name or alternative names", func() {
+			_, err := generator.Generate(Definition{Name: "director_ssl", Type: "certificate"}, Variables{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'common_name' or 'alternative_names'"))
+		})
+	})
+
+	It("returns an error for unsupported types", func() {
+		_, err := generator.Generate(Definition{Name: "fake-name", Type: "fake-type"}, Variables{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unsupported variable type 'fake-type'"))
+	})
+})
@@ -0,0 +1,169 @@
+package vars
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+
+	bmerr "github.com/cloudfoundry/bosh-micro-cli/release/errors"
+)
+
+// placeholderRegexp matches "((name))" placeholders. Names may use dots to refer to a key of a variable, e.g. "((aws.access_key_id))".
+var placeholderRegexp = regexp.MustCompile(`\(\(\s*([-\w.:/]+)\s*\)\)`)
+
+// Interpolator replaces the ((name)) placeholders of a YAML document with the values of the variables.
+// A placeholder that is the whole value is replaced by the variable value of any type,
+// a placeholder inside a longer string is replaced by the variable value as text.
+type Interpolator interface {
+	Interpolate(contents []byte) ([]byte, error)
+}
+
+type interpolator struct {
+	variables Variables
+}
+
+func NewInterpolator(variables Variables) Interpolator {
+	return &interpolator{
+		variables: variables,
+	}
+}
+
+// Interpolate returns the contents unchanged when they have no placeholders.
+// It fails with every missing variable and the paths it is used at.
+func (i *interpolator) Interpolate(contents []byte) ([]byte, error) {
+	if !placeholderRegexp.Match(contents) {
+		return contents, nil
+	}
+
+	var document interface{}
+	err := candiedyaml.Unmarshal(contents, &document)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling YAML for interpolation")
+	}
+
+	usages := &usages{paths: map[string][]string{}}
+	interpolated := i.interpolateValue(document, "", usages)
+
+	errs := usages.errors()
+	if len(errs) > 0 {
+		return nil, bosherr.WrapError(bmerr.NewExplainableError(errs), "Interpolating variables")
+	}
+
+	interpolatedContents, err := candiedyaml.Marshal(interpolated)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling interpolated YAML")
+	}
+
+	return interpolatedContents, nil
+}
+
+func (i *interpolator) interpolateValue(value interface{}, path string, usages *usages) interface{} {
+	switch typedValue := value.(type) {
+	case map[interface{}]interface{}:
+		interpolated := map[interface{}]interface{}{}
+		for key, item := range typedValue {
+			interpolated[key] = i.interpolateValue(item, childPath(path, fmt.Sprint(key)), usages)
+		}
+		return interpolated
+	case []interface{}:
+		interpolated := make([]interface{}, len(typedValue))
+		for index, item := range typedValue {
+			interpolated[index] = i.interpolateValue(item, fmt.Sprintf("%s[%d]", path, index), usages)
+		}
+		return interpolated
+	case string:
+		return i.interpolateString(typedValue, path, usages)
+	default:
+		return value
+	}
+}
+
+func (i *interpolator) interpolateString(value, path string, usages *usages) interface{} {
+	matches := placeholderRegexp.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return value
+	}
+
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(value) {
+		name := value[matches[0][2]:matches[0][3]]
+		variableValue, found := i.variables.Find(name)
+		if !found {
+			usages.missing(name, path)
+			return value
+		}
+		return variableValue
+	}
+
+	interpolated := ""
+	end := 0
+	for _, match := range matches {
+		interpolated += value[end:match[0]]
+		end = match[1]
+
+		name := value[match[2]:match[3]]
+		variableValue, found := i.variables.Find(name)
+		if !found {
+			usages.missing(name, path)
+			interpolated += value[match[0]:match[1]]
+			continue
+		}
+
+		switch variableValue.(type) {
+		case map[interface{}]interface{}, map[string]interface{}, []interface{}, nil:
+			usages.invalid(fmt.Sprintf("Variable '%s' used inside the string at '%s' must be a string, number or boolean", name, path))
+			interpolated += value[match[0]:match[1]]
+		default:
+			interpolated += fmt.Sprint(variableValue)
+		}
+	}
+	interpolated += value[end:]
+
+	return interpolated
+}
+
+func childPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// usages collects the missing & invalid variables found while interpolating
+type usages struct {
+	names    []string
+	paths    map[string][]string
+	invalids []string
+}
+
+func (u *usages) missing(name, path string) {
+	if _, found := u.paths[name]; !found {
+		u.names = append(u.names, name)
+	}
+	u.paths[name] = append(u.paths[name], path)
+}
+
+func (u *usages) invalid(message string) {
+	u.invalids = append(u.invalids, message)
+}
+
+// errors lists the missing variables & their paths, then the invalid variables, sorted as maps are walked in random order
+func (u *usages) errors() []error {
+	sort.Strings(u.names)
+
+	errs := []error{}
+	for _, name := range u.names {
+		paths := u.paths[name]
+		sort.Strings(paths)
+		errs = append(errs, bosherr.Errorf("Variable '%s' is not set, used at '%s'", name, strings.Join(paths, "', '")))
+	}
+
+	sort.Strings(u.invalids)
+	for _, message := range u.invalids {
+		errs = append(errs, bosherr.Error(message))
+	}
+	return errs
+}
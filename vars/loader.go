@@ -0,0 +1,96 @@
+package vars
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+)
+
+// Sources are where variables are loaded from, in increasing order of precedence:
+// environment variables with the prefixes, then vars files, then "name=value" vars.
+type Sources struct {
+	EnvPrefixes []string
+	Environ     []string
+	Files       []string
+	Vars        []string
+}
+
+type Loader interface {
+	Load(sources Sources) (Variables, error)
+}
+
+type loader struct {
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewLoader(fs boshsys.FileSystem, logger boshlog.Logger) Loader {
+	return &loader{
+		fs:     fs,
+		logger: logger,
+		logTag: "varsLoader",
+	}
+}
+
+func (l *loader) Load(sources Sources) (Variables, error) {
+	variables := Variables{}
+
+	for _, prefix := range sources.EnvPrefixes {
+		variables = variables.Merge(l.envVariables(prefix, sources.Environ))
+	}
+
+	for _, path := range sources.Files {
+		fileVariables, err := l.fileVariables(path)
+		if err != nil {
+			return nil, err
+		}
+		variables = variables.Merge(fileVariables)
+	}
+
+	for _, nameValue := range sources.Vars {
+		parts := strings.SplitN(nameValue, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, bosherr.Errorf("Invalid var '%s', expected 'name=value'", nameValue)
+		}
+		variables[parts[0]] = parts[1]
+	}
+
+	return variables, nil
+}
+
+// envVariables returns the environment variables named "<prefix>_<name>" as variables named "<name>"
+func (l *loader) envVariables(prefix string, environ []string) Variables {
+	variables := Variables{}
+	for _, nameValue := range environ {
+		parts := strings.SplitN(nameValue, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], prefix+"_") {
+			continue
+		}
+
+		name := strings.TrimPrefix(parts[0], prefix+"_")
+		if name != "" {
+			variables[name] = parts[1]
+		}
+	}
+	l.logger.Debug(l.logTag, "Loaded %d variable(s) from environment variables with prefix '%s'", len(variables), prefix)
+	return variables
+}
+
+func (l *loader) fileVariables(path string) (Variables, error) {
+	contents, err := l.fs.ReadFile(path)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading vars file '%s'", path)
+	}
+
+	variables := Variables{}
+	err = candiedyaml.Unmarshal(contents, &variables)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Unmarshalling vars file '%s'", path)
+	}
+
+	return variables, nil
+}
@@ -0,0 +1,66 @@
+package vars_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/vars"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+)
+
+var _ = Describe("Loader", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		loader Loader
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		loader = NewLoader(fs, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("loads variables from prefixed environment variables, vars files & vars, in increasing precedence", func() {
+		fs.WriteFileString("/fake-vars.yml", "---\nfile_var: fake-file-value\noverridden: fake-file-value\nport: 25555\n")
+
+		variables, err := loader.Load(Sources{
+			EnvPrefixes: []string{"MICRO"},
+			Environ: []string{
+				"MICRO_env_var=fake-env-value",
+				"MICRO_overridden=fake-env-value",
+				"OTHER_var=fake-other-value",
+			},
+			Files: []string{"/fake-vars.yml"},
+			Vars:  []string{"var=fake=value", "overridden=fake-var-value"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(variables).To(Equal(Variables{
+			"env_var":    "fake-env-value",
+			"file_var":   "fake-file-value",
+			"port":       int64(25555),
+			"var":        "fake=value",
+			"overridden": "fake-var-value",
+		}))
+	})
+
+	It("returns an error for a var without name", func() {
+		_, err := loader.Load(Sources{Vars: []string{"=fake-value"}})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Invalid var '=fake-value', expected 'name=value'"))
+	})
+
+	It("returns an error when a vars file cannot be read", func() {
+		_, err := loader.Load(Sources{Files: []string{"/missing-vars.yml"}})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reading vars file '/missing-vars.yml'"))
+	})
+
+	It("returns an error when a vars file is not a YAML map", func() {
+		fs.WriteFileString("/fake-vars.yml", "- fake-item")
+
+		_, err := loader.Load(Sources{Files: []string{"/fake-vars.yml"}})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling vars file '/fake-vars.yml'"))
+	})
+})
@@ -0,0 +1,71 @@
+package vars
+
+import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	bosherr "github.com/cloudfoundry/bosh-agent/errors"
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	boshsys "github.com/cloudfoundry/bosh-agent/system"
+)
+
+// Store persists generated variables, so that later commands reuse them
+type Store interface {
+	Load() (Variables, error)
+	Save(variables Variables) error
+}
+
+type fileStore struct {
+	path   string
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewFileStore returns a store of variables in a YAML file, readable by its owner only
+func NewFileStore(path string, fs boshsys.FileSystem, logger boshlog.Logger) Store {
+	return &fileStore{
+		path:   path,
+		fs:     fs,
+		logger: logger,
+		logTag: "varsFileStore",
+	}
+}
+
+func (s *fileStore) Load() (Variables, error) {
+	variables := Variables{}
+	if !s.fs.FileExists(s.path) {
+		return variables, nil
+	}
+
+	contents, err := s.fs.ReadFile(s.path)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading vars store '%s'", s.path)
+	}
+
+	err = candiedyaml.Unmarshal(contents, &variables)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Unmarshalling vars store '%s'", s.path)
+	}
+
+	s.logger.Debug(s.logTag, "Loaded %d variable(s) from vars store '%s'", len(variables), s.path)
+	return variables, nil
+}
+
+func (s *fileStore) Save(variables Variables) error {
+	contents, err := candiedyaml.Marshal(variables)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling vars store")
+	}
+
+	err = s.fs.WriteFile(s.path, contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing vars store '%s'", s.path)
+	}
+
+	err = s.fs.Chmod(s.path, 0600)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Setting permissions of vars store '%s'", s.path)
+	}
+
+	s.logger.Debug(s.logTag, "Saved %d variable(s) to vars store '%s'", len(variables), s.path)
+	return nil
+}
@@ -0,0 +1,53 @@
+package vars_test
+
+import (
+	"os"
+
+	. "github.com/cloudfoundry/bosh-micro-cli/vars"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-agent/logger"
+	fakesys "github.com/cloudfoundry/bosh-agent/system/fakes"
+)
+
+var _ = Describe("FileStore", func() {
+	var (
+		fs    *fakesys.FakeFileSystem
+		store Store
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		store = NewFileStore("/fake-vars-store.yml", fs, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("loads no variables when the file does not exist", func() {
+		Expect(store.Load()).To(Equal(Variables{}))
+	})
+
+	It("saves & loads the variables", func() {
+		err := store.Save(Variables{
+			"password": "fake-password",
+			"cert":     map[interface{}]interface{}{"certificate": "fake-certificate"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fs.GetFileTestStat("/fake-vars-store.yml").FileMode).To(Equal(os.FileMode(0600)))
+
+		variables, err := store.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(variables).To(Equal(Variables{
+			"password": "fake-password",
+			"cert":     map[interface{}]interface{}{"certificate": "fake-certificate"},
+		}))
+	})
+
+	It("returns an error when the file is not valid YAML", func() {
+		fs.WriteFileString("/fake-vars-store.yml", "- invalid")
+
+		_, err := store.Load()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling vars store '/fake-vars-store.yml'"))
+	})
+})
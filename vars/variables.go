@@ -0,0 +1,60 @@
+package vars
+
+import (
+	"strings"
+)
+
+// Variables are the values of the ((name)) placeholders of manifests, by name
+type Variables map[string]interface{}
+
+// Find returns the value of the variable with the name.
+// A name with dots that is not itself a variable refers to a key of a variable, e.g. "aws.access_key_id".
+func (v Variables) Find(name string) (interface{}, bool) {
+	value, found := v[name]
+	if found {
+		return value, true
+	}
+
+	segments := strings.Split(name, ".")
+	for i := len(segments) - 1; i > 0; i-- {
+		value, found := v[strings.Join(segments[:i], ".")]
+		if !found {
+			continue
+		}
+
+		for _, key := range segments[i:] {
+			value, found = findKey(value, key)
+			if !found {
+				return nil, false
+			}
+		}
+		return value, true
+	}
+
+	return nil, false
+}
+
+// Merge returns the variables, overridden by the other variables
+func (v Variables) Merge(other Variables) Variables {
+	merged := Variables{}
+	for name, value := range v {
+		merged[name] = value
+	}
+	for name, value := range other {
+		merged[name] = value
+	}
+	return merged
+}
+
+func findKey(value interface{}, key string) (interface{}, bool) {
+	switch typedValue := value.(type) {
+	case map[interface{}]interface{}:
+		keyValue, found := typedValue[key]
+		return keyValue, found
+	case map[string]interface{}:
+		keyValue, found := typedValue[key]
+		return keyValue, found
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,57 @@
+package vars_test
+
+import (
+	. "github.com/cloudfoundry/bosh-micro-cli/vars"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Variables", func() {
+	Describe("Find", func() {
+		var variables Variables
+
+		BeforeEach(func() {
+			variables = Variables{
+				"name":        "fake-value",
+				"dotted.name": "fake-dotted-value",
+				"aws": map[interface{}]interface{}{
+					"credentials": map[interface{}]interface{}{"access_key_id": "fake-access-key-id"},
+				},
+			}
+		})
+
+		It("finds variables by name", func() {
+			value, found := variables.Find("name")
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("fake-value"))
+
+			value, found = variables.Find("dotted.name")
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("fake-dotted-value"))
+		})
+
+		It("finds keys of variables by dotted names", func() {
+			value, found := variables.Find("aws.credentials.access_key_id")
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("fake-access-key-id"))
+		})
+
+		It("does not find unknown variables or keys", func() {
+			_, found := variables.Find("unknown")
+			Expect(found).To(BeFalse())
+
+			_, found = variables.Find("aws.credentials.unknown")
+			Expect(found).To(BeFalse())
+
+			_, found = variables.Find("name.unknown")
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("Merge", func() {
+		It("overrides the variables with the other variables", func() {
+			merged := Variables{"a": "1", "b": "2"}.Merge(Variables{"b": "3", "c": "4"})
+			Expect(merged).To(Equal(Variables{"a": "1", "b": "3", "c": "4"}))
+		})
+	})
+})